@@ -9,18 +9,27 @@ import (
 	"maps"
 	"slices"
 	"sync"
+
+	"charm.land/fantasy/schema"
 )
 
 // StepResult represents the result of a single step in an agent execution.
 type StepResult struct {
 	Response
-	Messages []Message
+	Messages []Message `json:"messages"`
+	// ToolUsage is the usage reported by the tools of the step, see
+	// [ReportToolUsage]. It is not part of the usage of the model response.
+	ToolUsage Usage `json:"tool_usage"`
 }
 
 // stepExecutionResult encapsulates the result of executing a step with stream processing.
 type stepExecutionResult struct {
 	StepResult     StepResult
 	ShouldContinue bool
+	// ToolResults and PendingApprovals are set when the step is suspended,
+	// the step content then only holds the model response.
+	ToolResults      []ToolResultContent
+	PendingApprovals []ToolCallContent
 }
 
 // StopCondition defines a function that determines when an agent should stop executing.
@@ -78,11 +87,12 @@ func FinishReasonIs(reason FinishReason) StopCondition {
 }
 
 // MaxTokensUsed returns a stop condition that stops when total token usage exceeds the specified limit.
+// See [MaxCostUSD] to limit the cost instead.
 func MaxTokensUsed(maxTokens int64) StopCondition {
 	return func(steps []StepResult) bool {
 		var totalTokens int64
 		for _, step := range steps {
-			totalTokens += step.Usage.TotalTokens
+			totalTokens += step.Usage.TotalTokens + step.ToolUsage.TotalTokens
 		}
 		return totalTokens >= maxTokens
 	}
@@ -135,10 +145,11 @@ type agentSettings struct {
 	topK             *int64
 	presencePenalty  *float64
 	frequencyPenalty *float64
+	stopSequences    []string
+	seed             *int64
 	headers          map[string]string
 	providerOptions  ProviderOptions
 
-	// TODO: add support for provider tools
 	tools      []AgentTool
 	maxRetries *int
 
@@ -148,6 +159,8 @@ type agentSettings struct {
 	prepareStep    PrepareStepFunction
 	repairToolCall RepairToolCallFunction
 	onRetry        OnRetryCallback
+	hooks          AgentHooks
+	contextManager ContextManager
 }
 
 // AgentCall represents a call to an agent.
@@ -161,6 +174,8 @@ type AgentCall struct {
 	TopK             *int64   `json:"top_k"`
 	PresencePenalty  *float64 `json:"presence_penalty"`
 	FrequencyPenalty *float64 `json:"frequency_penalty"`
+	StopSequences    []string `json:"stop_sequences"`
+	Seed             *int64   `json:"seed"`
 	ActiveTools      []string `json:"active_tools"`
 	ProviderOptions  ProviderOptions
 	OnRetry          OnRetryCallback
@@ -169,6 +184,11 @@ type AgentCall struct {
 	StopWhen       []StopCondition
 	PrepareStep    PrepareStepFunction
 	RepairToolCall RepairToolCallFunction
+
+	// Resume continues a suspended run with the given approvals, the
+	// prompt, files and messages are then taken from the suspended run.
+	Resume    *SuspendedRun
+	Approvals []ToolApproval
 }
 
 // Agent-level callbacks.
@@ -251,6 +271,8 @@ type AgentStreamCall struct {
 	TopK             *int64   `json:"top_k"`
 	PresencePenalty  *float64 `json:"presence_penalty"`
 	FrequencyPenalty *float64 `json:"frequency_penalty"`
+	StopSequences    []string `json:"stop_sequences"`
+	Seed             *int64   `json:"seed"`
 	ActiveTools      []string `json:"active_tools"`
 	Headers          map[string]string
 	ProviderOptions  ProviderOptions
@@ -261,6 +283,11 @@ type AgentStreamCall struct {
 	PrepareStep    PrepareStepFunction
 	RepairToolCall RepairToolCallFunction
 
+	// Resume continues a suspended run with the given approvals, the
+	// prompt, files and messages are then taken from the suspended run.
+	Resume    *SuspendedRun
+	Approvals []ToolApproval
+
 	// Agent-level callbacks
 	OnAgentStart  OnAgentStartFunc  // Called when agent starts
 	OnAgentFinish OnAgentFinishFunc // Called when agent finishes
@@ -293,11 +320,20 @@ type AgentResult struct {
 	// Final response
 	Response   Response
 	TotalUsage Usage
+	// Suspended is set when the run stopped before running tools that
+	// require approval, see [RequireApproval].
+	Suspended *SuspendedRun
 }
 
 // Agent represents an AI agent that can generate responses and stream responses.
 type Agent interface {
+	// Generate runs the agent until a stop condition is met or the model
+	// stops calling tools. When a tool returns an error, the tools still
+	// running are cancelled and Generate returns that error and no result,
+	// like Stream.
 	Generate(context.Context, AgentCall) (*AgentResult, error)
+	// Stream runs the agent like Generate, reporting its progress to the
+	// callbacks of the call.
 	Stream(context.Context, AgentStreamCall) (*AgentResult, error)
 }
 
@@ -328,6 +364,10 @@ func (a *agent) prepareCall(call AgentCall) AgentCall {
 	call.TopK = cmp.Or(call.TopK, a.settings.topK)
 	call.PresencePenalty = cmp.Or(call.PresencePenalty, a.settings.presencePenalty)
 	call.FrequencyPenalty = cmp.Or(call.FrequencyPenalty, a.settings.frequencyPenalty)
+	call.Seed = cmp.Or(call.Seed, a.settings.seed)
+	if len(call.StopSequences) == 0 && len(a.settings.stopSequences) > 0 {
+		call.StopSequences = a.settings.stopSequences
+	}
 	call.MaxRetries = cmp.Or(call.MaxRetries, a.settings.maxRetries)
 
 	if len(call.StopWhen) == 0 && len(a.settings.stopWhen) > 0 {
@@ -363,16 +403,50 @@ func (a *agent) prepareCall(call AgentCall) AgentCall {
 
 // Generate implements Agent.
 func (a *agent) Generate(ctx context.Context, opts AgentCall) (*AgentResult, error) {
+	ctx = a.settings.hooks.runStart(ctx, a.settings.model)
+	result, err := a.generate(ctx, opts)
+	a.settings.hooks.runFinish(ctx, result, err)
+	return result, err
+}
+
+func (a *agent) generate(ctx context.Context, opts AgentCall) (*AgentResult, error) {
 	opts = a.prepareCall(opts)
-	initialPrompt, err := a.createPrompt(a.settings.systemPrompt, opts.Prompt, opts.Messages, opts.Files...)
-	if err != nil {
-		return nil, err
-	}
-	var responseMessages []Message
+	var initialPrompt, responseMessages []Message
 	var steps []StepResult
+	var err error
+	if opts.Resume != nil {
+		initialPrompt = opts.Resume.Messages
+		steps = slices.Clone(opts.Resume.Steps)
+
+		stepCtx := a.settings.hooks.stepStart(ctx, len(steps), a.settings.model)
+		stepResult, err := a.resumeStep(stepCtx, opts.Resume, opts.Approvals, nil)
+		if err != nil {
+			a.settings.hooks.stepFinish(stepCtx, nil, err)
+			return nil, err
+		}
+		steps = append(steps, stepResult)
+		a.settings.hooks.stepFinish(stepCtx, &stepResult, nil)
+		responseMessages = stepResult.Messages
+
+		if isStopConditionMet(opts.StopWhen, steps) || stepResult.FinishReason != FinishReasonToolCalls {
+			return newAgentResult(steps), nil
+		}
+	} else {
+		initialPrompt, err = a.createPrompt(a.settings.systemPrompt, opts.Prompt, opts.Messages, opts.Files...)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	for {
 		stepInputMessages := append(initialPrompt, responseMessages...)
+		if a.settings.contextManager != nil {
+			stepInputMessages, err = a.manageContext(ctx, stepInputMessages, steps)
+			if err != nil {
+				return nil, err
+			}
+			initialPrompt, responseMessages = stepInputMessages, nil
+		}
 		stepModel := a.settings.model
 		stepSystemPrompt := a.settings.systemPrompt
 		stepActiveTools := opts.ActiveTools
@@ -414,29 +488,24 @@ func (a *agent) Generate(ctx context.Context, opts AgentCall) (*AgentResult, err
 			}
 		}
 
-		// Recreate prompt with potentially modified system prompt
+		// Replace the system message with the potentially modified system prompt
 		if stepSystemPrompt != a.settings.systemPrompt {
-			stepPrompt, err := a.createPrompt(stepSystemPrompt, opts.Prompt, opts.Messages, opts.Files...)
-			if err != nil {
-				return nil, err
-			}
-			// Replace system message part, keep the rest
-			if len(stepInputMessages) > 0 && len(stepPrompt) > 0 {
-				stepInputMessages[0] = stepPrompt[0] // Replace system message
-			}
+			stepInputMessages = withSystemPrompt(stepInputMessages, stepSystemPrompt)
 		}
 
 		preparedTools := a.prepareTools(stepTools, stepActiveTools, disableAllTools)
 
+		stepCtx := a.settings.hooks.stepStart(ctx, len(steps), stepModel)
+
 		retryOptions := DefaultRetryOptions()
 		if opts.MaxRetries != nil {
 			retryOptions.MaxRetries = *opts.MaxRetries
 		}
-		retryOptions.OnRetry = opts.OnRetry
+		retryOptions.OnRetry = a.settings.hooks.stepRetry(stepCtx, opts.OnRetry)
 		retry := RetryWithExponentialBackoffRespectingRetryHeaders[*Response](retryOptions)
 
-		result, err := retry(ctx, func() (*Response, error) {
-			return stepModel.Generate(ctx, Call{
+		result, err := retry(stepCtx, func() (*Response, error) {
+			return stepModel.Generate(stepCtx, Call{
 				Prompt:           stepInputMessages,
 				MaxOutputTokens:  opts.MaxOutputTokens,
 				Temperature:      opts.Temperature,
@@ -444,36 +513,39 @@ func (a *agent) Generate(ctx context.Context, opts AgentCall) (*AgentResult, err
 				TopK:             opts.TopK,
 				PresencePenalty:  opts.PresencePenalty,
 				FrequencyPenalty: opts.FrequencyPenalty,
+				StopSequences:    opts.StopSequences,
+				Seed:             opts.Seed,
 				Tools:            preparedTools,
 				ToolChoice:       &stepToolChoice,
 				ProviderOptions:  opts.ProviderOptions,
 			})
 		})
 		if err != nil {
+			a.settings.hooks.stepFinish(stepCtx, nil, err)
 			return nil, err
 		}
+		result.Usage.Cost, _ = ComputeCost(stepModel, *result)
 
 		var stepToolCalls []ToolCallContent
 		for _, content := range result.Content {
 			if content.GetType() == ContentTypeToolCall {
 				toolCall, ok := AsContentType[ToolCallContent](content)
-				if !ok {
+				if !ok || toolCall.ProviderExecuted {
+					// Provider executed tools already have their results in the response
 					continue
 				}
 
 				// Validate and potentially repair the tool call
-				validatedToolCall := a.validateAndRepairToolCall(ctx, toolCall, stepTools, stepSystemPrompt, stepInputMessages, a.settings.repairToolCall)
+				validatedToolCall := a.validateAndRepairToolCall(stepCtx, toolCall, stepTools, stepSystemPrompt, stepInputMessages, a.settings.repairToolCall)
 				stepToolCalls = append(stepToolCalls, validatedToolCall)
 			}
 		}
 
-		toolResults, err := a.executeTools(ctx, stepTools, stepToolCalls, nil)
-
-		// Build step content with validated tool calls and tool results
+		// Build step content with validated tool calls
 		stepContent := []Content{}
 		toolCallIndex := 0
 		for _, content := range result.Content {
-			if content.GetType() == ContentTypeToolCall {
+			if toolCall, ok := AsContentType[ToolCallContent](content); ok && !toolCall.ProviderExecuted {
 				// Replace with validated tool call
 				if toolCallIndex < len(stepToolCalls) {
 					stepContent = append(stepContent, stepToolCalls[toolCallIndex])
@@ -484,6 +556,27 @@ func (a *agent) Generate(ctx context.Context, opts AgentCall) (*AgentResult, err
 				stepContent = append(stepContent, content)
 			}
 		}
+
+		pendingApprovals := toolCallsRequiringApproval(stepTools, stepToolCalls)
+		toolResults, toolUsage, err := a.executeTools(stepCtx, stepTools, withoutToolCalls(stepToolCalls, pendingApprovals), nil)
+		if err != nil {
+			a.settings.hooks.stepFinish(stepCtx, nil, err)
+			return nil, err
+		}
+		if len(pendingApprovals) > 0 {
+			a.settings.hooks.stepFinish(stepCtx, nil, nil)
+			response := *result
+			response.Content = stepContent
+			return newSuspendedResult(steps, &SuspendedRun{
+				Messages:         stepInputMessages,
+				Steps:            steps,
+				Response:         response,
+				ToolUsage:        toolUsage,
+				ToolResults:      toolResults,
+				PendingApprovals: pendingApprovals,
+			}), nil
+		}
+
 		// Add tool results
 		for _, result := range toolResults {
 			stepContent = append(stepContent, result)
@@ -499,34 +592,59 @@ func (a *agent) Generate(ctx context.Context, opts AgentCall) (*AgentResult, err
 				Warnings:         result.Warnings,
 				ProviderMetadata: result.ProviderMetadata,
 			},
-			Messages: currentStepMessages,
+			Messages:  currentStepMessages,
+			ToolUsage: toolUsage,
 		}
 		steps = append(steps, stepResult)
+		a.settings.hooks.stepFinish(stepCtx, &stepResult, nil)
 		shouldStop := isStopConditionMet(opts.StopWhen, steps)
 
-		if shouldStop || err != nil || len(stepToolCalls) == 0 || result.FinishReason != FinishReasonToolCalls {
+		if shouldStop || len(stepToolCalls) == 0 || result.FinishReason != FinishReasonToolCalls {
 			break
 		}
 	}
 
-	totalUsage := Usage{}
+	return newAgentResult(steps), nil
+}
 
+// newAgentResult creates the result of a run that completed the given steps.
+func newAgentResult(steps []StepResult) *AgentResult {
+	totalUsage := Usage{}
 	for _, step := range steps {
-		usage := step.Usage
-		totalUsage.InputTokens += usage.InputTokens
-		totalUsage.OutputTokens += usage.OutputTokens
-		totalUsage.ReasoningTokens += usage.ReasoningTokens
-		totalUsage.CacheCreationTokens += usage.CacheCreationTokens
-		totalUsage.CacheReadTokens += usage.CacheReadTokens
-		totalUsage.TotalTokens += usage.TotalTokens
+		totalUsage = addUsage(totalUsage, step.Usage)
+		totalUsage = addUsage(totalUsage, step.ToolUsage)
 	}
-
-	agentResult := &AgentResult{
+	return &AgentResult{
 		Steps:      steps,
 		Response:   steps[len(steps)-1].Response,
 		TotalUsage: totalUsage,
 	}
-	return agentResult, nil
+}
+
+// withSystemPrompt returns the messages with the leading system message
+// replaced by the given system prompt.
+func withSystemPrompt(messages []Message, system string) []Message {
+	if len(messages) > 0 && messages[0].Role == MessageRoleSystem {
+		messages = messages[1:]
+	}
+	if system == "" {
+		return slices.Clone(messages)
+	}
+	return append([]Message{NewSystemMessage(system)}, messages...)
+}
+
+// manageContext runs the context manager on the messages of the next step.
+func (a *agent) manageContext(ctx context.Context, messages []Message, steps []StepResult) ([]Message, error) {
+	options := ContextManagerOptions{
+		Messages:   messages,
+		Steps:      steps,
+		StepNumber: len(steps),
+		Model:      a.settings.model,
+	}
+	if len(steps) > 0 {
+		options.InputTokens = steps[len(steps)-1].Usage.InputTokens
+	}
+	return a.settings.contextManager(ctx, options)
 }
 
 func isStopConditionMet(conditions []StopCondition, steps []StepResult) bool {
@@ -597,6 +715,16 @@ func toResponseMessages(content []Content) []Message {
 			if !ok {
 				continue
 			}
+			if result.ProviderExecuted {
+				// Provider executed results are part of the assistant response
+				assistantParts = append(assistantParts, ToolResultPart{
+					ToolCallID:       result.ToolCallID,
+					Output:           result.Result,
+					ProviderExecuted: true,
+					ProviderOptions:  ProviderOptions(result.ProviderMetadata),
+				})
+				continue
+			}
 			toolParts = append(toolParts, ToolResultPart{
 				ToolCallID:      result.ToolCallID,
 				Output:          result.Result,
@@ -621,33 +749,183 @@ func toResponseMessages(content []Content) []Message {
 	return messages
 }
 
-func (a *agent) executeTools(ctx context.Context, allTools []AgentTool, toolCalls []ToolCallContent, toolResultCallback func(result ToolResultContent) error) ([]ToolResultContent, error) {
+// executeTools runs the tool calls of a step and returns their results and
+// the usage reported by the tools.
+func (a *agent) executeTools(ctx context.Context, allTools []AgentTool, toolCalls []ToolCallContent, toolResultCallback func(result ToolResultContent) error) ([]ToolResultContent, Usage, error) {
 	if len(toolCalls) == 0 {
-		return nil, nil
+		return nil, Usage{}, nil
 	}
 
-	// Create a map for quick tool lookup
-	toolMap := make(map[string]AgentTool)
-	for _, tool := range allTools {
-		toolMap[tool.Info().Name] = tool
+	dispatcher := a.newToolDispatcher(ctx, allTools, toolResultCallback, nil)
+	for _, toolCall := range toolCalls {
+		dispatcher.submit(toolCall)
 	}
+	results, err := dispatcher.wait()
+	return results, dispatcher.run.totalUsage(), err
+}
 
-	// Execute all tool calls sequentially in order
-	results := make([]ToolResultContent, 0, len(toolCalls))
+// maxParallelTools bounds how many parallel-safe tools run at once within a
+// single step.
+const maxParallelTools = 5
+
+// toolDispatcher executes the tool calls of a single step.
+//
+// Tools whose [ToolInfo.Parallel] is set run concurrently on a bounded worker
+// pool, while every other tool acts as a barrier: it waits for all in-flight
+// tools to finish, runs on its own, and only then lets later calls start.
+// Results are always reported in the order the calls were submitted. The first
+// critical error cancels the context of the tools that are still running and
+// prevents any further calls from starting.
+type toolDispatcher struct {
+	agent    *agent
+	ctx      context.Context
+	cancel   context.CancelFunc
+	toolMap  map[string]AgentTool
+	callback func(result ToolResultContent) error
+	run      *toolRun
+
+	queue     chan *toolDispatch
+	done      chan struct{}
+	closeOnce sync.Once
+
+	sem     chan struct{}
+	running sync.WaitGroup
+
+	mu         sync.Mutex
+	callbackMu sync.Mutex
+	dispatches []*toolDispatch
+	err        error
+}
 
-	for _, toolCall := range toolCalls {
-		result, isCriticalError := a.executeSingleTool(ctx, toolMap, toolCall, toolResultCallback)
-		results = append(results, result)
-		if isCriticalError {
-			if errorResult, ok := result.Result.(ToolResultOutputContentError); ok && errorResult.Error != nil {
-				return nil, errorResult.Error
-			}
+type toolDispatch struct {
+	toolCall ToolCallContent
+	result   ToolResultContent
+	executed bool
+}
+
+func (a *agent) newToolDispatcher(ctx context.Context, tools []AgentTool, toolResultCallback func(result ToolResultContent) error, onChunk OnChunkFunc) *toolDispatcher {
+	toolMap := make(map[string]AgentTool, len(tools))
+	for _, tool := range tools {
+		toolMap[tool.Info().Name] = tool
+	}
+
+	run := &toolRun{onChunk: onChunk}
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, toolRunKey{}, run))
+	d := &toolDispatcher{
+		agent:   a,
+		ctx:     ctx,
+		cancel:  cancel,
+		toolMap: toolMap,
+		run:     run,
+		queue:   make(chan *toolDispatch, 10),
+		done:    make(chan struct{}),
+		sem:     make(chan struct{}, maxParallelTools),
+	}
+	if toolResultCallback != nil {
+		// Parallel tools finish concurrently; serialize the callback so callers
+		// don't need to guard their own state.
+		d.callback = func(result ToolResultContent) error {
+			d.callbackMu.Lock()
+			defer d.callbackMu.Unlock()
+			return toolResultCallback(result)
 		}
 	}
+	go d.loop()
+	return d
+}
+
+// submit queues a tool call for execution. It never blocks on tool execution,
+// so it is safe to call while a model stream is still being consumed.
+func (d *toolDispatcher) submit(toolCall ToolCallContent) {
+	dispatch := &toolDispatch{toolCall: toolCall}
+	d.mu.Lock()
+	d.dispatches = append(d.dispatches, dispatch)
+	d.mu.Unlock()
+	d.queue <- dispatch
+}
 
+// wait blocks until every submitted tool call has finished and returns the
+// results in submission order, or the first critical error.
+func (d *toolDispatcher) wait() ([]ToolResultContent, error) {
+	d.closeOnce.Do(func() { close(d.queue) })
+	<-d.done
+	d.cancel()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return nil, d.err
+	}
+	results := make([]ToolResultContent, 0, len(d.dispatches))
+	for _, dispatch := range d.dispatches {
+		if dispatch.executed {
+			results = append(results, dispatch.result)
+		}
+	}
 	return results, nil
 }
 
+// abort cancels all running tools and waits for them to return.
+func (d *toolDispatcher) abort() {
+	d.cancel()
+	_, _ = d.wait()
+}
+
+func (d *toolDispatcher) loop() {
+	defer close(d.done)
+	for dispatch := range d.queue {
+		if d.failed() {
+			continue
+		}
+		if d.isParallel(dispatch.toolCall) {
+			d.sem <- struct{}{}
+			d.running.Go(func() {
+				defer func() { <-d.sem }()
+				d.execute(dispatch)
+			})
+			continue
+		}
+		// Sequential tools are barriers: wait for everything before them.
+		d.running.Wait()
+		if d.failed() {
+			continue
+		}
+		d.execute(dispatch)
+	}
+	d.running.Wait()
+}
+
+func (d *toolDispatcher) isParallel(toolCall ToolCallContent) bool {
+	if toolCall.Invalid {
+		// Invalid calls are never run; they only produce an error result.
+		return true
+	}
+	tool, ok := d.toolMap[toolCall.ToolName]
+	return ok && tool.Info().Parallel
+}
+
+func (d *toolDispatcher) execute(dispatch *toolDispatch) {
+	result, isCriticalError := d.agent.executeSingleTool(d.ctx, d.toolMap, dispatch.toolCall, d.callback)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dispatch.result = result
+	dispatch.executed = true
+	if !isCriticalError || d.err != nil {
+		return
+	}
+	if errorResult, ok := result.Result.(ToolResultOutputContentError); ok && errorResult.Error != nil {
+		d.err = errorResult.Error
+		d.cancel()
+	}
+}
+
+func (d *toolDispatcher) failed() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err != nil
+}
+
 // executeSingleTool executes a single tool and returns its result and a critical error flag.
 func (a *agent) executeSingleTool(ctx context.Context, toolMap map[string]AgentTool, toolCall ToolCallContent, toolResultCallback func(result ToolResultContent) error) (ToolResultContent, bool) {
 	result := ToolResultContent{
@@ -679,11 +957,14 @@ func (a *agent) executeSingleTool(ctx context.Context, toolMap map[string]AgentT
 	}
 
 	// Execute the tool
-	toolResult, err := tool.Run(ctx, ToolCall{
+	call := ToolCall{
 		ID:    toolCall.ToolCallID,
 		Name:  toolCall.ToolName,
 		Input: toolCall.Input,
-	})
+	}
+	toolCtx := a.settings.hooks.toolStart(ctx, call)
+	toolResult, err := tool.Run(toolCtx, call)
+	a.settings.hooks.toolFinish(toolCtx, call, toolResult, err)
 	if err != nil {
 		result.Result = ToolResultOutputContentError{
 			Error: err,
@@ -706,6 +987,10 @@ func (a *agent) executeSingleTool(ctx context.Context, toolMap map[string]AgentT
 			MediaType: toolResult.MediaType,
 			Text:      toolResult.Content,
 		}
+	} else if err := validateToolOutput(tool.Info(), toolResult.Content); err != nil {
+		result.Result = ToolResultOutputContentError{
+			Error: err,
+		}
 	} else {
 		result.Result = ToolResultOutputContentText{
 			Text: toolResult.Content,
@@ -717,8 +1002,37 @@ func (a *agent) executeSingleTool(ctx context.Context, toolMap map[string]AgentT
 	return result, false
 }
 
+// validateToolOutput validates the text output of a tool against the output
+// schema the tool advertises.
+func validateToolOutput(info ToolInfo, output string) error {
+	if info.OutputSchema == nil {
+		return nil
+	}
+	validator, err := schema.CompileMap(info.OutputSchema)
+	if err != nil {
+		// Like input schemas, output schemas that can not be compiled are not
+		// enforced.
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal([]byte(output), &value); err != nil {
+		return fmt.Errorf("output of tool %s is not valid JSON: %w", info.Name, err)
+	}
+	if err := validator.Validate(value); err != nil {
+		return fmt.Errorf("output of tool %s does not match its output schema: %w", info.Name, err)
+	}
+	return nil
+}
+
 // Stream implements Agent.
 func (a *agent) Stream(ctx context.Context, opts AgentStreamCall) (*AgentResult, error) {
+	ctx = a.settings.hooks.runStart(ctx, a.settings.model)
+	result, err := a.stream(ctx, opts)
+	a.settings.hooks.runFinish(ctx, result, err)
+	return result, err
+}
+
+func (a *agent) stream(ctx context.Context, opts AgentStreamCall) (*AgentResult, error) {
 	// Convert AgentStreamCall to AgentCall for preparation
 	call := AgentCall{
 		Prompt:           opts.Prompt,
@@ -730,6 +1044,8 @@ func (a *agent) Stream(ctx context.Context, opts AgentStreamCall) (*AgentResult,
 		TopK:             opts.TopK,
 		PresencePenalty:  opts.PresencePenalty,
 		FrequencyPenalty: opts.FrequencyPenalty,
+		StopSequences:    opts.StopSequences,
+		Seed:             opts.Seed,
 		ActiveTools:      opts.ActiveTools,
 		ProviderOptions:  opts.ProviderOptions,
 		MaxRetries:       opts.MaxRetries,
@@ -737,26 +1053,63 @@ func (a *agent) Stream(ctx context.Context, opts AgentStreamCall) (*AgentResult,
 		StopWhen:         opts.StopWhen,
 		PrepareStep:      opts.PrepareStep,
 		RepairToolCall:   opts.RepairToolCall,
+		Resume:           opts.Resume,
+		Approvals:        opts.Approvals,
 	}
 
 	call = a.prepareCall(call)
 
-	initialPrompt, err := a.createPrompt(a.settings.systemPrompt, call.Prompt, call.Messages, call.Files...)
-	if err != nil {
-		return nil, err
-	}
-
-	var responseMessages []Message
+	var initialPrompt, responseMessages []Message
 	var steps []StepResult
-	var totalUsage Usage
+	var err error
+	var suspended *SuspendedRun
+	done := false
+	if call.Resume != nil {
+		initialPrompt = call.Resume.Messages
+		steps = slices.Clone(call.Resume.Steps)
+	} else {
+		initialPrompt, err = a.createPrompt(a.settings.systemPrompt, call.Prompt, call.Messages, call.Files...)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Start agent stream
 	if opts.OnAgentStart != nil {
 		opts.OnAgentStart()
 	}
 
-	for stepNumber := 0; ; stepNumber++ {
+	if call.Resume != nil {
+		stepCtx := a.settings.hooks.stepStart(ctx, len(steps), a.settings.model)
+		if opts.OnStepStart != nil {
+			_ = opts.OnStepStart(len(steps))
+		}
+		stepResult, err := a.resumeStep(stepCtx, call.Resume, call.Approvals, opts.OnToolResult)
+		if err != nil {
+			a.settings.hooks.stepFinish(stepCtx, nil, err)
+			if opts.OnError != nil {
+				opts.OnError(err)
+			}
+			return nil, err
+		}
+		steps = append(steps, stepResult)
+		a.settings.hooks.stepFinish(stepCtx, &stepResult, nil)
+		if opts.OnStepFinish != nil {
+			_ = opts.OnStepFinish(stepResult)
+		}
+		responseMessages = stepResult.Messages
+		done = isStopConditionMet(call.StopWhen, steps) || stepResult.FinishReason != FinishReasonToolCalls
+	}
+
+	for stepNumber := len(steps); !done; stepNumber++ {
 		stepInputMessages := append(initialPrompt, responseMessages...)
+		if a.settings.contextManager != nil {
+			stepInputMessages, err = a.manageContext(ctx, stepInputMessages, steps)
+			if err != nil {
+				return nil, err
+			}
+			initialPrompt, responseMessages = stepInputMessages, nil
+		}
 		stepModel := a.settings.model
 		stepSystemPrompt := a.settings.systemPrompt
 		stepActiveTools := call.ActiveTools
@@ -798,20 +1151,15 @@ func (a *agent) Stream(ctx context.Context, opts AgentStreamCall) (*AgentResult,
 			}
 		}
 
-		// Recreate prompt with potentially modified system prompt
+		// Replace the system message with the potentially modified system prompt
 		if stepSystemPrompt != a.settings.systemPrompt {
-			stepPrompt, err := a.createPrompt(stepSystemPrompt, call.Prompt, call.Messages, call.Files...)
-			if err != nil {
-				return nil, err
-			}
-			if len(stepInputMessages) > 0 && len(stepPrompt) > 0 {
-				stepInputMessages[0] = stepPrompt[0]
-			}
+			stepInputMessages = withSystemPrompt(stepInputMessages, stepSystemPrompt)
 		}
 
 		preparedTools := a.prepareTools(stepTools, stepActiveTools, disableAllTools)
 
 		// Start step stream
+		stepCtx := a.settings.hooks.stepStart(ctx, stepNumber, stepModel)
 		if opts.OnStepStart != nil {
 			_ = opts.OnStepStart(stepNumber)
 		}
@@ -825,6 +1173,8 @@ func (a *agent) Stream(ctx context.Context, opts AgentStreamCall) (*AgentResult,
 			TopK:             call.TopK,
 			PresencePenalty:  call.PresencePenalty,
 			FrequencyPenalty: call.FrequencyPenalty,
+			StopSequences:    call.StopSequences,
+			Seed:             call.Seed,
 			Tools:            preparedTools,
 			ToolChoice:       &stepToolChoice,
 			ProviderOptions:  call.ProviderOptions,
@@ -835,18 +1185,18 @@ func (a *agent) Stream(ctx context.Context, opts AgentStreamCall) (*AgentResult,
 		if call.MaxRetries != nil {
 			retryOptions.MaxRetries = *call.MaxRetries
 		}
-		retryOptions.OnRetry = call.OnRetry
+		retryOptions.OnRetry = a.settings.hooks.stepRetry(stepCtx, call.OnRetry)
 		retry := RetryWithExponentialBackoffRespectingRetryHeaders[stepExecutionResult](retryOptions)
 
-		result, err := retry(ctx, func() (stepExecutionResult, error) {
+		result, err := retry(stepCtx, func() (stepExecutionResult, error) {
 			// Create the stream
-			stream, err := stepModel.Stream(ctx, streamCall)
+			stream, err := stepModel.Stream(stepCtx, streamCall)
 			if err != nil {
 				return stepExecutionResult{}, err
 			}
 
 			// Process the stream
-			result, err := a.processStepStream(ctx, stream, opts, steps, stepTools)
+			result, err := a.processStepStream(stepCtx, stream, opts, steps, stepTools)
 			if err != nil {
 				return stepExecutionResult{}, err
 			}
@@ -854,14 +1204,29 @@ func (a *agent) Stream(ctx context.Context, opts AgentStreamCall) (*AgentResult,
 			return result, nil
 		})
 		if err != nil {
+			a.settings.hooks.stepFinish(stepCtx, nil, err)
 			if opts.OnError != nil {
 				opts.OnError(err)
 			}
 			return nil, err
 		}
+		result.StepResult.Usage.Cost, _ = ComputeCost(stepModel, result.StepResult.Response)
+
+		if len(result.PendingApprovals) > 0 {
+			a.settings.hooks.stepFinish(stepCtx, nil, nil)
+			suspended = &SuspendedRun{
+				Messages:         stepInputMessages,
+				Steps:            steps,
+				Response:         result.StepResult.Response,
+				ToolUsage:        result.StepResult.ToolUsage,
+				ToolResults:      result.ToolResults,
+				PendingApprovals: result.PendingApprovals,
+			}
+			break
+		}
 
 		steps = append(steps, result.StepResult)
-		totalUsage = addUsage(totalUsage, result.StepResult.Usage)
+		a.settings.hooks.stepFinish(stepCtx, &result.StepResult, nil)
 
 		// Call step finished callback
 		if opts.OnStepFinish != nil {
@@ -880,10 +1245,11 @@ func (a *agent) Stream(ctx context.Context, opts AgentStreamCall) (*AgentResult,
 	}
 
 	// Finish agent stream
-	agentResult := &AgentResult{
-		Steps:      steps,
-		Response:   steps[len(steps)-1].Response,
-		TotalUsage: totalUsage,
+	var agentResult *AgentResult
+	if suspended != nil {
+		agentResult = newSuspendedResult(steps, suspended)
+	} else {
+		agentResult = newAgentResult(steps)
 	}
 
 	if opts.OnFinish != nil {
@@ -911,15 +1277,15 @@ func (a *agent) prepareTools(tools []AgentTool, activeTools []string, disableAll
 		if len(activeTools) > 0 && !slices.Contains(activeTools, tool.Info().Name) {
 			continue
 		}
+		if providerTool, ok := tool.(ProviderDefinedTool); ok {
+			preparedTools = append(preparedTools, providerTool)
+			continue
+		}
 		info := tool.Info()
 		preparedTools = append(preparedTools, FunctionTool{
-			Name:        info.Name,
-			Description: info.Description,
-			InputSchema: map[string]any{
-				"type":       "object",
-				"properties": info.Parameters,
-				"required":   info.Required,
-			},
+			Name:            info.Name,
+			Description:     info.Description,
+			InputSchema:     toolInputSchema(info),
 			ProviderOptions: tool.ProviderOptions(),
 		})
 	}
@@ -974,17 +1340,42 @@ func (a *agent) validateToolCall(toolCall ToolCallContent, availableTools []Agen
 		return fmt.Errorf("invalid JSON input: %w", err)
 	}
 
-	// Basic schema validation (check required fields)
-	// TODO: more robust schema validation using JSON Schema or similar
 	toolInfo := tool.Info()
-	for _, required := range toolInfo.Required {
-		if _, exists := input[required]; !exists {
-			return fmt.Errorf("missing required parameter: %s", required)
+	validator, err := schema.CompileMap(toolInputSchema(toolInfo))
+	if err != nil {
+		// The schema of the tool can not be compiled, only check that the
+		// required parameters are present.
+		for _, required := range toolInfo.Required {
+			if _, exists := input[required]; !exists {
+				return fmt.Errorf("missing required parameter: %s", required)
+			}
+		}
+		return nil
+	}
+
+	var validationErr *schema.ValidationError
+	if err := validator.Validate(input); errors.As(err, &validationErr) {
+		return &ToolInputError{
+			ToolName:   toolCall.ToolName,
+			Violations: validationErr.Violations,
 		}
 	}
 	return nil
 }
 
+// toolInputSchema returns the JSON schema of the input of a tool.
+func toolInputSchema(info ToolInfo) map[string]any {
+	inputSchema := map[string]any{
+		"type":       "object",
+		"properties": info.Parameters,
+		"required":   info.Required,
+	}
+	if len(info.Defs) > 0 {
+		inputSchema["$defs"] = info.Defs
+	}
+	return inputSchema
+}
+
 func (a *agent) createPrompt(system, prompt string, messages []Message, files ...FilePart) (Prompt, error) {
 	if prompt == "" {
 		return nil, &Error{Title: "invalid argument", Message: "prompt can't be empty"}
@@ -1049,6 +1440,20 @@ func WithFrequencyPenalty(penalty float64) AgentOption {
 	}
 }
 
+// WithStopSequences sets the sequences that stop the generation for the agent.
+func WithStopSequences(sequences ...string) AgentOption {
+	return func(s *agentSettings) {
+		s.stopSequences = append(s.stopSequences, sequences...)
+	}
+}
+
+// WithSeed sets the seed used for sampling for the agent.
+func WithSeed(seed int64) AgentOption {
+	return func(s *agentSettings) {
+		s.seed = &seed
+	}
+}
+
 // WithTools sets the tools for the agent.
 func WithTools(tools ...AgentTool) AgentOption {
 	return func(s *agentSettings) {
@@ -1095,6 +1500,7 @@ func WithOnRetry(callback OnRetryCallback) AgentOption {
 func (a *agent) processStepStream(ctx context.Context, stream StreamResponse, opts AgentStreamCall, _ []StepResult, stepTools []AgentTool) (stepExecutionResult, error) {
 	var stepContent []Content
 	var stepToolCalls []ToolCallContent
+	var pendingApprovals []ToolCallContent
 	var stepUsage Usage
 	stepFinishReason := FinishReasonUnknown
 	var stepWarnings []CallWarning
@@ -1108,65 +1514,28 @@ func (a *agent) processStepStream(ctx context.Context, stream StreamResponse, op
 	}
 	activeReasoningContent := make(map[string]reasoningContent)
 
-	// Set up concurrent tool execution
-	type toolExecutionRequest struct {
-		toolCall ToolCallContent
-		parallel bool
-	}
-	toolChan := make(chan toolExecutionRequest, 10)
-	var toolExecutionWg sync.WaitGroup
-	var toolStateMu sync.Mutex
-	toolResults := make([]ToolResultContent, 0)
-	var toolExecutionErr error
-
-	// Create a map for quick tool lookup
-	toolMap := make(map[string]AgentTool)
-	for _, tool := range stepTools {
-		toolMap[tool.Info().Name] = tool
+	// Sub-agents run by the tools forward their parts while the stream is
+	// still being consumed, serialize the chunk callback.
+	onChunk := opts.OnChunk
+	if onChunk != nil {
+		var chunkMu sync.Mutex
+		onChunk = func(part StreamPart) error {
+			chunkMu.Lock()
+			defer chunkMu.Unlock()
+			return opts.OnChunk(part)
+		}
 	}
 
-	// Semaphores for controlling parallelism
-	parallelSem := make(chan struct{}, 5)
-	var sequentialMu sync.Mutex
-
-	// Single coordinator goroutine that dispatches tools
-	toolExecutionWg.Go(func() {
-		for req := range toolChan {
-			if req.parallel {
-				parallelSem <- struct{}{}
-				toolExecutionWg.Go(func() {
-					defer func() { <-parallelSem }()
-					result, isCriticalError := a.executeSingleTool(ctx, toolMap, req.toolCall, opts.OnToolResult)
-					toolStateMu.Lock()
-					toolResults = append(toolResults, result)
-					if isCriticalError && toolExecutionErr == nil {
-						if errorResult, ok := result.Result.(ToolResultOutputContentError); ok && errorResult.Error != nil {
-							toolExecutionErr = errorResult.Error
-						}
-					}
-					toolStateMu.Unlock()
-				})
-			} else {
-				sequentialMu.Lock()
-				result, isCriticalError := a.executeSingleTool(ctx, toolMap, req.toolCall, opts.OnToolResult)
-				toolStateMu.Lock()
-				toolResults = append(toolResults, result)
-				if isCriticalError && toolExecutionErr == nil {
-					if errorResult, ok := result.Result.(ToolResultOutputContentError); ok && errorResult.Error != nil {
-						toolExecutionErr = errorResult.Error
-					}
-				}
-				toolStateMu.Unlock()
-				sequentialMu.Unlock()
-			}
-		}
-	})
+	// Tools start executing as soon as their call is complete, while the rest
+	// of the stream is still being consumed.
+	toolDispatcher := a.newToolDispatcher(ctx, stepTools, opts.OnToolResult, onChunk)
+	defer toolDispatcher.abort()
 
 	// Process stream parts
 	for part := range stream {
 		// Forward all parts to chunk callback
-		if opts.OnChunk != nil {
-			err := opts.OnChunk(part)
+		if onChunk != nil {
+			err := onChunk(part)
 			if err != nil {
 				return stepExecutionResult{}, err
 			}
@@ -1304,6 +1673,20 @@ func (a *agent) processStepStream(ctx context.Context, stream StreamResponse, op
 				ProviderMetadata: part.ProviderMetadata,
 			}
 
+			if toolCall.ProviderExecuted {
+				// Provider executed tools are not run locally, their result
+				// arrives as a separate tool result part.
+				stepContent = append(stepContent, toolCall)
+				if opts.OnToolCall != nil {
+					err := opts.OnToolCall(toolCall)
+					if err != nil {
+						return stepExecutionResult{}, err
+					}
+				}
+				delete(activeToolCalls, part.ID)
+				continue
+			}
+
 			// Validate and potentially repair the tool call
 			validatedToolCall := a.validateAndRepairToolCall(ctx, toolCall, stepTools, a.settings.systemPrompt, nil, opts.RepairToolCall)
 			stepToolCalls = append(stepToolCalls, validatedToolCall)
@@ -1316,18 +1699,33 @@ func (a *agent) processStepStream(ctx context.Context, stream StreamResponse, op
 				}
 			}
 
-			// Determine if tool can run in parallel
-			isParallel := false
-			if tool, exists := toolMap[validatedToolCall.ToolName]; exists {
-				isParallel = tool.Info().Parallel
+			// Tools that require approval are not run, the step is
+			// suspended once the stream completes.
+			if len(toolCallsRequiringApproval(stepTools, []ToolCallContent{validatedToolCall})) > 0 {
+				pendingApprovals = append(pendingApprovals, validatedToolCall)
+			} else {
+				toolDispatcher.submit(validatedToolCall)
 			}
 
-			// Send tool call to execution channel
-			toolChan <- toolExecutionRequest{toolCall: validatedToolCall, parallel: isParallel}
-
 			// Clean up active tool call
 			delete(activeToolCalls, part.ID)
 
+		case StreamPartTypeToolResult:
+			toolResult := ToolResultContent{
+				ToolCallID:       part.ID,
+				ToolName:         part.ToolCallName,
+				Result:           part.ToolResult,
+				ProviderExecuted: part.ProviderExecuted,
+				ProviderMetadata: part.ProviderMetadata,
+			}
+			stepContent = append(stepContent, toolResult)
+			if opts.OnToolResult != nil {
+				err := opts.OnToolResult(toolResult)
+				if err != nil {
+					return stepExecutionResult{}, err
+				}
+			}
+
 		case StreamPartTypeSource:
 			sourceContent := SourceContent{
 				SourceType:       part.SourceType,
@@ -1360,20 +1758,29 @@ func (a *agent) processStepStream(ctx context.Context, stream StreamResponse, op
 		}
 	}
 
-	// Close the tool execution channel and wait for all executions to complete
-	close(toolChan)
-	toolExecutionWg.Wait()
-
-	// Check for tool execution errors
-	if toolExecutionErr != nil {
-		return stepExecutionResult{}, toolExecutionErr
+	// Wait for all tool executions to complete
+	toolResults, err := toolDispatcher.wait()
+	if err != nil {
+		return stepExecutionResult{}, err
+	}
+	if len(pendingApprovals) > 0 {
+		return stepExecutionResult{
+			StepResult: StepResult{
+				Response: Response{
+					Content:          stepContent,
+					FinishReason:     stepFinishReason,
+					Usage:            stepUsage,
+					Warnings:         stepWarnings,
+					ProviderMetadata: stepProviderMetadata,
+				},
+				ToolUsage: toolDispatcher.run.totalUsage(),
+			},
+			ToolResults:      toolResults,
+			PendingApprovals: pendingApprovals,
+		}, nil
 	}
-
-	// Add tool results to content if any
-	if len(toolResults) > 0 {
-		for _, result := range toolResults {
-			stepContent = append(stepContent, result)
-		}
+	for _, result := range toolResults {
+		stepContent = append(stepContent, result)
 	}
 
 	stepResult := StepResult{
@@ -1384,7 +1791,8 @@ func (a *agent) processStepStream(ctx context.Context, stream StreamResponse, op
 			Warnings:         stepWarnings,
 			ProviderMetadata: stepProviderMetadata,
 		},
-		Messages: toResponseMessages(stepContent),
+		Messages:  toResponseMessages(stepContent),
+		ToolUsage: toolDispatcher.run.totalUsage(),
 	}
 
 	// Determine if we should continue (has tool calls and not stopped)
@@ -1404,6 +1812,7 @@ func addUsage(a, b Usage) Usage {
 		ReasoningTokens:     a.ReasoningTokens + b.ReasoningTokens,
 		CacheCreationTokens: a.CacheCreationTokens + b.CacheCreationTokens,
 		CacheReadTokens:     a.CacheReadTokens + b.CacheReadTokens,
+		Cost:                addCost(a.Cost, b.Cost),
 	}
 }
 
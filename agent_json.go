@@ -0,0 +1,20 @@
+package fantasy
+
+import "encoding/json"
+
+// UnmarshalJSON implements json.Unmarshaler for StepResult.
+func (s *StepResult) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Messages  []Message `json:"messages"`
+		ToolUsage Usage     `json:"tool_usage"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if err := s.Response.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	s.Messages = aux.Messages
+	s.ToolUsage = aux.ToolUsage
+	return nil
+}
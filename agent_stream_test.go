@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -595,3 +597,196 @@ func TestStreamingAgentSources(t *testing.T) {
 	resultSources := result.Response.Content.Sources()
 	require.Equal(t, 2, len(resultSources))
 }
+
+// TestStreamingAgentParallelTools tests that parallel-safe tools overlap and
+// that their results keep the order of the tool calls.
+func TestStreamingAgentParallelTools(t *testing.T) {
+	t.Parallel()
+
+	stepCount := 0
+	mockModel := &mockLanguageModel{
+		streamFunc: func(ctx context.Context, call Call) (StreamResponse, error) {
+			stepCount++
+			return func(yield func(StreamPart) bool) {
+				if stepCount == 1 {
+					for _, id := range []string{"call-1", "call-2", "call-3"} {
+						if !yield(StreamPart{
+							Type:          StreamPartTypeToolCall,
+							ID:            id,
+							ToolCallName:  "lookup",
+							ToolCallInput: `{}`,
+						}) {
+							return
+						}
+					}
+					yield(StreamPart{Type: StreamPartTypeFinish, FinishReason: FinishReasonToolCalls})
+					return
+				}
+				yield(StreamPart{Type: StreamPartTypeFinish, FinishReason: FinishReasonStop})
+			}, nil
+		},
+	}
+
+	var started sync.WaitGroup
+	started.Add(3)
+	lookup := &mockTool{
+		name:     "lookup",
+		parallel: true,
+		executeFunc: func(ctx context.Context, call ToolCall) (ToolResponse, error) {
+			started.Done()
+			started.Wait()
+			if call.ID == "call-1" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			return NewTextResponse(call.ID), nil
+		},
+	}
+
+	var callbackOrder []string
+	agent := NewAgent(mockModel, WithTools(lookup))
+	result, err := agent.Stream(context.Background(), AgentStreamCall{
+		Prompt: "look things up",
+		OnToolResult: func(result ToolResultContent) error {
+			callbackOrder = append(callbackOrder, result.ToolCallID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, callbackOrder, 3)
+	require.Equal(t, "call-1", callbackOrder[2])
+
+	toolResults := result.Steps[0].Content.ToolResults()
+	require.Len(t, toolResults, 3)
+	require.Equal(t, "call-1", toolResults[0].ToolCallID)
+	require.Equal(t, "call-2", toolResults[1].ToolCallID)
+	require.Equal(t, "call-3", toolResults[2].ToolCallID)
+}
+
+func TestStreamingAgentProviderDefinedTools(t *testing.T) {
+	t.Parallel()
+
+	mockModel := &mockLanguageModel{
+		streamFunc: func(ctx context.Context, call Call) (StreamResponse, error) {
+			return func(yield func(StreamPart) bool) {
+				parts := []StreamPart{
+					{Type: StreamPartTypeToolCall, ID: "srv-1", ToolCallName: "web_search", ToolCallInput: `{"query":"go"}`, ProviderExecuted: true},
+					{Type: StreamPartTypeToolResult, ID: "srv-1", ToolCallName: "web_search", ToolResult: ToolResultOutputContentText{Text: "results"}, ProviderExecuted: true},
+					{Type: StreamPartTypeTextStart, ID: "text-1"},
+					{Type: StreamPartTypeTextDelta, ID: "text-1", Delta: "Go is a language."},
+					{Type: StreamPartTypeTextEnd, ID: "text-1"},
+					{Type: StreamPartTypeFinish, FinishReason: FinishReasonStop},
+				}
+				for _, part := range parts {
+					if !yield(part) {
+						return
+					}
+				}
+			}, nil
+		},
+	}
+
+	var toolCalls []ToolCallContent
+	var toolResults []ToolResultContent
+	agent := NewAgent(mockModel, WithTools(ProviderDefinedTool{ID: "test.web_search", Name: "web_search"}))
+	result, err := agent.Stream(context.Background(), AgentStreamCall{
+		Prompt: "what is go?",
+		OnToolCall: func(toolCall ToolCallContent) error {
+			toolCalls = append(toolCalls, toolCall)
+			return nil
+		},
+		OnToolResult: func(result ToolResultContent) error {
+			toolResults = append(toolResults, result)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Steps, 1)
+	require.Len(t, toolCalls, 1)
+	require.True(t, toolCalls[0].ProviderExecuted)
+	require.Len(t, toolResults, 1)
+	require.True(t, toolResults[0].ProviderExecuted)
+	require.Equal(t, "Go is a language.", result.Response.Content.Text())
+	require.Len(t, result.Response.Content.ToolResults(), 1)
+}
+
+func TestStreamingAgentHooks(t *testing.T) {
+	t.Parallel()
+
+	type ctxKey struct{}
+
+	step := 0
+	mockModel := &mockLanguageModel{
+		streamFunc: func(ctx context.Context, call Call) (StreamResponse, error) {
+			require.Equal(t, "step", ctx.Value(ctxKey{}))
+			step++
+			return func(yield func(StreamPart) bool) {
+				if step == 1 {
+					if !yield(StreamPart{Type: StreamPartTypeToolCall, ID: "call-1", ToolCallName: "echo", ToolCallInput: `{"message":"hi"}`}) {
+						return
+					}
+					yield(StreamPart{Type: StreamPartTypeFinish, FinishReason: FinishReasonToolCalls})
+					return
+				}
+				if !yield(StreamPart{Type: StreamPartTypeTextDelta, ID: "0", Delta: "done"}) {
+					return
+				}
+				yield(StreamPart{Type: StreamPartTypeFinish, FinishReason: FinishReasonStop})
+			}, nil
+		},
+	}
+
+	type echoInput struct {
+		Message string `json:"message"`
+	}
+	echoTool := NewAgentTool("echo", "Echo a message",
+		func(ctx context.Context, input echoInput, call ToolCall) (ToolResponse, error) {
+			require.Equal(t, "tool", ctx.Value(ctxKey{}))
+			return NewTextResponse(input.Message), nil
+		},
+	)
+
+	var events []string
+	hooks := AgentHooks{
+		OnRunStart: func(ctx context.Context, model LanguageModel) context.Context {
+			events = append(events, "run start "+model.Model())
+			return context.WithValue(ctx, ctxKey{}, "run")
+		},
+		OnRunFinish: func(ctx context.Context, result *AgentResult, err error) {
+			require.Equal(t, "run", ctx.Value(ctxKey{}))
+			events = append(events, fmt.Sprintf("run finish %d", len(result.Steps)))
+		},
+		OnStepStart: func(ctx context.Context, stepNumber int, model LanguageModel) context.Context {
+			require.Equal(t, "run", ctx.Value(ctxKey{}))
+			events = append(events, fmt.Sprintf("step start %d", stepNumber))
+			return context.WithValue(ctx, ctxKey{}, "step")
+		},
+		OnStepFinish: func(ctx context.Context, step *StepResult, err error) {
+			require.Equal(t, "step", ctx.Value(ctxKey{}))
+			events = append(events, "step finish "+string(step.FinishReason))
+		},
+		OnToolStart: func(ctx context.Context, toolCall ToolCall) context.Context {
+			require.Equal(t, "step", ctx.Value(ctxKey{}))
+			events = append(events, "tool start "+toolCall.Name)
+			return context.WithValue(ctx, ctxKey{}, "tool")
+		},
+		OnToolFinish: func(ctx context.Context, toolCall ToolCall, response ToolResponse, err error) {
+			require.Equal(t, "tool", ctx.Value(ctxKey{}))
+			events = append(events, "tool finish "+response.Content)
+		},
+	}
+
+	agent := NewAgent(mockModel, WithTools(echoTool), WithHooks(hooks))
+	_, err := agent.Stream(t.Context(), AgentStreamCall{Prompt: "echo hi"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"run start mock-model",
+		"step start 0",
+		"tool start echo",
+		"tool finish hi",
+		"step finish tool-calls",
+		"step start 1",
+		"step finish stop",
+		"run finish 2",
+	}, events)
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -17,6 +19,8 @@ type mockTool struct {
 	description     string
 	parameters      map[string]any
 	required        []string
+	outputSchema    map[string]any
+	parallel        bool
 	executeFunc     func(ctx context.Context, call ToolCall) (ToolResponse, error)
 }
 
@@ -30,10 +34,12 @@ func (m *mockTool) ProviderOptions() ProviderOptions {
 
 func (m *mockTool) Info() ToolInfo {
 	return ToolInfo{
-		Name:        m.name,
-		Description: m.description,
-		Parameters:  m.parameters,
-		Required:    m.required,
+		Name:         m.name,
+		Description:  m.description,
+		Parameters:   m.parameters,
+		Required:     m.required,
+		OutputSchema: m.outputSchema,
+		Parallel:     m.parallel,
 	}
 }
 
@@ -48,6 +54,9 @@ func (m *mockTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 type mockLanguageModel struct {
 	generateFunc func(ctx context.Context, call Call) (*Response, error)
 	streamFunc   func(ctx context.Context, call Call) (StreamResponse, error)
+
+	generateObjectFunc func(ctx context.Context, call ObjectCall) (*ObjectResponse, error)
+	streamObjectFunc   func(ctx context.Context, call ObjectCall) (ObjectStreamResponse, error)
 }
 
 func (m *mockLanguageModel) Generate(ctx context.Context, call Call) (*Response, error) {
@@ -83,10 +92,16 @@ func (m *mockLanguageModel) Model() string {
 }
 
 func (m *mockLanguageModel) GenerateObject(ctx context.Context, call ObjectCall) (*ObjectResponse, error) {
+	if m.generateObjectFunc != nil {
+		return m.generateObjectFunc(ctx, call)
+	}
 	return nil, fmt.Errorf("mock GenerateObject not implemented")
 }
 
 func (m *mockLanguageModel) StreamObject(ctx context.Context, call ObjectCall) (ObjectStreamResponse, error) {
+	if m.streamObjectFunc != nil {
+		return m.streamObjectFunc(ctx, call)
+	}
 	return nil, fmt.Errorf("mock StreamObject not implemented")
 }
 
@@ -577,6 +592,38 @@ func TestAgent_Generate_WithSystemPrompt(t *testing.T) {
 	require.NotNil(t, result)
 }
 
+func TestAgent_Generate_StopSequencesAndSeed(t *testing.T) {
+	t.Parallel()
+
+	var calls []Call
+	model := &mockLanguageModel{
+		generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+			calls = append(calls, call)
+			return &Response{
+				Content:      []Content{TextContent{Text: "Hello"}},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+
+	agent := NewAgent(model, WithStopSequences("END", "STOP"), WithSeed(42))
+
+	_, err := agent.Generate(context.Background(), AgentCall{Prompt: "test prompt"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"END", "STOP"}, calls[0].StopSequences)
+	require.Equal(t, int64(42), *calls[0].Seed)
+
+	// Call options take precedence over the agent settings
+	_, err = agent.Generate(context.Background(), AgentCall{
+		Prompt:        "test prompt",
+		StopSequences: []string{"###"},
+		Seed:          Opt[int64](7),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"###"}, calls[1].StopSequences)
+	require.Equal(t, int64(7), *calls[1].Seed)
+}
+
 // Test options.activeTools filtering
 func TestAgent_Generate_OptionsActiveTools(t *testing.T) {
 	t.Parallel()
@@ -843,6 +890,11 @@ func TestStopConditions(t *testing.T) {
 		// Should stop when at or over limit
 		require.True(t, condition([]StepResult{step1, step2, step3})) // 45 tokens
 
+		// Should count the usage of the tools
+		withTools := step2
+		withTools.ToolUsage = Usage{TotalTokens: 5}
+		require.True(t, condition([]StepResult{step1, withTools})) // 30 tokens
+
 		// Should not stop with empty steps
 		require.False(t, condition([]StepResult{}))
 	})
@@ -1344,7 +1396,7 @@ func TestToolCallRepair(t *testing.T) {
 		toolCalls := result.Steps[0].Content.ToolCalls()
 		require.Len(t, toolCalls, 1)
 		require.True(t, toolCalls[0].Invalid) // Should be invalid
-		require.Contains(t, toolCalls[0].ValidationError.Error(), "missing required parameter: value")
+		require.Contains(t, toolCalls[0].ValidationError.Error(), "Required property 'value' is missing")
 	})
 
 	t.Run("Invalid tool call with successful repair", func(t *testing.T) {
@@ -1449,7 +1501,107 @@ func TestToolCallRepair(t *testing.T) {
 		toolCalls := result.Steps[0].Content.ToolCalls()
 		require.Len(t, toolCalls, 1)
 		require.True(t, toolCalls[0].Invalid) // Should be invalid
-		require.Contains(t, toolCalls[0].ValidationError.Error(), "missing required parameter: value")
+		require.Contains(t, toolCalls[0].ValidationError.Error(), "Required property 'value' is missing")
+	})
+
+	t.Run("Schema violation is repaired", func(t *testing.T) {
+		t.Parallel()
+		model := &mockLanguageModel{
+			generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+				return &Response{
+					Content: ResponseContent{
+						ToolCallContent{
+							ToolCallID: "call1",
+							ToolName:   "test_tool",
+							Input:      `{"units": "kelvin"}`, // Not one of the enum values
+						},
+					},
+					Usage:        Usage{TotalTokens: 10},
+					FinishReason: FinishReasonStop,
+				}, nil
+			},
+		}
+
+		tool := &mockTool{
+			name:        "test_tool",
+			description: "Test tool",
+			parameters: map[string]any{
+				"units": map[string]any{"type": "string", "enum": []any{"celsius", "fahrenheit"}},
+			},
+			required: []string{"units"},
+		}
+
+		var validationErr error
+		repairFunc := func(ctx context.Context, options ToolCallRepairOptions) (*ToolCallContent, error) {
+			validationErr = options.ValidationError
+			repairedToolCall := options.OriginalToolCall
+			repairedToolCall.Input = `{"units": "celsius"}`
+			return &repairedToolCall, nil
+		}
+
+		agent := NewAgent(model, WithTools(tool), WithRepairToolCall(repairFunc), WithStopConditions(StepCountIs(2)))
+
+		result, err := agent.Generate(context.Background(), AgentCall{
+			Prompt: "test prompt",
+		})
+
+		require.NoError(t, err)
+		toolCalls := result.Steps[0].Content.ToolCalls()
+		require.Len(t, toolCalls, 1)
+		require.False(t, toolCalls[0].Invalid)
+
+		var inputErr *ToolInputError
+		require.ErrorAs(t, validationErr, &inputErr)
+		require.Equal(t, "test_tool", inputErr.ToolName)
+		require.Len(t, inputErr.Violations, 1)
+		require.Equal(t, "/units", inputErr.Violations[0].Path)
+		require.Equal(t, "enum", inputErr.Violations[0].Keyword)
+	})
+
+	t.Run("Tool output violating its output schema", func(t *testing.T) {
+		t.Parallel()
+		model := &mockLanguageModel{
+			generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+				return &Response{
+					Content: ResponseContent{
+						ToolCallContent{ToolCallID: "call1", ToolName: "test_tool", Input: `{}`},
+						ToolCallContent{ToolCallID: "call2", ToolName: "test_tool", Input: `{}`},
+					},
+					Usage:        Usage{TotalTokens: 10},
+					FinishReason: FinishReasonStop,
+				}, nil
+			},
+		}
+
+		tool := &mockTool{
+			name:        "test_tool",
+			description: "Test tool",
+			outputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"temperature": map[string]any{"type": "number"}},
+				"required":   []any{"temperature"},
+			},
+			executeFunc: func(ctx context.Context, call ToolCall) (ToolResponse, error) {
+				if call.ID == "call1" {
+					return NewTextResponse(`{"temperature": 21.5}`), nil
+				}
+				return NewTextResponse(`{"temperature": "warm"}`), nil
+			},
+		}
+
+		agent := NewAgent(model, WithTools(tool), WithStopConditions(StepCountIs(1)))
+
+		result, err := agent.Generate(context.Background(), AgentCall{
+			Prompt: "test prompt",
+		})
+
+		require.NoError(t, err)
+		toolResults := result.Steps[0].Content.ToolResults()
+		require.Len(t, toolResults, 2)
+		require.Equal(t, ToolResultOutputContentText{Text: `{"temperature": 21.5}`}, toolResults[0].Result)
+		errResult, ok := toolResults[1].Result.(ToolResultOutputContentError)
+		require.True(t, ok)
+		require.Contains(t, errResult.Error.Error(), "output of tool test_tool does not match its output schema")
 	})
 
 	t.Run("Nonexistent tool call", func(t *testing.T) {
@@ -1768,3 +1920,211 @@ func TestAgent_MediaToolResponses(t *testing.T) {
 		require.Equal(t, 600, metadata.Height)
 	})
 }
+
+func TestAgent_ParallelTools(t *testing.T) {
+	t.Parallel()
+
+	toolCallsModel := func(calls ...ToolCallContent) *mockLanguageModel {
+		step := 0
+		return &mockLanguageModel{
+			generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+				step++
+				if step > 1 {
+					return &Response{
+						Content:      []Content{TextContent{Text: "Done"}},
+						FinishReason: FinishReasonStop,
+					}, nil
+				}
+				content := make([]Content, 0, len(calls))
+				for _, c := range calls {
+					content = append(content, c)
+				}
+				return &Response{
+					Content:      content,
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			},
+		}
+	}
+
+	t.Run("runs parallel tools concurrently and keeps call order", func(t *testing.T) {
+		t.Parallel()
+
+		var started sync.WaitGroup
+		started.Add(3)
+		lookup := &mockTool{
+			name:     "lookup",
+			parallel: true,
+			executeFunc: func(ctx context.Context, call ToolCall) (ToolResponse, error) {
+				// Every call blocks until all of them are running, so this only
+				// completes if the calls overlap.
+				started.Done()
+				started.Wait()
+				// Finish in reverse order to make sure results are reordered.
+				switch call.ID {
+				case "call-1":
+					time.Sleep(30 * time.Millisecond)
+				case "call-2":
+					time.Sleep(15 * time.Millisecond)
+				}
+				return NewTextResponse(call.ID), nil
+			},
+		}
+
+		model := toolCallsModel(
+			ToolCallContent{ToolCallID: "call-1", ToolName: "lookup", Input: `{}`},
+			ToolCallContent{ToolCallID: "call-2", ToolName: "lookup", Input: `{}`},
+			ToolCallContent{ToolCallID: "call-3", ToolName: "lookup", Input: `{}`},
+		)
+
+		agent := NewAgent(model, WithTools(lookup))
+		result, err := agent.Generate(context.Background(), AgentCall{Prompt: "look things up"})
+		require.NoError(t, err)
+
+		toolResults := result.Steps[0].Content.ToolResults()
+		require.Len(t, toolResults, 3)
+		for i, toolResult := range toolResults {
+			id := fmt.Sprintf("call-%d", i+1)
+			require.Equal(t, id, toolResult.ToolCallID)
+			text, ok := toolResult.Result.(ToolResultOutputContentText)
+			require.True(t, ok)
+			require.Equal(t, id, text.Text)
+		}
+	})
+
+	t.Run("sequential tools act as barriers", func(t *testing.T) {
+		t.Parallel()
+
+		var mu sync.Mutex
+		var events []string
+		record := func(event string) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}
+		run := func(ctx context.Context, call ToolCall) (ToolResponse, error) {
+			record("start " + call.ID)
+			time.Sleep(10 * time.Millisecond)
+			record("end " + call.ID)
+			return NewTextResponse(call.ID), nil
+		}
+		read := &mockTool{name: "read", parallel: true, executeFunc: run}
+		write := &mockTool{name: "write", executeFunc: run}
+
+		model := toolCallsModel(
+			ToolCallContent{ToolCallID: "read-1", ToolName: "read", Input: `{}`},
+			ToolCallContent{ToolCallID: "read-2", ToolName: "read", Input: `{}`},
+			ToolCallContent{ToolCallID: "write", ToolName: "write", Input: `{}`},
+			ToolCallContent{ToolCallID: "read-3", ToolName: "read", Input: `{}`},
+		)
+
+		agent := NewAgent(model, WithTools(read, write))
+		result, err := agent.Generate(context.Background(), AgentCall{Prompt: "read then write"})
+		require.NoError(t, err)
+		require.Len(t, result.Steps[0].Content.ToolResults(), 4)
+
+		index := func(event string) int {
+			for i, e := range events {
+				if e == event {
+					return i
+				}
+			}
+			t.Fatalf("event %q not recorded", event)
+			return -1
+		}
+		require.Greater(t, index("start write"), index("end read-1"))
+		require.Greater(t, index("start write"), index("end read-2"))
+		require.Greater(t, index("start read-3"), index("end write"))
+	})
+
+	t.Run("critical error cancels running tools", func(t *testing.T) {
+		t.Parallel()
+
+		cancelled := make(chan struct{})
+		slow := &mockTool{
+			name:     "slow",
+			parallel: true,
+			executeFunc: func(ctx context.Context, call ToolCall) (ToolResponse, error) {
+				select {
+				case <-ctx.Done():
+					close(cancelled)
+					return ToolResponse{}, ctx.Err()
+				case <-time.After(5 * time.Second):
+					return NewTextResponse("too slow"), nil
+				}
+			},
+		}
+		failing := &mockTool{
+			name:     "failing",
+			parallel: true,
+			executeFunc: func(ctx context.Context, call ToolCall) (ToolResponse, error) {
+				return ToolResponse{}, errors.New("boom")
+			},
+		}
+
+		model := toolCallsModel(
+			ToolCallContent{ToolCallID: "call-1", ToolName: "slow", Input: `{}`},
+			ToolCallContent{ToolCallID: "call-2", ToolName: "failing", Input: `{}`},
+		)
+
+		agent := NewAgent(model, WithTools(slow, failing))
+		result, err := agent.Generate(context.Background(), AgentCall{Prompt: "fail"})
+		// Like Stream, Generate fails with the tool error instead of
+		// returning the steps completed so far.
+		require.EqualError(t, err, "boom")
+		require.Nil(t, result)
+
+		select {
+		case <-cancelled:
+		default:
+			t.Fatal("expected the running tool to be cancelled")
+		}
+	})
+}
+
+func TestAgent_ProviderDefinedTools(t *testing.T) {
+	t.Parallel()
+
+	webSearch := ProviderDefinedTool{
+		ID:   "test.web_search",
+		Name: "web_search",
+		Args: map[string]any{"max_uses": 3},
+	}
+
+	var calls []Call
+	model := &mockLanguageModel{
+		generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+			calls = append(calls, call)
+			return &Response{
+				Content: []Content{
+					ToolCallContent{ToolCallID: "srv-1", ToolName: "web_search", Input: `{"query":"go"}`, ProviderExecuted: true},
+					ToolResultContent{ToolCallID: "srv-1", ToolName: "web_search", Result: ToolResultOutputContentText{Text: "results"}, ProviderExecuted: true},
+					SourceContent{SourceType: SourceTypeURL, ID: "src-1", URL: "https://go.dev"},
+					TextContent{Text: "Go is a programming language."},
+				},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+
+	agent := NewAgent(model, WithTools(webSearch))
+	result, err := agent.Generate(context.Background(), AgentCall{Prompt: "what is go?"})
+	require.NoError(t, err)
+
+	require.Len(t, calls, 1)
+	require.Equal(t, []Tool{webSearch}, calls[0].Tools)
+
+	require.Len(t, result.Steps, 1)
+	step := result.Steps[0]
+	require.Len(t, step.Content.ToolCalls(), 1)
+	require.Len(t, step.Content.ToolResults(), 1)
+	require.Equal(t, "results", step.Content.ToolResults()[0].Result.(ToolResultOutputContentText).Text)
+	require.Len(t, step.Content.Sources(), 1)
+
+	// Provider executed results stay in the assistant message.
+	require.Len(t, step.Messages, 1)
+	require.Equal(t, MessageRoleAssistant, step.Messages[0].Role)
+	resultPart, ok := AsMessagePart[ToolResultPart](step.Messages[0].Content[1])
+	require.True(t, ok)
+	require.True(t, resultPart.ProviderExecuted)
+}
@@ -0,0 +1,206 @@
+package fantasy
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ApprovalDecision is the decision taken on a tool call that requires
+// approval.
+type ApprovalDecision string
+
+const (
+	// ApprovalDecisionApprove runs the tool call as the model requested it.
+	ApprovalDecisionApprove ApprovalDecision = "approve"
+	// ApprovalDecisionDeny does not run the tool call, the model receives an
+	// error result instead.
+	ApprovalDecisionDeny ApprovalDecision = "deny"
+	// ApprovalDecisionEdit runs the tool call with an edited input.
+	ApprovalDecisionEdit ApprovalDecision = "edit"
+)
+
+// ToolApproval is the decision on a pending tool call of a suspended run.
+type ToolApproval struct {
+	ToolCallID string           `json:"tool_call_id"`
+	Decision   ApprovalDecision `json:"decision"`
+	// Input replaces the input of the tool call when it is edited.
+	Input string `json:"input,omitempty"`
+	// Reason is sent to the model when the tool call is denied.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ApproveToolCall approves a pending tool call.
+func ApproveToolCall(toolCallID string) ToolApproval {
+	return ToolApproval{ToolCallID: toolCallID, Decision: ApprovalDecisionApprove}
+}
+
+// DenyToolCall denies a pending tool call, the reason is sent to the model.
+func DenyToolCall(toolCallID, reason string) ToolApproval {
+	return ToolApproval{ToolCallID: toolCallID, Decision: ApprovalDecisionDeny, Reason: reason}
+}
+
+// EditToolCall approves a pending tool call with an edited input.
+func EditToolCall(toolCallID, input string) ToolApproval {
+	return ToolApproval{ToolCallID: toolCallID, Decision: ApprovalDecisionEdit, Input: input}
+}
+
+// SuspendedRun is the state of an agent run that stopped before running tools
+// that require approval. It can be serialized to JSON, e.g. to persist it
+// between requests, and is continued by passing it to [AgentCall.Resume]
+// together with a decision for each pending tool call.
+//
+// When resumed, the tools are looked up in the tools of the agent.
+type SuspendedRun struct {
+	// Messages is the conversation sent to the model in the suspended step.
+	Messages []Message `json:"messages"`
+	// Steps are the steps completed before the suspended step.
+	Steps []StepResult `json:"steps"`
+	// Response is the model response of the suspended step, with the
+	// validated tool calls.
+	Response Response `json:"response"`
+	// ToolUsage is the usage reported by the tools of the suspended step
+	// that did not require approval.
+	ToolUsage Usage `json:"tool_usage"`
+	// ToolResults are the results of the tool calls of the suspended step
+	// that did not require approval.
+	ToolResults []ToolResultContent `json:"tool_results"`
+	// PendingApprovals are the tool calls waiting for a decision.
+	PendingApprovals []ToolCallContent `json:"pending_approvals"`
+}
+
+// newSuspendedResult creates the result of a run suspended after the given
+// steps.
+func newSuspendedResult(steps []StepResult, suspended *SuspendedRun) *AgentResult {
+	totalUsage := addUsage(suspended.Response.Usage, suspended.ToolUsage)
+	for _, step := range steps {
+		totalUsage = addUsage(totalUsage, step.Usage)
+		totalUsage = addUsage(totalUsage, step.ToolUsage)
+	}
+	return &AgentResult{
+		Steps:      steps,
+		Response:   suspended.Response,
+		TotalUsage: totalUsage,
+		Suspended:  suspended,
+	}
+}
+
+// toolCallsRequiringApproval returns the valid tool calls to tools that
+// require approval.
+func toolCallsRequiringApproval(tools []AgentTool, toolCalls []ToolCallContent) []ToolCallContent {
+	var pending []ToolCallContent
+	for _, toolCall := range toolCalls {
+		if toolCall.Invalid {
+			continue
+		}
+		for _, tool := range tools {
+			if info := tool.Info(); info.Name == toolCall.ToolName && info.RequiresApproval {
+				pending = append(pending, toolCall)
+				break
+			}
+		}
+	}
+	return pending
+}
+
+// withoutToolCalls returns the tool calls that are not in exclude.
+func withoutToolCalls(toolCalls, exclude []ToolCallContent) []ToolCallContent {
+	return slices.DeleteFunc(slices.Clone(toolCalls), func(toolCall ToolCallContent) bool {
+		return slices.ContainsFunc(exclude, func(excluded ToolCallContent) bool {
+			return excluded.ToolCallID == toolCall.ToolCallID
+		})
+	})
+}
+
+// resumeStep completes the suspended step of a run, applying the approvals to
+// its pending tool calls.
+func (a *agent) resumeStep(ctx context.Context, run *SuspendedRun, approvals []ToolApproval, toolResultCallback func(result ToolResultContent) error) (StepResult, error) {
+	decisions := make(map[string]ToolApproval, len(approvals))
+	for _, approval := range approvals {
+		if !slices.ContainsFunc(run.PendingApprovals, func(toolCall ToolCallContent) bool {
+			return toolCall.ToolCallID == approval.ToolCallID
+		}) {
+			return StepResult{}, &Error{Title: "invalid argument", Message: fmt.Sprintf("no pending tool call with id %q", approval.ToolCallID)}
+		}
+		decisions[approval.ToolCallID] = approval
+	}
+
+	var approved []ToolCallContent
+	edited := map[string]ToolCallContent{}
+	results := map[string]ToolResultContent{}
+	for _, result := range run.ToolResults {
+		results[result.ToolCallID] = result
+	}
+	for _, toolCall := range run.PendingApprovals {
+		approval, ok := decisions[toolCall.ToolCallID]
+		if !ok {
+			return StepResult{}, &Error{Title: "invalid argument", Message: fmt.Sprintf("missing approval for tool call %q", toolCall.ToolCallID)}
+		}
+		switch approval.Decision {
+		case ApprovalDecisionApprove:
+			approved = append(approved, toolCall)
+		case ApprovalDecisionEdit:
+			toolCall.Input = approval.Input
+			if err := a.validateToolCall(toolCall, a.settings.tools); err != nil {
+				toolCall.Invalid = true
+				toolCall.ValidationError = err
+			}
+			edited[toolCall.ToolCallID] = toolCall
+			approved = append(approved, toolCall)
+		case ApprovalDecisionDeny:
+			result := ToolResultContent{
+				ToolCallID: toolCall.ToolCallID,
+				ToolName:   toolCall.ToolName,
+				Result: ToolResultOutputContentError{
+					Error: errors.New(cmp.Or(approval.Reason, "the tool call was denied")),
+				},
+			}
+			results[toolCall.ToolCallID] = result
+			if toolResultCallback != nil {
+				if err := toolResultCallback(result); err != nil {
+					return StepResult{}, err
+				}
+			}
+		default:
+			return StepResult{}, &Error{Title: "invalid argument", Message: fmt.Sprintf("unknown approval decision %q", approval.Decision)}
+		}
+	}
+
+	toolResults, toolUsage, err := a.executeTools(ctx, a.settings.tools, approved, toolResultCallback)
+	if err != nil {
+		return StepResult{}, err
+	}
+	for _, result := range toolResults {
+		results[result.ToolCallID] = result
+	}
+
+	// Rebuild the step content with the edited tool calls, followed by the
+	// tool results in the order of the calls.
+	var stepContent []Content
+	var stepToolCalls []ToolCallContent
+	for _, content := range run.Response.Content {
+		if toolCall, ok := AsContentType[ToolCallContent](content); ok && !toolCall.ProviderExecuted {
+			if editedToolCall, ok := edited[toolCall.ToolCallID]; ok {
+				toolCall = editedToolCall
+			}
+			stepToolCalls = append(stepToolCalls, toolCall)
+			content = toolCall
+		}
+		stepContent = append(stepContent, content)
+	}
+	for _, toolCall := range stepToolCalls {
+		if result, ok := results[toolCall.ToolCallID]; ok {
+			stepContent = append(stepContent, result)
+		}
+	}
+
+	response := run.Response
+	response.Content = stepContent
+	return StepResult{
+		Response:  response,
+		Messages:  toResponseMessages(stepContent),
+		ToolUsage: addUsage(run.ToolUsage, toolUsage),
+	}, nil
+}
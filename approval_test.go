@@ -0,0 +1,259 @@
+package fantasy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type deployInput struct {
+	Env string `json:"env"`
+}
+
+// approvalFixture is an agent that calls a tool requiring approval together
+// with a tool that does not in its first step.
+type approvalFixture struct {
+	agent    Agent
+	deployed []string
+	lookups  int
+	prompts  []Prompt
+}
+
+func newApprovalFixture() *approvalFixture {
+	f := &approvalFixture{}
+	toolCalls := ResponseContent{
+		TextContent{Text: "Deploying."},
+		ToolCallContent{ToolCallID: "call-1", ToolName: "lookup", Input: `{}`},
+		ToolCallContent{ToolCallID: "call-2", ToolName: "deploy", Input: `{"env":"production"}`},
+	}
+	model := &mockLanguageModel{
+		generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+			f.prompts = append(f.prompts, call.Prompt)
+			if len(f.prompts) == 1 {
+				return &Response{
+					Content:      toolCalls,
+					FinishReason: FinishReasonToolCalls,
+					Usage:        Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+				}, nil
+			}
+			return &Response{
+				Content:      ResponseContent{TextContent{Text: "done"}},
+				FinishReason: FinishReasonStop,
+				Usage:        Usage{InputTokens: 20, OutputTokens: 2, TotalTokens: 22},
+			}, nil
+		},
+		streamFunc: func(ctx context.Context, call Call) (StreamResponse, error) {
+			f.prompts = append(f.prompts, call.Prompt)
+			if len(f.prompts) == 1 {
+				return func(yield func(StreamPart) bool) {
+					for _, part := range []StreamPart{
+						{Type: StreamPartTypeToolCall, ID: "call-1", ToolCallName: "lookup", ToolCallInput: `{}`},
+						{Type: StreamPartTypeToolCall, ID: "call-2", ToolCallName: "deploy", ToolCallInput: `{"env":"production"}`},
+						{Type: StreamPartTypeFinish, FinishReason: FinishReasonToolCalls},
+					} {
+						if !yield(part) {
+							return
+						}
+					}
+				}, nil
+			}
+			return func(yield func(StreamPart) bool) {
+				for _, part := range []StreamPart{
+					{Type: StreamPartTypeTextStart, ID: "0"},
+					{Type: StreamPartTypeTextDelta, ID: "0", Delta: "done"},
+					{Type: StreamPartTypeTextEnd, ID: "0"},
+					{Type: StreamPartTypeFinish, FinishReason: FinishReasonStop},
+				} {
+					if !yield(part) {
+						return
+					}
+				}
+			}, nil
+		},
+	}
+
+	deploy := RequireApproval(NewAgentTool("deploy", "Deploy the application",
+		func(ctx context.Context, input deployInput, call ToolCall) (ToolResponse, error) {
+			f.deployed = append(f.deployed, input.Env)
+			return NewTextResponse("deployed to " + input.Env), nil
+		},
+	))
+	lookup := NewAgentTool("lookup", "Look up the current version",
+		func(ctx context.Context, input struct{}, call ToolCall) (ToolResponse, error) {
+			f.lookups++
+			return NewTextResponse("v1.2.3"), nil
+		},
+	)
+	f.agent = NewAgent(model, WithSystemPrompt("You deploy things."), WithTools(deploy, lookup))
+	return f
+}
+
+// roundtrip serializes and deserializes the suspended run, as if it was
+// persisted between requests.
+func roundtrip(t *testing.T, run *SuspendedRun) *SuspendedRun {
+	t.Helper()
+	data, err := json.Marshal(run)
+	require.NoError(t, err)
+	var decoded SuspendedRun
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	return &decoded
+}
+
+func lastToolResults(t *testing.T, prompt Prompt) map[string]ToolResultOutputContent {
+	t.Helper()
+	last := prompt[len(prompt)-1]
+	require.Equal(t, MessageRoleTool, last.Role)
+	results := map[string]ToolResultOutputContent{}
+	for _, part := range last.Content {
+		result, ok := AsMessagePart[ToolResultPart](part)
+		require.True(t, ok)
+		results[result.ToolCallID] = result.Output
+	}
+	return results
+}
+
+func TestAgent_ToolApproval(t *testing.T) {
+	t.Parallel()
+
+	t.Run("suspends before running the tool", func(t *testing.T) {
+		t.Parallel()
+
+		f := newApprovalFixture()
+		result, err := f.agent.Generate(t.Context(), AgentCall{Prompt: "Deploy to production."})
+		require.NoError(t, err)
+
+		require.NotNil(t, result.Suspended)
+		require.Empty(t, result.Steps)
+		require.Empty(t, f.deployed)
+		require.Equal(t, 1, f.lookups, "tools without approval still run")
+		require.Len(t, result.Suspended.PendingApprovals, 1)
+		require.Equal(t, "call-2", result.Suspended.PendingApprovals[0].ToolCallID)
+		require.Len(t, result.Suspended.ToolResults, 1)
+		require.Equal(t, int64(15), result.TotalUsage.TotalTokens)
+		require.Len(t, result.Response.Content.ToolCalls(), 2)
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		t.Parallel()
+
+		f := newApprovalFixture()
+		result, err := f.agent.Generate(t.Context(), AgentCall{Prompt: "Deploy to production."})
+		require.NoError(t, err)
+
+		result, err = f.agent.Generate(t.Context(), AgentCall{
+			Resume:    roundtrip(t, result.Suspended),
+			Approvals: []ToolApproval{ApproveToolCall("call-2")},
+		})
+		require.NoError(t, err)
+		require.Nil(t, result.Suspended)
+		require.Equal(t, []string{"production"}, f.deployed)
+		require.Equal(t, 1, f.lookups)
+
+		require.Len(t, result.Steps, 2)
+		require.Equal(t, "done", result.Response.Content.Text())
+		require.Equal(t, int64(37), result.TotalUsage.TotalTokens)
+
+		require.Len(t, f.prompts, 2)
+		require.Len(t, f.prompts[1], 4, "system, user, assistant and tool messages")
+		require.Equal(t, MessageRoleSystem, f.prompts[1][0].Role)
+		results := lastToolResults(t, f.prompts[1])
+		require.Equal(t, ToolResultOutputContentText{Text: "v1.2.3"}, results["call-1"])
+		require.Equal(t, ToolResultOutputContentText{Text: "deployed to production"}, results["call-2"])
+	})
+
+	t.Run("deny", func(t *testing.T) {
+		t.Parallel()
+
+		f := newApprovalFixture()
+		result, err := f.agent.Generate(t.Context(), AgentCall{Prompt: "Deploy to production."})
+		require.NoError(t, err)
+
+		_, err = f.agent.Generate(t.Context(), AgentCall{
+			Resume:    roundtrip(t, result.Suspended),
+			Approvals: []ToolApproval{DenyToolCall("call-2", "not on a friday")},
+		})
+		require.NoError(t, err)
+		require.Empty(t, f.deployed)
+
+		output, ok := AsToolResultOutputType[ToolResultOutputContentError](lastToolResults(t, f.prompts[1])["call-2"])
+		require.True(t, ok)
+		require.EqualError(t, output.Error, "not on a friday")
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		t.Parallel()
+
+		f := newApprovalFixture()
+		result, err := f.agent.Generate(t.Context(), AgentCall{Prompt: "Deploy to production."})
+		require.NoError(t, err)
+
+		result, err = f.agent.Generate(t.Context(), AgentCall{
+			Resume:    roundtrip(t, result.Suspended),
+			Approvals: []ToolApproval{EditToolCall("call-2", `{"env":"staging"}`)},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"staging"}, f.deployed)
+
+		toolCalls := result.Steps[0].Content.ToolCalls()
+		require.Len(t, toolCalls, 2)
+		require.Equal(t, `{"env":"staging"}`, toolCalls[1].Input)
+	})
+
+	t.Run("rejects provider-defined tools", func(t *testing.T) {
+		t.Parallel()
+
+		require.PanicsWithValue(t, "provider-defined tool web_search cannot require approval", func() {
+			RequireApproval(ProviderDefinedTool{ID: "test.web_search", Name: "web_search"})
+		})
+	})
+
+	t.Run("missing approval", func(t *testing.T) {
+		t.Parallel()
+
+		f := newApprovalFixture()
+		result, err := f.agent.Generate(t.Context(), AgentCall{Prompt: "Deploy to production."})
+		require.NoError(t, err)
+
+		_, err = f.agent.Generate(t.Context(), AgentCall{Resume: result.Suspended})
+		require.ErrorContains(t, err, "missing approval for tool call \"call-2\"")
+		require.Empty(t, f.deployed)
+	})
+}
+
+func TestAgent_StreamToolApproval(t *testing.T) {
+	t.Parallel()
+
+	f := newApprovalFixture()
+	result, err := f.agent.Stream(t.Context(), AgentStreamCall{Prompt: "Deploy to production."})
+	require.NoError(t, err)
+	require.NotNil(t, result.Suspended)
+	require.Empty(t, f.deployed)
+	require.Equal(t, 1, f.lookups)
+
+	var toolResults []ToolResultContent
+	var stepStarts []int
+	result, err = f.agent.Stream(t.Context(), AgentStreamCall{
+		Resume:    roundtrip(t, result.Suspended),
+		Approvals: []ToolApproval{ApproveToolCall("call-2")},
+		OnStepStart: func(stepNumber int) error {
+			stepStarts = append(stepStarts, stepNumber)
+			return nil
+		},
+		OnToolResult: func(result ToolResultContent) error {
+			toolResults = append(toolResults, result)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Suspended)
+	require.Equal(t, []string{"production"}, f.deployed)
+	require.Equal(t, []int{0, 1}, stepStarts)
+	require.Len(t, toolResults, 1)
+	require.Len(t, result.Steps, 2)
+	require.Equal(t, "done", result.Response.Content.Text())
+
+	results := lastToolResults(t, f.prompts[1])
+	require.Len(t, results, 2)
+}
@@ -0,0 +1,215 @@
+// Package cache provides a response cache for language models.
+//
+// The cache key is derived from the provider, the model and the canonical
+// JSON of the [fantasy.Call], so the prompt, the tools and the provider
+// options all take part in it. Generate hits return the stored response and
+// Stream hits replay the recorded stream parts:
+//
+//	model = cache.WrapLanguageModel(model, cache.NewMemoryStore(1000), cache.WithTTL(time.Hour))
+//
+// Object calls are not cached.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"charm.land/fantasy"
+)
+
+type bypassKey struct{}
+
+// WithBypass returns a context that makes calls skip the cache: the response
+// is neither looked up nor stored.
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// IsBypassed reports whether the cache is bypassed for the context.
+func IsBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+type settings struct {
+	ttl     time.Duration
+	onError func(error)
+}
+
+// Option configures the cache.
+type Option = func(*settings)
+
+// WithTTL sets how long responses are cached, they never expire by default.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *settings) {
+		s.ttl = ttl
+	}
+}
+
+// WithOnError sets a callback for store and encoding errors. These errors
+// never fail the model call, a failed lookup is treated as a miss.
+func WithOnError(fn func(error)) Option {
+	return func(s *settings) {
+		s.onError = fn
+	}
+}
+
+// entry is the cached value of a call.
+type entry struct {
+	Response *fantasy.Response    `json:"response,omitempty"`
+	Parts    []fantasy.StreamPart `json:"parts,omitempty"`
+}
+
+type cache struct {
+	store    Store
+	settings settings
+	provider string
+	model    string
+}
+
+// WrapLanguageModel wraps a language model with a response cache backed by
+// the given store.
+func WrapLanguageModel(model fantasy.LanguageModel, store Store, opts ...Option) fantasy.LanguageModel {
+	c := &cache{
+		store:    store,
+		provider: model.Provider(),
+		model:    model.Model(),
+	}
+	for _, o := range opts {
+		o(&c.settings)
+	}
+	return fantasy.WrapLanguageModel(model, fantasy.LanguageModelMiddleware{
+		WrapGenerate: c.generate,
+		WrapStream:   c.stream,
+	})
+}
+
+// Key returns the cache key of a call to the given provider and model.
+func Key(provider, model string, callType fantasy.CallType, call fantasy.Call) (string, error) {
+	data, err := json.Marshal(struct {
+		Provider string           `json:"provider"`
+		Model    string           `json:"model"`
+		Type     fantasy.CallType `json:"type"`
+		Call     fantasy.Call     `json:"call"`
+	}{
+		Provider: provider,
+		Model:    model,
+		Type:     callType,
+		Call:     call,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (c *cache) generate(ctx context.Context, call fantasy.Call, next fantasy.GenerateFunc) (*fantasy.Response, error) {
+	if IsBypassed(ctx) {
+		return next(ctx, call)
+	}
+	key, ok := c.key(fantasy.CallTypeGenerate, call)
+	if !ok {
+		return next(ctx, call)
+	}
+	if cached, ok := c.get(ctx, key); ok && cached.Response != nil {
+		return cached.Response, nil
+	}
+
+	resp, err := next(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, key, entry{Response: resp})
+	return resp, nil
+}
+
+func (c *cache) stream(ctx context.Context, call fantasy.Call, next fantasy.StreamFunc) (fantasy.StreamResponse, error) {
+	if IsBypassed(ctx) {
+		return next(ctx, call)
+	}
+	key, ok := c.key(fantasy.CallTypeStream, call)
+	if !ok {
+		return next(ctx, call)
+	}
+	if cached, ok := c.get(ctx, key); ok && cached.Parts != nil {
+		return func(yield func(fantasy.StreamPart) bool) {
+			for _, part := range cached.Parts {
+				if !yield(part) {
+					return
+				}
+			}
+		}, nil
+	}
+
+	stream, err := next(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		var parts []fantasy.StreamPart
+		finished, failed := false, false
+		for part := range stream {
+			switch part.Type {
+			case fantasy.StreamPartTypeFinish:
+				finished = true
+			case fantasy.StreamPartTypeError:
+				failed = true
+			}
+			parts = append(parts, part)
+			if !yield(part) {
+				// Partial streams are not cached.
+				return
+			}
+		}
+		if finished && !failed {
+			c.set(ctx, key, entry{Parts: parts})
+		}
+	}, nil
+}
+
+func (c *cache) key(callType fantasy.CallType, call fantasy.Call) (string, bool) {
+	key, err := Key(c.provider, c.model, callType, call)
+	if err != nil {
+		c.reportError(err)
+		return "", false
+	}
+	return key, true
+}
+
+func (c *cache) get(ctx context.Context, key string) (entry, bool) {
+	data, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.reportError(err)
+		return entry{}, false
+	}
+	if !ok {
+		return entry{}, false
+	}
+	var cached entry
+	if err := json.Unmarshal(data, &cached); err != nil {
+		c.reportError(err)
+		return entry{}, false
+	}
+	return cached, true
+}
+
+func (c *cache) set(ctx context.Context, key string, value entry) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		c.reportError(err)
+		return
+	}
+	if err := c.store.Set(ctx, key, data, c.settings.ttl); err != nil {
+		c.reportError(err)
+	}
+}
+
+func (c *cache) reportError(err error) {
+	if c.settings.onError != nil {
+		c.settings.onError(err)
+	}
+}
@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLanguageModel struct {
+	generateCalls int
+	streamCalls   int
+	streamParts   []fantasy.StreamPart
+}
+
+func (m *mockLanguageModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	m.generateCalls++
+	return &fantasy.Response{
+		Content: fantasy.ResponseContent{
+			fantasy.TextContent{Text: "hello"},
+			fantasy.ToolCallContent{ToolCallID: "call-1", ToolName: "weather", Input: `{"city":"Paris"}`},
+		},
+		FinishReason: fantasy.FinishReasonToolCalls,
+		Usage:        fantasy.Usage{InputTokens: 3, OutputTokens: 2, TotalTokens: 5},
+	}, nil
+}
+
+func (m *mockLanguageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.streamCalls++
+	return func(yield func(fantasy.StreamPart) bool) {
+		for _, part := range m.streamParts {
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (m *mockLanguageModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLanguageModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLanguageModel) Provider() string {
+	return "mock-provider"
+}
+
+func (m *mockLanguageModel) Model() string {
+	return "mock-model"
+}
+
+func testCall(prompt string) fantasy.Call {
+	return fantasy.Call{
+		Prompt:      fantasy.Prompt{fantasy.NewUserMessage(prompt)},
+		Temperature: fantasy.Opt(0.5),
+		Tools: []fantasy.Tool{fantasy.FunctionTool{
+			Name:        "weather",
+			Description: "Get the weather",
+			InputSchema: map[string]any{"type": "object"},
+		}},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns the stored response on a hit", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockLanguageModel{}
+		model := WrapLanguageModel(mock, NewMemoryStore(10))
+
+		first, err := model.Generate(t.Context(), testCall("hi"))
+		require.NoError(t, err)
+		second, err := model.Generate(t.Context(), testCall("hi"))
+		require.NoError(t, err)
+
+		require.Equal(t, 1, mock.generateCalls)
+		require.Equal(t, first, second)
+		require.Equal(t, "mock-provider", model.Provider())
+		require.Equal(t, "mock-model", model.Model())
+	})
+
+	t.Run("different calls miss", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockLanguageModel{}
+		model := WrapLanguageModel(mock, NewMemoryStore(10))
+
+		_, err := model.Generate(t.Context(), testCall("hi"))
+		require.NoError(t, err)
+		_, err = model.Generate(t.Context(), testCall("hello"))
+		require.NoError(t, err)
+
+		call := testCall("hi")
+		call.Temperature = fantasy.Opt(0.7)
+		_, err = model.Generate(t.Context(), call)
+		require.NoError(t, err)
+
+		call = testCall("hi")
+		call.Tools = nil
+		_, err = model.Generate(t.Context(), call)
+		require.NoError(t, err)
+
+		require.Equal(t, 4, mock.generateCalls)
+	})
+
+	t.Run("bypass skips the cache", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockLanguageModel{}
+		store := NewMemoryStore(10)
+		model := WrapLanguageModel(mock, store)
+
+		ctx := WithBypass(t.Context())
+		_, err := model.Generate(ctx, testCall("hi"))
+		require.NoError(t, err)
+		require.Equal(t, 0, store.Len())
+
+		_, err = model.Generate(t.Context(), testCall("hi"))
+		require.NoError(t, err)
+		_, err = model.Generate(ctx, testCall("hi"))
+		require.NoError(t, err)
+		require.Equal(t, 3, mock.generateCalls)
+	})
+
+	t.Run("expired responses miss", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockLanguageModel{}
+		model := WrapLanguageModel(mock, NewMemoryStore(10), WithTTL(time.Millisecond))
+
+		_, err := model.Generate(t.Context(), testCall("hi"))
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		_, err = model.Generate(t.Context(), testCall("hi"))
+		require.NoError(t, err)
+		require.Equal(t, 2, mock.generateCalls)
+	})
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	parts := []fantasy.StreamPart{
+		{Type: fantasy.StreamPartTypeTextStart, ID: "0"},
+		{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: "hel"},
+		{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: "lo"},
+		{Type: fantasy.StreamPartTypeTextEnd, ID: "0"},
+		{
+			Type:         fantasy.StreamPartTypeFinish,
+			FinishReason: fantasy.FinishReasonStop,
+			Usage:        fantasy.Usage{InputTokens: 3, OutputTokens: 2},
+		},
+	}
+
+	collect := func(t *testing.T, model fantasy.LanguageModel, ctx context.Context) []fantasy.StreamPart {
+		t.Helper()
+		stream, err := model.Stream(ctx, testCall("hi"))
+		require.NoError(t, err)
+		var got []fantasy.StreamPart
+		for part := range stream {
+			got = append(got, part)
+		}
+		return got
+	}
+
+	t.Run("replays the recorded parts on a hit", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockLanguageModel{streamParts: parts}
+		model := WrapLanguageModel(mock, NewMemoryStore(10))
+
+		require.Equal(t, parts, collect(t, model, t.Context()))
+		require.Equal(t, parts, collect(t, model, t.Context()))
+		require.Equal(t, 1, mock.streamCalls)
+	})
+
+	t.Run("failed streams are not cached", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockLanguageModel{streamParts: []fantasy.StreamPart{
+			{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: "hel"},
+			{Type: fantasy.StreamPartTypeError, Error: errors.New("overloaded")},
+		}}
+		store := NewMemoryStore(10)
+		model := WrapLanguageModel(mock, store)
+
+		collect(t, model, t.Context())
+		require.Equal(t, 0, store.Len())
+	})
+
+	t.Run("partially consumed streams are not cached", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockLanguageModel{streamParts: parts}
+		store := NewMemoryStore(10)
+		model := WrapLanguageModel(mock, store)
+
+		stream, err := model.Stream(t.Context(), testCall("hi"))
+		require.NoError(t, err)
+		for range stream {
+			break
+		}
+		require.Equal(t, 0, store.Len())
+	})
+
+	t.Run("generate and stream are cached separately", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockLanguageModel{streamParts: parts}
+		model := WrapLanguageModel(mock, NewMemoryStore(10))
+
+		_, err := model.Generate(t.Context(), testCall("hi"))
+		require.NoError(t, err)
+		collect(t, model, t.Context())
+		require.Equal(t, 1, mock.generateCalls)
+		require.Equal(t, 1, mock.streamCalls)
+	})
+
+	t.Run("replays from disk", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := NewDiskStore(t.TempDir())
+		require.NoError(t, err)
+
+		mock := &mockLanguageModel{streamParts: parts}
+		require.Equal(t, parts, collect(t, WrapLanguageModel(mock, store), t.Context()))
+		require.Equal(t, parts, collect(t, WrapLanguageModel(mock, store), t.Context()))
+		require.Equal(t, 1, mock.streamCalls)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("evicts the least recently used value", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		store := NewMemoryStore(2)
+		require.NoError(t, store.Set(ctx, "a", []byte("1"), 0))
+		require.NoError(t, store.Set(ctx, "b", []byte("2"), 0))
+
+		_, ok, err := store.Get(ctx, "a")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.NoError(t, store.Set(ctx, "c", []byte("3"), 0))
+		_, ok, _ = store.Get(ctx, "b")
+		require.False(t, ok, "b was the least recently used value")
+		value, ok, _ := store.Get(ctx, "a")
+		require.True(t, ok)
+		require.Equal(t, []byte("1"), value)
+		require.Equal(t, 2, store.Len())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		store := NewMemoryStore(0)
+		require.NoError(t, store.Set(ctx, "a", []byte("1"), 0))
+		require.NoError(t, store.Delete(ctx, "a"))
+		_, ok, _ := store.Get(ctx, "a")
+		require.False(t, ok)
+	})
+}
+
+func TestDiskStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store, err := NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), 0))
+	value, ok, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("1"), value)
+
+	require.NoError(t, store.Set(ctx, "b", []byte("2"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err = store.Get(ctx, "b")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, store.Delete(ctx, "a"))
+	_, ok, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, _, err = store.Get(ctx, "../a")
+	require.Error(t, err)
+}
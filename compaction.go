@@ -0,0 +1,329 @@
+package fantasy
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContextManagerOptions holds the state passed to a context manager before
+// each step.
+type ContextManagerOptions struct {
+	// Messages is the conversation the next step would send to the model.
+	Messages []Message
+	// Steps are the steps executed so far.
+	Steps      []StepResult
+	StepNumber int
+	// InputTokens is the number of input tokens the model reported for the
+	// previous step, it is zero before the first step.
+	InputTokens int64
+	// Model is the model of the agent.
+	Model LanguageModel
+}
+
+// ContextManager manages the conversation history of an agent. It is called
+// before each step and the returned messages replace the history for that
+// step and all the following ones.
+type ContextManager = func(ctx context.Context, options ContextManagerOptions) ([]Message, error)
+
+// WithContextManager sets the context manager for the agent.
+func WithContextManager(manager ContextManager) AgentOption {
+	return func(s *agentSettings) {
+		s.contextManager = manager
+	}
+}
+
+// CompactionOptions holds the state passed to a compaction strategy.
+type CompactionOptions struct {
+	Messages []Message
+	// Tokens is the current size of the messages in tokens.
+	Tokens int64
+	// Budget is the size in tokens the messages should fit in.
+	Budget int64
+}
+
+// CompactionStrategy shrinks the conversation history.
+type CompactionStrategy = func(ctx context.Context, options CompactionOptions) ([]Message, error)
+
+// CompactContext creates a context manager that keeps the conversation within
+// a token budget.
+//
+// The size of the conversation is the input tokens reported for the previous
+// step plus an estimate of the messages added by it, or an estimate of the
+// whole conversation before the first step. While it exceeds the budget the
+// strategies are applied in order, e.g.:
+//
+//	fantasy.CompactContext(100_000,
+//		fantasy.TruncateToolResults(2_000, 2),
+//		fantasy.DropOldestTurns(4),
+//	)
+//
+// A budget of zero uses the context window of the model minus its maximum
+// output, see [ModelInfo.ContextBudget]. The conversation is left as is when
+// neither is known.
+func CompactContext(budget int64, strategies ...CompactionStrategy) ContextManager {
+	return func(ctx context.Context, options ContextManagerOptions) ([]Message, error) {
+		budget := budget
+		if budget <= 0 && options.Model != nil {
+			if info, ok := ModelInfoOf(options.Model); ok {
+				budget = info.ContextBudget()
+			}
+		}
+		messages := options.Messages
+		if budget <= 0 {
+			return messages, nil
+		}
+		tokens := EstimateTokens(messages)
+		if options.InputTokens > 0 && len(options.Steps) > 0 {
+			tokens = options.InputTokens + EstimateTokens(options.Steps[len(options.Steps)-1].Messages)
+		}
+
+		for _, strategy := range strategies {
+			if tokens <= budget {
+				break
+			}
+			compacted, err := strategy(ctx, CompactionOptions{
+				Messages: messages,
+				Tokens:   tokens,
+				Budget:   budget,
+			})
+			if err != nil {
+				return nil, err
+			}
+			tokens -= EstimateTokens(messages) - EstimateTokens(compacted)
+			messages = compacted
+		}
+		return messages, nil
+	}
+}
+
+// estimatedFileTokens is the estimated size of a file in tokens.
+const estimatedFileTokens = 1024
+
+// EstimateTokens estimates the size of messages in tokens, counting about
+// four characters per token.
+func EstimateTokens(messages []Message) int64 {
+	var chars, tokens int64
+	for _, message := range messages {
+		for _, part := range message.Content {
+			switch part := part.(type) {
+			case TextPart:
+				chars += int64(len(part.Text))
+			case ReasoningPart:
+				chars += int64(len(part.Text))
+			case FilePart:
+				tokens += estimatedFileTokens
+			case ToolCallPart:
+				chars += int64(len(part.ToolName) + len(part.Input))
+			case ToolResultPart:
+				switch output := part.Output.(type) {
+				case ToolResultOutputContentText:
+					chars += int64(len(output.Text))
+				case ToolResultOutputContentError:
+					if output.Error != nil {
+						chars += int64(len(output.Error.Error()))
+					}
+				case ToolResultOutputContentMedia:
+					chars += int64(len(output.Text))
+					tokens += estimatedFileTokens
+				}
+			}
+		}
+	}
+	return tokens + chars/4
+}
+
+// TruncateToolResults keeps the first maxChars bytes of the text of tool
+// results, followed by a "[truncated]" marker that is not counted in the
+// limit, and replaces media results with a placeholder. The results of the
+// keepRecent most recent tool messages are left untouched. A negative maxChars
+// is treated as zero.
+func TruncateToolResults(maxChars, keepRecent int) CompactionStrategy {
+	maxChars = max(maxChars, 0)
+	return func(_ context.Context, options CompactionOptions) ([]Message, error) {
+		messages := make([]Message, len(options.Messages))
+		copy(messages, options.Messages)
+
+		kept := 0
+		for i := len(messages) - 1; i >= 0; i-- {
+			if messages[i].Role != MessageRoleTool {
+				continue
+			}
+			if kept < keepRecent {
+				kept++
+				continue
+			}
+			messages[i] = truncateToolMessage(messages[i], maxChars)
+		}
+		return messages, nil
+	}
+}
+
+func truncateToolMessage(message Message, maxChars int) Message {
+	content := make([]MessagePart, len(message.Content))
+	for i, part := range message.Content {
+		result, ok := AsMessagePart[ToolResultPart](part)
+		if !ok {
+			content[i] = part
+			continue
+		}
+		switch output := result.Output.(type) {
+		case ToolResultOutputContentText:
+			result.Output = ToolResultOutputContentText{Text: truncate(output.Text, maxChars)}
+		case ToolResultOutputContentError:
+			if output.Error != nil && len(output.Error.Error()) > maxChars {
+				result.Output = ToolResultOutputContentError{Error: errors.New(truncate(output.Error.Error(), maxChars))}
+			}
+		case ToolResultOutputContentMedia:
+			result.Output = ToolResultOutputContentText{Text: truncate(cmp.Or(output.Text, "[media omitted]"), maxChars)}
+		}
+		content[i] = result
+	}
+	message.Content = content
+	return message
+}
+
+// truncate cuts the text to at most maxChars bytes, backing off to the start
+// of a rune so a multi-byte character is never split, and marks it as
+// truncated. The marker comes on top of the maxChars bytes.
+func truncate(text string, maxChars int) string {
+	maxChars = max(maxChars, 0)
+	if len(text) <= maxChars {
+		return text
+	}
+	end := maxChars
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[:end] + "\n[truncated]"
+}
+
+// DropOldestTurns drops the oldest messages until the conversation fits in
+// the budget.
+//
+// The leading system messages and the first user message are always kept, as
+// are the keepRecent most recent turns. A turn is a message together with the
+// tool messages that follow it, so tool calls and their results are dropped
+// together.
+func DropOldestTurns(keepRecent int) CompactionStrategy {
+	return func(_ context.Context, options CompactionOptions) ([]Message, error) {
+		prefix, turns := splitTurns(options.Messages)
+		tokens := options.Tokens
+		drop := 0
+		for drop < len(turns)-keepRecent && tokens > options.Budget {
+			tokens -= EstimateTokens(turns[drop])
+			drop++
+		}
+		return joinTurns(prefix, turns[drop:]), nil
+	}
+}
+
+// summaryPrompt is the system prompt used to summarize the conversation.
+const summaryPrompt = `You are summarizing the earlier part of a conversation between a user and an AI assistant that uses tools, so that the assistant can continue the task without it.
+
+Write a concise summary that keeps the user's goals and constraints, the decisions made, the important facts and tool results, and the work that is still left to do. Only output the summary.`
+
+// SummarizeHistory replaces the earlier part of the conversation with a
+// summary written by the given model.
+//
+// The leading system messages, the first user message and the keepRecent most
+// recent turns are kept as they are, see [DropOldestTurns].
+func SummarizeHistory(model LanguageModel, keepRecent int) CompactionStrategy {
+	return func(ctx context.Context, options CompactionOptions) ([]Message, error) {
+		prefix, turns := splitTurns(options.Messages)
+		if len(turns) <= keepRecent {
+			return options.Messages, nil
+		}
+		older, recent := turns[:len(turns)-keepRecent], turns[len(turns)-keepRecent:]
+
+		resp, err := model.Generate(ctx, Call{
+			Prompt: Prompt{
+				NewSystemMessage(summaryPrompt),
+				NewUserMessage(transcript(joinTurns(nil, older))),
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize conversation: %w", err)
+		}
+
+		summary := NewUserMessage("Summary of the earlier conversation:\n\n" + resp.Content.Text())
+		return joinTurns(append(prefix, summary), recent), nil
+	}
+}
+
+// splitTurns splits messages into the prefix that is always kept, the leading
+// system messages and the first user message, and the turns that follow it.
+func splitTurns(messages []Message) ([]Message, [][]Message) {
+	i := 0
+	for i < len(messages) && messages[i].Role == MessageRoleSystem {
+		i++
+	}
+	if i < len(messages) && messages[i].Role == MessageRoleUser {
+		i++
+	}
+	prefix := messages[:i:i]
+
+	var turns [][]Message
+	for _, message := range messages[i:] {
+		if message.Role == MessageRoleTool && len(turns) > 0 {
+			turns[len(turns)-1] = append(turns[len(turns)-1], message)
+			continue
+		}
+		turns = append(turns, []Message{message})
+	}
+	return prefix, turns
+}
+
+func joinTurns(prefix []Message, turns [][]Message) []Message {
+	messages := append([]Message{}, prefix...)
+	for _, turn := range turns {
+		messages = append(messages, turn...)
+	}
+	return messages
+}
+
+// transcript renders messages as plain text, so they can be summarized by
+// a model without declaring the tools they use.
+func transcript(messages []Message) string {
+	toolNames := map[string]string{}
+	var sb strings.Builder
+	for _, message := range messages {
+		fmt.Fprintf(&sb, "%s:\n", message.Role)
+		for _, part := range message.Content {
+			switch part := part.(type) {
+			case TextPart:
+				sb.WriteString(part.Text)
+			case ReasoningPart:
+				continue
+			case FilePart:
+				fmt.Fprintf(&sb, "[file %s %s]", part.Filename, part.MediaType)
+			case ToolCallPart:
+				toolNames[part.ToolCallID] = part.ToolName
+				fmt.Fprintf(&sb, "[tool call %s: %s]", part.ToolName, part.Input)
+			case ToolResultPart:
+				fmt.Fprintf(&sb, "[tool result %s: %s]", toolNames[part.ToolCallID], toolResultText(part.Output))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func toolResultText(output ToolResultOutputContent) string {
+	switch output := output.(type) {
+	case ToolResultOutputContentText:
+		return output.Text
+	case ToolResultOutputContentError:
+		if output.Error != nil {
+			return "error: " + output.Error.Error()
+		}
+		return "error"
+	case ToolResultOutputContentMedia:
+		return cmp.Or(output.Text, "[media "+output.MediaType+"]")
+	}
+	return ""
+}
@@ -0,0 +1,277 @@
+package fantasy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func toolTurn(id, result string) []Message {
+	return []Message{
+		{
+			Role: MessageRoleAssistant,
+			Content: []MessagePart{
+				ToolCallPart{ToolCallID: id, ToolName: "search", Input: `{"query":"` + id + `"}`},
+			},
+		},
+		{
+			Role: MessageRoleTool,
+			Content: []MessagePart{
+				ToolResultPart{ToolCallID: id, Output: ToolResultOutputContentText{Text: result}},
+			},
+		},
+	}
+}
+
+func testConversation(turns int, resultSize int) []Message {
+	messages := []Message{
+		NewSystemMessage("You are a helpful assistant."),
+		NewUserMessage("Research the topic."),
+	}
+	for i := range turns {
+		messages = append(messages, toolTurn(fmt.Sprintf("call-%d", i), strings.Repeat("x", resultSize))...)
+	}
+	return messages
+}
+
+// requireNoOrphans checks that every tool result has a matching tool call
+// and that tool messages always follow an assistant message.
+func requireNoOrphans(t *testing.T, messages []Message) {
+	t.Helper()
+	calls := map[string]bool{}
+	for i, message := range messages {
+		if message.Role == MessageRoleTool {
+			require.Greater(t, i, 0)
+			require.Contains(t, []MessageRole{MessageRoleAssistant, MessageRoleTool}, messages[i-1].Role)
+		}
+		for _, part := range message.Content {
+			if call, ok := AsMessagePart[ToolCallPart](part); ok {
+				calls[call.ToolCallID] = true
+			}
+			if result, ok := AsMessagePart[ToolResultPart](part); ok {
+				require.True(t, calls[result.ToolCallID], "orphaned tool result %s", result.ToolCallID)
+			}
+		}
+	}
+}
+
+func TestCompactContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("does nothing under budget", func(t *testing.T) {
+		t.Parallel()
+
+		messages := testConversation(3, 100)
+		manager := CompactContext(10_000, DropOldestTurns(0))
+		compacted, err := manager(t.Context(), ContextManagerOptions{Messages: messages})
+		require.NoError(t, err)
+		require.Equal(t, messages, compacted)
+	})
+
+	t.Run("uses the context window of the model", func(t *testing.T) {
+		t.Parallel()
+
+		RegisterModelInfo("compaction-test", "small-model", ModelInfo{ContextWindow: 2_000, MaxOutputTokens: 1_000})
+		model := newNamedModel("small-model", nil)
+		model.provider = "compaction-test"
+
+		messages := testConversation(3, 2000)
+		manager := CompactContext(0, DropOldestTurns(1))
+		compacted, err := manager(t.Context(), ContextManagerOptions{Messages: messages, Model: model})
+		require.NoError(t, err)
+		require.Len(t, compacted, 4, "system, user and the most recent turn")
+
+		// Without a known context window the conversation is kept.
+		compacted, err = manager(t.Context(), ContextManagerOptions{Messages: messages, Model: &mockLanguageModel{}})
+		require.NoError(t, err)
+		require.Equal(t, messages, compacted)
+	})
+
+	t.Run("uses the reported input tokens", func(t *testing.T) {
+		t.Parallel()
+
+		messages := testConversation(3, 100)
+		manager := CompactContext(10_000, DropOldestTurns(1))
+		compacted, err := manager(t.Context(), ContextManagerOptions{
+			Messages:    messages,
+			Steps:       []StepResult{{Messages: messages[len(messages)-2:]}},
+			InputTokens: 20_000,
+		})
+		require.NoError(t, err)
+		require.Len(t, compacted, 4, "system, user and the most recent turn")
+		requireNoOrphans(t, compacted)
+	})
+
+	t.Run("stops once under budget", func(t *testing.T) {
+		t.Parallel()
+
+		messages := testConversation(4, 4000)
+		strategyCalls := 0
+		manager := CompactContext(1500,
+			TruncateToolResults(100, 1),
+			func(ctx context.Context, options CompactionOptions) ([]Message, error) {
+				strategyCalls++
+				return options.Messages, nil
+			},
+		)
+		compacted, err := manager(t.Context(), ContextManagerOptions{Messages: messages})
+		require.NoError(t, err)
+		require.Len(t, compacted, len(messages))
+		require.Equal(t, 0, strategyCalls)
+	})
+}
+
+func TestTruncateToolResults(t *testing.T) {
+	t.Parallel()
+
+	messages := testConversation(3, 500)
+	compacted, err := TruncateToolResults(10, 1)(t.Context(), CompactionOptions{Messages: messages})
+	require.NoError(t, err)
+	require.Len(t, compacted, len(messages))
+
+	var texts []string
+	for _, message := range compacted {
+		if message.Role != MessageRoleTool {
+			continue
+		}
+		result, ok := AsMessagePart[ToolResultPart](message.Content[0])
+		require.True(t, ok)
+		output, ok := AsToolResultOutputType[ToolResultOutputContentText](result.Output)
+		require.True(t, ok)
+		texts = append(texts, output.Text)
+	}
+	require.Equal(t, []string{
+		"xxxxxxxxxx\n[truncated]",
+		"xxxxxxxxxx\n[truncated]",
+		strings.Repeat("x", 500),
+	}, texts)
+
+	// The original messages are left untouched.
+	original, _ := AsMessagePart[ToolResultPart](messages[3].Content[0])
+	require.Equal(t, strings.Repeat("x", 500), original.Output.(ToolResultOutputContentText).Text)
+
+	// A negative limit drops the whole text.
+	compacted, err = TruncateToolResults(-1, 0)(t.Context(), CompactionOptions{Messages: messages})
+	require.NoError(t, err)
+	result, _ := AsMessagePart[ToolResultPart](compacted[len(compacted)-1].Content[0])
+	require.Equal(t, ToolResultOutputContentText{Text: "\n[truncated]"}, result.Output)
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	// "é" is two bytes, cutting at 3 bytes would split the second one.
+	truncated := truncate("éééé", 3)
+	require.True(t, utf8.ValidString(truncated))
+	require.Equal(t, "é\n[truncated]", truncated)
+	require.Equal(t, "éé", truncate("éé", 4))
+	require.Equal(t, "\n[truncated]", truncate("éé", -1))
+}
+
+func TestDropOldestTurns(t *testing.T) {
+	t.Parallel()
+
+	messages := testConversation(5, 400)
+	compacted, err := DropOldestTurns(1)(t.Context(), CompactionOptions{
+		Messages: messages,
+		Tokens:   EstimateTokens(messages),
+		Budget:   250,
+	})
+	require.NoError(t, err)
+	requireNoOrphans(t, compacted)
+
+	require.Equal(t, MessageRoleSystem, compacted[0].Role)
+	require.Equal(t, MessageRoleUser, compacted[1].Role)
+	require.LessOrEqual(t, EstimateTokens(compacted), int64(250))
+
+	last, ok := AsMessagePart[ToolCallPart](compacted[len(compacted)-2].Content[0])
+	require.True(t, ok)
+	require.Equal(t, "call-4", last.ToolCallID)
+}
+
+func TestSummarizeHistory(t *testing.T) {
+	t.Parallel()
+
+	var summaryCall Call
+	summarizer := &mockLanguageModel{
+		generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+			summaryCall = call
+			return &Response{Content: ResponseContent{TextContent{Text: "searched for call-0 and call-1"}}}, nil
+		},
+	}
+
+	messages := testConversation(3, 10)
+	compacted, err := SummarizeHistory(summarizer, 1)(t.Context(), CompactionOptions{Messages: messages})
+	require.NoError(t, err)
+	requireNoOrphans(t, compacted)
+
+	require.Len(t, compacted, 5, "system, user, summary and the most recent turn")
+	require.Equal(t, MessageRoleUser, compacted[2].Role)
+	summary, _ := AsMessagePart[TextPart](compacted[2].Content[0])
+	require.Contains(t, summary.Text, "searched for call-0 and call-1")
+
+	require.Len(t, summaryCall.Prompt, 2)
+	require.Empty(t, summaryCall.Tools)
+	transcript, _ := AsMessagePart[TextPart](summaryCall.Prompt[1].Content[0])
+	require.Contains(t, transcript.Text, `[tool call search: {"query":"call-0"}]`)
+	require.Contains(t, transcript.Text, "[tool result search: xxxxxxxxxx]")
+	require.NotContains(t, transcript.Text, "call-2")
+}
+
+func TestAgent_ContextManager(t *testing.T) {
+	t.Parallel()
+
+	var prompts []Prompt
+	step := 0
+	model := &mockLanguageModel{
+		generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+			prompts = append(prompts, call.Prompt)
+			step++
+			if step < 4 {
+				return &Response{
+					Content: ResponseContent{ToolCallContent{
+						ToolCallID: fmt.Sprintf("call-%d", step),
+						ToolName:   "search",
+						Input:      `{}`,
+					}},
+					FinishReason: FinishReasonToolCalls,
+					Usage:        Usage{InputTokens: int64(1000 * step)},
+				}, nil
+			}
+			return &Response{
+				Content:      ResponseContent{TextContent{Text: "done"}},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	search := NewAgentTool("search", "Search",
+		func(ctx context.Context, input struct{}, call ToolCall) (ToolResponse, error) {
+			return NewTextResponse(strings.Repeat("x", 400)), nil
+		},
+	)
+
+	agent := NewAgent(model,
+		WithSystemPrompt("You are a researcher."),
+		WithTools(search),
+		WithContextManager(CompactContext(1500, DropOldestTurns(1))),
+	)
+	result, err := agent.Generate(t.Context(), AgentCall{Prompt: "Research the topic."})
+	require.NoError(t, err)
+	require.Len(t, result.Steps, 4)
+
+	require.Len(t, prompts[0], 2)
+	require.Len(t, prompts[1], 4)
+	// From the third step on the reported input tokens exceed the budget and
+	// only the most recent turn is kept.
+	require.Len(t, prompts[2], 4)
+	require.Len(t, prompts[3], 4)
+	for _, prompt := range prompts {
+		requireNoOrphans(t, prompt)
+	}
+	last, _ := AsMessagePart[ToolCallPart](prompts[3][2].Content[0])
+	require.Equal(t, "call-3", last.ToolCallID)
+}
@@ -0,0 +1,213 @@
+package fantasy
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProviderConfig configures a provider created by a registered
+// [ProviderFactory]. The API key, the base URL and the header values can
+// reference environment variables as $VAR or ${VAR}.
+type ProviderConfig struct {
+	// Type is the type of the provider, e.g. "openai". It defaults to the
+	// name of the provider in the configuration.
+	Type    string            `json:"type,omitempty" yaml:"type,omitempty"`
+	APIKey  string            `json:"api_key,omitempty" yaml:"api_key,omitempty"`
+	BaseURL string            `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Options are the default provider options of the calls to the models of
+	// the provider, in the format of the ParseOptions function of the
+	// provider package. Options set on a call take precedence. Setting options
+	// for a provider that takes none is an error.
+	Options map[string]any `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// ProviderFactory creates the providers of a type from their configuration.
+type ProviderFactory struct {
+	// New creates a provider. The environment variables of the configuration
+	// are already expanded and the API key is read from APIKeyEnv when the
+	// configuration does not set one.
+	New func(config ProviderConfig) (Provider, error)
+	// ParseOptions parses the default provider options of a configuration.
+	ParseOptions func(data map[string]any) (ProviderOptionsData, error)
+	// OptionsKey is the key of the options of the provider in
+	// [ProviderOptions], it defaults to the type of the provider.
+	OptionsKey string
+	// APIKeyEnv is the environment variable holding the API key, e.g.
+	// "OPENAI_API_KEY".
+	APIKeyEnv string
+}
+
+// providerFactories holds the registered factories by provider type.
+var providerFactories sync.Map
+
+// RegisterProviderFactory registers the factory of a provider type.
+// Provider packages register their factory in an init function, so importing
+// a provider package makes its type available to [OpenModel] and [Config].
+func RegisterProviderFactory(providerType string, factory ProviderFactory) {
+	providerFactories.Store(providerType, factory)
+}
+
+// Config declares providers by name, e.g. in YAML:
+//
+//	providers:
+//	  openai:
+//	    api_key: ${OPENAI_API_KEY}
+//	  local:
+//	    type: openai-compat
+//	    base_url: http://localhost:8080/v1
+//	    options:
+//	      reasoning_effort: low
+type Config struct {
+	Providers map[string]ProviderConfig `json:"providers" yaml:"providers"`
+}
+
+// ParseConfig parses a configuration in YAML or JSON.
+func ParseConfig(data []byte) (*Config, error) {
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, &Error{Title: "invalid config", Message: err.Error(), Cause: err}
+	}
+	return &config, nil
+}
+
+// LoadConfig reads a configuration file in YAML or JSON.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data)
+}
+
+// Registry creates the providers of a configuration. Providers are created
+// on first use and reused afterwards. Names missing from the configuration
+// are resolved as provider types with the default configuration.
+type Registry struct {
+	config Config
+
+	mu        sync.Mutex
+	providers map[string]configuredProvider
+}
+
+// configuredProvider is a provider with its default provider options.
+type configuredProvider struct {
+	provider Provider
+	options  ProviderOptions
+}
+
+// NewRegistry creates a registry for the configuration.
+func NewRegistry(config Config) *Registry {
+	return &Registry{
+		config:    config,
+		providers: map[string]configuredProvider{},
+	}
+}
+
+// Provider returns the provider with the given name.
+func (r *Registry) Provider(name string) (Provider, error) {
+	configured, err := r.configuredProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	return configured.provider, nil
+}
+
+// OpenModel opens a language model from a reference of the form
+// "provider/model", e.g. "anthropic/claude-sonnet-4". Everything after the
+// first slash is the model ID, e.g. "openrouter/anthropic/claude-sonnet-4".
+// The default provider options of the configuration are added to the calls to
+// the model.
+func (r *Registry) OpenModel(ctx context.Context, ref string) (LanguageModel, error) {
+	name, modelID, ok := strings.Cut(ref, "/")
+	if !ok || name == "" || modelID == "" {
+		return nil, &Error{Title: "invalid model", Message: fmt.Sprintf("model %q is not of the form provider/model", ref)}
+	}
+	configured, err := r.configuredProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	model, err := configured.provider.LanguageModel(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(configured.options) > 0 {
+		model = WrapLanguageModel(model, DefaultProviderOptions(configured.options))
+	}
+	return model, nil
+}
+
+func (r *Registry) configuredProvider(name string) (configuredProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if configured, ok := r.providers[name]; ok {
+		return configured, nil
+	}
+
+	config := r.config.Providers[name]
+	config.Type = cmp.Or(config.Type, name)
+	value, ok := providerFactories.Load(config.Type)
+	if !ok {
+		return configuredProvider{}, &Error{
+			Title:   "unknown provider",
+			Message: fmt.Sprintf("provider type %q is not registered, import its package", config.Type),
+		}
+	}
+	factory := value.(ProviderFactory)
+
+	config.APIKey = os.ExpandEnv(config.APIKey)
+	if config.APIKey == "" && factory.APIKeyEnv != "" {
+		config.APIKey = os.Getenv(factory.APIKeyEnv)
+	}
+	config.BaseURL = os.ExpandEnv(config.BaseURL)
+	config.Headers = maps.Clone(config.Headers)
+	for key, value := range config.Headers {
+		config.Headers[key] = os.ExpandEnv(value)
+	}
+
+	var configured configuredProvider
+	if len(config.Options) > 0 && factory.ParseOptions == nil {
+		return configuredProvider{}, &Error{
+			Title:   "invalid config",
+			Message: fmt.Sprintf("provider %q does not take options", name),
+		}
+	}
+	if len(config.Options) > 0 {
+		options, err := factory.ParseOptions(config.Options)
+		if err != nil {
+			return configuredProvider{}, &Error{
+				Title:   "invalid config",
+				Message: fmt.Sprintf("invalid options for provider %q", name),
+				Cause:   err,
+			}
+		}
+		configured.options = ProviderOptions{cmp.Or(factory.OptionsKey, config.Type): options}
+	}
+
+	provider, err := factory.New(config)
+	if err != nil {
+		return configuredProvider{}, err
+	}
+	configured.provider = provider
+	r.providers[name] = configured
+	return configured, nil
+}
+
+// defaultRegistry resolves the providers of [OpenModel].
+var defaultRegistry = NewRegistry(Config{})
+
+// OpenModel opens a language model from a reference of the form
+// "provider/model", e.g. "anthropic/claude-sonnet-4", with the default
+// configuration of the provider type. The API key is read from the
+// environment variable of the provider, e.g. ANTHROPIC_API_KEY. The provider
+// package must be imported for its type to be registered.
+func OpenModel(ctx context.Context, ref string) (LanguageModel, error) {
+	return defaultRegistry.OpenModel(ctx, ref)
+}
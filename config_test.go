@@ -0,0 +1,135 @@
+package fantasy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// configTestProvider records the configuration it was created with.
+type configTestProvider struct {
+	config ProviderConfig
+	calls  *[]Call
+}
+
+func (p *configTestProvider) Name() string { return "config-test" }
+
+func (p *configTestProvider) LanguageModel(_ context.Context, modelID string) (LanguageModel, error) {
+	model := newNamedModel(modelID, func(_ context.Context, call Call) (*Response, error) {
+		*p.calls = append(*p.calls, call)
+		return &Response{FinishReason: FinishReasonStop}, nil
+	})
+	model.provider = p.Name()
+	return model, nil
+}
+
+func TestRegistry(t *testing.T) {
+	var created []*configTestProvider
+	var calls []Call
+	RegisterProviderFactory("config-test", ProviderFactory{
+		New: func(config ProviderConfig) (Provider, error) {
+			provider := &configTestProvider{config: config, calls: &calls}
+			created = append(created, provider)
+			return provider, nil
+		},
+		ParseOptions: func(data map[string]any) (ProviderOptionsData, error) {
+			var options mockProviderData
+			if err := ParseOptions(data, &options); err != nil {
+				return nil, err
+			}
+			return &options, nil
+		},
+		APIKeyEnv: "CONFIG_TEST_API_KEY",
+	})
+	t.Setenv("CONFIG_TEST_API_KEY", "from-default-env")
+	t.Setenv("CONFIG_TEST_TOKEN", "from-config-env")
+
+	config, err := ParseConfig([]byte(`
+providers:
+  primary:
+    type: config-test
+    api_key: ${CONFIG_TEST_TOKEN}
+    base_url: https://example.com/v1
+    headers:
+      X-Token: $CONFIG_TEST_TOKEN
+    options:
+      key: from-config
+`))
+	require.NoError(t, err)
+	registry := NewRegistry(*config)
+
+	t.Run("creates configured providers", func(t *testing.T) {
+		model, err := registry.OpenModel(t.Context(), "primary/vendor/model-1")
+		require.NoError(t, err)
+		require.Equal(t, "vendor/model-1", model.Model())
+		require.Equal(t, "config-test", model.Provider())
+
+		provider := created[len(created)-1]
+		require.Equal(t, "from-config-env", provider.config.APIKey)
+		require.Equal(t, "https://example.com/v1", provider.config.BaseURL)
+		require.Equal(t, map[string]string{"X-Token": "from-config-env"}, provider.config.Headers)
+
+		// The provider is reused.
+		_, err = registry.OpenModel(t.Context(), "primary/model-2")
+		require.NoError(t, err)
+		require.Len(t, created, 1)
+	})
+
+	t.Run("adds the default provider options", func(t *testing.T) {
+		model, err := registry.OpenModel(t.Context(), "primary/model")
+		require.NoError(t, err)
+
+		_, err = model.Generate(t.Context(), Call{})
+		require.NoError(t, err)
+		require.Equal(t, &mockProviderData{Key: "from-config"}, calls[len(calls)-1].ProviderOptions["config-test"])
+
+		_, err = model.Generate(t.Context(), Call{ProviderOptions: ProviderOptions{"config-test": &mockProviderData{Key: "from-call"}}})
+		require.NoError(t, err)
+		require.Equal(t, &mockProviderData{Key: "from-call"}, calls[len(calls)-1].ProviderOptions["config-test"])
+	})
+
+	t.Run("uses the provider type with the default configuration", func(t *testing.T) {
+		model, err := NewRegistry(Config{}).OpenModel(t.Context(), "config-test/model")
+		require.NoError(t, err)
+		require.Equal(t, "model", model.Model())
+		require.Equal(t, "from-default-env", created[len(created)-1].config.APIKey)
+	})
+
+	t.Run("reports invalid references", func(t *testing.T) {
+		_, err := registry.OpenModel(t.Context(), "model")
+		require.ErrorContains(t, err, `model "model" is not of the form provider/model`)
+
+		_, err = registry.OpenModel(t.Context(), "missing/model")
+		require.ErrorContains(t, err, `provider type "missing" is not registered`)
+	})
+
+	t.Run("rejects options of providers without options", func(t *testing.T) {
+		RegisterProviderFactory("config-test-no-options", ProviderFactory{
+			New: func(config ProviderConfig) (Provider, error) {
+				return &configTestProvider{config: config, calls: &calls}, nil
+			},
+		})
+		registry := NewRegistry(Config{Providers: map[string]ProviderConfig{
+			"plain": {Type: "config-test-no-options", Options: map[string]any{"key": "ignored"}},
+		}})
+
+		_, err := registry.OpenModel(t.Context(), "plain/model")
+		var fantasyErr *Error
+		require.ErrorAs(t, err, &fantasyErr)
+		require.Equal(t, "invalid config", fantasyErr.Title)
+		require.Contains(t, fantasyErr.Message, `provider "plain" does not take options`)
+	})
+}
+
+func TestParseConfigJSON(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig([]byte(`{"providers": {"openai": {"api_key": "key", "options": {"user": "me"}}}}`))
+	require.NoError(t, err)
+	require.Equal(t, "key", config.Providers["openai"].APIKey)
+	require.Equal(t, map[string]any{"user": "me"}, config.Providers["openai"].Options)
+
+	_, err = ParseConfig([]byte(`providers: [`))
+	require.Error(t, err)
+}
@@ -0,0 +1,304 @@
+package fantasy
+
+import (
+	"cmp"
+	"context"
+	"sync"
+)
+
+// Cost is the cost of a call in USD, broken down by kind of token.
+type Cost struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+	Reasoning  float64 `json:"reasoning"`
+	// Total is the cost of the call. It is the sum of the other fields unless
+	// the provider reported the cost itself, see [CostReporter].
+	Total float64 `json:"total"`
+}
+
+func addCost(a, b Cost) Cost {
+	return Cost{
+		Input:      a.Input + b.Input,
+		Output:     a.Output + b.Output,
+		CacheWrite: a.CacheWrite + b.CacheWrite,
+		CacheRead:  a.CacheRead + b.CacheRead,
+		Reasoning:  a.Reasoning + b.Reasoning,
+		Total:      a.Total + b.Total,
+	}
+}
+
+// Price is the price of a model in USD per million tokens.
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+	// CacheWrite is the price of the tokens written to the prompt cache, it
+	// defaults to Input.
+	CacheWrite float64 `json:"cache_write,omitempty"`
+	// CacheRead is the price of the tokens read from the prompt cache, it
+	// defaults to Input.
+	CacheRead float64 `json:"cache_read,omitempty"`
+	// Reasoning is the price of the reasoning tokens, it defaults to Output.
+	Reasoning float64 `json:"reasoning,omitempty"`
+
+	// InputIncludesCacheRead is set for providers that count the tokens read
+	// from the cache in the input tokens, such as OpenAI and Google.
+	InputIncludesCacheRead bool `json:"input_includes_cache_read,omitempty"`
+	// OutputIncludesReasoning is set for providers that count the reasoning
+	// tokens in the output tokens, such as OpenAI.
+	OutputIncludesReasoning bool `json:"output_includes_reasoning,omitempty"`
+}
+
+// Cost returns the cost of the given usage.
+func (p Price) Cost(usage Usage) Cost {
+	cacheWrite := cmp.Or(p.CacheWrite, p.Input)
+	cacheRead := cmp.Or(p.CacheRead, p.Input)
+	reasoning := cmp.Or(p.Reasoning, p.Output)
+
+	inputTokens := usage.InputTokens
+	if p.InputIncludesCacheRead {
+		inputTokens -= usage.CacheReadTokens
+	}
+	outputTokens := usage.OutputTokens
+	if p.OutputIncludesReasoning {
+		outputTokens -= usage.ReasoningTokens
+	}
+
+	cost := Cost{
+		Input:      perMillion(inputTokens, p.Input),
+		Output:     perMillion(outputTokens, p.Output),
+		CacheWrite: perMillion(usage.CacheCreationTokens, cacheWrite),
+		CacheRead:  perMillion(usage.CacheReadTokens, cacheRead),
+		Reasoning:  perMillion(usage.ReasoningTokens, reasoning),
+	}
+	cost.Total = cost.Input + cost.Output + cost.CacheWrite + cost.CacheRead + cost.Reasoning
+	return cost
+}
+
+func perMillion(tokens int64, price float64) float64 {
+	return float64(max(tokens, 0)) * price / 1_000_000
+}
+
+// CostReporter is implemented by provider metadata that holds the cost of a
+// call as reported by the provider, such as OpenRouter usage accounting. A
+// reported cost takes precedence over the price catalog.
+type CostReporter interface {
+	ReportedCost() (float64, bool)
+}
+
+var (
+	pricesMu sync.RWMutex
+	prices   = map[string]map[string]Price{}
+)
+
+// RegisterPrice registers the price of a model, replacing the built-in price
+// if there is one. The model is matched exactly or as the prefix of a dated
+// or suffixed model ID, e.g. "gpt-4o" matches "gpt-4o-2024-08-06" and
+// "claude-sonnet-4" matches "claude-sonnet-4@20250514"; the longest
+// registered prefix wins.
+func RegisterPrice(provider, model string, price Price) {
+	pricesMu.Lock()
+	defer pricesMu.Unlock()
+	if prices[provider] == nil {
+		prices[provider] = map[string]Price{}
+	}
+	prices[provider][model] = price
+}
+
+// LookupPrice returns the price of a model of a provider.
+func LookupPrice(provider, model string) (Price, bool) {
+	pricesMu.RLock()
+	defer pricesMu.RUnlock()
+
+	return lookupModel(prices[provider], model)
+}
+
+// ComputeCost returns the cost of a response of a model. The cost reported by
+// the provider is used if there is one, otherwise the cost is computed from
+// the usage with the registered price of the model. It returns false when the
+// cost is unknown.
+//
+// The models of the built-in providers set [Usage.Cost] with ComputeCost,
+// see [WithCost].
+//
+// Responses of a [FallbackModel] are priced with the model that served them.
+func ComputeCost(model LanguageModel, resp Response) (Cost, bool) {
+	provider, modelID := model.Provider(), model.Model()
+	if metadata, ok := resp.ProviderMetadata[FallbackMetadataKey].(*FallbackMetadata); ok {
+		provider, modelID = metadata.Provider, metadata.Model
+	}
+
+	var cost Cost
+	price, priced := LookupPrice(provider, modelID)
+	if priced {
+		cost = price.Cost(resp.Usage)
+	}
+	for _, metadata := range resp.ProviderMetadata {
+		if reporter, ok := metadata.(CostReporter); ok {
+			if total, ok := reporter.ReportedCost(); ok {
+				cost.Total = total
+				return cost, true
+			}
+		}
+	}
+	return cost, priced
+}
+
+// WithCost wraps a language model so that the usage of its responses, object
+// responses and finish stream parts carries their cost, see [ComputeCost].
+// The built-in providers return their language models wrapped with it.
+func WithCost(model LanguageModel) LanguageModel {
+	responseCost := func(usage Usage, metadata ProviderMetadata) Cost {
+		cost, _ := ComputeCost(model, Response{Usage: usage, ProviderMetadata: metadata})
+		return cost
+	}
+	return WrapLanguageModel(model, LanguageModelMiddleware{
+		WrapGenerate: func(ctx context.Context, call Call, next GenerateFunc) (*Response, error) {
+			resp, err := next(ctx, call)
+			if err != nil {
+				return nil, err
+			}
+			resp.Usage.Cost = responseCost(resp.Usage, resp.ProviderMetadata)
+			return resp, nil
+		},
+		WrapStream: func(ctx context.Context, call Call, next StreamFunc) (StreamResponse, error) {
+			stream, err := next(ctx, call)
+			if err != nil {
+				return nil, err
+			}
+			return func(yield func(StreamPart) bool) {
+				for part := range stream {
+					if part.Type == StreamPartTypeFinish {
+						part.Usage.Cost = responseCost(part.Usage, part.ProviderMetadata)
+					}
+					if !yield(part) {
+						return
+					}
+				}
+			}, nil
+		},
+		WrapGenerateObject: func(ctx context.Context, call ObjectCall, next GenerateObjectFunc) (*ObjectResponse, error) {
+			resp, err := next(ctx, call)
+			if err != nil {
+				return nil, err
+			}
+			resp.Usage.Cost = responseCost(resp.Usage, resp.ProviderMetadata)
+			return resp, nil
+		},
+		WrapStreamObject: func(ctx context.Context, call ObjectCall, next StreamObjectFunc) (ObjectStreamResponse, error) {
+			stream, err := next(ctx, call)
+			if err != nil {
+				return nil, err
+			}
+			return func(yield func(ObjectStreamPart) bool) {
+				for part := range stream {
+					if part.Type == ObjectStreamPartTypeFinish {
+						part.Usage.Cost = responseCost(part.Usage, part.ProviderMetadata)
+					}
+					if !yield(part) {
+						return
+					}
+				}
+			}, nil
+		},
+	})
+}
+
+// MaxCostUSD returns a stop condition that stops when the total cost of the
+// steps reaches the specified amount in USD. Steps of models without a known
+// price do not count.
+func MaxCostUSD(maxCost float64) StopCondition {
+	return func(steps []StepResult) bool {
+		var totalCost float64
+		for _, step := range steps {
+			totalCost += step.Usage.Cost.Total + step.ToolUsage.Cost.Total
+		}
+		return totalCost >= maxCost
+	}
+}
+
+// Prices of the models of the built-in providers in USD per million tokens.
+func init() {
+	openai := func(input, cacheRead, output float64) Price {
+		return Price{
+			Input:                   input,
+			Output:                  output,
+			CacheRead:               cacheRead,
+			InputIncludesCacheRead:  true,
+			OutputIncludesReasoning: true,
+		}
+	}
+	// Variants priced apart from their base model, such as the pro models,
+	// need their own entry, otherwise they match the base model by prefix.
+	for model, price := range map[string]Price{
+		"gpt-5.2":               openai(1.75, 0.175, 14),
+		"gpt-5.2-pro":           openai(21, 21, 168),
+		"gpt-5.1":               openai(1.25, 0.125, 10),
+		"gpt-5.1-codex-mini":    openai(0.25, 0.025, 2),
+		"gpt-5":                 openai(1.25, 0.125, 10),
+		"gpt-5-pro":             openai(15, 15, 120),
+		"gpt-5-mini":            openai(0.25, 0.025, 2),
+		"gpt-5-nano":            openai(0.05, 0.005, 0.40),
+		"gpt-4.1":               openai(2, 0.50, 8),
+		"gpt-4.1-mini":          openai(0.40, 0.10, 1.60),
+		"gpt-4.1-nano":          openai(0.10, 0.025, 0.40),
+		"gpt-4o":                openai(2.50, 1.25, 10),
+		"gpt-4o-2024-05-13":     openai(5, 5, 15),
+		"gpt-4o-mini":           openai(0.15, 0.075, 0.60),
+		"o1":                    openai(15, 7.50, 60),
+		"o1-mini":               openai(1.10, 0.55, 4.40),
+		"o1-pro":                openai(150, 150, 600),
+		"o3":                    openai(2, 0.50, 8),
+		"o3-pro":                openai(20, 20, 80),
+		"o3-deep-research":      openai(10, 2.50, 40),
+		"o3-mini":               openai(1.10, 0.55, 4.40),
+		"o4-mini":               openai(1.10, 0.275, 4.40),
+		"o4-mini-deep-research": openai(2, 0.50, 8),
+	} {
+		RegisterPrice("openai", model, price)
+	}
+
+	anthropic := func(input, output float64) Price {
+		return Price{
+			Input:      input,
+			Output:     output,
+			CacheWrite: input * 1.25,
+			CacheRead:  input * 0.1,
+		}
+	}
+	for model, price := range map[string]Price{
+		"claude-opus-4-5":   anthropic(5, 25),
+		"claude-opus-4-1":   anthropic(15, 75),
+		"claude-opus-4":     anthropic(15, 75),
+		"claude-sonnet-4-5": anthropic(3, 15),
+		"claude-sonnet-4":   anthropic(3, 15),
+		"claude-3-7-sonnet": anthropic(3, 15),
+		"claude-haiku-4-5":  anthropic(1, 5),
+		"claude-3-5-haiku":  anthropic(0.80, 4),
+	} {
+		RegisterPrice("anthropic", model, price)
+	}
+
+	google := func(input, cacheRead, output float64) Price {
+		return Price{
+			Input:                  input,
+			Output:                 output,
+			CacheRead:              cacheRead,
+			InputIncludesCacheRead: true,
+		}
+	}
+	for model, price := range map[string]Price{
+		"gemini-3-pro":                 google(2, 0.20, 12),
+		"gemini-2.5-pro":               google(1.25, 0.31, 10),
+		"gemini-2.5-pro-preview-tts":   google(1, 1, 20),
+		"gemini-2.5-flash":             google(0.30, 0.075, 2.50),
+		"gemini-2.5-flash-image":       google(0.30, 0.075, 30),
+		"gemini-2.5-flash-preview-tts": google(0.50, 0.50, 10),
+		"gemini-2.5-flash-lite":        google(0.10, 0.025, 0.40),
+		"gemini-2.0-flash":             google(0.10, 0.025, 0.40),
+		"gemini-2.0-flash-lite":        google(0.075, 0.075, 0.30),
+	} {
+		RegisterPrice("google", model, price)
+	}
+}
@@ -0,0 +1,214 @@
+package fantasy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// reportedCostMetadata is provider metadata reporting the cost of a call, it
+// embeds FallbackMetadata to implement ProviderOptionsData.
+type reportedCostMetadata struct {
+	*FallbackMetadata
+	cost float64
+}
+
+func (m reportedCostMetadata) ReportedCost() (float64, bool) {
+	return m.cost, true
+}
+
+func TestPriceCost(t *testing.T) {
+	t.Parallel()
+
+	t.Run("cached and reasoning tokens included", func(t *testing.T) {
+		t.Parallel()
+		price, ok := LookupPrice("openai", "gpt-4o-2024-08-06")
+		require.True(t, ok)
+
+		cost := price.Cost(Usage{
+			InputTokens:     1_000_000,
+			CacheReadTokens: 400_000,
+			OutputTokens:    200_000,
+			ReasoningTokens: 50_000,
+		})
+		require.InDelta(t, 1.5, cost.Input, 1e-9)     // 600k at 2.50
+		require.InDelta(t, 0.5, cost.CacheRead, 1e-9) // 400k at 1.25
+		require.InDelta(t, 1.5, cost.Output, 1e-9)    // 150k at 10
+		require.InDelta(t, 0.5, cost.Reasoning, 1e-9) // 50k at 10
+		require.InDelta(t, 4.0, cost.Total, 1e-9)
+	})
+
+	t.Run("cache tokens counted separately", func(t *testing.T) {
+		t.Parallel()
+		price, ok := LookupPrice("anthropic", "claude-sonnet-4-5-20250929")
+		require.True(t, ok)
+
+		cost := price.Cost(Usage{
+			InputTokens:         1_000_000,
+			OutputTokens:        100_000,
+			CacheCreationTokens: 1_000_000,
+			CacheReadTokens:     1_000_000,
+		})
+		require.InDelta(t, 3, cost.Input, 1e-9)
+		require.InDelta(t, 1.5, cost.Output, 1e-9)
+		require.InDelta(t, 3.75, cost.CacheWrite, 1e-9)
+		require.InDelta(t, 0.3, cost.CacheRead, 1e-9)
+		require.InDelta(t, 8.55, cost.Total, 1e-9)
+	})
+
+	t.Run("longest prefix wins", func(t *testing.T) {
+		t.Parallel()
+		price, ok := LookupPrice("openai", "gpt-4o-mini-2024-07-18")
+		require.True(t, ok)
+		require.Equal(t, 0.15, price.Input)
+
+		_, ok = LookupPrice("openai", "gpt-4omni")
+		require.False(t, ok)
+		_, ok = LookupPrice("unknown", "gpt-4o")
+		require.False(t, ok)
+	})
+	t.Run("variants are priced apart from their base model", func(t *testing.T) {
+		t.Parallel()
+		for _, tt := range []struct {
+			provider, model string
+			input, output   float64
+		}{
+			{"openai", "o1-pro-2025-03-19", 150, 600},
+			{"openai", "o3-pro", 20, 80},
+			{"openai", "gpt-5-pro-2025-10-06", 15, 120},
+			{"openai", "gpt-5.2", 1.75, 14},
+			{"openai", "gpt-5.1-codex", 1.25, 10},
+			{"anthropic", "claude-opus-4-5-20251101", 5, 25},
+			{"google", "gemini-2.5-flash-image", 0.30, 30},
+			{"google", "gemini-2.5-flash-preview-tts", 0.50, 10},
+		} {
+			price, ok := LookupPrice(tt.provider, tt.model)
+			require.True(t, ok, tt.model)
+			require.Equal(t, tt.input, price.Input, tt.model)
+			require.Equal(t, tt.output, price.Output, tt.model)
+		}
+	})
+}
+
+func TestComputeCost(t *testing.T) {
+	t.Parallel()
+
+	RegisterPrice("cost-test", "model", Price{Input: 1, Output: 2})
+	model := &mockLanguageModel{}
+
+	usage := Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000}
+
+	_, ok := ComputeCost(model, Response{Usage: usage})
+	require.False(t, ok, "the mock provider has no prices")
+
+	fallback := &FallbackMetadata{Provider: "cost-test", Model: "model"}
+	cost, ok := ComputeCost(model, Response{
+		Usage:            usage,
+		ProviderMetadata: ProviderMetadata{FallbackMetadataKey: fallback},
+	})
+	require.True(t, ok)
+	require.InDelta(t, 3, cost.Total, 1e-9)
+
+	cost, ok = ComputeCost(model, Response{
+		Usage: usage,
+		ProviderMetadata: ProviderMetadata{
+			FallbackMetadataKey: fallback,
+			"reporter":          reportedCostMetadata{FallbackMetadata: fallback, cost: 0.42},
+		},
+	})
+	require.True(t, ok)
+	require.InDelta(t, 0.42, cost.Total, 1e-9)
+}
+
+func TestMaxCostUSD(t *testing.T) {
+	t.Parallel()
+
+	RegisterPrice("max-cost-test", "model", Price{Input: 10, Output: 10})
+	calls := 0
+	model := &pricedModel{mockLanguageModel: &mockLanguageModel{
+		generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+			calls++
+			return &Response{
+				Content: ResponseContent{
+					ToolCallContent{ToolCallID: "call", ToolName: "test_tool", Input: `{}`},
+				},
+				Usage:        Usage{InputTokens: 50_000, OutputTokens: 50_000, TotalTokens: 100_000},
+				FinishReason: FinishReasonToolCalls,
+			}, nil
+		},
+	}}
+	tool := &mockTool{
+		name: "test_tool",
+		executeFunc: func(ctx context.Context, call ToolCall) (ToolResponse, error) {
+			return NewTextResponse("ok"), nil
+		},
+	}
+
+	// Every step costs $1.
+	agent := NewAgent(model, WithTools(tool), WithStopConditions(MaxCostUSD(2.5), StepCountIs(10)))
+	result, err := agent.Generate(context.Background(), AgentCall{Prompt: "test prompt"})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+	require.InDelta(t, 1, result.Steps[0].Usage.Cost.Total, 1e-9)
+	require.InDelta(t, 3, result.TotalUsage.Cost.Total, 1e-9)
+}
+
+func TestWithCost(t *testing.T) {
+	t.Parallel()
+
+	RegisterPrice("max-cost-test", "model", Price{Input: 10, Output: 10})
+	usage := Usage{InputTokens: 50_000, OutputTokens: 50_000, TotalTokens: 100_000}
+	model := WithCost(&pricedModel{mockLanguageModel: &mockLanguageModel{
+		generateFunc: func(context.Context, Call) (*Response, error) {
+			return &Response{Usage: usage}, nil
+		},
+		streamFunc: func(context.Context, Call) (StreamResponse, error) {
+			return func(yield func(StreamPart) bool) {
+				_ = yield(StreamPart{Type: StreamPartTypeTextDelta, Delta: "Hi"}) &&
+					yield(StreamPart{Type: StreamPartTypeFinish, Usage: usage})
+			}, nil
+		},
+		generateObjectFunc: func(context.Context, ObjectCall) (*ObjectResponse, error) {
+			return &ObjectResponse{Usage: usage}, nil
+		},
+		streamObjectFunc: func(context.Context, ObjectCall) (ObjectStreamResponse, error) {
+			return func(yield func(ObjectStreamPart) bool) {
+				_ = yield(ObjectStreamPart{Type: ObjectStreamPartTypeFinish, Usage: usage})
+			}, nil
+		},
+	}})
+	require.Equal(t, "max-cost-test", model.Provider())
+
+	resp, err := model.Generate(t.Context(), Call{})
+	require.NoError(t, err)
+	require.InDelta(t, 1.0, resp.Usage.Cost.Total, 1e-9)
+
+	stream, err := model.Stream(t.Context(), Call{})
+	require.NoError(t, err)
+	var parts []StreamPart
+	for part := range stream {
+		parts = append(parts, part)
+	}
+	require.Len(t, parts, 2)
+	require.Zero(t, parts[0].Usage.Cost)
+	require.InDelta(t, 1.0, parts[1].Usage.Cost.Total, 1e-9)
+
+	objectResp, err := model.GenerateObject(t.Context(), ObjectCall{})
+	require.NoError(t, err)
+	require.InDelta(t, 1.0, objectResp.Usage.Cost.Total, 1e-9)
+
+	objectStream, err := model.StreamObject(t.Context(), ObjectCall{})
+	require.NoError(t, err)
+	for part := range objectStream {
+		require.InDelta(t, 1.0, part.Usage.Cost.Total, 1e-9)
+	}
+}
+
+// pricedModel is a mock language model with a registered price.
+type pricedModel struct {
+	*mockLanguageModel
+}
+
+func (m *pricedModel) Provider() string { return "max-cost-test" }
+func (m *pricedModel) Model() string    { return "model" }
@@ -0,0 +1,38 @@
+package fantasy
+
+import "charm.land/fantasy/schema"
+
+// NormalizeTool converts the input schema of a function tool to the schema
+// dialect of a provider. Every transformation that loses information is
+// reported as a warning.
+func NormalizeTool(ft FunctionTool, d schema.Dialect) (FunctionTool, []CallWarning) {
+	inputSchema, losses := schema.Normalize(ft.InputSchema, d)
+	ft.InputSchema = inputSchema
+
+	warnings := make([]CallWarning, 0, len(losses))
+	for _, loss := range losses {
+		warnings = append(warnings, CallWarning{
+			Type:    CallWarningTypeOther,
+			Tool:    ft,
+			Message: loss,
+		})
+	}
+	return ft, warnings
+}
+
+// NormalizeSchema converts the schema of an object call to the schema dialect
+// of a provider. Every transformation that loses information is reported as a
+// warning.
+func NormalizeSchema(s Schema, d schema.Dialect) (map[string]any, []CallWarning) {
+	result, losses := schema.Normalize(schema.ToMap(s), d)
+
+	warnings := make([]CallWarning, 0, len(losses))
+	for _, loss := range losses {
+		warnings = append(warnings, CallWarning{
+			Type:    CallWarningTypeOther,
+			Setting: "schema",
+			Message: loss,
+		})
+	}
+	return result, warnings
+}
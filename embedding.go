@@ -0,0 +1,78 @@
+package fantasy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// EmbedBatchFunc embeds one batch of values with a single provider request.
+type EmbedBatchFunc = func(ctx context.Context, call EmbedManyCall) (*EmbedManyResponse, error)
+
+// EmbedInBatches splits the values of call into batches of at most maxPerCall
+// values and embeds them one batch after another. Embeddings are returned in
+// the order of the values, usage is summed across all requests and warnings
+// and provider metadata are taken from the first request.
+//
+// A maxPerCall of zero or less sends every value in a single request.
+// Providers use this to implement [EmbeddingModel.EmbedMany].
+func EmbedInBatches(ctx context.Context, call EmbedManyCall, maxPerCall int, embed EmbedBatchFunc) (*EmbedManyResponse, error) {
+	if call.Dimensions != nil && *call.Dimensions <= 0 {
+		return nil, &Error{
+			Title:   "invalid argument",
+			Message: fmt.Sprintf("dimensions must be greater than zero, got %d", *call.Dimensions),
+		}
+	}
+	if len(call.Values) == 0 {
+		return &EmbedManyResponse{}, nil
+	}
+	if maxPerCall <= 0 {
+		maxPerCall = len(call.Values)
+	}
+
+	result := &EmbedManyResponse{
+		Embeddings: make([]Embedding, 0, len(call.Values)),
+	}
+	first := true
+	for batch := range slices.Chunk(call.Values, maxPerCall) {
+		batchCall := call
+		batchCall.Values = batch
+		resp, err := embed(ctx, batchCall)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Embeddings) != len(batch) {
+			return nil, &Error{
+				Title:   "invalid response",
+				Message: fmt.Sprintf("expected %d embeddings, got %d", len(batch), len(resp.Embeddings)),
+			}
+		}
+		result.Embeddings = append(result.Embeddings, resp.Embeddings...)
+		result.Usage = addUsage(result.Usage, resp.Usage)
+		if first {
+			result.Warnings = resp.Warnings
+			result.ProviderMetadata = resp.ProviderMetadata
+			first = false
+		}
+	}
+	return result, nil
+}
+
+// EmbedSingle embeds a single value by sending a one-value batch to embed.
+// Providers use this to implement [EmbeddingModel.Embed].
+func EmbedSingle(ctx context.Context, call EmbedCall, embed EmbedBatchFunc) (*EmbedResponse, error) {
+	resp, err := EmbedInBatches(ctx, EmbedManyCall{
+		Values:          []string{call.Value},
+		Dimensions:      call.Dimensions,
+		ProviderOptions: call.ProviderOptions,
+	}, 1, embed)
+	if err != nil {
+		return nil, err
+	}
+	return &EmbedResponse{
+		Embedding:        resp.Embeddings[0],
+		Usage:            resp.Usage,
+		Warnings:         resp.Warnings,
+		ProviderMetadata: resp.ProviderMetadata,
+	}, nil
+}
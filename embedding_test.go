@@ -0,0 +1,119 @@
+package fantasy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fakeEmbed(calls *[][]string) EmbedBatchFunc {
+	return func(_ context.Context, call EmbedManyCall) (*EmbedManyResponse, error) {
+		*calls = append(*calls, call.Values)
+		embeddings := make([]Embedding, 0, len(call.Values))
+		for _, v := range call.Values {
+			embeddings = append(embeddings, Embedding{float64(len(v))})
+		}
+		return &EmbedManyResponse{
+			Embeddings: embeddings,
+			Usage:      Usage{InputTokens: int64(len(call.Values)), TotalTokens: int64(len(call.Values))},
+			Warnings:   []CallWarning{{Type: CallWarningTypeOther, Message: "batch " + strings.Join(call.Values, ",")}},
+		}, nil
+	}
+}
+
+func TestEmbedInBatches(t *testing.T) {
+	t.Parallel()
+
+	t.Run("splits values into batches and keeps order", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]string
+		resp, err := EmbedInBatches(context.Background(), EmbedManyCall{
+			Values: []string{"a", "bb", "ccc", "dddd", "eeeee"},
+		}, 2, fakeEmbed(&calls))
+		require.NoError(t, err)
+
+		require.Equal(t, [][]string{{"a", "bb"}, {"ccc", "dddd"}, {"eeeee"}}, calls)
+		require.Equal(t, []Embedding{{1}, {2}, {3}, {4}, {5}}, resp.Embeddings)
+		require.Equal(t, int64(5), resp.Usage.InputTokens)
+		require.Equal(t, int64(5), resp.Usage.TotalTokens)
+		require.Len(t, resp.Warnings, 1)
+		require.Equal(t, "batch a,bb", resp.Warnings[0].Message)
+	})
+
+	t.Run("no limit sends a single request", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]string
+		resp, err := EmbedInBatches(context.Background(), EmbedManyCall{
+			Values: []string{"a", "b", "c"},
+		}, 0, fakeEmbed(&calls))
+		require.NoError(t, err)
+		require.Len(t, calls, 1)
+		require.Len(t, resp.Embeddings, 3)
+	})
+
+	t.Run("empty values", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]string
+		resp, err := EmbedInBatches(context.Background(), EmbedManyCall{}, 10, fakeEmbed(&calls))
+		require.NoError(t, err)
+		require.Empty(t, calls)
+		require.Empty(t, resp.Embeddings)
+	})
+
+	t.Run("invalid dimensions", func(t *testing.T) {
+		t.Parallel()
+
+		dimensions := int64(0)
+		var calls [][]string
+		_, err := EmbedInBatches(context.Background(), EmbedManyCall{
+			Values:     []string{"a"},
+			Dimensions: &dimensions,
+		}, 10, fakeEmbed(&calls))
+		require.Error(t, err)
+		require.Empty(t, calls)
+	})
+
+	t.Run("mismatched embedding count", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := EmbedInBatches(context.Background(), EmbedManyCall{
+			Values: []string{"a", "b"},
+		}, 10, func(context.Context, EmbedManyCall) (*EmbedManyResponse, error) {
+			return &EmbedManyResponse{Embeddings: []Embedding{{1}}}, nil
+		})
+		var fantasyErr *Error
+		require.ErrorAs(t, err, &fantasyErr)
+		require.Equal(t, "invalid response", fantasyErr.Title)
+	})
+
+	t.Run("stops at the first failing batch", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		_, err := EmbedInBatches(context.Background(), EmbedManyCall{
+			Values: []string{"a", "b", "c"},
+		}, 1, func(context.Context, EmbedManyCall) (*EmbedManyResponse, error) {
+			calls++
+			return nil, errors.New("boom")
+		})
+		require.EqualError(t, err, "boom")
+		require.Equal(t, 1, calls)
+	})
+}
+
+func TestEmbedSingle(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	resp, err := EmbedSingle(context.Background(), EmbedCall{Value: "hello"}, fakeEmbed(&calls))
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"hello"}}, calls)
+	require.Equal(t, Embedding{5}, resp.Embedding)
+	require.Equal(t, int64(1), resp.Usage.InputTokens)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 
+	"charm.land/fantasy/schema"
 	"github.com/charmbracelet/x/exp/slice"
 )
 
@@ -102,3 +103,20 @@ func IsNoObjectGeneratedError(err error) bool {
 	var target *NoObjectGeneratedError
 	return errors.As(err, &target)
 }
+
+// ToolInputError is returned when the input of a tool call does not match the
+// schema of the tool. Its message lists every violation so that the model, or
+// a RepairToolCall function, can correct the input.
+type ToolInputError struct {
+	ToolName   string
+	Violations []schema.Violation
+}
+
+func (e *ToolInputError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "invalid input for tool %s:", e.ToolName)
+	for _, v := range e.Violations {
+		fmt.Fprintf(&sb, "\n- %s", v)
+	}
+	return sb.String()
+}
@@ -0,0 +1,513 @@
+package fantasy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FallbackMetadataKey is the provider metadata key under which a fallback
+// model reports the model that served the response, see [FallbackMetadata].
+const FallbackMetadataKey = "fallback"
+
+// TypeFallbackMetadata is the type identifier of [FallbackMetadata].
+const TypeFallbackMetadata = "fantasy.fallback_metadata"
+
+func init() {
+	RegisterProviderType(TypeFallbackMetadata, func(data []byte) (ProviderOptionsData, error) {
+		var v FallbackMetadata
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
+}
+
+// FallbackMetadata reports which model of a fallback model served a
+// response. It is set in the provider metadata of responses, finish stream
+// parts and object responses under [FallbackMetadataKey].
+type FallbackMetadata struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Attempts lists the models that failed before, in the order they were
+	// tried.
+	Attempts []FallbackAttempt `json:"attempts,omitempty"`
+}
+
+// FallbackAttempt is a failed attempt of a fallback model.
+type FallbackAttempt struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Error    string `json:"error"`
+}
+
+// Options implements the ProviderOptionsData interface.
+func (*FallbackMetadata) Options() {}
+
+// MarshalJSON implements custom JSON marshaling with type info for FallbackMetadata.
+func (m FallbackMetadata) MarshalJSON() ([]byte, error) {
+	type plain FallbackMetadata
+	return MarshalProviderType(TypeFallbackMetadata, plain(m))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling with type info for FallbackMetadata.
+func (m *FallbackMetadata) UnmarshalJSON(data []byte) error {
+	type plain FallbackMetadata
+	var p plain
+	if err := UnmarshalProviderType(data, &p); err != nil {
+		return err
+	}
+	*m = FallbackMetadata(p)
+	return nil
+}
+
+// FallbackOptions configures a fallback model.
+type FallbackOptions struct {
+	// ShouldFallback reports whether a failed call moves on to the next
+	// model. It defaults to [IsFallbackError].
+	ShouldFallback func(err error) bool
+	// Timeout bounds the time a model has to respond before the call moves
+	// on to the next model. For streams it bounds the time until the first
+	// content part. Zero disables the timeout.
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failures after which a
+	// model is skipped for CoolDown. Zero uses the default.
+	FailureThreshold int
+	// CoolDown is how long a model is skipped once it reached the
+	// FailureThreshold. After the cool-down the model is tried again, a
+	// success closes the circuit and a failure opens it for another
+	// cool-down. Zero uses the default.
+	CoolDown time.Duration
+	// OnFallback is called when a model failed and the call moves on to the
+	// next model.
+	OnFallback func(model LanguageModel, err error)
+}
+
+// DefaultFallbackOptions returns the default fallback options.
+func DefaultFallbackOptions() FallbackOptions {
+	return FallbackOptions{
+		ShouldFallback:   IsFallbackError,
+		FailureThreshold: 3,
+		CoolDown:         30 * time.Second,
+	}
+}
+
+// IsFallbackError reports whether an error is caused by the availability of
+// a model rather than by the call itself: retryable provider errors, server
+// errors (including overloaded errors), network errors and timeouts.
+func IsFallbackError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		if providerErr.IsRetryable() || providerErr.StatusCode >= http.StatusInternalServerError {
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// FallbackModel returns a language model that calls the given models in
+// order, moving on to the next model when a call fails with a fallback error,
+// see [IsFallbackError]. It uses the [DefaultFallbackOptions].
+func FallbackModel(models ...LanguageModel) LanguageModel {
+	return FallbackModelWithOptions(DefaultFallbackOptions(), models...)
+}
+
+// FallbackModelWithOptions is like [FallbackModel] with custom options.
+//
+// Each model has a circuit breaker: once a model failed FailureThreshold
+// times in a row it is skipped for the CoolDown. When every model is skipped
+// they are all tried anyway.
+//
+// Streams only move on to the next model while no content has been emitted,
+// later errors are passed through. The model that served the response is
+// reported in the provider metadata, see [FallbackMetadata]. Provider and
+// Model return the ones of the first model.
+func FallbackModelWithOptions(options FallbackOptions, models ...LanguageModel) LanguageModel {
+	defaults := DefaultFallbackOptions()
+	if options.ShouldFallback == nil {
+		options.ShouldFallback = defaults.ShouldFallback
+	}
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = defaults.FailureThreshold
+	}
+	if options.CoolDown <= 0 {
+		options.CoolDown = defaults.CoolDown
+	}
+	return &fallbackModel{
+		models:   models,
+		options:  options,
+		circuits: make([]circuit, len(models)),
+		now:      time.Now,
+	}
+}
+
+type fallbackModel struct {
+	models  []LanguageModel
+	options FallbackOptions
+
+	mu       sync.Mutex
+	circuits []circuit
+	now      func() time.Time
+}
+
+// circuit tracks the health of a model.
+type circuit struct {
+	failures  int
+	openUntil time.Time
+}
+
+// Generate implements LanguageModel.
+func (f *fallbackModel) Generate(ctx context.Context, call Call) (*Response, error) {
+	resp, metadata, err := fallbackCall(ctx, f, func(ctx context.Context, model LanguageModel) (*Response, error) {
+		return model.Generate(ctx, call)
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.ProviderMetadata = withFallbackMetadata(resp.ProviderMetadata, metadata)
+	return resp, nil
+}
+
+// Stream implements LanguageModel.
+func (f *fallbackModel) Stream(ctx context.Context, call Call) (StreamResponse, error) {
+	return fallbackStream(ctx, f, fallbackStreamSpec[StreamPart]{
+		start: func(ctx context.Context, model LanguageModel) (iter.Seq[StreamPart], error) {
+			return model.Stream(ctx, call)
+		},
+		isContent: func(part StreamPart) bool {
+			return part.Type != StreamPartTypeWarnings && part.Type != StreamPartTypeError
+		},
+		err: func(part StreamPart) error {
+			if part.Type == StreamPartTypeError {
+				return part.Error
+			}
+			return nil
+		},
+		errorPart: func(err error) StreamPart {
+			return StreamPart{Type: StreamPartTypeError, Error: err}
+		},
+		finish: func(part StreamPart, metadata *FallbackMetadata) StreamPart {
+			if part.Type == StreamPartTypeFinish {
+				part.ProviderMetadata = withFallbackMetadata(part.ProviderMetadata, metadata)
+			}
+			return part
+		},
+	})
+}
+
+// GenerateObject implements LanguageModel.
+func (f *fallbackModel) GenerateObject(ctx context.Context, call ObjectCall) (*ObjectResponse, error) {
+	resp, metadata, err := fallbackCall(ctx, f, func(ctx context.Context, model LanguageModel) (*ObjectResponse, error) {
+		return model.GenerateObject(ctx, call)
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.ProviderMetadata = withFallbackMetadata(resp.ProviderMetadata, metadata)
+	return resp, nil
+}
+
+// StreamObject implements LanguageModel.
+func (f *fallbackModel) StreamObject(ctx context.Context, call ObjectCall) (ObjectStreamResponse, error) {
+	return fallbackStream(ctx, f, fallbackStreamSpec[ObjectStreamPart]{
+		start: func(ctx context.Context, model LanguageModel) (iter.Seq[ObjectStreamPart], error) {
+			return model.StreamObject(ctx, call)
+		},
+		isContent: func(part ObjectStreamPart) bool {
+			// Parts that only carry warnings are not content.
+			warningsOnly := part.Type == ObjectStreamPartTypeObject && part.Object == nil && part.Delta == ""
+			return part.Type != ObjectStreamPartTypeError && !warningsOnly
+		},
+		err: func(part ObjectStreamPart) error {
+			if part.Type == ObjectStreamPartTypeError {
+				return part.Error
+			}
+			return nil
+		},
+		errorPart: func(err error) ObjectStreamPart {
+			return ObjectStreamPart{Type: ObjectStreamPartTypeError, Error: err}
+		},
+		finish: func(part ObjectStreamPart, metadata *FallbackMetadata) ObjectStreamPart {
+			if part.Type == ObjectStreamPartTypeFinish {
+				part.ProviderMetadata = withFallbackMetadata(part.ProviderMetadata, metadata)
+			}
+			return part
+		},
+	})
+}
+
+// Provider implements LanguageModel.
+func (f *fallbackModel) Provider() string {
+	if len(f.models) == 0 {
+		return ""
+	}
+	return f.models[0].Provider()
+}
+
+// Model implements LanguageModel.
+func (f *fallbackModel) Model() string {
+	if len(f.models) == 0 {
+		return ""
+	}
+	return f.models[0].Model()
+}
+
+// candidates returns the indexes of the models to try in order, skipping the
+// models with an open circuit unless every circuit is open.
+func (f *fallbackModel) candidates() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	var available, all []int
+	for i, c := range f.circuits {
+		all = append(all, i)
+		if !now.Before(c.openUntil) {
+			available = append(available, i)
+		}
+	}
+	if len(available) == 0 {
+		return all
+	}
+	return available
+}
+
+func (f *fallbackModel) recordSuccess(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.circuits[i] = circuit{}
+}
+
+func (f *fallbackModel) recordFailure(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := &f.circuits[i]
+	c.failures++
+	if c.failures >= f.options.FailureThreshold {
+		c.openUntil = f.now().Add(f.options.CoolDown)
+	}
+}
+
+// fallbackAttempt is the state of a call to one of the models.
+type fallbackAttempt struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	timer  *time.Timer
+	index  int
+}
+
+func (f *fallbackModel) startAttempt(ctx context.Context, i int) *fallbackAttempt {
+	a := &fallbackAttempt{index: i}
+	a.ctx, a.cancel = context.WithCancelCause(ctx)
+	if f.options.Timeout > 0 {
+		a.timer = time.AfterFunc(f.options.Timeout, func() {
+			a.cancel(context.DeadlineExceeded)
+		})
+	}
+	return a
+}
+
+// stopTimer stops the timeout of the attempt, the call continues without it.
+func (a *fallbackAttempt) stopTimer() {
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+}
+
+func (a *fallbackAttempt) end() {
+	a.stopTimer()
+	a.cancel(context.Canceled)
+}
+
+// fail records a failed attempt, it reports whether the call moves on to the
+// next model.
+func (f *fallbackModel) fail(ctx context.Context, a *fallbackAttempt, err error, metadata *FallbackMetadata) (bool, error) {
+	if context.Cause(a.ctx) == context.DeadlineExceeded && ctx.Err() == nil {
+		model := f.models[a.index]
+		err = fmt.Errorf("%s/%s did not respond within %s: %w", model.Provider(), model.Model(), f.options.Timeout, context.DeadlineExceeded)
+	}
+	if ctx.Err() != nil || !f.options.ShouldFallback(err) {
+		return false, err
+	}
+
+	model := f.models[a.index]
+	f.recordFailure(a.index)
+	metadata.Attempts = append(metadata.Attempts, FallbackAttempt{
+		Provider: model.Provider(),
+		Model:    model.Model(),
+		Error:    err.Error(),
+	})
+	if f.options.OnFallback != nil {
+		f.options.OnFallback(model, err)
+	}
+	return true, err
+}
+
+// served sets the model that served the call in the metadata.
+func (f *fallbackModel) served(i int, metadata *FallbackMetadata) {
+	metadata.Provider = f.models[i].Provider()
+	metadata.Model = f.models[i].Model()
+}
+
+// fallbackCall calls the models in order until one succeeds.
+func fallbackCall[T any](ctx context.Context, f *fallbackModel, fn func(context.Context, LanguageModel) (T, error)) (T, *FallbackMetadata, error) {
+	var zero T
+	metadata := &FallbackMetadata{}
+	var errs []error
+	for _, i := range f.candidates() {
+		a := f.startAttempt(ctx, i)
+		result, err := fn(a.ctx, f.models[i])
+		a.end()
+		if err == nil {
+			f.recordSuccess(i)
+			f.served(i, metadata)
+			return result, metadata, nil
+		}
+		next, err := f.fail(ctx, a, err, metadata)
+		if !next {
+			return zero, nil, err
+		}
+		errs = append(errs, err)
+	}
+	return zero, nil, fallbackError(errs)
+}
+
+// fallbackStreamSpec describes the parts of a stream to fallbackStream.
+type fallbackStreamSpec[P any] struct {
+	start     func(context.Context, LanguageModel) (iter.Seq[P], error)
+	isContent func(P) bool
+	err       func(P) error
+	errorPart func(error) P
+	finish    func(P, *FallbackMetadata) P
+}
+
+// fallbackStream streams from the models in order. The parts are held back
+// until the first content part so that an error before it moves on to the
+// next model.
+func fallbackStream[P any](ctx context.Context, f *fallbackModel, spec fallbackStreamSpec[P]) (iter.Seq[P], error) {
+	candidates := f.candidates()
+	metadata := &FallbackMetadata{}
+	var errs []error
+
+	// start opens the stream of the first candidate from pos that does not
+	// fail to start.
+	start := func(pos int) (int, iter.Seq[P], *fallbackAttempt, error) {
+		for ; pos < len(candidates); pos++ {
+			a := f.startAttempt(ctx, candidates[pos])
+			stream, err := spec.start(a.ctx, f.models[a.index])
+			if err == nil {
+				return pos, stream, a, nil
+			}
+			a.end()
+			next, err := f.fail(ctx, a, err, metadata)
+			if !next {
+				return pos, nil, nil, err
+			}
+			errs = append(errs, err)
+		}
+		return pos, nil, nil, fallbackError(errs)
+	}
+
+	pos, stream, a, err := start(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(P) bool) {
+		for {
+			var held []P
+			started := false
+			var fallbackErr error
+			for part := range stream {
+				if !started {
+					if partErr := spec.err(part); partErr != nil {
+						if next, err := f.fail(ctx, a, partErr, metadata); next {
+							fallbackErr = err
+							break
+						}
+					}
+					if !spec.isContent(part) && spec.err(part) == nil {
+						held = append(held, part)
+						continue
+					}
+					started = true
+					a.stopTimer()
+					f.served(a.index, metadata)
+					if spec.err(part) == nil {
+						f.recordSuccess(a.index)
+					}
+					for _, p := range held {
+						if !yield(p) {
+							a.end()
+							return
+						}
+					}
+				}
+				if partErr := spec.err(part); partErr != nil && ctx.Err() == nil && f.options.ShouldFallback(partErr) {
+					// It is too late to move on to the next model but the
+					// failure counts towards its health.
+					f.recordFailure(a.index)
+				}
+				if !yield(spec.finish(part, metadata)) {
+					a.end()
+					return
+				}
+			}
+			a.end()
+
+			if fallbackErr == nil {
+				// The stream ended without content, the held parts are
+				// passed through.
+				if !started {
+					f.recordSuccess(a.index)
+					f.served(a.index, metadata)
+					for _, p := range held {
+						if !yield(spec.finish(p, metadata)) {
+							return
+						}
+					}
+				}
+				return
+			}
+
+			errs = append(errs, fallbackErr)
+			pos, stream, a, err = start(pos + 1)
+			if err != nil {
+				yield(spec.errorPart(err))
+				return
+			}
+		}
+	}, nil
+}
+
+// fallbackError is returned when every model failed.
+func fallbackError(errs []error) error {
+	if len(errs) == 0 {
+		return &Error{Title: "fallback failed", Message: "no models to call"}
+	}
+	return &Error{
+		Title:   "fallback failed",
+		Message: fmt.Sprintf("all %d models failed, last error: %v", len(errs), errs[len(errs)-1]),
+		Cause:   errors.Join(errs...),
+	}
+}
+
+func withFallbackMetadata(providerMetadata ProviderMetadata, metadata *FallbackMetadata) ProviderMetadata {
+	result := maps.Clone(providerMetadata)
+	if result == nil {
+		result = ProviderMetadata{}
+	}
+	result[FallbackMetadataKey] = metadata
+	return result
+}
@@ -0,0 +1,275 @@
+package fantasy
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// namedModel is a mock language model with its own model name.
+type namedModel struct {
+	*mockLanguageModel
+	name     string
+	provider string
+	calls    atomic.Int32
+}
+
+func (m *namedModel) Model() string {
+	return m.name
+}
+
+func (m *namedModel) Provider() string {
+	return cmp.Or(m.provider, m.mockLanguageModel.Provider())
+}
+
+func (m *namedModel) Generate(ctx context.Context, call Call) (*Response, error) {
+	m.calls.Add(1)
+	return m.mockLanguageModel.Generate(ctx, call)
+}
+
+func (m *namedModel) Stream(ctx context.Context, call Call) (StreamResponse, error) {
+	m.calls.Add(1)
+	return m.mockLanguageModel.Stream(ctx, call)
+}
+
+func newNamedModel(name string, generate func(ctx context.Context, call Call) (*Response, error)) *namedModel {
+	return &namedModel{
+		mockLanguageModel: &mockLanguageModel{generateFunc: generate},
+		name:              name,
+	}
+}
+
+func overloaded(context.Context, Call) (*Response, error) {
+	return nil, &ProviderError{Title: "overloaded", Message: "overloaded", StatusCode: 529}
+}
+
+func textResponse(text string) func(context.Context, Call) (*Response, error) {
+	return func(context.Context, Call) (*Response, error) {
+		return &Response{
+			Content:      ResponseContent{TextContent{Text: text}},
+			FinishReason: FinishReasonStop,
+		}, nil
+	}
+}
+
+func TestFallbackModelGenerate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("falls back on overloaded errors", func(t *testing.T) {
+		t.Parallel()
+		primary := newNamedModel("primary", overloaded)
+		secondary := newNamedModel("secondary", textResponse("from secondary"))
+
+		var fallbacks []string
+		model := FallbackModelWithOptions(FallbackOptions{
+			OnFallback: func(model LanguageModel, err error) {
+				fallbacks = append(fallbacks, model.Model())
+			},
+		}, primary, secondary)
+
+		resp, err := model.Generate(context.Background(), Call{})
+		require.NoError(t, err)
+		require.Equal(t, "from secondary", resp.Content.Text())
+		require.Equal(t, []string{"primary"}, fallbacks)
+
+		metadata, ok := resp.ProviderMetadata[FallbackMetadataKey].(*FallbackMetadata)
+		require.True(t, ok)
+		require.Equal(t, "secondary", metadata.Model)
+		require.Len(t, metadata.Attempts, 1)
+		require.Equal(t, "primary", metadata.Attempts[0].Model)
+		require.Equal(t, "primary", model.Model())
+	})
+
+	t.Run("returns other errors", func(t *testing.T) {
+		t.Parallel()
+		primary := newNamedModel("primary", func(context.Context, Call) (*Response, error) {
+			return nil, &ProviderError{Title: "bad request", StatusCode: http.StatusBadRequest}
+		})
+		secondary := newNamedModel("secondary", textResponse("from secondary"))
+
+		_, err := FallbackModel(primary, secondary).Generate(context.Background(), Call{})
+		var providerErr *ProviderError
+		require.ErrorAs(t, err, &providerErr)
+		require.Equal(t, http.StatusBadRequest, providerErr.StatusCode)
+		require.Zero(t, secondary.calls.Load())
+	})
+
+	t.Run("reports all errors when every model fails", func(t *testing.T) {
+		t.Parallel()
+		primary := newNamedModel("primary", overloaded)
+		secondary := newNamedModel("secondary", func(context.Context, Call) (*Response, error) {
+			return nil, &ProviderError{Title: "rate limited", StatusCode: http.StatusTooManyRequests}
+		})
+
+		_, err := FallbackModel(primary, secondary).Generate(context.Background(), Call{})
+		require.ErrorContains(t, err, "all 2 models failed")
+		var providerErr *ProviderError
+		require.ErrorAs(t, err, &providerErr)
+	})
+
+	t.Run("falls back on timeouts", func(t *testing.T) {
+		t.Parallel()
+		primary := newNamedModel("primary", func(ctx context.Context, _ Call) (*Response, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		secondary := newNamedModel("secondary", textResponse("from secondary"))
+
+		model := FallbackModelWithOptions(FallbackOptions{Timeout: 10 * time.Millisecond}, primary, secondary)
+		resp, err := model.Generate(context.Background(), Call{})
+		require.NoError(t, err)
+		require.Equal(t, "from secondary", resp.Content.Text())
+		metadata := resp.ProviderMetadata[FallbackMetadataKey].(*FallbackMetadata)
+		require.Contains(t, metadata.Attempts[0].Error, "did not respond within 10ms")
+	})
+
+	t.Run("skips models with an open circuit", func(t *testing.T) {
+		t.Parallel()
+		var primaryDown atomic.Bool
+		primaryDown.Store(true)
+		primary := newNamedModel("primary", func(ctx context.Context, call Call) (*Response, error) {
+			if primaryDown.Load() {
+				return overloaded(ctx, call)
+			}
+			return textResponse("from primary")(ctx, call)
+		})
+		secondary := newNamedModel("secondary", textResponse("from secondary"))
+
+		now := time.Now()
+		model := FallbackModelWithOptions(FallbackOptions{
+			FailureThreshold: 2,
+			CoolDown:         time.Minute,
+		}, primary, secondary).(*fallbackModel)
+		model.now = func() time.Time { return now }
+
+		for range 3 {
+			_, err := model.Generate(context.Background(), Call{})
+			require.NoError(t, err)
+		}
+		// The circuit opened after two failures.
+		require.Equal(t, int32(2), primary.calls.Load())
+
+		// After the cool-down the primary model is tried again.
+		primaryDown.Store(false)
+		now = now.Add(2 * time.Minute)
+		resp, err := model.Generate(context.Background(), Call{})
+		require.NoError(t, err)
+		require.Equal(t, "from primary", resp.Content.Text())
+		require.Equal(t, int32(3), primary.calls.Load())
+	})
+}
+
+func TestFallbackModelStream(t *testing.T) {
+	t.Parallel()
+
+	streamOf := func(parts ...StreamPart) func(context.Context, Call) (StreamResponse, error) {
+		return func(context.Context, Call) (StreamResponse, error) {
+			return func(yield func(StreamPart) bool) {
+				for _, part := range parts {
+					if !yield(part) {
+						return
+					}
+				}
+			}, nil
+		}
+	}
+	collect := func(t *testing.T, model LanguageModel) []StreamPart {
+		stream, err := model.Stream(context.Background(), Call{})
+		require.NoError(t, err)
+		var parts []StreamPart
+		for part := range stream {
+			parts = append(parts, part)
+		}
+		return parts
+	}
+	overloadedErr := &ProviderError{Title: "overloaded", StatusCode: 529}
+
+	t.Run("falls back before the first content", func(t *testing.T) {
+		t.Parallel()
+		primary := newNamedModel("primary", nil)
+		primary.streamFunc = streamOf(
+			StreamPart{Type: StreamPartTypeWarnings, Warnings: []CallWarning{{Message: "primary warning"}}},
+			StreamPart{Type: StreamPartTypeError, Error: overloadedErr},
+		)
+		secondary := newNamedModel("secondary", nil)
+		secondary.streamFunc = streamOf(
+			StreamPart{Type: StreamPartTypeTextDelta, Delta: "hello"},
+			StreamPart{Type: StreamPartTypeFinish, FinishReason: FinishReasonStop},
+		)
+
+		parts := collect(t, FallbackModel(primary, secondary))
+		require.Len(t, parts, 2)
+		require.Equal(t, "hello", parts[0].Delta)
+		metadata := parts[1].ProviderMetadata[FallbackMetadataKey].(*FallbackMetadata)
+		require.Equal(t, "secondary", metadata.Model)
+		require.Len(t, metadata.Attempts, 1)
+	})
+
+	t.Run("falls back when the stream can not start", func(t *testing.T) {
+		t.Parallel()
+		primary := newNamedModel("primary", nil)
+		primary.streamFunc = func(context.Context, Call) (StreamResponse, error) {
+			return nil, overloadedErr
+		}
+		secondary := newNamedModel("secondary", nil)
+		secondary.streamFunc = streamOf(StreamPart{Type: StreamPartTypeFinish})
+
+		parts := collect(t, FallbackModel(primary, secondary))
+		require.Len(t, parts, 1)
+		metadata := parts[0].ProviderMetadata[FallbackMetadataKey].(*FallbackMetadata)
+		require.Equal(t, "secondary", metadata.Model)
+	})
+
+	t.Run("passes errors through after the first content", func(t *testing.T) {
+		t.Parallel()
+		primary := newNamedModel("primary", nil)
+		primary.streamFunc = streamOf(
+			StreamPart{Type: StreamPartTypeTextDelta, Delta: "hel"},
+			StreamPart{Type: StreamPartTypeError, Error: overloadedErr},
+		)
+		secondary := newNamedModel("secondary", nil)
+		secondary.streamFunc = streamOf(StreamPart{Type: StreamPartTypeFinish})
+
+		parts := collect(t, FallbackModel(primary, secondary))
+		require.Len(t, parts, 2)
+		require.Equal(t, StreamPartTypeError, parts[1].Type)
+		require.Zero(t, secondary.calls.Load())
+	})
+
+	t.Run("yields an error part when every model fails", func(t *testing.T) {
+		t.Parallel()
+		primary := newNamedModel("primary", nil)
+		primary.streamFunc = streamOf(StreamPart{Type: StreamPartTypeError, Error: overloadedErr})
+		secondary := newNamedModel("secondary", nil)
+		secondary.streamFunc = streamOf(StreamPart{Type: StreamPartTypeError, Error: overloadedErr})
+
+		parts := collect(t, FallbackModel(primary, secondary))
+		require.Len(t, parts, 1)
+		require.ErrorContains(t, parts[0].Error, "all 2 models failed")
+	})
+
+	t.Run("falls back when the first content times out", func(t *testing.T) {
+		t.Parallel()
+		primary := newNamedModel("primary", nil)
+		primary.streamFunc = func(ctx context.Context, _ Call) (StreamResponse, error) {
+			return func(yield func(StreamPart) bool) {
+				<-ctx.Done()
+				yield(StreamPart{Type: StreamPartTypeError, Error: ctx.Err()})
+			}, nil
+		}
+		secondary := newNamedModel("secondary", nil)
+		secondary.streamFunc = streamOf(
+			StreamPart{Type: StreamPartTypeTextDelta, Delta: "hello"},
+			StreamPart{Type: StreamPartTypeFinish},
+		)
+
+		parts := collect(t, FallbackModelWithOptions(FallbackOptions{Timeout: 10 * time.Millisecond}, primary, secondary))
+		require.Len(t, parts, 2)
+		require.Equal(t, "hello", parts[0].Delta)
+	})
+}
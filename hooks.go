@@ -0,0 +1,95 @@
+package fantasy
+
+import (
+	"context"
+	"time"
+)
+
+// AgentHooks observe an agent run from the inside.
+//
+// Unlike the stream callbacks they receive the context of the run, step or
+// tool call and the start hooks can return a derived context, which makes them
+// suitable for instrumentation such as tracing. The context returned by a
+// start hook is the one passed to the matching finish hook. All hooks are
+// optional.
+type AgentHooks struct {
+	// OnRunStart is called when Generate or Stream starts.
+	OnRunStart func(ctx context.Context, model LanguageModel) context.Context
+	// OnRunFinish is called when Generate or Stream returns.
+	OnRunFinish func(ctx context.Context, result *AgentResult, err error)
+
+	// OnStepStart is called before the model is called for a step, the
+	// returned context is used for the model call and the tool calls of the
+	// step.
+	OnStepStart func(ctx context.Context, stepNumber int, model LanguageModel) context.Context
+	// OnStepRetry is called when the model call of a step is retried.
+	OnStepRetry func(ctx context.Context, err *ProviderError, delay time.Duration)
+	// OnStepFinish is called when a step finishes, step is nil if it failed
+	// or was suspended for approval.
+	OnStepFinish func(ctx context.Context, step *StepResult, err error)
+
+	// OnToolStart is called before a tool is run, the returned context is
+	// passed to the tool.
+	OnToolStart func(ctx context.Context, toolCall ToolCall) context.Context
+	// OnToolFinish is called after a tool ran.
+	OnToolFinish func(ctx context.Context, toolCall ToolCall, response ToolResponse, err error)
+}
+
+// WithHooks sets the hooks for the agent.
+func WithHooks(hooks AgentHooks) AgentOption {
+	return func(s *agentSettings) {
+		s.hooks = hooks
+	}
+}
+
+func (h AgentHooks) runStart(ctx context.Context, model LanguageModel) context.Context {
+	if h.OnRunStart == nil {
+		return ctx
+	}
+	return h.OnRunStart(ctx, model)
+}
+
+func (h AgentHooks) runFinish(ctx context.Context, result *AgentResult, err error) {
+	if h.OnRunFinish != nil {
+		h.OnRunFinish(ctx, result, err)
+	}
+}
+
+func (h AgentHooks) stepStart(ctx context.Context, stepNumber int, model LanguageModel) context.Context {
+	if h.OnStepStart == nil {
+		return ctx
+	}
+	return h.OnStepStart(ctx, stepNumber, model)
+}
+
+func (h AgentHooks) stepFinish(ctx context.Context, step *StepResult, err error) {
+	if h.OnStepFinish != nil {
+		h.OnStepFinish(ctx, step, err)
+	}
+}
+
+// stepRetry combines the step retry hook with the retry callback of the call.
+func (h AgentHooks) stepRetry(ctx context.Context, callback OnRetryCallback) OnRetryCallback {
+	if h.OnStepRetry == nil {
+		return callback
+	}
+	return func(err *ProviderError, delay time.Duration) {
+		h.OnStepRetry(ctx, err, delay)
+		if callback != nil {
+			callback(err, delay)
+		}
+	}
+}
+
+func (h AgentHooks) toolStart(ctx context.Context, toolCall ToolCall) context.Context {
+	if h.OnToolStart == nil {
+		return ctx
+	}
+	return h.OnToolStart(ctx, toolCall)
+}
+
+func (h AgentHooks) toolFinish(ctx context.Context, toolCall ToolCall, response ToolResponse, err error) {
+	if h.OnToolFinish != nil {
+		h.OnToolFinish(ctx, toolCall, response, err)
+	}
+}
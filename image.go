@@ -0,0 +1,43 @@
+package fantasy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// imageToolInput is the input of the image generation tool.
+type imageToolInput struct {
+	Prompt string `json:"prompt" description:"A detailed description of the image to generate"`
+	Size   string `json:"size,omitempty" description:"The size of the image as {width}x{height}, e.g. 1024x1024"`
+}
+
+// NewImageTool creates a tool that lets an agent generate images with the
+// given model. The defaults are used for every call, the prompt and size are
+// chosen by the model calling the tool.
+//
+// The tool returns the first generated image, failed generations are
+// reported to the model as error responses.
+func NewImageTool(name, description string, model ImageModel, defaults ImageCall) AgentTool {
+	return NewAgentTool(name, description, func(ctx context.Context, input imageToolInput, _ ToolCall) (ToolResponse, error) {
+		call := defaults
+		call.Prompt = input.Prompt
+		call.N = 1
+		if input.Size != "" {
+			call.Size = input.Size
+		}
+
+		resp, err := model.GenerateImage(ctx, call)
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return ToolResponse{}, err
+		}
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("failed to generate image: %s", err)), nil
+		}
+		if len(resp.Images) == 0 {
+			return NewTextErrorResponse("no image was generated"), nil
+		}
+		image := resp.Images[0]
+		return NewImageResponse(image.Data, image.MediaType), nil
+	})
+}
@@ -0,0 +1,75 @@
+package fantasy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeImageModel struct {
+	calls []ImageCall
+	err   error
+}
+
+func (m *fakeImageModel) GenerateImage(_ context.Context, call ImageCall) (*ImageResponse, error) {
+	m.calls = append(m.calls, call)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &ImageResponse{
+		Images: []FileContent{{MediaType: "image/png", Data: []byte(call.Prompt)}},
+	}, nil
+}
+
+func (m *fakeImageModel) Provider() string { return "fake" }
+func (m *fakeImageModel) Model() string    { return "fake-image" }
+
+func TestImageTool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("generates an image with the defaults", func(t *testing.T) {
+		t.Parallel()
+
+		model := &fakeImageModel{}
+		tool := NewImageTool("draw", "Draw an image", model, ImageCall{Size: "512x512", AspectRatio: "1:1", N: 4})
+		require.Equal(t, []string{"prompt"}, tool.Info().Required)
+
+		resp, err := tool.Run(t.Context(), ToolCall{ID: "1", Name: "draw", Input: `{"prompt":"a cat"}`})
+		require.NoError(t, err)
+		require.Equal(t, NewImageResponse([]byte("a cat"), "image/png"), resp)
+		require.Equal(t, []ImageCall{{Prompt: "a cat", N: 1, Size: "512x512", AspectRatio: "1:1"}}, model.calls)
+	})
+
+	t.Run("uses the requested size", func(t *testing.T) {
+		t.Parallel()
+
+		model := &fakeImageModel{}
+		tool := NewImageTool("draw", "Draw an image", model, ImageCall{Size: "512x512"})
+
+		_, err := tool.Run(t.Context(), ToolCall{ID: "1", Name: "draw", Input: `{"prompt":"a cat","size":"1024x1024"}`})
+		require.NoError(t, err)
+		require.Equal(t, "1024x1024", model.calls[0].Size)
+	})
+
+	t.Run("reports failures to the model", func(t *testing.T) {
+		t.Parallel()
+
+		tool := NewImageTool("draw", "Draw an image", &fakeImageModel{err: errors.New("content policy")}, ImageCall{})
+
+		resp, err := tool.Run(t.Context(), ToolCall{ID: "1", Name: "draw", Input: `{"prompt":"a cat"}`})
+		require.NoError(t, err)
+		require.True(t, resp.IsError)
+		require.Contains(t, resp.Content, "content policy")
+	})
+
+	t.Run("returns cancellation errors", func(t *testing.T) {
+		t.Parallel()
+
+		tool := NewImageTool("draw", "Draw an image", &fakeImageModel{err: context.Canceled}, ImageCall{})
+
+		_, err := tool.Run(t.Context(), ToolCall{ID: "1", Name: "draw", Input: `{"prompt":"a cat"}`})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,175 @@
+// Package mcp exposes the tools of a Model Context Protocol server as
+// [fantasy.AgentTool]s.
+//
+// A [Client] connects to a server over stdio or streamable HTTP and keeps the
+// list of its tools up to date when the server reports that it changed:
+//
+//	client, err := mcp.ConnectStdio(ctx, exec.Command("my-mcp-server"))
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	agent := fantasy.NewAgent(model, fantasy.WithTools(client.Tools()...))
+//
+// Tools returns a snapshot of the tools, to pick up changes during a run
+// return it from a [fantasy.PrepareStepFunction].
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sync"
+
+	"charm.land/fantasy"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Transport is the transport used to connect to an MCP server.
+type Transport = mcpsdk.Transport
+
+// implementation identifies the client to MCP servers.
+var implementation = &mcpsdk.Implementation{Name: "fantasy", Version: "1.0.0"}
+
+type settings struct {
+	toolPrefix     string
+	onToolsChanged func([]fantasy.AgentTool)
+	onError        func(error)
+	httpClient     *http.Client
+}
+
+// Option configures the client.
+type Option = func(*settings)
+
+// WithToolPrefix prefixes the names of the tools, e.g. to avoid name clashes
+// between servers. The server is always called with the original name.
+func WithToolPrefix(prefix string) Option {
+	return func(s *settings) {
+		s.toolPrefix = prefix
+	}
+}
+
+// WithOnToolsChanged sets a callback that is called with the new tools after
+// the server reported that its tool list changed.
+func WithOnToolsChanged(fn func(tools []fantasy.AgentTool)) Option {
+	return func(s *settings) {
+		s.onToolsChanged = fn
+	}
+}
+
+// WithOnError sets a callback for errors that happen while refreshing the
+// tools in the background.
+func WithOnError(fn func(error)) Option {
+	return func(s *settings) {
+		s.onError = fn
+	}
+}
+
+// WithHTTPClient sets the HTTP client used by [ConnectHTTP].
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *settings) {
+		s.httpClient = client
+	}
+}
+
+// Client is a connection to an MCP server.
+type Client struct {
+	settings settings
+	session  *mcpsdk.ClientSession
+
+	// refreshMu serializes refreshes, mu guards tools.
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	tools     []fantasy.AgentTool
+}
+
+// ConnectStdio starts the command and connects to the MCP server over its
+// stdin and stdout.
+func ConnectStdio(ctx context.Context, cmd *exec.Cmd, opts ...Option) (*Client, error) {
+	return Connect(ctx, &mcpsdk.CommandTransport{Command: cmd}, opts...)
+}
+
+// ConnectHTTP connects to an MCP server over streamable HTTP.
+func ConnectHTTP(ctx context.Context, endpoint string, opts ...Option) (*Client, error) {
+	var s settings
+	for _, o := range opts {
+		o(&s)
+	}
+	return Connect(ctx, &mcpsdk.StreamableClientTransport{
+		Endpoint:   endpoint,
+		HTTPClient: s.httpClient,
+	}, opts...)
+}
+
+// Connect connects to an MCP server over the given transport and lists its
+// tools.
+func Connect(ctx context.Context, transport Transport, opts ...Option) (*Client, error) {
+	c := &Client{}
+	for _, o := range opts {
+		o(&c.settings)
+	}
+
+	client := mcpsdk.NewClient(implementation, &mcpsdk.ClientOptions{
+		ToolListChangedHandler: c.toolListChanged,
+	})
+	session, err := client.Connect(ctx, transport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mcp server: %w", err)
+	}
+	c.session = session
+
+	if err := c.Refresh(ctx); err != nil {
+		_ = session.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Tools returns the tools of the server.
+func (c *Client) Tools() []fantasy.AgentTool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tools
+}
+
+// Refresh lists the tools of the server again.
+func (c *Client) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	var tools []fantasy.AgentTool
+	for tool, err := range c.session.Tools(ctx, nil) {
+		if err != nil {
+			return fmt.Errorf("failed to list mcp tools: %w", err)
+		}
+		tools = append(tools, newTool(c.session, c.settings.toolPrefix, tool))
+	}
+
+	c.mu.Lock()
+	c.tools = tools
+	c.mu.Unlock()
+	return nil
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	return c.session.Close()
+}
+
+func (c *Client) toolListChanged(ctx context.Context, _ *mcpsdk.ToolListChangedRequest) {
+	// The notification is handled on the connection, so the tools are listed
+	// in the background to not block it.
+	go func() {
+		if err := c.Refresh(context.WithoutCancel(ctx)); err != nil {
+			if c.settings.onError != nil {
+				c.settings.onError(err)
+			}
+			return
+		}
+		if c.settings.onToolsChanged != nil {
+			c.settings.onToolsChanged(c.Tools())
+		}
+	}()
+}
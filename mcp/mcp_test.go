@@ -0,0 +1,228 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// serverEnv makes the test binary run the fixture server over stdio.
+const serverEnv = "FANTASY_MCP_TEST_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(serverEnv) == "1" {
+		if err := newTestServer().Run(context.Background(), &mcpsdk.StdioTransport{}); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+type echoInput struct {
+	Text  string `json:"text" jsonschema:"the text to echo"`
+	Times int    `json:"times,omitempty"`
+}
+
+type forecastOutput struct {
+	Summary     string  `json:"summary"`
+	Temperature float64 `json:"temperature"`
+}
+
+var pixel = []byte{0x89, 0x50, 0x4e, 0x47}
+
+func newTestServer() *mcpsdk.Server {
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "test", Version: "1.0.0"}, nil)
+	mcpsdk.AddTool(server, &mcpsdk.Tool{Name: "echo", Description: "Echo the text"},
+		func(ctx context.Context, req *mcpsdk.CallToolRequest, input echoInput) (*mcpsdk.CallToolResult, any, error) {
+			return &mcpsdk.CallToolResult{
+				Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: input.Text}},
+			}, nil, nil
+		},
+	)
+	mcpsdk.AddTool(server, &mcpsdk.Tool{Name: "screenshot", Description: "Take a screenshot"},
+		func(ctx context.Context, req *mcpsdk.CallToolRequest, input struct{}) (*mcpsdk.CallToolResult, any, error) {
+			return &mcpsdk.CallToolResult{
+				Content: []mcpsdk.Content{
+					&mcpsdk.TextContent{Text: "the screen"},
+					&mcpsdk.ImageContent{Data: pixel, MIMEType: "image/png"},
+				},
+			}, nil, nil
+		},
+	)
+	mcpsdk.AddTool(server, &mcpsdk.Tool{Name: "forecast", Description: "Get the forecast"},
+		func(ctx context.Context, req *mcpsdk.CallToolRequest, input struct{}) (*mcpsdk.CallToolResult, forecastOutput, error) {
+			return &mcpsdk.CallToolResult{
+				Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: "Sunny and warm"}},
+			}, forecastOutput{Summary: "Sunny", Temperature: 21.5}, nil
+		},
+	)
+	mcpsdk.AddTool(server, &mcpsdk.Tool{Name: "fail", Description: "Always fails"},
+		func(ctx context.Context, req *mcpsdk.CallToolRequest, input struct{}) (*mcpsdk.CallToolResult, any, error) {
+			return nil, nil, errors.New("disk full")
+		},
+	)
+	return server
+}
+
+// connectInMemory connects to the server in-process.
+func connectInMemory(t *testing.T, server *mcpsdk.Server, opts ...Option) *Client {
+	t.Helper()
+	serverTransport, clientTransport := mcpsdk.NewInMemoryTransports()
+	serverSession, err := server.Connect(t.Context(), serverTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = serverSession.Close() })
+
+	client, err := Connect(t.Context(), clientTransport, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func findTool(t *testing.T, tools []fantasy.AgentTool, name string) fantasy.AgentTool {
+	t.Helper()
+	for _, tool := range tools {
+		if tool.Info().Name == name {
+			return tool
+		}
+	}
+	require.Failf(t, "tool not found", "no tool named %s", name)
+	return nil
+}
+
+func TestTools(t *testing.T) {
+	t.Parallel()
+
+	client := connectInMemory(t, newTestServer(), WithToolPrefix("test_"))
+	tools := client.Tools()
+	require.Len(t, tools, 4)
+
+	info := findTool(t, tools, "test_echo").Info()
+	require.Equal(t, "Echo the text", info.Description)
+	require.Equal(t, []string{"text"}, info.Required)
+	require.Contains(t, info.Parameters, "text")
+	require.Contains(t, info.Parameters, "times")
+	text, ok := info.Parameters["text"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "string", text["type"])
+	require.Equal(t, "the text to echo", text["description"])
+	require.Nil(t, info.OutputSchema)
+
+	info = findTool(t, tools, "test_forecast").Info()
+	require.Equal(t, "object", info.OutputSchema["type"])
+	require.Contains(t, info.OutputSchema["properties"], "temperature")
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	client := connectInMemory(t, newTestServer())
+	tools := client.Tools()
+
+	t.Run("text", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := findTool(t, tools, "echo").Run(t.Context(), fantasy.ToolCall{ID: "1", Name: "echo", Input: `{"text":"hello"}`})
+		require.NoError(t, err)
+		require.Equal(t, fantasy.NewTextResponse("hello"), resp)
+	})
+
+	t.Run("image", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := findTool(t, tools, "screenshot").Run(t.Context(), fantasy.ToolCall{ID: "1", Name: "screenshot"})
+		require.NoError(t, err)
+		require.Equal(t, "image", resp.Type)
+		require.Equal(t, pixel, resp.Data)
+		require.Equal(t, "image/png", resp.MediaType)
+		require.Equal(t, "the screen", resp.Content)
+	})
+
+	t.Run("structured", func(t *testing.T) {
+		t.Parallel()
+
+		// The structured content, which matches the output schema, is sent
+		// instead of the text.
+		resp, err := findTool(t, tools, "forecast").Run(t.Context(), fantasy.ToolCall{ID: "1", Name: "forecast", Input: `{}`})
+		require.NoError(t, err)
+		require.False(t, resp.IsError)
+		require.JSONEq(t, `{"summary":"Sunny","temperature":21.5}`, resp.Content)
+	})
+
+	t.Run("tool error", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := findTool(t, tools, "fail").Run(t.Context(), fantasy.ToolCall{ID: "1", Name: "fail", Input: `{}`})
+		require.NoError(t, err)
+		require.True(t, resp.IsError)
+		require.Contains(t, resp.Content, "disk full")
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := findTool(t, tools, "echo").Run(t.Context(), fantasy.ToolCall{ID: "1", Name: "echo", Input: `{"text":1}`})
+		require.NoError(t, err)
+		require.True(t, resp.IsError)
+	})
+}
+
+func TestToolListChanged(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer()
+	changed := make(chan []fantasy.AgentTool, 1)
+	client := connectInMemory(t, server, WithOnToolsChanged(func(tools []fantasy.AgentTool) {
+		changed <- tools
+	}))
+	require.Len(t, client.Tools(), 4)
+
+	server.RemoveTools("fail")
+	select {
+	case tools := <-changed:
+		require.Len(t, tools, 3)
+		require.Equal(t, tools, client.Tools())
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools were not refreshed")
+	}
+}
+
+func TestConnectStdio(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), serverEnv+"=1")
+	client, err := ConnectStdio(t.Context(), cmd)
+	require.NoError(t, err)
+	defer client.Close() //nolint: errcheck
+
+	resp, err := findTool(t, client.Tools(), "echo").Run(t.Context(), fantasy.ToolCall{ID: "1", Name: "echo", Input: `{"text":"over stdio"}`})
+	require.NoError(t, err)
+	require.Equal(t, "over stdio", resp.Content)
+}
+
+func TestConnectHTTP(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer()
+	handler := mcpsdk.NewStreamableHTTPHandler(func(*http.Request) *mcpsdk.Server { return server }, nil)
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	client, err := ConnectHTTP(t.Context(), httpServer.URL, WithHTTPClient(httpServer.Client()))
+	require.NoError(t, err)
+	defer client.Close() //nolint: errcheck
+
+	resp, err := findTool(t, client.Tools(), "echo").Run(t.Context(), fantasy.ToolCall{ID: "1", Name: "echo", Input: `{"text":"over http"}`})
+	require.NoError(t, err)
+	require.Equal(t, "over http", resp.Content)
+}
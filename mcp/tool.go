@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// tool is an MCP server tool exposed as an agent tool.
+type tool struct {
+	session         *mcpsdk.ClientSession
+	name            string
+	info            fantasy.ToolInfo
+	providerOptions fantasy.ProviderOptions
+}
+
+var _ fantasy.AgentTool = (*tool)(nil)
+
+func newTool(session *mcpsdk.ClientSession, prefix string, t *mcpsdk.Tool) *tool {
+	parameters, required, defs := convertInputSchema(t.InputSchema)
+	return &tool{
+		session: session,
+		name:    t.Name,
+		info: fantasy.ToolInfo{
+			Name:         prefix + t.Name,
+			Description:  t.Description,
+			Parameters:   parameters,
+			Required:     required,
+			Defs:         defs,
+			OutputSchema: toSchemaMap(t.OutputSchema),
+		},
+	}
+}
+
+// convertInputSchema maps the object schema of a tool input onto its
+// properties, required properties and definitions.
+func convertInputSchema(inputSchema any) (map[string]any, []string, map[string]any) {
+	parameters := map[string]any{}
+	required := []string{}
+
+	schema := toSchemaMap(inputSchema)
+	if schema == nil {
+		return parameters, required, nil
+	}
+
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		parameters = properties
+	}
+	if names, ok := schema["required"].([]any); ok {
+		for _, name := range names {
+			if name, ok := name.(string); ok {
+				required = append(required, name)
+			}
+		}
+	}
+	defs, _ := schema["$defs"].(map[string]any)
+	return parameters, required, defs
+}
+
+// toSchemaMap returns a schema as a map, or nil if there is no schema.
+func toSchemaMap(s any) map[string]any {
+	switch s := s.(type) {
+	case map[string]any:
+		return s
+	case nil:
+		return nil
+	default:
+		// Schemas set by in-process servers can be typed values.
+		var schema map[string]any
+		data, err := json.Marshal(s)
+		if err != nil || json.Unmarshal(data, &schema) != nil {
+			return nil
+		}
+		return schema
+	}
+}
+
+func (t *tool) Info() fantasy.ToolInfo {
+	return t.info
+}
+
+func (t *tool) ProviderOptions() fantasy.ProviderOptions {
+	return t.providerOptions
+}
+
+func (t *tool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.providerOptions = opts
+}
+
+// Run calls the tool on the server. Errors reported by the server are
+// returned as error responses, so the model can see them.
+func (t *tool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	input := params.Input
+	if strings.TrimSpace(input) == "" {
+		input = "{}"
+	}
+	if !json.Valid([]byte(input)) {
+		return fantasy.NewTextErrorResponse("invalid parameters: " + input), nil
+	}
+
+	result, err := t.session.CallTool(ctx, &mcpsdk.CallToolParams{
+		Name:      t.name,
+		Arguments: json.RawMessage(input),
+	})
+	var wireErr *jsonrpc.Error
+	if errors.As(err, &wireErr) {
+		return fantasy.NewTextErrorResponse(wireErr.Message), nil
+	}
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to call mcp tool %s: %w", t.name, err)
+	}
+	return convertResult(result, t.info.OutputSchema != nil), nil
+}
+
+// convertResult maps the content of a tool result onto a tool response. Text
+// content is joined, only the first image or audio content is kept. The
+// structured content replaces the text of tools with an output schema, since
+// the agent validates the text against that schema.
+func convertResult(result *mcpsdk.CallToolResult, structured bool) fantasy.ToolResponse {
+	var texts []string
+	var response fantasy.ToolResponse
+	for _, content := range result.Content {
+		switch content := content.(type) {
+		case *mcpsdk.TextContent:
+			texts = append(texts, content.Text)
+		case *mcpsdk.ImageContent:
+			if response.Data == nil {
+				response = fantasy.NewImageResponse(content.Data, content.MIMEType)
+			}
+		case *mcpsdk.AudioContent:
+			if response.Data == nil {
+				response = fantasy.NewMediaResponse(content.Data, content.MIMEType)
+			}
+		case *mcpsdk.EmbeddedResource:
+			if content.Resource != nil && content.Resource.Text != "" {
+				texts = append(texts, content.Resource.Text)
+			}
+		case *mcpsdk.ResourceLink:
+			texts = append(texts, fmt.Sprintf("[resource %s]", content.URI))
+		}
+	}
+	if result.StructuredContent != nil && (structured || len(texts) == 0) {
+		if data, err := json.Marshal(result.StructuredContent); err == nil {
+			texts = []string{string(data)}
+		}
+	}
+
+	text := strings.Join(texts, "\n")
+	if result.IsError {
+		return fantasy.NewTextErrorResponse(text)
+	}
+	if response.Data == nil {
+		return fantasy.NewTextResponse(text)
+	}
+	response.Content = text
+	return response
+}
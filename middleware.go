@@ -0,0 +1,185 @@
+package fantasy
+
+import (
+	"context"
+	"maps"
+)
+
+// CallType identifies the kind of language model call passing through a
+// middleware.
+type CallType string
+
+const (
+	// CallTypeGenerate is a Generate call.
+	CallTypeGenerate CallType = "generate"
+	// CallTypeStream is a Stream call.
+	CallTypeStream CallType = "stream"
+	// CallTypeGenerateObject is a GenerateObject call.
+	CallTypeGenerateObject CallType = "generate-object"
+	// CallTypeStreamObject is a StreamObject call.
+	CallTypeStreamObject CallType = "stream-object"
+)
+
+// GenerateFunc generates a response for a call.
+type GenerateFunc func(ctx context.Context, call Call) (*Response, error)
+
+// StreamFunc streams a response for a call.
+type StreamFunc func(ctx context.Context, call Call) (StreamResponse, error)
+
+// GenerateObjectFunc generates an object for a call.
+type GenerateObjectFunc func(ctx context.Context, call ObjectCall) (*ObjectResponse, error)
+
+// StreamObjectFunc streams an object for a call.
+type StreamObjectFunc func(ctx context.Context, call ObjectCall) (ObjectStreamResponse, error)
+
+// LanguageModelMiddleware hooks into the calls made to a language model.
+// All hooks are optional.
+type LanguageModelMiddleware struct {
+	// TransformCall is called before every call reaches the model and can
+	// rewrite it, e.g. to redact the prompt or add default provider options.
+	// Object calls go through it as well, only the prompt, the settings and
+	// the provider options of the returned call are used for them.
+	TransformCall func(ctx context.Context, callType CallType, call Call) (Call, error)
+
+	// WrapGenerate wraps Generate calls, next calls the wrapped model.
+	WrapGenerate func(ctx context.Context, call Call, next GenerateFunc) (*Response, error)
+	// WrapStream wraps Stream calls, next calls the wrapped model. The
+	// returned stream can be used to observe or rewrite the stream parts.
+	WrapStream func(ctx context.Context, call Call, next StreamFunc) (StreamResponse, error)
+
+	// WrapGenerateObject wraps GenerateObject calls, next calls the wrapped model.
+	WrapGenerateObject func(ctx context.Context, call ObjectCall, next GenerateObjectFunc) (*ObjectResponse, error)
+	// WrapStreamObject wraps StreamObject calls, next calls the wrapped model.
+	WrapStreamObject func(ctx context.Context, call ObjectCall, next StreamObjectFunc) (ObjectStreamResponse, error)
+}
+
+// WrapLanguageModel wraps a language model with the given middlewares.
+//
+// The first middleware is the outermost one: it sees the call first and the
+// response last. The wrapped model reports the provider and model of the
+// underlying model.
+func WrapLanguageModel(model LanguageModel, middlewares ...LanguageModelMiddleware) LanguageModel {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		model = &wrappedLanguageModel{
+			model:      model,
+			middleware: middlewares[i],
+		}
+	}
+	return model
+}
+
+type wrappedLanguageModel struct {
+	model      LanguageModel
+	middleware LanguageModelMiddleware
+}
+
+// Generate implements LanguageModel.
+func (w *wrappedLanguageModel) Generate(ctx context.Context, call Call) (*Response, error) {
+	call, err := w.transformCall(ctx, CallTypeGenerate, call)
+	if err != nil {
+		return nil, err
+	}
+	if w.middleware.WrapGenerate == nil {
+		return w.model.Generate(ctx, call)
+	}
+	return w.middleware.WrapGenerate(ctx, call, w.model.Generate)
+}
+
+// Stream implements LanguageModel.
+func (w *wrappedLanguageModel) Stream(ctx context.Context, call Call) (StreamResponse, error) {
+	call, err := w.transformCall(ctx, CallTypeStream, call)
+	if err != nil {
+		return nil, err
+	}
+	if w.middleware.WrapStream == nil {
+		return w.model.Stream(ctx, call)
+	}
+	return w.middleware.WrapStream(ctx, call, w.model.Stream)
+}
+
+// GenerateObject implements LanguageModel.
+func (w *wrappedLanguageModel) GenerateObject(ctx context.Context, call ObjectCall) (*ObjectResponse, error) {
+	call, err := w.transformObjectCall(ctx, CallTypeGenerateObject, call)
+	if err != nil {
+		return nil, err
+	}
+	if w.middleware.WrapGenerateObject == nil {
+		return w.model.GenerateObject(ctx, call)
+	}
+	return w.middleware.WrapGenerateObject(ctx, call, w.model.GenerateObject)
+}
+
+// StreamObject implements LanguageModel.
+func (w *wrappedLanguageModel) StreamObject(ctx context.Context, call ObjectCall) (ObjectStreamResponse, error) {
+	call, err := w.transformObjectCall(ctx, CallTypeStreamObject, call)
+	if err != nil {
+		return nil, err
+	}
+	if w.middleware.WrapStreamObject == nil {
+		return w.model.StreamObject(ctx, call)
+	}
+	return w.middleware.WrapStreamObject(ctx, call, w.model.StreamObject)
+}
+
+// Provider implements LanguageModel.
+func (w *wrappedLanguageModel) Provider() string {
+	return w.model.Provider()
+}
+
+// Model implements LanguageModel.
+func (w *wrappedLanguageModel) Model() string {
+	return w.model.Model()
+}
+
+func (w *wrappedLanguageModel) transformCall(ctx context.Context, callType CallType, call Call) (Call, error) {
+	if w.middleware.TransformCall == nil {
+		return call, nil
+	}
+	return w.middleware.TransformCall(ctx, callType, call)
+}
+
+func (w *wrappedLanguageModel) transformObjectCall(ctx context.Context, callType CallType, call ObjectCall) (ObjectCall, error) {
+	if w.middleware.TransformCall == nil {
+		return call, nil
+	}
+	transformed, err := w.middleware.TransformCall(ctx, callType, Call{
+		Prompt:           call.Prompt,
+		MaxOutputTokens:  call.MaxOutputTokens,
+		Temperature:      call.Temperature,
+		TopP:             call.TopP,
+		TopK:             call.TopK,
+		PresencePenalty:  call.PresencePenalty,
+		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
+		ProviderOptions:  call.ProviderOptions,
+	})
+	if err != nil {
+		return call, err
+	}
+	call.Prompt = transformed.Prompt
+	call.MaxOutputTokens = transformed.MaxOutputTokens
+	call.Temperature = transformed.Temperature
+	call.TopP = transformed.TopP
+	call.TopK = transformed.TopK
+	call.PresencePenalty = transformed.PresencePenalty
+	call.FrequencyPenalty = transformed.FrequencyPenalty
+	call.StopSequences = transformed.StopSequences
+	call.Seed = transformed.Seed
+	call.ProviderOptions = transformed.ProviderOptions
+	return call, nil
+}
+
+// DefaultProviderOptions returns a middleware that adds the given provider
+// options to every call that does not set options for the same provider.
+func DefaultProviderOptions(options ProviderOptions) LanguageModelMiddleware {
+	return LanguageModelMiddleware{
+		TransformCall: func(_ context.Context, _ CallType, call Call) (Call, error) {
+			merged := make(ProviderOptions, len(options)+len(call.ProviderOptions))
+			maps.Copy(merged, options)
+			maps.Copy(merged, call.ProviderOptions)
+			call.ProviderOptions = merged
+			return call, nil
+		},
+	}
+}
@@ -0,0 +1,245 @@
+package fantasy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapLanguageModel_Order(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	named := func(name string) LanguageModelMiddleware {
+		return LanguageModelMiddleware{
+			TransformCall: func(ctx context.Context, callType CallType, call Call) (Call, error) {
+				require.Equal(t, CallTypeGenerate, callType)
+				order = append(order, "transform "+name)
+				return call, nil
+			},
+			WrapGenerate: func(ctx context.Context, call Call, next GenerateFunc) (*Response, error) {
+				order = append(order, "before "+name)
+				resp, err := next(ctx, call)
+				order = append(order, "after "+name)
+				return resp, err
+			},
+		}
+	}
+
+	model := &mockLanguageModel{
+		generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+			order = append(order, "model")
+			return &Response{Content: []Content{TextContent{Text: "ok"}}}, nil
+		},
+	}
+
+	wrapped := WrapLanguageModel(model, named("a"), named("b"))
+	resp, err := wrapped.Generate(t.Context(), Call{})
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp.Content.Text())
+	require.Equal(t, []string{
+		"transform a",
+		"before a",
+		"transform b",
+		"before b",
+		"model",
+		"after b",
+		"after a",
+	}, order)
+}
+
+func TestWrapLanguageModel_TransformCall(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rewrites the call", func(t *testing.T) {
+		t.Parallel()
+
+		var received Call
+		model := &mockLanguageModel{
+			generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+				received = call
+				return &Response{}, nil
+			},
+		}
+		wrapped := WrapLanguageModel(model, LanguageModelMiddleware{
+			TransformCall: func(ctx context.Context, callType CallType, call Call) (Call, error) {
+				call.Temperature = Opt(0.5)
+				call.Prompt = append(Prompt{NewSystemMessage("be brief")}, call.Prompt...)
+				return call, nil
+			},
+		})
+
+		_, err := wrapped.Generate(t.Context(), Call{Prompt: Prompt{NewUserMessage("hi")}})
+		require.NoError(t, err)
+		require.Len(t, received.Prompt, 2)
+		require.Equal(t, MessageRoleSystem, received.Prompt[0].Role)
+		require.NotNil(t, received.Temperature)
+		require.Equal(t, 0.5, *received.Temperature)
+	})
+
+	t.Run("error stops the call", func(t *testing.T) {
+		t.Parallel()
+
+		called := false
+		model := &mockLanguageModel{
+			generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+				called = true
+				return &Response{}, nil
+			},
+		}
+		wrapped := WrapLanguageModel(model, LanguageModelMiddleware{
+			TransformCall: func(ctx context.Context, callType CallType, call Call) (Call, error) {
+				return call, errors.New("rejected")
+			},
+		})
+
+		_, err := wrapped.Generate(t.Context(), Call{})
+		require.EqualError(t, err, "rejected")
+		require.False(t, called)
+	})
+}
+
+func TestWrapLanguageModel_Stream(t *testing.T) {
+	t.Parallel()
+
+	model := &mockLanguageModel{
+		streamFunc: func(ctx context.Context, call Call) (StreamResponse, error) {
+			return func(yield func(StreamPart) bool) {
+				for _, delta := range []string{"hello", " world"} {
+					if !yield(StreamPart{Type: StreamPartTypeTextDelta, ID: "0", Delta: delta}) {
+						return
+					}
+				}
+				yield(StreamPart{Type: StreamPartTypeFinish, FinishReason: FinishReasonStop})
+			}, nil
+		},
+	}
+
+	var callType CallType
+	var seen []StreamPartType
+	wrapped := WrapLanguageModel(model, LanguageModelMiddleware{
+		TransformCall: func(ctx context.Context, ct CallType, call Call) (Call, error) {
+			callType = ct
+			return call, nil
+		},
+		WrapStream: func(ctx context.Context, call Call, next StreamFunc) (StreamResponse, error) {
+			stream, err := next(ctx, call)
+			if err != nil {
+				return nil, err
+			}
+			return func(yield func(StreamPart) bool) {
+				for part := range stream {
+					seen = append(seen, part.Type)
+					if part.Type == StreamPartTypeTextDelta {
+						part.Delta = "[" + part.Delta + "]"
+					}
+					if !yield(part) {
+						return
+					}
+				}
+			}, nil
+		},
+	})
+
+	stream, err := wrapped.Stream(t.Context(), Call{})
+	require.NoError(t, err)
+
+	var text string
+	for part := range stream {
+		if part.Type == StreamPartTypeTextDelta {
+			text += part.Delta
+		}
+	}
+	require.Equal(t, CallTypeStream, callType)
+	require.Equal(t, "[hello][ world]", text)
+	require.Equal(t, []StreamPartType{
+		StreamPartTypeTextDelta,
+		StreamPartTypeTextDelta,
+		StreamPartTypeFinish,
+	}, seen)
+}
+
+func TestWrapLanguageModel_GenerateObject(t *testing.T) {
+	t.Parallel()
+
+	var received ObjectCall
+	model := &mockLanguageModel{
+		generateObjectFunc: func(ctx context.Context, call ObjectCall) (*ObjectResponse, error) {
+			received = call
+			return &ObjectResponse{Object: map[string]any{"ok": true}}, nil
+		},
+	}
+
+	var callType CallType
+	wrapped := WrapLanguageModel(model, LanguageModelMiddleware{
+		TransformCall: func(ctx context.Context, ct CallType, call Call) (Call, error) {
+			callType = ct
+			call.MaxOutputTokens = Opt(int64(100))
+			return call, nil
+		},
+		WrapGenerateObject: func(ctx context.Context, call ObjectCall, next GenerateObjectFunc) (*ObjectResponse, error) {
+			resp, err := next(ctx, call)
+			if err != nil {
+				return nil, err
+			}
+			resp.RawText = "wrapped"
+			return resp, nil
+		},
+	})
+
+	resp, err := wrapped.GenerateObject(t.Context(), ObjectCall{
+		Prompt:            Prompt{NewUserMessage("hi")},
+		SchemaName:        "answer",
+		SchemaDescription: "the answer",
+	})
+	require.NoError(t, err)
+	require.Equal(t, CallTypeGenerateObject, callType)
+	require.Equal(t, "wrapped", resp.RawText)
+	require.Equal(t, "answer", received.SchemaName)
+	require.Equal(t, "the answer", received.SchemaDescription)
+	require.Len(t, received.Prompt, 1)
+	require.NotNil(t, received.MaxOutputTokens)
+	require.Equal(t, int64(100), *received.MaxOutputTokens)
+}
+
+func TestWrapLanguageModel_ProviderAndModel(t *testing.T) {
+	t.Parallel()
+
+	wrapped := WrapLanguageModel(&mockLanguageModel{}, LanguageModelMiddleware{}, LanguageModelMiddleware{})
+	require.Equal(t, "mock-provider", wrapped.Provider())
+	require.Equal(t, "mock-model", wrapped.Model())
+}
+
+func TestDefaultProviderOptions(t *testing.T) {
+	t.Parallel()
+
+	var received Call
+	model := &mockLanguageModel{
+		generateFunc: func(ctx context.Context, call Call) (*Response, error) {
+			received = call
+			return &Response{}, nil
+		},
+	}
+
+	t.Run("merges the options of the call", func(t *testing.T) {
+		wrapped := WrapLanguageModel(model, DefaultProviderOptions(ProviderOptions{
+			"a": &mockProviderData{Key: "default"},
+			"b": &mockProviderData{Key: "default"},
+		}))
+		_, err := wrapped.Generate(t.Context(), Call{ProviderOptions: ProviderOptions{"b": &mockProviderData{Key: "call"}}})
+		require.NoError(t, err)
+		require.Equal(t, ProviderOptions{
+			"a": &mockProviderData{Key: "default"},
+			"b": &mockProviderData{Key: "call"},
+		}, received.ProviderOptions)
+	})
+
+	t.Run("accepts no options", func(t *testing.T) {
+		wrapped := WrapLanguageModel(model, DefaultProviderOptions(nil))
+		_, err := wrapped.Generate(t.Context(), Call{ProviderOptions: ProviderOptions{"b": &mockProviderData{Key: "call"}}})
+		require.NoError(t, err)
+		require.Equal(t, ProviderOptions{"b": &mockProviderData{Key: "call"}}, received.ProviderOptions)
+	})
+}
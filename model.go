@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"iter"
+	"time"
 )
 
 // Usage represents token usage statistics for a model call.
@@ -14,6 +15,9 @@ type Usage struct {
 	ReasoningTokens     int64 `json:"reasoning_tokens"`
 	CacheCreationTokens int64 `json:"cache_creation_tokens"`
 	CacheReadTokens     int64 `json:"cache_read_tokens"`
+	// Cost is the cost of the tokens, it is set for models with a known
+	// price by the built-in providers and the agent, see [ComputeCost].
+	Cost Cost `json:"cost,omitzero"`
 }
 
 func (u Usage) String() string {
@@ -170,10 +174,13 @@ type StreamPart struct {
 	ToolCallInput    string         `json:"tool_call_input"`
 	Delta            string         `json:"delta"`
 	ProviderExecuted bool           `json:"provider_executed"`
-	Usage            Usage          `json:"usage"`
-	FinishReason     FinishReason   `json:"finish_reason"`
-	Error            error          `json:"error"`
-	Warnings         []CallWarning  `json:"warnings"`
+	// ToolResult holds the output of provider-executed tools for tool result
+	// stream parts.
+	ToolResult   ToolResultOutputContent `json:"tool_result"`
+	Usage        Usage                   `json:"usage"`
+	FinishReason FinishReason            `json:"finish_reason"`
+	Error        error                   `json:"error"`
+	Warnings     []CallWarning           `json:"warnings"`
 
 	// Source-related fields
 	SourceType SourceType `json:"source_type"`
@@ -181,6 +188,12 @@ type StreamPart struct {
 	Title      string     `json:"title"`
 
 	ProviderMetadata ProviderMetadata `json:"provider_metadata"`
+
+	// AgentPath identifies the sub-agent that emitted the part when it is
+	// forwarded by a tool created with [NewSubAgentTool]: it holds the IDs of
+	// the tool calls that run the sub-agent, outermost first. It is empty for
+	// the parts of the model of the agent itself.
+	AgentPath []string `json:"agent_path,omitempty"`
 }
 
 // StreamResponse represents a streaming response sequence.
@@ -212,6 +225,8 @@ type Call struct {
 	TopK             *int64      `json:"top_k"`
 	PresencePenalty  *float64    `json:"presence_penalty"`
 	FrequencyPenalty *float64    `json:"frequency_penalty"`
+	StopSequences    []string    `json:"stop_sequences"`
+	Seed             *int64      `json:"seed"`
 	Tools            []Tool      `json:"tools"`
 	ToolChoice       *ToolChoice `json:"tool_choice"`
 
@@ -253,3 +268,198 @@ type LanguageModel interface {
 	Provider() string
 	Model() string
 }
+
+// Embedding is a vector representation of a single value.
+type Embedding []float64
+
+// EmbedCall represents a request to embed a single value.
+type EmbedCall struct {
+	Value string `json:"value"`
+	// Dimensions requests a specific output dimensionality from models that
+	// support shortening their embeddings.
+	Dimensions      *int64          `json:"dimensions"`
+	ProviderOptions ProviderOptions `json:"provider_options"`
+}
+
+// EmbedResponse represents the response of embedding a single value.
+type EmbedResponse struct {
+	Embedding        Embedding        `json:"embedding"`
+	Usage            Usage            `json:"usage"`
+	Warnings         []CallWarning    `json:"warnings"`
+	ProviderMetadata ProviderMetadata `json:"provider_metadata"`
+}
+
+// EmbedManyCall represents a request to embed multiple values.
+type EmbedManyCall struct {
+	Values []string `json:"values"`
+	// Dimensions requests a specific output dimensionality from models that
+	// support shortening their embeddings.
+	Dimensions      *int64          `json:"dimensions"`
+	ProviderOptions ProviderOptions `json:"provider_options"`
+}
+
+// EmbedManyResponse represents the response of embedding multiple values.
+// Embeddings are returned in the same order as the values of the call.
+type EmbedManyResponse struct {
+	Embeddings       []Embedding      `json:"embeddings"`
+	Usage            Usage            `json:"usage"`
+	Warnings         []CallWarning    `json:"warnings"`
+	ProviderMetadata ProviderMetadata `json:"provider_metadata"`
+}
+
+// EmbeddingModel represents a model that turns values into embeddings.
+type EmbeddingModel interface {
+	Embed(context.Context, EmbedCall) (*EmbedResponse, error)
+	// EmbedMany embeds all values, transparently splitting them into as
+	// many requests as MaxEmbeddingsPerCall requires.
+	EmbedMany(context.Context, EmbedManyCall) (*EmbedManyResponse, error)
+
+	// Dimensions returns the default dimensionality of the embeddings produced
+	// by the model, or 0 if it is not known.
+	Dimensions() int64
+	// MaxEmbeddingsPerCall returns the maximum number of values the provider
+	// accepts in a single request, or 0 if there is no limit.
+	MaxEmbeddingsPerCall() int
+
+	Provider() string
+	Model() string
+}
+
+// ImageCall represents a request to generate images, or to edit images when
+// input images are given.
+type ImageCall struct {
+	Prompt string `json:"prompt"`
+	// N is the number of images to generate, one if not set.
+	N int `json:"n"`
+	// Size is the size of the images as "{width}x{height}", e.g. "1024x1024".
+	Size string `json:"size"`
+	// AspectRatio is the aspect ratio of the images as "{width}:{height}",
+	// e.g. "16:9", for models that take it instead of a size.
+	AspectRatio string `json:"aspect_ratio"`
+	Seed        *int64 `json:"seed"`
+	// Images are the input images to edit.
+	Images []FilePart `json:"images"`
+	// Mask is an image whose fully transparent areas mark where the first
+	// input image should be edited.
+	Mask            *FilePart       `json:"mask"`
+	ProviderOptions ProviderOptions `json:"provider_options"`
+}
+
+// ImageResponse represents the response of an image generation.
+type ImageResponse struct {
+	Images           []FileContent    `json:"images"`
+	Usage            Usage            `json:"usage"`
+	Warnings         []CallWarning    `json:"warnings"`
+	ProviderMetadata ProviderMetadata `json:"provider_metadata"`
+}
+
+// ImageModel represents a model that generates and edits images.
+type ImageModel interface {
+	GenerateImage(context.Context, ImageCall) (*ImageResponse, error)
+
+	Provider() string
+	Model() string
+}
+
+// TranscriptionCall represents a request to transcribe audio into text.
+type TranscriptionCall struct {
+	Audio FilePart `json:"audio"`
+	// Language is the ISO-639-1 language of the audio, e.g. "en". It is
+	// detected when not set.
+	Language string `json:"language"`
+	// Prompt guides the style or the vocabulary of the transcription.
+	Prompt          string          `json:"prompt"`
+	Temperature     *float64        `json:"temperature"`
+	ProviderOptions ProviderOptions `json:"provider_options"`
+}
+
+// TranscriptionSegment is a part of a transcription with its position in the
+// audio.
+type TranscriptionSegment struct {
+	Text  string        `json:"text"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// TranscriptionResponse represents the response of a transcription.
+type TranscriptionResponse struct {
+	Text string `json:"text"`
+	// Segments are only returned by models that support timestamps.
+	Segments []TranscriptionSegment `json:"segments"`
+	// Language is the detected language of the audio, if known.
+	Language string `json:"language"`
+	// Duration is the duration of the audio, if known.
+	Duration         time.Duration    `json:"duration"`
+	Usage            Usage            `json:"usage"`
+	Warnings         []CallWarning    `json:"warnings"`
+	ProviderMetadata ProviderMetadata `json:"provider_metadata"`
+}
+
+// TranscriptionModel represents a model that turns speech into text.
+type TranscriptionModel interface {
+	Transcribe(context.Context, TranscriptionCall) (*TranscriptionResponse, error)
+
+	Provider() string
+	Model() string
+}
+
+// SpeechCall represents a request to synthesize speech from text.
+type SpeechCall struct {
+	Text  string `json:"text"`
+	Voice string `json:"voice"`
+	// Instructions control the tone, accent or style of the speech.
+	Instructions string `json:"instructions"`
+	// Speed is the speed of the speech, 1 is the normal speed.
+	Speed *float64 `json:"speed"`
+	// OutputFormat is the format of the audio, e.g. "mp3" or "wav". The
+	// provider's default format is used when not set.
+	OutputFormat    string          `json:"output_format"`
+	ProviderOptions ProviderOptions `json:"provider_options"`
+}
+
+// SpeechResponse represents the response of a speech synthesis.
+type SpeechResponse struct {
+	Audio            FileContent      `json:"audio"`
+	Usage            Usage            `json:"usage"`
+	Warnings         []CallWarning    `json:"warnings"`
+	ProviderMetadata ProviderMetadata `json:"provider_metadata"`
+}
+
+// SpeechStreamPartType represents the type of a speech stream part.
+type SpeechStreamPartType string
+
+const (
+	// SpeechStreamPartTypeWarnings represents warnings speech stream part type.
+	SpeechStreamPartTypeWarnings SpeechStreamPartType = "warnings"
+	// SpeechStreamPartTypeAudioDelta represents audio delta speech stream part type.
+	SpeechStreamPartTypeAudioDelta SpeechStreamPartType = "audio_delta"
+	// SpeechStreamPartTypeFinish represents finish speech stream part type.
+	SpeechStreamPartTypeFinish SpeechStreamPartType = "finish"
+	// SpeechStreamPartTypeError represents error speech stream part type.
+	SpeechStreamPartTypeError SpeechStreamPartType = "error"
+)
+
+// SpeechStreamPart represents a part of a streaming speech response. The
+// audio deltas are consecutive chunks of a single audio file.
+type SpeechStreamPart struct {
+	Type      SpeechStreamPartType `json:"type"`
+	MediaType string               `json:"media_type"`
+	Data      []byte               `json:"data"`
+	Usage     Usage                `json:"usage"`
+	Warnings  []CallWarning        `json:"warnings"`
+	Error     error                `json:"error"`
+
+	ProviderMetadata ProviderMetadata `json:"provider_metadata"`
+}
+
+// SpeechStreamResponse represents a streaming speech response sequence.
+type SpeechStreamResponse = iter.Seq[SpeechStreamPart]
+
+// SpeechModel represents a model that turns text into speech.
+type SpeechModel interface {
+	GenerateSpeech(context.Context, SpeechCall) (*SpeechResponse, error)
+	StreamSpeech(context.Context, SpeechCall) (SpeechStreamResponse, error)
+
+	Provider() string
+	Model() string
+}
@@ -15,6 +15,8 @@ func (c *Call) UnmarshalJSON(data []byte) error {
 		TopK             *int64                     `json:"top_k"`
 		PresencePenalty  *float64                   `json:"presence_penalty"`
 		FrequencyPenalty *float64                   `json:"frequency_penalty"`
+		StopSequences    []string                   `json:"stop_sequences"`
+		Seed             *int64                     `json:"seed"`
 		Tools            []json.RawMessage          `json:"tools"`
 		ToolChoice       *ToolChoice                `json:"tool_choice"`
 		ProviderOptions  map[string]json.RawMessage `json:"provider_options"`
@@ -31,6 +33,8 @@ func (c *Call) UnmarshalJSON(data []byte) error {
 	c.TopK = aux.TopK
 	c.PresencePenalty = aux.PresencePenalty
 	c.FrequencyPenalty = aux.FrequencyPenalty
+	c.StopSequences = aux.StopSequences
+	c.Seed = aux.Seed
 	c.ToolChoice = aux.ToolChoice
 
 	// Unmarshal Tools slice
@@ -129,6 +133,7 @@ func (s *StreamPart) UnmarshalJSON(data []byte) error {
 	aux := struct {
 		*alias
 		Error            string                     `json:"error"`
+		ToolResult       json.RawMessage            `json:"tool_result"`
 		ProviderMetadata map[string]json.RawMessage `json:"provider_metadata"`
 	}{
 		alias: (*alias)(s),
@@ -138,6 +143,15 @@ func (s *StreamPart) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// Unmarshal the tool result output
+	if len(aux.ToolResult) > 0 && string(aux.ToolResult) != "null" {
+		result, err := UnmarshalToolResultOutputContent(aux.ToolResult)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal tool result: %w", err)
+		}
+		s.ToolResult = result
+	}
+
 	// Unmarshal error string back to error type
 	if aux.Error != "" {
 		s.Error = fmt.Errorf("%s", aux.Error)
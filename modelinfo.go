@@ -0,0 +1,332 @@
+package fantasy
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ModelInfo describes the capabilities and limits of a model.
+type ModelInfo struct {
+	// ContextWindow is the maximum number of input and output tokens.
+	ContextWindow int64 `json:"context_window,omitempty"`
+	// MaxOutputTokens is the maximum number of output tokens per call.
+	MaxOutputTokens int64 `json:"max_output_tokens,omitempty"`
+
+	Tools  bool `json:"tools,omitempty"`
+	Vision bool `json:"vision,omitempty"`
+	PDF    bool `json:"pdf,omitempty"`
+	// Reasoning is set for models that reason before answering. The OpenAI
+	// provider removes the sampling settings these models reject.
+	Reasoning bool `json:"reasoning,omitempty"`
+	// JSONSchema is set for models that constrain their output to a JSON
+	// schema natively, see [ModelInfo.ResolveObjectMode].
+	JSONSchema bool `json:"json_schema,omitempty"`
+	// WebSearch is set for models that search the web on every call, such as
+	// the OpenAI search preview models.
+	WebSearch bool `json:"web_search,omitempty"`
+
+	// MaxTemperature is the highest temperature the model accepts, higher
+	// temperatures are lowered to it. Zero means no limit.
+	MaxTemperature float64 `json:"max_temperature,omitempty"`
+	// DefaultTemperature is the temperature used when the call does not set
+	// one.
+	DefaultTemperature *float64 `json:"default_temperature,omitempty"`
+
+	// ServiceTiers are the processing tiers the model supports besides the
+	// default one, e.g. "flex" and "priority" for OpenAI.
+	ServiceTiers []string `json:"service_tiers,omitempty"`
+	// SystemMessageMode is how system messages are sent to the model: as
+	// "system" messages (the default), as "developer" messages, or "remove"d.
+	SystemMessageMode string `json:"system_message_mode,omitempty"`
+}
+
+// SupportsServiceTier reports whether the model supports the service tier.
+func (info ModelInfo) SupportsServiceTier(tier string) bool {
+	return slices.Contains(info.ServiceTiers, tier)
+}
+
+// ResolveObjectMode resolves [ObjectModeAuto] to the best mode the model
+// supports: native JSON schema, then tool calling, then text. Other modes are
+// returned unchanged.
+func (info ModelInfo) ResolveObjectMode(mode ObjectMode) ObjectMode {
+	if mode != ObjectModeAuto {
+		return mode
+	}
+	switch {
+	case info.JSONSchema:
+		return ObjectModeJSON
+	case info.Tools:
+		return ObjectModeTool
+	default:
+		return ObjectModeText
+	}
+}
+
+// ContextBudget returns the number of input tokens that leaves room for the
+// maximum output of the model, it is zero when the context window is unknown.
+func (info ModelInfo) ContextBudget() int64 {
+	if info.ContextWindow == 0 {
+		return 0
+	}
+	return max(info.ContextWindow-info.MaxOutputTokens, 0)
+}
+
+var (
+	modelInfosMu sync.RWMutex
+	modelInfos   = map[string]map[string]ModelInfo{}
+)
+
+// RegisterModelInfo registers the capabilities of a model, replacing the
+// built-in entry if there is one. The model is matched like in
+// [RegisterPrice].
+func RegisterModelInfo(provider, model string, info ModelInfo) {
+	modelInfosMu.Lock()
+	defer modelInfosMu.Unlock()
+	if modelInfos[provider] == nil {
+		modelInfos[provider] = map[string]ModelInfo{}
+	}
+	modelInfos[provider][model] = info
+}
+
+// LookupModelInfo returns the capabilities of a model of a provider.
+func LookupModelInfo(provider, model string) (ModelInfo, bool) {
+	modelInfosMu.RLock()
+	defer modelInfosMu.RUnlock()
+	return lookupModel(modelInfos[provider], model)
+}
+
+// ModelInfoOf returns the capabilities of a language model. Models of
+// aggregators such as OpenRouter, whose IDs are prefixed with the vendor,
+// e.g. "anthropic/claude-sonnet-4", are also looked up in the catalog of the
+// vendor.
+func ModelInfoOf(model LanguageModel) (ModelInfo, bool) {
+	if info, ok := LookupModelInfo(model.Provider(), model.Model()); ok {
+		return info, true
+	}
+	if vendor, modelID, ok := strings.Cut(model.Model(), "/"); ok {
+		return LookupModelInfo(vendor, modelID)
+	}
+	return ModelInfo{}, false
+}
+
+// lookupModel returns the entry of a model matched exactly, or the entry of
+// the longest model ID that is a prefix of it followed by a separator, e.g.
+// "gpt-4o" matches "gpt-4o-2024-08-06" and "claude-sonnet-4" matches
+// "claude-sonnet-4@20250514".
+func lookupModel[T any](models map[string]T, model string) (T, bool) {
+	if entry, ok := models[model]; ok {
+		return entry, true
+	}
+
+	var match string
+	for name := range maps.Keys(models) {
+		if len(name) <= len(match) || !strings.HasPrefix(model, name) {
+			continue
+		}
+		if strings.ContainsRune("-@:", rune(model[len(name)])) {
+			match = name
+		}
+	}
+	if match == "" {
+		var zero T
+		return zero, false
+	}
+	return models[match], true
+}
+
+// ApplyModelInfo adapts a call to the capabilities of a model and returns a
+// warning for each setting or content it changes. Function tools are removed
+// for models without tool support, images and PDFs for models that can not
+// read them, and the maximum output tokens and temperature are lowered to the
+// limits of the model. Providers call it before building the request.
+func ApplyModelInfo(info ModelInfo, call Call) (Call, []CallWarning) {
+	var warnings []CallWarning
+
+	if !info.Tools && len(call.Tools) > 0 {
+		tools := make([]Tool, 0, len(call.Tools))
+		for _, tool := range call.Tools {
+			if tool.GetType() != ToolTypeFunction {
+				tools = append(tools, tool)
+				continue
+			}
+			warnings = append(warnings, CallWarning{
+				Type:    CallWarningTypeUnsupportedTool,
+				Tool:    tool,
+				Message: "the model does not support tools",
+			})
+		}
+		call.Tools = tools
+	}
+
+	if !info.Vision || !info.PDF {
+		var prompt Prompt
+		prompt, warnings = removeUnsupportedFiles(info, call.Prompt, warnings)
+		call.Prompt = prompt
+	}
+
+	if info.MaxOutputTokens > 0 && call.MaxOutputTokens != nil && *call.MaxOutputTokens > info.MaxOutputTokens {
+		warnings = append(warnings, CallWarning{
+			Type:    CallWarningTypeUnsupportedSetting,
+			Setting: "MaxOutputTokens",
+			Details: fmt.Sprintf("the model outputs at most %d tokens", info.MaxOutputTokens),
+		})
+		call.MaxOutputTokens = Opt(info.MaxOutputTokens)
+	}
+
+	if call.Temperature == nil && info.DefaultTemperature != nil {
+		call.Temperature = Opt(*info.DefaultTemperature)
+	}
+	if info.MaxTemperature > 0 && call.Temperature != nil && *call.Temperature > info.MaxTemperature {
+		warnings = append(warnings, CallWarning{
+			Type:    CallWarningTypeUnsupportedSetting,
+			Setting: "Temperature",
+			Details: fmt.Sprintf("the model accepts temperatures up to %g", info.MaxTemperature),
+		})
+		call.Temperature = Opt(info.MaxTemperature)
+	}
+
+	return call, warnings
+}
+
+// removeUnsupportedFiles returns the prompt without the images and PDFs the
+// model can not read. The messages of the original prompt are not modified.
+func removeUnsupportedFiles(info ModelInfo, prompt Prompt, warnings []CallWarning) (Prompt, []CallWarning) {
+	supported := func(part MessagePart) bool {
+		file, ok := AsMessagePart[FilePart](part)
+		if !ok {
+			return true
+		}
+		switch {
+		case strings.HasPrefix(file.MediaType, "image/"):
+			return info.Vision
+		case file.MediaType == "application/pdf":
+			return info.PDF
+		}
+		return true
+	}
+
+	var result Prompt
+	for i, message := range prompt {
+		if !slices.ContainsFunc(message.Content, func(part MessagePart) bool { return !supported(part) }) {
+			if result != nil {
+				result = append(result, message)
+			}
+			continue
+		}
+		if result == nil {
+			result = append(make(Prompt, 0, len(prompt)), prompt[:i]...)
+		}
+
+		content := make([]MessagePart, 0, len(message.Content))
+		for _, part := range message.Content {
+			if supported(part) {
+				content = append(content, part)
+				continue
+			}
+			file, _ := AsMessagePart[FilePart](part)
+			warnings = append(warnings, CallWarning{
+				Type:    CallWarningTypeOther,
+				Message: fmt.Sprintf("the model can not read %s files, %q has been removed", file.MediaType, file.Filename),
+			})
+		}
+		message.Content = content
+		result = append(result, message)
+	}
+	if result == nil {
+		return prompt, warnings
+	}
+	return result, warnings
+}
+
+// Capabilities of the models of the built-in providers.
+func init() {
+	openaiTiers := []string{"flex", "priority"}
+	for model, info := range map[string]ModelInfo{
+		"gpt-5.2":                    {ContextWindow: 400_000, MaxOutputTokens: 128_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, ServiceTiers: openaiTiers, SystemMessageMode: "developer"},
+		"gpt-5.2-pro":                {ContextWindow: 400_000, MaxOutputTokens: 128_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, SystemMessageMode: "developer"},
+		"gpt-5.2-chat":               {ContextWindow: 128_000, MaxOutputTokens: 16_384, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, SystemMessageMode: "developer"},
+		"gpt-5.1":                    {ContextWindow: 400_000, MaxOutputTokens: 128_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, ServiceTiers: openaiTiers, SystemMessageMode: "developer"},
+		"gpt-5.1-codex":              {ContextWindow: 400_000, MaxOutputTokens: 128_000, Tools: true, Vision: true, Reasoning: true, JSONSchema: true, ServiceTiers: openaiTiers, SystemMessageMode: "developer"},
+		"gpt-5.1-codex-mini":         {ContextWindow: 400_000, MaxOutputTokens: 128_000, Tools: true, Vision: true, Reasoning: true, JSONSchema: true, SystemMessageMode: "developer"},
+		"gpt-5.1-chat":               {ContextWindow: 128_000, MaxOutputTokens: 16_384, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, SystemMessageMode: "developer"},
+		"gpt-5-pro":                  {ContextWindow: 400_000, MaxOutputTokens: 272_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, SystemMessageMode: "developer"},
+		"gpt-5":                      {ContextWindow: 400_000, MaxOutputTokens: 128_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, ServiceTiers: openaiTiers, SystemMessageMode: "developer"},
+		"gpt-5-mini":                 {ContextWindow: 400_000, MaxOutputTokens: 128_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, ServiceTiers: openaiTiers, SystemMessageMode: "developer"},
+		"gpt-5-nano":                 {ContextWindow: 400_000, MaxOutputTokens: 128_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, ServiceTiers: []string{"flex"}, SystemMessageMode: "developer"},
+		"gpt-5-codex":                {ContextWindow: 400_000, MaxOutputTokens: 128_000, Tools: true, Vision: true, Reasoning: true, JSONSchema: true, ServiceTiers: openaiTiers, SystemMessageMode: "developer"},
+		"gpt-5-chat":                 {ContextWindow: 128_000, MaxOutputTokens: 16_384, Vision: true, PDF: true, JSONSchema: true},
+		"gpt-4.1":                    {ContextWindow: 1_047_576, MaxOutputTokens: 32_768, Tools: true, Vision: true, PDF: true, JSONSchema: true, ServiceTiers: []string{"priority"}},
+		"gpt-4.1-mini":               {ContextWindow: 1_047_576, MaxOutputTokens: 32_768, Tools: true, Vision: true, PDF: true, JSONSchema: true, ServiceTiers: []string{"priority"}},
+		"gpt-4.1-nano":               {ContextWindow: 1_047_576, MaxOutputTokens: 32_768, Tools: true, Vision: true, PDF: true, JSONSchema: true, ServiceTiers: []string{"priority"}},
+		"gpt-4o":                     {ContextWindow: 128_000, MaxOutputTokens: 16_384, Tools: true, Vision: true, PDF: true, JSONSchema: true, ServiceTiers: []string{"priority"}},
+		"gpt-4o-mini":                {ContextWindow: 128_000, MaxOutputTokens: 16_384, Tools: true, Vision: true, PDF: true, JSONSchema: true, ServiceTiers: []string{"priority"}},
+		"gpt-4o-search-preview":      {ContextWindow: 128_000, MaxOutputTokens: 16_384, JSONSchema: true, WebSearch: true, ServiceTiers: []string{"priority"}},
+		"gpt-4o-mini-search-preview": {ContextWindow: 128_000, MaxOutputTokens: 16_384, JSONSchema: true, WebSearch: true, ServiceTiers: []string{"priority"}},
+		"gpt-4-turbo":                {ContextWindow: 128_000, MaxOutputTokens: 4_096, Tools: true, Vision: true, ServiceTiers: []string{"priority"}},
+		"gpt-4":                      {ContextWindow: 8_192, MaxOutputTokens: 8_192, Tools: true, ServiceTiers: []string{"priority"}},
+		"o1":                         {ContextWindow: 200_000, MaxOutputTokens: 100_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, SystemMessageMode: "developer"},
+		"o1-pro":                     {ContextWindow: 200_000, MaxOutputTokens: 100_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, SystemMessageMode: "developer"},
+		"o1-mini":                    {ContextWindow: 128_000, MaxOutputTokens: 65_536, Reasoning: true, SystemMessageMode: "remove"},
+		"o1-preview":                 {ContextWindow: 128_000, MaxOutputTokens: 32_768, Reasoning: true, SystemMessageMode: "remove"},
+		"o3":                         {ContextWindow: 200_000, MaxOutputTokens: 100_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, ServiceTiers: openaiTiers, SystemMessageMode: "developer"},
+		"o3-pro":                     {ContextWindow: 200_000, MaxOutputTokens: 100_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, ServiceTiers: []string{"flex"}, SystemMessageMode: "developer"},
+		"o3-mini":                    {ContextWindow: 200_000, MaxOutputTokens: 100_000, Tools: true, Reasoning: true, JSONSchema: true, ServiceTiers: openaiTiers, SystemMessageMode: "developer"},
+		"o4-mini":                    {ContextWindow: 200_000, MaxOutputTokens: 100_000, Tools: true, Vision: true, PDF: true, Reasoning: true, JSONSchema: true, ServiceTiers: openaiTiers, SystemMessageMode: "developer"},
+		"codex-mini":                 {ContextWindow: 200_000, MaxOutputTokens: 100_000, Tools: true, Vision: true, Reasoning: true, JSONSchema: true, SystemMessageMode: "developer"},
+		"computer-use-preview":       {ContextWindow: 8_192, MaxOutputTokens: 1_024, Tools: true, Vision: true, Reasoning: true, SystemMessageMode: "developer"},
+		"gpt-oss":                    {ContextWindow: 131_072, MaxOutputTokens: 131_072, Tools: true, Reasoning: true, JSONSchema: true, SystemMessageMode: "developer"},
+	} {
+		info.MaxTemperature = 2
+		RegisterModelInfo("openai", model, info)
+	}
+
+	anthropic := func(maxOutputTokens int64, reasoning, jsonSchema bool) ModelInfo {
+		return ModelInfo{
+			ContextWindow:   200_000,
+			MaxOutputTokens: maxOutputTokens,
+			Tools:           true,
+			Vision:          true,
+			PDF:             true,
+			Reasoning:       reasoning,
+			JSONSchema:      jsonSchema,
+			MaxTemperature:  1,
+		}
+	}
+	for model, info := range map[string]ModelInfo{
+		"claude-opus-4-5":   anthropic(64_000, true, true),
+		"claude-opus-4-1":   anthropic(32_000, true, true),
+		"claude-opus-4":     anthropic(32_000, true, false),
+		"claude-sonnet-4-5": anthropic(64_000, true, true),
+		"claude-sonnet-4":   anthropic(64_000, true, false),
+		"claude-3-7-sonnet": anthropic(64_000, true, false),
+		"claude-haiku-4-5":  anthropic(64_000, true, true),
+		"claude-3-5-haiku":  anthropic(8_192, false, false),
+	} {
+		RegisterModelInfo("anthropic", model, info)
+	}
+
+	google := func(maxOutputTokens int64, reasoning bool) ModelInfo {
+		return ModelInfo{
+			ContextWindow:   1_048_576,
+			MaxOutputTokens: maxOutputTokens,
+			Tools:           true,
+			Vision:          true,
+			PDF:             true,
+			Reasoning:       reasoning,
+			JSONSchema:      true,
+			MaxTemperature:  2,
+		}
+	}
+	for model, info := range map[string]ModelInfo{
+		"gemini-2.5-pro":        google(65_536, true),
+		"gemini-2.5-flash":      google(65_536, true),
+		"gemini-2.5-flash-lite": google(65_536, true),
+		"gemini-2.0-flash":      google(8_192, false),
+		"gemini-2.0-flash-lite": google(8_192, false),
+	} {
+		RegisterModelInfo("google", model, info)
+	}
+}
@@ -0,0 +1,111 @@
+package fantasy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupModelInfo(t *testing.T) {
+	t.Parallel()
+
+	info, ok := LookupModelInfo("openai", "gpt-4o-2024-08-06")
+	require.True(t, ok)
+	require.True(t, info.Vision)
+	require.True(t, info.SupportsServiceTier("priority"))
+	require.False(t, info.SupportsServiceTier("flex"))
+
+	// The longest prefix wins.
+	info, ok = LookupModelInfo("openai", "gpt-4o-mini-search-preview-2025-03-11")
+	require.True(t, ok)
+	require.True(t, info.WebSearch)
+
+	info, ok = LookupModelInfo("anthropic", "claude-sonnet-4@20250514")
+	require.True(t, ok)
+	require.False(t, info.JSONSchema)
+	info, ok = LookupModelInfo("anthropic", "claude-sonnet-4-5-20250929")
+	require.True(t, ok)
+	require.True(t, info.JSONSchema)
+
+	_, ok = LookupModelInfo("openai", "gpt-4omni")
+	require.False(t, ok)
+
+	RegisterModelInfo("modelinfo-test", "custom", ModelInfo{ContextWindow: 32_000, Tools: true})
+	info, ok = LookupModelInfo("modelinfo-test", "custom:7b")
+	require.True(t, ok)
+	require.Equal(t, int64(32_000), info.ContextWindow)
+
+	model := newNamedModel("openai/gpt-5-mini", nil)
+	model.provider = "openrouter"
+	info, ok = ModelInfoOf(model)
+	require.True(t, ok)
+	require.True(t, info.Reasoning)
+}
+
+func TestModelInfoResolveObjectMode(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, ObjectModeJSON, ModelInfo{JSONSchema: true, Tools: true}.ResolveObjectMode(ObjectModeAuto))
+	require.Equal(t, ObjectModeTool, ModelInfo{Tools: true}.ResolveObjectMode(ObjectModeAuto))
+	require.Equal(t, ObjectModeText, ModelInfo{}.ResolveObjectMode(ObjectModeAuto))
+	require.Equal(t, ObjectModeTool, ModelInfo{JSONSchema: true}.ResolveObjectMode(ObjectModeTool))
+}
+
+func TestApplyModelInfo(t *testing.T) {
+	t.Parallel()
+
+	image := FilePart{Filename: "cat.png", MediaType: "image/png", Data: []byte("png")}
+	pdf := FilePart{Filename: "report.pdf", MediaType: "application/pdf", Data: []byte("pdf")}
+	prompt := Prompt{
+		NewSystemMessage("You are helpful."),
+		NewUserMessage("Describe these.", image, pdf),
+	}
+	webSearch := ProviderDefinedTool{ID: "web_search", Name: "web_search"}
+	call := Call{
+		Prompt:          prompt,
+		Tools:           []Tool{FunctionTool{Name: "search"}, webSearch},
+		MaxOutputTokens: Opt(int64(100_000)),
+		Temperature:     Opt(1.5),
+	}
+
+	t.Run("keeps supported settings", func(t *testing.T) {
+		t.Parallel()
+
+		info := ModelInfo{Tools: true, Vision: true, PDF: true}
+		got, warnings := ApplyModelInfo(info, call)
+		require.Empty(t, warnings)
+		require.Equal(t, call, got)
+	})
+
+	t.Run("removes unsupported settings", func(t *testing.T) {
+		t.Parallel()
+
+		info := ModelInfo{Vision: true, MaxOutputTokens: 8_192, MaxTemperature: 1}
+		got, warnings := ApplyModelInfo(info, call)
+		require.Len(t, warnings, 4)
+
+		require.Equal(t, []Tool{webSearch}, got.Tools)
+		require.Equal(t, CallWarningTypeUnsupportedTool, warnings[0].Type)
+		require.Equal(t, "the model does not support tools", warnings[0].Message)
+
+		require.Len(t, got.Prompt[1].Content, 2)
+		require.Equal(t, image, got.Prompt[1].Content[1])
+		require.Contains(t, warnings[1].Message, `"report.pdf" has been removed`)
+		// The prompt of the call is left untouched.
+		require.Len(t, prompt[1].Content, 3)
+
+		require.Equal(t, int64(8_192), *got.MaxOutputTokens)
+		require.Equal(t, "MaxOutputTokens", warnings[2].Setting)
+		require.Equal(t, 1.0, *got.Temperature)
+		require.Equal(t, "Temperature", warnings[3].Setting)
+	})
+
+	t.Run("sets the default temperature", func(t *testing.T) {
+		t.Parallel()
+
+		info := ModelInfo{Tools: true, Vision: true, PDF: true, DefaultTemperature: Opt(0.6)}
+		got, warnings := ApplyModelInfo(info, Call{Prompt: prompt})
+		require.Empty(t, warnings)
+		require.Equal(t, 0.6, *got.Temperature)
+	})
+}
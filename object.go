@@ -40,6 +40,8 @@ type ObjectCall struct {
 	TopK             *int64
 	PresencePenalty  *float64
 	FrequencyPenalty *float64
+	StopSequences    []string
+	Seed             *int64
 
 	ProviderOptions ProviderOptions
 
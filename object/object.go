@@ -120,6 +120,8 @@ func GenerateWithTool(
 		TopK:             call.TopK,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	})
 	if err != nil {
@@ -212,12 +214,21 @@ func GenerateWithText(
 		TopK:             call.TopK,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("text-based generation failed: %w", err)
 	}
 
+	return ParseResponse(ctx, call, resp)
+}
+
+// ParseResponse parses the text of a response as the object of the call,
+// repairing it with the call's repair function when set. It is used by
+// providers with native JSON output.
+func ParseResponse(ctx context.Context, call fantasy.ObjectCall, resp *fantasy.Response) (*fantasy.ObjectResponse, error) {
 	textContent := resp.Content.Text()
 	if textContent == "" {
 		return nil, &fantasy.NoObjectGeneratedError{
@@ -229,6 +240,7 @@ func GenerateWithText(
 	}
 
 	var obj any
+	var err error
 	if call.RepairText != nil {
 		obj, err = schema.ParseAndValidateWithRepair(ctx, textContent, call.Schema, call.RepairText)
 	} else {
@@ -265,6 +277,12 @@ func StreamWithTool(
 	model fantasy.LanguageModel,
 	call fantasy.ObjectCall,
 ) (fantasy.ObjectStreamResponse, error) {
+	// The schema is compiled once, every partial object is validated.
+	validator, err := schema.Compile(call.Schema)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a tool from the schema
 	toolName := call.SchemaName
 	if toolName == "" {
@@ -294,6 +312,8 @@ func StreamWithTool(
 		TopK:             call.TopK,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	})
 	if err != nil {
@@ -318,7 +338,7 @@ func StreamWithTool(
 				obj, state, parseErr := schema.ParsePartialJSON(accumulated)
 
 				if state == schema.ParseStateSuccessful || state == schema.ParseStateRepaired {
-					if err := schema.ValidateAgainstSchema(obj, call.Schema); err == nil {
+					if err := validator.Validate(obj); err == nil {
 						if !reflect.DeepEqual(obj, lastParsedObject) {
 							if !yield(fantasy.ObjectStreamPart{
 								Type:   fantasy.ObjectStreamPartTypeObject,
@@ -336,7 +356,7 @@ func StreamWithTool(
 					if repairErr == nil {
 						obj2, state2, _ := schema.ParsePartialJSON(repairedText)
 						if (state2 == schema.ParseStateSuccessful || state2 == schema.ParseStateRepaired) &&
-							schema.ValidateAgainstSchema(obj2, call.Schema) == nil {
+							validator.Validate(obj2) == nil {
 							if !reflect.DeepEqual(obj2, lastParsedObject) {
 								if !yield(fantasy.ObjectStreamPart{
 									Type:   fantasy.ObjectStreamPartTypeObject,
@@ -355,7 +375,7 @@ func StreamWithTool(
 
 				obj, state, parseErr := schema.ParsePartialJSON(accumulated)
 				if state == schema.ParseStateSuccessful || state == schema.ParseStateRepaired {
-					if err := schema.ValidateAgainstSchema(obj, call.Schema); err == nil {
+					if err := validator.Validate(obj); err == nil {
 						if !reflect.DeepEqual(obj, lastParsedObject) {
 							if !yield(fantasy.ObjectStreamPart{
 								Type:   fantasy.ObjectStreamPartTypeObject,
@@ -373,7 +393,7 @@ func StreamWithTool(
 					if repairErr == nil {
 						obj2, state2, _ := schema.ParsePartialJSON(repairedText)
 						if (state2 == schema.ParseStateSuccessful || state2 == schema.ParseStateRepaired) &&
-							schema.ValidateAgainstSchema(obj2, call.Schema) == nil {
+							validator.Validate(obj2) == nil {
 							if !reflect.DeepEqual(obj2, lastParsedObject) {
 								if !yield(fantasy.ObjectStreamPart{
 									Type:   fantasy.ObjectStreamPartTypeObject,
@@ -424,7 +444,7 @@ func StreamWithTool(
 				finishReason = part.FinishReason
 
 			case fantasy.StreamPartTypeWarnings:
-				warnings = part.Warnings
+				warnings = append(warnings, part.Warnings...)
 			}
 
 			if len(part.ProviderMetadata) > 0 {
@@ -503,13 +523,31 @@ func StreamWithText(
 		TopK:             call.TopK,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("text-based streaming failed: %w", err)
 	}
 
+	return ParseStream(ctx, call, stream), nil
+}
+
+// ParseStream parses the streamed text as the object of the call, emitting
+// every valid partial object. It is used by providers with native JSON
+// output.
+func ParseStream(ctx context.Context, call fantasy.ObjectCall, stream fantasy.StreamResponse) fantasy.ObjectStreamResponse {
 	return func(yield func(fantasy.ObjectStreamPart) bool) {
+		validator, err := schema.Compile(call.Schema)
+		if err != nil {
+			yield(fantasy.ObjectStreamPart{
+				Type:  fantasy.ObjectStreamPartTypeError,
+				Error: err,
+			})
+			return
+		}
+
 		var accumulated string
 		var lastParsedObject any
 		var usage fantasy.Usage
@@ -526,7 +564,7 @@ func StreamWithText(
 				obj, state, parseErr := schema.ParsePartialJSON(accumulated)
 
 				if state == schema.ParseStateSuccessful || state == schema.ParseStateRepaired {
-					if err := schema.ValidateAgainstSchema(obj, call.Schema); err == nil {
+					if err := validator.Validate(obj); err == nil {
 						if !reflect.DeepEqual(obj, lastParsedObject) {
 							if !yield(fantasy.ObjectStreamPart{
 								Type:   fantasy.ObjectStreamPartTypeObject,
@@ -544,7 +582,7 @@ func StreamWithText(
 					if repairErr == nil {
 						obj2, state2, _ := schema.ParsePartialJSON(repairedText)
 						if (state2 == schema.ParseStateSuccessful || state2 == schema.ParseStateRepaired) &&
-							schema.ValidateAgainstSchema(obj2, call.Schema) == nil {
+							validator.Validate(obj2) == nil {
 							if !reflect.DeepEqual(obj2, lastParsedObject) {
 								if !yield(fantasy.ObjectStreamPart{
 									Type:   fantasy.ObjectStreamPartTypeObject,
@@ -572,7 +610,7 @@ func StreamWithText(
 				finishReason = part.FinishReason
 
 			case fantasy.StreamPartTypeWarnings:
-				warnings = part.Warnings
+				warnings = append(warnings, part.Warnings...)
 			}
 
 			if len(part.ProviderMetadata) > 0 {
@@ -599,7 +637,7 @@ func StreamWithText(
 				},
 			})
 		}
-	}, nil
+	}
 }
 
 func unmarshal(obj any, target any) error {
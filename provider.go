@@ -9,3 +9,29 @@ type Provider interface {
 	Name() string
 	LanguageModel(ctx context.Context, modelID string) (LanguageModel, error)
 }
+
+// EmbeddingProvider is implemented by providers that also offer embedding
+// models.
+type EmbeddingProvider interface {
+	Provider
+	EmbeddingModel(ctx context.Context, modelID string) (EmbeddingModel, error)
+}
+
+// ImageProvider is implemented by providers that also offer image models.
+type ImageProvider interface {
+	Provider
+	ImageModel(ctx context.Context, modelID string) (ImageModel, error)
+}
+
+// TranscriptionProvider is implemented by providers that also offer
+// transcription models.
+type TranscriptionProvider interface {
+	Provider
+	TranscriptionModel(ctx context.Context, modelID string) (TranscriptionModel, error)
+}
+
+// SpeechProvider is implemented by providers that also offer speech models.
+type SpeechProvider interface {
+	Provider
+	SpeechModel(ctx context.Context, modelID string) (SpeechModel, error)
+}
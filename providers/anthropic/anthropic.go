@@ -10,10 +10,12 @@ import (
 	"fmt"
 	"io"
 	"maps"
+	"slices"
 	"strings"
 
 	"charm.land/fantasy"
 	"charm.land/fantasy/object"
+	"charm.land/fantasy/schema"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/charmbracelet/anthropic-sdk-go"
 	"github.com/charmbracelet/anthropic-sdk-go/bedrock"
@@ -50,6 +52,26 @@ type provider struct {
 	options options
 }
 
+// Register the Anthropic provider with the factory registry.
+func init() {
+	fantasy.RegisterProviderFactory(Name, fantasy.ProviderFactory{
+		New: func(config fantasy.ProviderConfig) (fantasy.Provider, error) {
+			opts := []Option{WithHeaders(config.Headers)}
+			if config.APIKey != "" {
+				opts = append(opts, WithAPIKey(config.APIKey))
+			}
+			if config.BaseURL != "" {
+				opts = append(opts, WithBaseURL(config.BaseURL))
+			}
+			return New(opts...)
+		},
+		ParseOptions: func(data map[string]any) (fantasy.ProviderOptionsData, error) {
+			return ParseOptions(data)
+		},
+		APIKeyEnv: "ANTHROPIC_API_KEY",
+	})
+}
+
 // Option defines a function that configures Anthropic provider options.
 type Option = func(*options)
 
@@ -125,13 +147,12 @@ func WithHTTPClient(client option.HTTPClient) Option {
 	}
 }
 
-// WithObjectMode sets the object generation mode.
+// WithObjectMode sets the object generation mode. ObjectModeJSON and
+// ObjectModeAuto use structured outputs when the model supports them and fall
+// back to tool mode otherwise, e.g. on Bedrock and Vertex. ObjectModeJSON then
+// adds a warning to the response.
 func WithObjectMode(om fantasy.ObjectMode) Option {
 	return func(o *options) {
-		// not supported
-		if om == fantasy.ObjectModeJSON {
-			om = fantasy.ObjectModeAuto
-		}
 		o.objectMode = om
 	}
 }
@@ -191,12 +212,12 @@ func (a *provider) LanguageModel(ctx context.Context, modelID string) (fantasy.L
 			}
 		}
 	}
-	return languageModel{
+	return fantasy.WithCost(languageModel{
 		modelID:  modelID,
 		provider: a.options.name,
 		options:  a.options,
 		client:   anthropic.NewClient(clientOptions...),
-	}, nil
+	}), nil
 }
 
 type languageModel struct {
@@ -225,11 +246,16 @@ func (a languageModel) prepareParams(call fantasy.Call) (*anthropic.MessageNewPa
 			return nil, nil, &fantasy.Error{Title: "invalid argument", Message: "anthropic provider options should be *anthropic.ProviderOptions"}
 		}
 	}
+	var warnings []fantasy.CallWarning
+	if info, ok := a.modelInfo(); ok {
+		call, warnings = fantasy.ApplyModelInfo(info, call)
+	}
 	sendReasoning := true
 	if providerOptions.SendReasoning != nil {
 		sendReasoning = *providerOptions.SendReasoning
 	}
-	systemBlocks, messages, warnings := toPrompt(call.Prompt, sendReasoning)
+	systemBlocks, messages, promptWarnings := toPrompt(call.Prompt, sendReasoning)
+	warnings = append(warnings, promptWarnings...)
 
 	if call.FrequencyPenalty != nil {
 		warnings = append(warnings, fantasy.CallWarning{
@@ -243,6 +269,12 @@ func (a languageModel) prepareParams(call fantasy.Call) (*anthropic.MessageNewPa
 			Setting: "PresencePenalty",
 		})
 	}
+	if call.Seed != nil {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "Seed",
+		})
+	}
 
 	params.System = systemBlocks
 	params.Messages = messages
@@ -262,6 +294,9 @@ func (a languageModel) prepareParams(call fantasy.Call) (*anthropic.MessageNewPa
 	if call.TopP != nil {
 		params.TopP = param.NewOpt(*call.TopP)
 	}
+	if len(call.StopSequences) > 0 {
+		params.StopSequences = call.StopSequences
+	}
 
 	isThinking := false
 	var thinkingBudget int64
@@ -401,6 +436,8 @@ func (a languageModel) toTools(tools []fantasy.Tool, toolChoice *fantasy.ToolCho
 			if !ok {
 				continue
 			}
+			ft, schemaWarnings := fantasy.NormalizeTool(ft, toolSchemaDialect)
+			warnings = append(warnings, schemaWarnings...)
 			required := []string{}
 			var properties any
 			if props, ok := ft.InputSchema["properties"]; ok {
@@ -421,13 +458,28 @@ func (a languageModel) toTools(tools []fantasy.Tool, toolChoice *fantasy.ToolCho
 					Required:   required,
 				},
 			}
+			if defs, ok := ft.InputSchema["$defs"]; ok {
+				anthropicTool.InputSchema.ExtraFields = map[string]any{"$defs": defs}
+			}
 			if cacheControl != nil {
 				anthropicTool.CacheControl = anthropic.NewCacheControlEphemeralParam()
 			}
 			anthropicTools = append(anthropicTools, anthropic.ToolUnionParam{OfTool: &anthropicTool})
 			continue
 		}
-		// TODO: handle provider tool calls
+		if pt, ok := tool.(fantasy.ProviderDefinedTool); ok && pt.ID == WebSearchToolID {
+			webSearch, err := toWebSearchTool(pt)
+			if err != nil {
+				warnings = append(warnings, fantasy.CallWarning{
+					Type:    fantasy.CallWarningTypeUnsupportedTool,
+					Tool:    tool,
+					Message: fmt.Sprintf("invalid web search tool arguments: %v", err),
+				})
+				continue
+			}
+			anthropicTools = append(anthropicTools, anthropic.ToolUnionParam{OfWebSearchTool20250305: webSearch})
+			continue
+		}
 		warnings = append(warnings, fantasy.CallWarning{
 			Type:    fantasy.CallWarningTypeUnsupportedTool,
 			Tool:    tool,
@@ -641,6 +693,18 @@ func toPrompt(prompt fantasy.Prompt, sendReasoningData bool) ([]anthropic.TextBl
 		case fantasy.MessageRoleAssistant:
 			var anthropicContent []anthropic.ContentBlockParamUnion
 			for _, msg := range block.Messages {
+				// Provider executed tool calls can only be sent back together
+				// with their results.
+				providerResults := map[string]anthropic.ContentBlockParamUnion{}
+				for _, part := range msg.Content {
+					result, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](part)
+					if !ok || !result.ProviderExecuted {
+						continue
+					}
+					if resultBlock, ok := toWebSearchToolResultBlock(result); ok {
+						providerResults[result.ToolCallID] = resultBlock
+					}
+				}
 				for i, part := range msg.Content {
 					isLastPart := i == len(msg.Content)-1
 					cacheControl := GetCacheControl(part.Options())
@@ -700,7 +764,19 @@ func toPrompt(prompt fantasy.Prompt, sendReasoningData bool) ([]anthropic.TextBl
 							continue
 						}
 						if toolCall.ProviderExecuted {
-							// TODO: implement provider executed call
+							if _, ok := providerResults[toolCall.ToolCallID]; !ok {
+								continue
+							}
+							var input any
+							if err := json.Unmarshal([]byte(toolCall.Input), &input); err != nil {
+								continue
+							}
+							anthropicContent = append(anthropicContent, anthropic.ContentBlockParamUnion{
+								OfServerToolUse: &anthropic.ServerToolUseBlockParam{
+									ID:    toolCall.ToolCallID,
+									Input: input,
+								},
+							})
 							continue
 						}
 
@@ -715,7 +791,13 @@ func toPrompt(prompt fantasy.Prompt, sendReasoningData bool) ([]anthropic.TextBl
 						}
 						anthropicContent = append(anthropicContent, toolUseBlock)
 					case fantasy.ContentTypeToolResult:
-						// TODO: implement provider executed tool result
+						result, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](part)
+						if !ok || !result.ProviderExecuted {
+							continue
+						}
+						if resultBlock, ok := providerResults[result.ToolCallID]; ok {
+							anthropicContent = append(anthropicContent, resultBlock)
+						}
 					}
 				}
 			}
@@ -744,13 +826,24 @@ func hasVisibleUserContent(content []anthropic.ContentBlockParamUnion) bool {
 
 func hasVisibleAssistantContent(content []anthropic.ContentBlockParamUnion) bool {
 	for _, block := range content {
-		if block.OfText != nil || block.OfToolUse != nil {
+		if block.OfText != nil || block.OfToolUse != nil || block.OfServerToolUse != nil {
 			return true
 		}
 	}
 	return false
 }
 
+// responseMetadata returns the provider metadata of a response, reporting
+// the stop sequence that ended the generation if any.
+func responseMetadata(stopSequence string) fantasy.ProviderMetadata {
+	if stopSequence == "" {
+		return fantasy.ProviderMetadata{}
+	}
+	return fantasy.ProviderMetadata{
+		Name: &ResponseMetadata{StopSequence: stopSequence},
+	}
+}
+
 func mapFinishReason(finishReason string) fantasy.FinishReason {
 	switch finishReason {
 	case "end_turn", "pause_turn", "stop_sequence":
@@ -766,11 +859,15 @@ func mapFinishReason(finishReason string) fantasy.FinishReason {
 
 // Generate implements fantasy.LanguageModel.
 func (a languageModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	return a.generate(ctx, call)
+}
+
+func (a languageModel) generate(ctx context.Context, call fantasy.Call, opts ...option.RequestOption) (*fantasy.Response, error) {
 	params, warnings, err := a.prepareParams(call)
 	if err != nil {
 		return nil, err
 	}
-	response, err := a.client.Messages.New(ctx, *params)
+	response, err := a.client.Messages.New(ctx, *params, opts...)
 	if err != nil {
 		return nil, toProviderErr(err)
 	}
@@ -823,6 +920,19 @@ func (a languageModel) Generate(ctx context.Context, call fantasy.Call) (*fantas
 				Input:            string(toolUse.Input),
 				ProviderExecuted: false,
 			})
+		case "server_tool_use":
+			content = append(content, fantasy.ToolCallContent{
+				ToolCallID:       block.ID,
+				ToolName:         block.Name,
+				Input:            string(block.Input),
+				ProviderExecuted: true,
+			})
+		case "web_search_tool_result":
+			toolResult, sources := webSearchResultContent(block)
+			content = append(content, toolResult)
+			for _, source := range sources {
+				content = append(content, source)
+			}
 		}
 	}
 
@@ -836,19 +946,23 @@ func (a languageModel) Generate(ctx context.Context, call fantasy.Call) (*fantas
 			CacheReadTokens:     response.Usage.CacheReadInputTokens,
 		},
 		FinishReason:     mapFinishReason(string(response.StopReason)),
-		ProviderMetadata: fantasy.ProviderMetadata{},
+		ProviderMetadata: responseMetadata(response.StopSequence),
 		Warnings:         warnings,
 	}, nil
 }
 
 // Stream implements fantasy.LanguageModel.
 func (a languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	return a.stream(ctx, call)
+}
+
+func (a languageModel) stream(ctx context.Context, call fantasy.Call, opts ...option.RequestOption) (fantasy.StreamResponse, error) {
 	params, warnings, err := a.prepareParams(call)
 	if err != nil {
 		return nil, err
 	}
 
-	stream := a.client.Messages.NewStreaming(ctx, *params)
+	stream := a.client.Messages.NewStreaming(ctx, *params, opts...)
 	acc := anthropic.Message{}
 	return func(yield func(fantasy.StreamPart) bool) {
 		if len(warnings) > 0 {
@@ -902,6 +1016,15 @@ func (a languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.S
 					}) {
 						return
 					}
+				case "server_tool_use":
+					if !yield(fantasy.StreamPart{
+						Type:             fantasy.StreamPartTypeToolInputStart,
+						ID:               chunk.ContentBlock.ID,
+						ToolCallName:     chunk.ContentBlock.Name,
+						ProviderExecuted: true,
+					}) {
+						return
+					}
 				}
 			case "content_block_stop":
 				if len(acc.Content)-1 < int(chunk.Index) {
@@ -938,6 +1061,46 @@ func (a languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.S
 					}) {
 						return
 					}
+				case "server_tool_use":
+					if !yield(fantasy.StreamPart{
+						Type:             fantasy.StreamPartTypeToolInputEnd,
+						ID:               contentBlock.ID,
+						ProviderExecuted: true,
+					}) {
+						return
+					}
+					if !yield(fantasy.StreamPart{
+						Type:             fantasy.StreamPartTypeToolCall,
+						ID:               contentBlock.ID,
+						ToolCallName:     contentBlock.Name,
+						ToolCallInput:    string(contentBlock.Input),
+						ProviderExecuted: true,
+					}) {
+						return
+					}
+				case "web_search_tool_result":
+					toolResult, sources := webSearchResultContent(contentBlock)
+					if !yield(fantasy.StreamPart{
+						Type:             fantasy.StreamPartTypeToolResult,
+						ID:               toolResult.ToolCallID,
+						ToolCallName:     toolResult.ToolName,
+						ToolResult:       toolResult.Result,
+						ProviderExecuted: true,
+						ProviderMetadata: toolResult.ProviderMetadata,
+					}) {
+						return
+					}
+					for _, source := range sources {
+						if !yield(fantasy.StreamPart{
+							Type:       fantasy.StreamPartTypeSource,
+							ID:         source.ID,
+							SourceType: source.SourceType,
+							URL:        source.URL,
+							Title:      source.Title,
+						}) {
+							return
+						}
+					}
 				}
 			case "content_block_delta":
 				switch chunk.Delta.Type {
@@ -999,7 +1162,7 @@ func (a languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.S
 					CacheCreationTokens: acc.Usage.CacheCreationInputTokens,
 					CacheReadTokens:     acc.Usage.CacheReadInputTokens,
 				},
-				ProviderMetadata: fantasy.ProviderMetadata{},
+				ProviderMetadata: responseMetadata(acc.StopSequence),
 			})
 			return
 		} else { //nolint: revive
@@ -1014,20 +1177,169 @@ func (a languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.S
 
 // GenerateObject implements fantasy.LanguageModel.
 func (a languageModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
-	switch a.options.objectMode {
+	mode, modeWarnings := a.objectMode()
+	switch mode {
 	case fantasy.ObjectModeText:
 		return object.GenerateWithText(ctx, a, call)
+	case fantasy.ObjectModeJSON:
+		opts, warnings := structuredOutputOptions(call.Schema)
+		response, err := a.generate(ctx, objectCall(call), opts...)
+		if err != nil {
+			return nil, err
+		}
+		response.Warnings = append(response.Warnings, warnings...)
+		return object.ParseResponse(ctx, call, response)
 	default:
-		return object.GenerateWithTool(ctx, a, call)
+		response, err := object.GenerateWithTool(ctx, a, call)
+		if err != nil {
+			return nil, err
+		}
+		response.Warnings = slices.Concat(modeWarnings, response.Warnings)
+		return response, nil
 	}
 }
 
 // StreamObject implements fantasy.LanguageModel.
 func (a languageModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
-	switch a.options.objectMode {
+	mode, modeWarnings := a.objectMode()
+	switch mode {
 	case fantasy.ObjectModeText:
 		return object.StreamWithText(ctx, a, call)
+	case fantasy.ObjectModeJSON:
+		opts, warnings := structuredOutputOptions(call.Schema)
+		stream, err := a.stream(ctx, objectCall(call), opts...)
+		if err != nil {
+			return nil, err
+		}
+		return object.ParseStream(ctx, call, withWarnings(stream, warnings)), nil
+	default:
+		stream, err := object.StreamWithTool(ctx, a, call)
+		if err != nil {
+			return nil, err
+		}
+		return withObjectWarnings(stream, modeWarnings), nil
+	}
+}
+
+// objectMode resolves the object mode used for the model. ObjectModeAuto and
+// ObjectModeJSON use structured outputs when the model supports them and tool
+// mode otherwise, with a warning when JSON mode was asked for.
+func (a languageModel) objectMode() (fantasy.ObjectMode, []fantasy.CallWarning) {
+	switch a.options.objectMode {
+	case fantasy.ObjectModeAuto, fantasy.ObjectModeJSON:
+		if a.supportsStructuredOutputs() {
+			return fantasy.ObjectModeJSON, nil
+		}
+		if a.options.objectMode == fantasy.ObjectModeAuto {
+			return fantasy.ObjectModeTool, nil
+		}
+		return fantasy.ObjectModeTool, []fantasy.CallWarning{{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "ObjectMode",
+			Message: "structured outputs are not supported by this model, using tool mode",
+		}}
 	default:
-		return object.StreamWithTool(ctx, a, call)
+		return a.options.objectMode, nil
+	}
+}
+
+// supportsStructuredOutputs reports whether structured outputs can be used,
+// they are only available on the Anthropic API.
+func (a languageModel) supportsStructuredOutputs() bool {
+	if a.options.useBedrock || a.options.vertexProject != "" {
+		return false
+	}
+	info, _ := a.modelInfo()
+	return info.JSONSchema
+}
+
+// modelInfo returns the catalog entry of the model. Models served through
+// Bedrock are looked up in the Anthropic catalog without their region and
+// vendor prefixes, e.g. "us.anthropic.claude-sonnet-4-20250514-v1:0".
+func (a languageModel) modelInfo() (fantasy.ModelInfo, bool) {
+	if info, ok := fantasy.LookupModelInfo(a.provider, a.modelID); ok {
+		return info, true
+	}
+	modelID := a.modelID
+	if _, after, ok := strings.Cut(modelID, "anthropic."); ok {
+		modelID = after
+	}
+	return fantasy.LookupModelInfo(Name, modelID)
+}
+
+const structuredOutputsBeta = "structured-outputs-2025-11-13"
+
+// structuredOutputSchemaDialect is the schema dialect of structured outputs,
+// they require closed objects and do not support numerical and string length
+// constraints.
+var structuredOutputSchemaDialect = schema.Dialect{
+	Name:                "anthropic structured outputs",
+	CloseObjects:        true,
+	OneOfAsAnyOf:        true,
+	UnsupportedKeywords: []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf", "minLength", "maxLength"},
+}
+
+// toolSchemaDialect is the schema dialect of tool input schemas.
+var toolSchemaDialect = schema.Dialect{Name: "anthropic"}
+
+// structuredOutputOptions constrains the response to the JSON schema. The
+// beta and the output format are not part of the SDK yet so they are set on
+// the raw request.
+func structuredOutputOptions(s fantasy.Schema) ([]option.RequestOption, []fantasy.CallWarning) {
+	jsonSchema, warnings := fantasy.NormalizeSchema(s, structuredOutputSchemaDialect)
+	return []option.RequestOption{
+		option.WithHeaderAdd("anthropic-beta", structuredOutputsBeta),
+		option.WithJSONSet("output_format", map[string]any{
+			"type":   "json_schema",
+			"schema": jsonSchema,
+		}),
+	}, warnings
+}
+
+// withWarnings adds warnings to a stream.
+func withWarnings(stream fantasy.StreamResponse, warnings []fantasy.CallWarning) fantasy.StreamResponse {
+	if len(warnings) == 0 {
+		return stream
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		if !yield(fantasy.StreamPart{
+			Type:     fantasy.StreamPartTypeWarnings,
+			Warnings: warnings,
+		}) {
+			return
+		}
+		stream(yield)
+	}
+}
+
+// withObjectWarnings adds warnings to the finish part of an object stream.
+func withObjectWarnings(stream fantasy.ObjectStreamResponse, warnings []fantasy.CallWarning) fantasy.ObjectStreamResponse {
+	if len(warnings) == 0 {
+		return stream
+	}
+	return func(yield func(fantasy.ObjectStreamPart) bool) {
+		for part := range stream {
+			if part.Type == fantasy.ObjectStreamPartTypeFinish {
+				part.Warnings = slices.Concat(warnings, part.Warnings)
+			}
+			if !yield(part) {
+				return
+			}
+		}
+	}
+}
+
+func objectCall(call fantasy.ObjectCall) fantasy.Call {
+	return fantasy.Call{
+		Prompt:           call.Prompt,
+		MaxOutputTokens:  call.MaxOutputTokens,
+		Temperature:      call.Temperature,
+		TopP:             call.TopP,
+		TopK:             call.TopK,
+		PresencePenalty:  call.PresencePenalty,
+		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
+		ProviderOptions:  call.ProviderOptions,
 	}
 }
@@ -1,7 +1,12 @@
 package anthropic
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"charm.land/fantasy"
@@ -341,3 +346,291 @@ func TestToPrompt_DropsEmptyMessages(t *testing.T) {
 		require.Empty(t, warnings)
 	})
 }
+
+func TestToPrompt_ProviderExecutedWebSearch(t *testing.T) {
+	t.Parallel()
+
+	userMessage := fantasy.Message{
+		Role: fantasy.MessageRoleUser,
+		Content: []fantasy.MessagePart{
+			fantasy.TextPart{Text: "What is new in Go?"},
+		},
+	}
+
+	t.Run("should send web search calls back with their results", func(t *testing.T) {
+		t.Parallel()
+
+		prompt := fantasy.Prompt{
+			userMessage,
+			{
+				Role: fantasy.MessageRoleAssistant,
+				Content: []fantasy.MessagePart{
+					fantasy.ToolCallPart{
+						ToolCallID:       "srvtoolu_1",
+						ToolName:         "web_search",
+						Input:            `{"query":"go release"}`,
+						ProviderExecuted: true,
+					},
+					fantasy.ToolResultPart{
+						ToolCallID:       "srvtoolu_1",
+						Output:           fantasy.ToolResultOutputContentText{Text: "[]"},
+						ProviderExecuted: true,
+						ProviderOptions: fantasy.ProviderOptions{
+							Name: &WebSearchResultMetadata{
+								Results: []WebSearchResult{
+									{URL: "https://go.dev/blog", Title: "The Go Blog", EncryptedContent: "enc"},
+								},
+							},
+						},
+					},
+					fantasy.TextPart{Text: "Go 1.25 is out."},
+				},
+			},
+		}
+
+		_, messages, warnings := toPrompt(prompt, true)
+
+		require.Empty(t, warnings)
+		require.Len(t, messages, 2)
+		content := messages[1].Content
+		require.Len(t, content, 3)
+		require.NotNil(t, content[0].OfServerToolUse)
+		require.Equal(t, "srvtoolu_1", content[0].OfServerToolUse.ID)
+		require.NotNil(t, content[1].OfWebSearchToolResult)
+		require.Equal(t, "srvtoolu_1", content[1].OfWebSearchToolResult.ToolUseID)
+		require.Len(t, content[1].OfWebSearchToolResult.Content.OfWebSearchToolResultBlockItem, 1)
+		require.Equal(t, "enc", content[1].OfWebSearchToolResult.Content.OfWebSearchToolResultBlockItem[0].EncryptedContent)
+		require.NotNil(t, content[2].OfText)
+	})
+
+	t.Run("should drop provider executed calls without web search results", func(t *testing.T) {
+		t.Parallel()
+
+		prompt := fantasy.Prompt{
+			userMessage,
+			{
+				Role: fantasy.MessageRoleAssistant,
+				Content: []fantasy.MessagePart{
+					fantasy.ToolCallPart{
+						ToolCallID:       "call_1",
+						ToolName:         "code_execution",
+						Input:            `{}`,
+						ProviderExecuted: true,
+					},
+					fantasy.ToolResultPart{
+						ToolCallID:       "call_1",
+						Output:           fantasy.ToolResultOutputContentText{Text: "42"},
+						ProviderExecuted: true,
+					},
+					fantasy.TextPart{Text: "The answer is 42."},
+				},
+			},
+		}
+
+		_, messages, warnings := toPrompt(prompt, true)
+
+		require.Empty(t, warnings)
+		require.Len(t, messages, 2)
+		require.Len(t, messages[1].Content, 1)
+		require.NotNil(t, messages[1].Content[0].OfText)
+	})
+}
+
+func TestObjectGeneration(t *testing.T) {
+	t.Parallel()
+
+	objectSchema := fantasy.Schema{
+		Type: "object",
+		Properties: map[string]*fantasy.Schema{
+			"name": {Type: "string"},
+			"age":  {Type: "integer", Minimum: fantasy.Opt(0.0)},
+		},
+		Required: []string{"name", "age"},
+	}
+
+	type request struct {
+		beta string
+		body map[string]any
+	}
+	newServer := func(t *testing.T, requests chan<- request, stream bool) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			requests <- request{beta: r.Header.Get("anthropic-beta"), body: body}
+
+			if !stream {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"id":"msg_01","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"{\"name\":\"Ada\",\"age\":36}"}],"stop_reason":"end_turn","usage":{"input_tokens":20,"output_tokens":10}}`)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			events := []string{
+				`{"type":"message_start","message":{"id":"msg_01","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":20,"output_tokens":1}}}`,
+				`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"{\"name\":\"Ada\","}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"\"age\":36}"}}`,
+				`{"type":"content_block_stop","index":0}`,
+				`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":10}}`,
+				`{"type":"message_stop"}`,
+			}
+			for _, event := range events {
+				var typed struct {
+					Type string `json:"type"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(event), &typed))
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typed.Type, event)
+			}
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	requireStructuredOutput := func(t *testing.T, req request) {
+		require.Equal(t, structuredOutputsBeta, req.beta)
+		require.Nil(t, req.body["tools"])
+		outputFormat := req.body["output_format"].(map[string]any)
+		require.Equal(t, "json_schema", outputFormat["type"])
+		jsonSchema := outputFormat["schema"].(map[string]any)
+		require.Equal(t, false, jsonSchema["additionalProperties"])
+		age := jsonSchema["properties"].(map[string]any)["age"].(map[string]any)
+		require.NotContains(t, age, "minimum")
+	}
+
+	t.Run("should use structured outputs for supported models", func(t *testing.T) {
+		t.Parallel()
+
+		requests := make(chan request, 1)
+		server := newServer(t, requests, false)
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "claude-sonnet-4-5-20250929")
+		require.NoError(t, err)
+
+		result, err := model.GenerateObject(t.Context(), fantasy.ObjectCall{
+			Prompt: fantasy.Prompt{fantasy.NewUserMessage("Describe Ada Lovelace")},
+			Schema: objectSchema,
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"name": "Ada", "age": float64(36)}, result.Object)
+		require.Equal(t, int64(30), result.Usage.TotalTokens)
+		requireStructuredOutput(t, <-requests)
+	})
+
+	t.Run("should stream structured outputs", func(t *testing.T) {
+		t.Parallel()
+
+		requests := make(chan request, 1)
+		server := newServer(t, requests, true)
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithObjectMode(fantasy.ObjectModeJSON))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "claude-opus-4-1")
+		require.NoError(t, err)
+
+		stream, err := model.StreamObject(t.Context(), fantasy.ObjectCall{
+			Prompt: fantasy.Prompt{fantasy.NewUserMessage("Describe Ada Lovelace")},
+			Schema: objectSchema,
+		})
+		require.NoError(t, err)
+
+		var objects []any
+		var last fantasy.ObjectStreamPart
+		for part := range stream {
+			if part.Type == fantasy.ObjectStreamPartTypeObject {
+				objects = append(objects, part.Object)
+			}
+			last = part
+		}
+		require.NotEmpty(t, objects)
+		require.Equal(t, map[string]any{"name": "Ada", "age": float64(36)}, objects[len(objects)-1])
+		require.Equal(t, fantasy.ObjectStreamPartTypeFinish, last.Type)
+		require.Equal(t, fantasy.FinishReasonStop, last.FinishReason)
+		requireStructuredOutput(t, <-requests)
+	})
+
+	t.Run("should fall back to tools for other models", func(t *testing.T) {
+		t.Parallel()
+
+		model := languageModel{modelID: "claude-sonnet-4-20250514", options: options{objectMode: fantasy.ObjectModeAuto}}
+		mode, warnings := model.objectMode()
+		require.Equal(t, fantasy.ObjectModeTool, mode)
+		require.Empty(t, warnings)
+
+		model = languageModel{modelID: "claude-sonnet-4-5", options: options{objectMode: fantasy.ObjectModeAuto, useBedrock: true}}
+		mode, warnings = model.objectMode()
+		require.Equal(t, fantasy.ObjectModeTool, mode)
+		require.Empty(t, warnings)
+
+		model = languageModel{modelID: "claude-3-5-haiku-20241022", options: options{objectMode: fantasy.ObjectModeJSON}}
+		mode, warnings = model.objectMode()
+		require.Equal(t, fantasy.ObjectModeTool, mode)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "ObjectMode", warnings[0].Setting)
+	})
+
+	// toolServer answers with a tool call, as used by tool mode.
+	toolServer := func(t *testing.T, requests chan<- request) *http.Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			requests <- request{beta: r.Header.Get("anthropic-beta"), body: body}
+
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"msg_01","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"toolu_01","name":"generate_object","input":{"name":"Ada","age":36}}],"stop_reason":"tool_use","usage":{"input_tokens":20,"output_tokens":10}}`)
+		}))
+		t.Cleanup(server.Close)
+		target, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		return &http.Client{Transport: redirectTransport{target: target}}
+	}
+
+	requireToolMode := func(t *testing.T, result *fantasy.ObjectResponse, req request) {
+		require.Equal(t, map[string]any{"name": "Ada", "age": float64(36)}, result.Object)
+		require.Len(t, result.Warnings, 1)
+		require.Equal(t, fantasy.CallWarningTypeUnsupportedSetting, result.Warnings[0].Type)
+		require.Equal(t, "ObjectMode", result.Warnings[0].Setting)
+		require.NotContains(t, req.beta, structuredOutputsBeta)
+		require.NotContains(t, req.body, "output_format")
+		require.Len(t, req.body["tools"], 1)
+	}
+
+	for name, opt := range map[string]Option{
+		"bedrock": WithBedrock(),
+		"vertex":  WithVertex("project", "us-east5"),
+	} {
+		t.Run("should fall back to tools on "+name, func(t *testing.T) {
+			t.Parallel()
+
+			requests := make(chan request, 1)
+			provider, err := New(
+				opt,
+				WithSkipAuth(true),
+				WithHTTPClient(toolServer(t, requests)),
+				WithObjectMode(fantasy.ObjectModeJSON),
+			)
+			require.NoError(t, err)
+			model, err := provider.LanguageModel(t.Context(), "claude-sonnet-4-5-20250929")
+			require.NoError(t, err)
+
+			result, err := model.GenerateObject(t.Context(), fantasy.ObjectCall{
+				Prompt: fantasy.Prompt{fantasy.NewUserMessage("Describe Ada Lovelace")},
+				Schema: objectSchema,
+			})
+			require.NoError(t, err)
+			requireToolMode(t, result, <-requests)
+		})
+	}
+}
+
+// redirectTransport sends every request to the target server, whatever the
+// endpoint of the client.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	r.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
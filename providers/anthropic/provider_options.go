@@ -12,6 +12,8 @@ const (
 	TypeProviderOptions         = Name + ".options"
 	TypeReasoningOptionMetadata = Name + ".reasoning_metadata"
 	TypeProviderCacheControl    = Name + ".cache_control_options"
+	TypeWebSearchResultMetadata = Name + ".web_search_result_metadata"
+	TypeResponseMetadata        = Name + ".response_metadata"
 )
 
 // Register Anthropic provider-specific types with the global registry.
@@ -37,6 +39,20 @@ func init() {
 		}
 		return &v, nil
 	})
+	fantasy.RegisterProviderType(TypeWebSearchResultMetadata, func(data []byte) (fantasy.ProviderOptionsData, error) {
+		var v WebSearchResultMetadata
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
+	fantasy.RegisterProviderType(TypeResponseMetadata, func(data []byte) (fantasy.ProviderOptionsData, error) {
+		var v ResponseMetadata
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
 }
 
 // ProviderOptions represents additional options for the Anthropic provider.
@@ -122,6 +138,67 @@ func (o *ProviderCacheControlOptions) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// WebSearchResultMetadata holds the raw web search results of a provider
+// executed web search, they are sent back to Anthropic in follow-up requests.
+type WebSearchResultMetadata struct {
+	Results   []WebSearchResult `json:"results"`
+	ErrorCode string            `json:"error_code,omitempty"`
+}
+
+// WebSearchResult represents a single Anthropic web search result.
+type WebSearchResult struct {
+	URL              string `json:"url"`
+	Title            string `json:"title"`
+	EncryptedContent string `json:"encrypted_content"`
+	PageAge          string `json:"page_age,omitempty"`
+}
+
+// Options implements the ProviderOptions interface.
+func (*WebSearchResultMetadata) Options() {}
+
+// MarshalJSON implements custom JSON marshaling with type info for WebSearchResultMetadata.
+func (m WebSearchResultMetadata) MarshalJSON() ([]byte, error) {
+	type plain WebSearchResultMetadata
+	return fantasy.MarshalProviderType(TypeWebSearchResultMetadata, plain(m))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling with type info for WebSearchResultMetadata.
+func (m *WebSearchResultMetadata) UnmarshalJSON(data []byte) error {
+	type plain WebSearchResultMetadata
+	var p plain
+	if err := fantasy.UnmarshalProviderType(data, &p); err != nil {
+		return err
+	}
+	*m = WebSearchResultMetadata(p)
+	return nil
+}
+
+// ResponseMetadata represents the response metadata of the Anthropic provider.
+type ResponseMetadata struct {
+	// StopSequence is the stop sequence that ended the generation.
+	StopSequence string `json:"stop_sequence"`
+}
+
+// Options implements the ProviderOptions interface.
+func (*ResponseMetadata) Options() {}
+
+// MarshalJSON implements custom JSON marshaling with type info for ResponseMetadata.
+func (m ResponseMetadata) MarshalJSON() ([]byte, error) {
+	type plain ResponseMetadata
+	return fantasy.MarshalProviderType(TypeResponseMetadata, plain(m))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling with type info for ResponseMetadata.
+func (m *ResponseMetadata) UnmarshalJSON(data []byte) error {
+	type plain ResponseMetadata
+	var p plain
+	if err := fantasy.UnmarshalProviderType(data, &p); err != nil {
+		return err
+	}
+	*m = ResponseMetadata(p)
+	return nil
+}
+
 // CacheControl represents cache control settings for the Anthropic provider.
 type CacheControl struct {
 	Type string `json:"type"`
@@ -0,0 +1,169 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/anthropic-sdk-go"
+	"github.com/charmbracelet/anthropic-sdk-go/packages/param"
+	"github.com/google/uuid"
+)
+
+// WebSearchToolID is the ID of the Anthropic web search provider-defined tool.
+const WebSearchToolID = Name + ".web_search_20250305"
+
+// WebSearchToolOptions configures the Anthropic web search tool.
+type WebSearchToolOptions struct {
+	// MaxUses limits the number of searches per request.
+	MaxUses *int64 `json:"max_uses,omitempty"`
+	// AllowedDomains restricts the search to the given domains, it can not be
+	// combined with BlockedDomains.
+	AllowedDomains []string               `json:"allowed_domains,omitempty"`
+	BlockedDomains []string               `json:"blocked_domains,omitempty"`
+	UserLocation   *WebSearchUserLocation `json:"user_location,omitempty"`
+}
+
+// WebSearchUserLocation is the approximate location of the user.
+type WebSearchUserLocation struct {
+	City     string `json:"city,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// WebSearchTool creates the Anthropic web search tool. The searches are run
+// by Anthropic and the results are returned as provider executed tool calls
+// and results, along with the sources cited by the model.
+func WebSearchTool(opts *WebSearchToolOptions) fantasy.ProviderDefinedTool {
+	args := map[string]any{}
+	if data, err := json.Marshal(opts); err == nil {
+		_ = json.Unmarshal(data, &args)
+	}
+	return fantasy.ProviderDefinedTool{
+		ID:   WebSearchToolID,
+		Name: "web_search",
+		Args: args,
+	}
+}
+
+// GetWebSearchResultMetadata extracts web search result metadata from provider options.
+func GetWebSearchResultMetadata(providerOptions fantasy.ProviderOptions) *WebSearchResultMetadata {
+	if anthropicOptions, ok := providerOptions[Name]; ok {
+		if metadata, ok := anthropicOptions.(*WebSearchResultMetadata); ok {
+			return metadata
+		}
+	}
+	return nil
+}
+
+func toWebSearchTool(tool fantasy.ProviderDefinedTool) (*anthropic.WebSearchTool20250305Param, error) {
+	var opts WebSearchToolOptions
+	if err := fantasy.ParseOptions(tool.Args, &opts); err != nil {
+		return nil, err
+	}
+	webSearch := &anthropic.WebSearchTool20250305Param{
+		AllowedDomains: opts.AllowedDomains,
+		BlockedDomains: opts.BlockedDomains,
+	}
+	if opts.MaxUses != nil {
+		webSearch.MaxUses = param.NewOpt(*opts.MaxUses)
+	}
+	if loc := opts.UserLocation; loc != nil {
+		webSearch.UserLocation = anthropic.WebSearchTool20250305UserLocationParam{
+			City:     optString(loc.City),
+			Region:   optString(loc.Region),
+			Country:  optString(loc.Country),
+			Timezone: optString(loc.Timezone),
+		}
+	}
+	return webSearch, nil
+}
+
+func optString(s string) param.Opt[string] {
+	if s == "" {
+		return param.Opt[string]{}
+	}
+	return param.NewOpt(s)
+}
+
+type webSearchResultOutput struct {
+	URL     string `json:"url"`
+	Title   string `json:"title"`
+	PageAge string `json:"page_age,omitempty"`
+}
+
+// webSearchResultContent converts a web search tool result block into a
+// provider executed tool result and the sources it found.
+func webSearchResultContent(block anthropic.ContentBlockUnion) (fantasy.ToolResultContent, []fantasy.SourceContent) {
+	metadata := &WebSearchResultMetadata{
+		ErrorCode: string(block.Content.ErrorCode),
+	}
+	toolResult := fantasy.ToolResultContent{
+		ToolCallID:       block.ToolUseID,
+		ToolName:         "web_search",
+		ProviderExecuted: true,
+		ProviderMetadata: fantasy.ProviderMetadata{
+			Name: metadata,
+		},
+	}
+	if metadata.ErrorCode != "" {
+		toolResult.Result = fantasy.ToolResultOutputContentError{
+			Error: errors.New(metadata.ErrorCode),
+		}
+		return toolResult, nil
+	}
+
+	var sources []fantasy.SourceContent
+	output := make([]webSearchResultOutput, 0, len(block.Content.OfWebSearchResultBlockArray))
+	for _, result := range block.Content.OfWebSearchResultBlockArray {
+		metadata.Results = append(metadata.Results, WebSearchResult{
+			URL:              result.URL,
+			Title:            result.Title,
+			EncryptedContent: result.EncryptedContent,
+			PageAge:          result.PageAge,
+		})
+		output = append(output, webSearchResultOutput{
+			URL:     result.URL,
+			Title:   result.Title,
+			PageAge: result.PageAge,
+		})
+		sources = append(sources, fantasy.SourceContent{
+			SourceType: fantasy.SourceTypeURL,
+			ID:         uuid.NewString(),
+			URL:        result.URL,
+			Title:      result.Title,
+		})
+	}
+	data, _ := json.Marshal(output)
+	toolResult.Result = fantasy.ToolResultOutputContentText{Text: string(data)}
+	return toolResult, sources
+}
+
+// toWebSearchToolResultBlock converts a provider executed web search result
+// back into the block Anthropic expects in the conversation.
+func toWebSearchToolResultBlock(result fantasy.ToolResultPart) (anthropic.ContentBlockParamUnion, bool) {
+	metadata := GetWebSearchResultMetadata(result.ProviderOptions)
+	if metadata == nil {
+		return anthropic.ContentBlockParamUnion{}, false
+	}
+	block := &anthropic.WebSearchToolResultBlockParam{
+		ToolUseID: result.ToolCallID,
+	}
+	if metadata.ErrorCode != "" {
+		block.Content.OfRequestWebSearchToolResultError = &anthropic.WebSearchToolRequestErrorParam{
+			ErrorCode: anthropic.WebSearchToolRequestErrorErrorCode(metadata.ErrorCode),
+		}
+	} else {
+		block.Content.OfWebSearchToolResultBlockItem = make([]anthropic.WebSearchResultBlockParam, 0, len(metadata.Results))
+		for _, r := range metadata.Results {
+			block.Content.OfWebSearchToolResultBlockItem = append(block.Content.OfWebSearchToolResultBlockItem, anthropic.WebSearchResultBlockParam{
+				URL:              r.URL,
+				Title:            r.Title,
+				EncryptedContent: r.EncryptedContent,
+				PageAge:          optString(r.PageAge),
+			})
+		}
+	}
+	return anthropic.ContentBlockParamUnion{OfWebSearchToolResult: block}, true
+}
@@ -35,6 +35,27 @@ const (
 // * resource-id.openai.azure.com.
 var azureURLPattern = regexp.MustCompile(`^(?:https?://)?([a-zA-Z0-9-]+)\.(?:openai|cognitiveservices|services\.ai)\.azure\.com(?:/.*)?$`)
 
+// Register the Azure provider with the factory registry.
+func init() {
+	fantasy.RegisterProviderFactory(Name, fantasy.ProviderFactory{
+		New: func(config fantasy.ProviderConfig) (fantasy.Provider, error) {
+			if config.BaseURL == "" {
+				return nil, &fantasy.Error{Title: "invalid config", Message: "the azure provider requires a base URL"}
+			}
+			return New(
+				WithBaseURL(config.BaseURL),
+				WithAPIKey(config.APIKey),
+				WithHeaders(config.Headers),
+			)
+		},
+		ParseOptions: func(data map[string]any) (fantasy.ProviderOptionsData, error) {
+			return openai.ParseOptions(data)
+		},
+		OptionsKey: openai.Name,
+		APIKeyEnv:  "AZURE_OPENAI_API_KEY",
+	})
+}
+
 // Option defines a function that configures Azure provider options.
 type Option = func(*options)
 
@@ -1,32 +1,80 @@
 // Package bedrock provides an implementation of the fantasy AI SDK for AWS Bedrock's language models.
+//
+// Anthropic models are called through the Anthropic Messages API that Bedrock
+// exposes, all other models (Llama, Mistral, Cohere, Nova, ...) are called
+// through the Bedrock Converse API.
 package bedrock
 
 import (
+	"cmp"
+	"context"
+	"os"
+	"strings"
+
 	"charm.land/fantasy"
 	"charm.land/fantasy/providers/anthropic"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	smithyauth "github.com/aws/smithy-go/auth"
+	"github.com/aws/smithy-go/auth/bearer"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/charmbracelet/anthropic-sdk-go/option"
 )
 
 type options struct {
+	apiKey           string
+	headers          map[string]string
+	client           option.HTTPClient
 	skipAuth         bool
+	useConverse      bool
+	objectMode       fantasy.ObjectMode
 	anthropicOptions []anthropic.Option
 }
 
+type provider struct {
+	options   options
+	anthropic fantasy.Provider
+}
+
 const (
 	// Name is the name of the Bedrock provider.
 	Name = "bedrock"
 )
 
+// Register the Bedrock provider with the factory registry.
+func init() {
+	fantasy.RegisterProviderFactory(Name, fantasy.ProviderFactory{
+		New: func(config fantasy.ProviderConfig) (fantasy.Provider, error) {
+			if config.BaseURL != "" {
+				return nil, &fantasy.Error{Title: "invalid config", Message: "the bedrock provider does not support a base URL"}
+			}
+			opts := []Option{WithHeaders(config.Headers)}
+			if config.APIKey != "" {
+				opts = append(opts, WithAPIKey(config.APIKey))
+			}
+			return New(opts...)
+		},
+		ParseOptions: func(data map[string]any) (fantasy.ProviderOptionsData, error) {
+			return ParseOptions(data)
+		},
+		APIKeyEnv: "AWS_BEARER_TOKEN_BEDROCK",
+	})
+}
+
 // Option defines a function that configures Bedrock provider options.
 type Option = func(*options)
 
 // New creates a new Bedrock provider with the given options.
 func New(opts ...Option) (fantasy.Provider, error) {
-	var o options
+	o := options{
+		headers:    map[string]string{},
+		objectMode: fantasy.ObjectModeAuto,
+	}
 	for _, opt := range opts {
 		opt(&o)
 	}
-	return anthropic.New(
+	anthropicProvider, err := anthropic.New(
 		append(
 			o.anthropicOptions,
 			anthropic.WithName(Name),
@@ -34,11 +82,16 @@ func New(opts ...Option) (fantasy.Provider, error) {
 			anthropic.WithSkipAuth(o.skipAuth),
 		)...,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &provider{options: o, anthropic: anthropicProvider}, nil
 }
 
 // WithAPIKey sets the access token for the Bedrock provider.
 func WithAPIKey(apiKey string) Option {
 	return func(o *options) {
+		o.apiKey = apiKey
 		o.anthropicOptions = append(o.anthropicOptions, anthropic.WithAPIKey(apiKey))
 	}
 }
@@ -46,6 +99,9 @@ func WithAPIKey(apiKey string) Option {
 // WithHeaders sets the headers for the Bedrock provider.
 func WithHeaders(headers map[string]string) Option {
 	return func(o *options) {
+		for k, v := range headers {
+			o.headers[k] = v
+		}
 		o.anthropicOptions = append(o.anthropicOptions, anthropic.WithHeaders(headers))
 	}
 }
@@ -53,6 +109,7 @@ func WithHeaders(headers map[string]string) Option {
 // WithHTTPClient sets the HTTP client for the Bedrock provider.
 func WithHTTPClient(client option.HTTPClient) Option {
 	return func(o *options) {
+		o.client = client
 		o.anthropicOptions = append(o.anthropicOptions, anthropic.WithHTTPClient(client))
 	}
 }
@@ -63,3 +120,91 @@ func WithSkipAuth(skipAuth bool) Option {
 		o.skipAuth = skipAuth
 	}
 }
+
+// WithConverse calls Anthropic models through the Converse API as well,
+// instead of the Anthropic Messages API.
+func WithConverse() Option {
+	return func(o *options) {
+		o.useConverse = true
+	}
+}
+
+// WithObjectMode sets the object generation mode.
+func WithObjectMode(om fantasy.ObjectMode) Option {
+	return func(o *options) {
+		o.objectMode = om
+		o.anthropicOptions = append(o.anthropicOptions, anthropic.WithObjectMode(om))
+	}
+}
+
+// Name implements fantasy.Provider.
+func (p *provider) Name() string {
+	return Name
+}
+
+// LanguageModel implements fantasy.Provider.
+func (p *provider) LanguageModel(ctx context.Context, modelID string) (fantasy.LanguageModel, error) {
+	if isAnthropicModel(modelID) && !p.options.useConverse {
+		return p.anthropic.LanguageModel(ctx, modelID)
+	}
+	client, err := p.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fantasy.WithCost(languageModel{
+		provider: Name,
+		modelID:  modelID,
+		client:   client,
+		options:  p.options,
+	}), nil
+}
+
+func (p *provider) newClient(ctx context.Context) (*bedrockruntime.Client, error) {
+	var cfg aws.Config
+	if p.options.skipAuth || p.options.apiKey != "" {
+		cfg = aws.Config{Region: region()}
+	} else {
+		var err error
+		cfg, err = config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, &fantasy.Error{Title: "invalid configuration", Message: "failed to load aws configuration", Cause: err}
+		}
+		cfg.Region = cmp.Or(cfg.Region, region())
+	}
+
+	return bedrockruntime.NewFromConfig(cfg, func(o *bedrockruntime.Options) {
+		// Retries are handled by fantasy.
+		o.Retryer = aws.NopRetryer{}
+		if p.options.client != nil {
+			o.HTTPClient = p.options.client
+		}
+		switch {
+		case p.options.apiKey != "":
+			o.BearerAuthTokenProvider = bearer.StaticTokenProvider{Token: bearer.Token{Value: p.options.apiKey}}
+			o.AuthSchemePreference = []string{"httpBearerAuth"}
+		case p.options.skipAuth:
+			o.AuthSchemeResolver = anonymousAuthResolver{}
+		}
+		for k, v := range p.options.headers {
+			o.APIOptions = append(o.APIOptions, smithyhttp.SetHeaderValue(k, v))
+		}
+	}), nil
+}
+
+// anonymousAuthResolver sends requests unsigned, the client ignores anonymous
+// credentials otherwise.
+type anonymousAuthResolver struct{}
+
+func (anonymousAuthResolver) ResolveAuthSchemes(context.Context, *bedrockruntime.AuthResolverParameters) ([]*smithyauth.Option, error) {
+	return []*smithyauth.Option{{SchemeID: smithyauth.SchemeIDAnonymous}}, nil
+}
+
+// isAnthropicModel reports whether the model ID, or inference profile ID,
+// refers to an Anthropic model.
+func isAnthropicModel(modelID string) bool {
+	return strings.HasPrefix(modelID, "anthropic.") || strings.Contains(modelID, ".anthropic.")
+}
+
+func region() string {
+	return cmp.Or(os.Getenv("AWS_REGION"), "us-east-1")
+}
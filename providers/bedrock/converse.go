@@ -0,0 +1,800 @@
+package bedrock
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/object"
+	"charm.land/fantasy/schema"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+// languageModel calls models through the Bedrock Converse API.
+type languageModel struct {
+	provider string
+	modelID  string
+	client   *bedrockruntime.Client
+	options  options
+}
+
+// Model implements fantasy.LanguageModel.
+func (m languageModel) Model() string {
+	return m.modelID
+}
+
+// Provider implements fantasy.LanguageModel.
+func (m languageModel) Provider() string {
+	return m.provider
+}
+
+// modelInfo returns the catalog entry of the model. Models of a vendor, e.g.
+// "us.anthropic.claude-sonnet-4-20250514-v1:0", are also looked up in the
+// catalog of the vendor without their region and vendor prefixes.
+func (m languageModel) modelInfo() (fantasy.ModelInfo, bool) {
+	if info, ok := fantasy.LookupModelInfo(m.provider, m.modelID); ok {
+		return info, true
+	}
+	for _, vendor := range []string{"anthropic", "openai"} {
+		if _, modelID, ok := strings.Cut(m.modelID, vendor+"."); ok {
+			return fantasy.LookupModelInfo(vendor, modelID)
+		}
+	}
+	return fantasy.ModelInfo{}, false
+}
+
+func (m languageModel) prepareParams(call fantasy.Call) (*bedrockruntime.ConverseInput, []fantasy.CallWarning, error) {
+	providerOptions := &ProviderOptions{}
+	if v, ok := call.ProviderOptions[Name]; ok {
+		providerOptions, ok = v.(*ProviderOptions)
+		if !ok {
+			return nil, nil, &fantasy.Error{Title: "invalid argument", Message: "bedrock provider options should be *bedrock.ProviderOptions"}
+		}
+	}
+	var warnings []fantasy.CallWarning
+	if info, ok := m.modelInfo(); ok {
+		call, warnings = fantasy.ApplyModelInfo(info, call)
+	}
+	sendReasoning := true
+	if providerOptions.SendReasoning != nil {
+		sendReasoning = *providerOptions.SendReasoning
+	}
+	system, messages, promptWarnings, err := toPrompt(call.Prompt, sendReasoning)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, promptWarnings...)
+
+	if call.FrequencyPenalty != nil {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "FrequencyPenalty",
+		})
+	}
+	if call.PresencePenalty != nil {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "PresencePenalty",
+		})
+	}
+	if call.Seed != nil {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "Seed",
+		})
+	}
+	if call.TopK != nil {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "TopK",
+			Details: "TopK can be set through AdditionalModelRequestFields for models that support it",
+		})
+	}
+
+	input := &bedrockruntime.ConverseInput{
+		ModelId:  aws.String(m.modelID),
+		System:   system,
+		Messages: messages,
+	}
+
+	var inferenceConfig types.InferenceConfiguration
+	hasInferenceConfig := false
+	if call.MaxOutputTokens != nil {
+		inferenceConfig.MaxTokens = aws.Int32(int32(*call.MaxOutputTokens))
+		hasInferenceConfig = true
+	}
+	if call.Temperature != nil {
+		inferenceConfig.Temperature = aws.Float32(float32(*call.Temperature))
+		hasInferenceConfig = true
+	}
+	if call.TopP != nil {
+		inferenceConfig.TopP = aws.Float32(float32(*call.TopP))
+		hasInferenceConfig = true
+	}
+	if len(call.StopSequences) > 0 {
+		inferenceConfig.StopSequences = call.StopSequences
+		hasInferenceConfig = true
+	}
+	if hasInferenceConfig {
+		input.InferenceConfig = &inferenceConfig
+	}
+
+	if len(providerOptions.AdditionalModelRequestFields) > 0 {
+		input.AdditionalModelRequestFields = document.NewLazyDocument(providerOptions.AdditionalModelRequestFields)
+	}
+
+	if len(call.Tools) > 0 {
+		toolConfig, toolWarnings := toTools(call.Tools, call.ToolChoice)
+		input.ToolConfig = toolConfig
+		warnings = append(warnings, toolWarnings...)
+	}
+
+	return input, warnings, nil
+}
+
+// converseSchemaDialect is the schema dialect of Converse tools. The models
+// served through Converse do not all resolve references so they are inlined.
+var converseSchemaDialect = schema.Dialect{Name: "bedrock", InlineRefs: true}
+
+func toTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice) (*types.ToolConfiguration, []fantasy.CallWarning) {
+	var warnings []fantasy.CallWarning
+	toolConfig := &types.ToolConfiguration{}
+	for _, tool := range tools {
+		ft, ok := tool.(fantasy.FunctionTool)
+		if !ok || tool.GetType() != fantasy.ToolTypeFunction {
+			warnings = append(warnings, fantasy.CallWarning{
+				Type:    fantasy.CallWarningTypeUnsupportedTool,
+				Tool:    tool,
+				Message: "tool is not supported",
+			})
+			continue
+		}
+
+		ft, schemaWarnings := fantasy.NormalizeTool(ft, converseSchemaDialect)
+		warnings = append(warnings, schemaWarnings...)
+		inputSchema := ft.InputSchema
+		// Bedrock rejects a null or empty list of required properties.
+		if required, ok := inputSchema["required"].([]string); !ok || len(required) == 0 {
+			delete(inputSchema, "required")
+		}
+		spec := types.ToolSpecification{
+			Name:        aws.String(ft.Name),
+			InputSchema: &types.ToolInputSchemaMemberJson{Value: document.NewLazyDocument(inputSchema)},
+		}
+		if ft.Description != "" {
+			spec.Description = aws.String(ft.Description)
+		}
+		toolConfig.Tools = append(toolConfig.Tools, &types.ToolMemberToolSpec{Value: spec})
+	}
+	if len(toolConfig.Tools) == 0 {
+		return nil, warnings
+	}
+
+	if toolChoice != nil {
+		switch *toolChoice {
+		case fantasy.ToolChoiceAuto:
+			toolConfig.ToolChoice = &types.ToolChoiceMemberAuto{}
+		case fantasy.ToolChoiceRequired:
+			toolConfig.ToolChoice = &types.ToolChoiceMemberAny{}
+		case fantasy.ToolChoiceNone:
+			// The Converse API cannot disable tools, they are still sent since
+			// the prompt may contain tool calls.
+			warnings = append(warnings, fantasy.CallWarning{
+				Type:    fantasy.CallWarningTypeUnsupportedSetting,
+				Setting: "ToolChoice",
+				Details: "the Converse API cannot disable tools, the model may still call them",
+			})
+		default:
+			toolConfig.ToolChoice = &types.ToolChoiceMemberTool{
+				Value: types.SpecificToolChoice{Name: aws.String(string(*toolChoice))},
+			}
+		}
+	}
+	return toolConfig, warnings
+}
+
+func toPrompt(prompt fantasy.Prompt, sendReasoning bool) ([]types.SystemContentBlock, []types.Message, []fantasy.CallWarning, error) {
+	var system []types.SystemContentBlock
+	var messages []types.Message
+	var warnings []fantasy.CallWarning
+	documents := 0
+
+	// Consecutive messages of the same role are merged, tool results are sent
+	// as user messages.
+	appendMessage := func(role types.ConversationRole, content []types.ContentBlock) {
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, content...)
+			return
+		}
+		messages = append(messages, types.Message{Role: role, Content: content})
+	}
+
+	for _, msg := range prompt {
+		switch msg.Role {
+		case fantasy.MessageRoleSystem:
+			for _, part := range msg.Content {
+				text, ok := fantasy.AsMessagePart[fantasy.TextPart](part)
+				if !ok || text.Text == "" {
+					continue
+				}
+				system = append(system, &types.SystemContentBlockMemberText{Value: text.Text})
+			}
+
+		case fantasy.MessageRoleUser:
+			var content []types.ContentBlock
+			for _, part := range msg.Content {
+				switch part.GetType() {
+				case fantasy.ContentTypeText:
+					text, ok := fantasy.AsMessagePart[fantasy.TextPart](part)
+					if !ok || text.Text == "" {
+						continue
+					}
+					content = append(content, &types.ContentBlockMemberText{Value: text.Text})
+				case fantasy.ContentTypeFile:
+					file, ok := fantasy.AsMessagePart[fantasy.FilePart](part)
+					if !ok {
+						continue
+					}
+					block, isDocument, ok := toFileBlock(file, documents)
+					if !ok {
+						warnings = append(warnings, fantasy.CallWarning{
+							Type:    fantasy.CallWarningTypeOther,
+							Message: fmt.Sprintf("file of type %s is not supported", file.MediaType),
+						})
+						continue
+					}
+					if isDocument {
+						documents++
+					}
+					content = append(content, block)
+				}
+			}
+			if len(content) == 0 {
+				warnings = append(warnings, fantasy.CallWarning{
+					Type:    fantasy.CallWarningTypeOther,
+					Message: "dropping empty user message (contains neither user-facing content nor tool results)",
+				})
+				continue
+			}
+			appendMessage(types.ConversationRoleUser, content)
+
+		case fantasy.MessageRoleTool:
+			var content []types.ContentBlock
+			for _, part := range msg.Content {
+				result, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](part)
+				if !ok {
+					continue
+				}
+				block, resultWarnings := toToolResultBlock(result)
+				warnings = append(warnings, resultWarnings...)
+				content = append(content, &types.ContentBlockMemberToolResult{Value: block})
+			}
+			if len(content) > 0 {
+				appendMessage(types.ConversationRoleUser, content)
+			}
+
+		case fantasy.MessageRoleAssistant:
+			var content []types.ContentBlock
+			for _, part := range msg.Content {
+				switch part.GetType() {
+				case fantasy.ContentTypeText:
+					text, ok := fantasy.AsMessagePart[fantasy.TextPart](part)
+					// Bedrock rejects blank text blocks.
+					if !ok || strings.TrimSpace(text.Text) == "" {
+						continue
+					}
+					content = append(content, &types.ContentBlockMemberText{Value: text.Text})
+				case fantasy.ContentTypeReasoning:
+					reasoning, ok := fantasy.AsMessagePart[fantasy.ReasoningPart](part)
+					if !ok {
+						continue
+					}
+					if !sendReasoning {
+						warnings = append(warnings, fantasy.CallWarning{
+							Type:    fantasy.CallWarningTypeOther,
+							Message: "sending reasoning content is disabled for this model",
+						})
+						continue
+					}
+					metadata := GetReasoningMetadata(part.Options())
+					switch {
+					case metadata != nil && metadata.Signature != "":
+						content = append(content, &types.ContentBlockMemberReasoningContent{
+							Value: &types.ReasoningContentBlockMemberReasoningText{
+								Value: types.ReasoningTextBlock{
+									Text:      aws.String(reasoning.Text),
+									Signature: aws.String(metadata.Signature),
+								},
+							},
+						})
+					case metadata != nil && len(metadata.RedactedData) > 0:
+						content = append(content, &types.ContentBlockMemberReasoningContent{
+							Value: &types.ReasoningContentBlockMemberRedactedContent{Value: metadata.RedactedData},
+						})
+					default:
+						warnings = append(warnings, fantasy.CallWarning{
+							Type:    fantasy.CallWarningTypeOther,
+							Message: "unsupported reasoning metadata",
+						})
+					}
+				case fantasy.ContentTypeToolCall:
+					toolCall, ok := fantasy.AsMessagePart[fantasy.ToolCallPart](part)
+					if !ok || toolCall.ProviderExecuted {
+						continue
+					}
+					// Dropping the call would leave its result without a call,
+					// which Bedrock rejects.
+					var input map[string]any
+					if err := json.Unmarshal([]byte(cmp.Or(toolCall.Input, "{}")), &input); err != nil {
+						return nil, nil, nil, &fantasy.Error{
+							Title:   "invalid argument",
+							Message: fmt.Sprintf("input of tool call %s is not a JSON object", toolCall.ToolCallID),
+							Cause:   err,
+						}
+					}
+					content = append(content, &types.ContentBlockMemberToolUse{
+						Value: types.ToolUseBlock{
+							ToolUseId: aws.String(toolCall.ToolCallID),
+							Name:      aws.String(toolCall.ToolName),
+							Input:     document.NewLazyDocument(input),
+						},
+					})
+				}
+			}
+			if len(content) == 0 {
+				warnings = append(warnings, fantasy.CallWarning{
+					Type:    fantasy.CallWarningTypeOther,
+					Message: "dropping empty assistant message (contains neither user-facing content nor tool calls)",
+				})
+				continue
+			}
+			appendMessage(types.ConversationRoleAssistant, content)
+		}
+	}
+	return system, messages, warnings, nil
+}
+
+func toToolResultBlock(result fantasy.ToolResultPart) (types.ToolResultBlock, []fantasy.CallWarning) {
+	var warnings []fantasy.CallWarning
+	block := types.ToolResultBlock{
+		ToolUseId: aws.String(result.ToolCallID),
+	}
+	switch result.Output.GetType() {
+	case fantasy.ToolResultContentTypeText:
+		content, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentText](result.Output)
+		if ok {
+			block.Content = append(block.Content, &types.ToolResultContentBlockMemberText{Value: content.Text})
+		}
+	case fantasy.ToolResultContentTypeMedia:
+		content, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentMedia](result.Output)
+		if !ok {
+			break
+		}
+		data, err := base64.StdEncoding.DecodeString(content.Data)
+		format, supported := imageFormats[content.MediaType]
+		if err == nil && supported {
+			block.Content = append(block.Content, &types.ToolResultContentBlockMemberImage{
+				Value: types.ImageBlock{
+					Format: format,
+					Source: &types.ImageSourceMemberBytes{Value: data},
+				},
+			})
+		} else {
+			warnings = append(warnings, fantasy.CallWarning{
+				Type:    fantasy.CallWarningTypeOther,
+				Message: fmt.Sprintf("tool result media of type %s is not supported", content.MediaType),
+			})
+		}
+		if content.Text != "" {
+			block.Content = append(block.Content, &types.ToolResultContentBlockMemberText{Value: content.Text})
+		}
+	case fantasy.ToolResultContentTypeError:
+		content, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentError](result.Output)
+		if ok {
+			block.Content = append(block.Content, &types.ToolResultContentBlockMemberText{Value: content.Error.Error()})
+			block.Status = types.ToolResultStatusError
+		}
+	}
+	// Bedrock requires tool results to have content.
+	if len(block.Content) == 0 {
+		block.Content = append(block.Content, &types.ToolResultContentBlockMemberText{Value: ""})
+	}
+	return block, warnings
+}
+
+var imageFormats = map[string]types.ImageFormat{
+	"image/png":  types.ImageFormatPng,
+	"image/jpeg": types.ImageFormatJpeg,
+	"image/jpg":  types.ImageFormatJpeg,
+	"image/gif":  types.ImageFormatGif,
+	"image/webp": types.ImageFormatWebp,
+}
+
+var documentFormats = map[string]types.DocumentFormat{
+	"application/pdf":    types.DocumentFormatPdf,
+	"text/csv":           types.DocumentFormatCsv,
+	"application/msword": types.DocumentFormatDoc,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": types.DocumentFormatDocx,
+	"application/vnd.ms-excel": types.DocumentFormatXls,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": types.DocumentFormatXlsx,
+	"text/html":     types.DocumentFormatHtml,
+	"text/plain":    types.DocumentFormatTxt,
+	"text/markdown": types.DocumentFormatMd,
+}
+
+// toFileBlock converts a file to an image or document block, index is the
+// number of documents before this one and is used to name unnamed documents.
+func toFileBlock(file fantasy.FilePart, index int) (block types.ContentBlock, isDocument bool, ok bool) {
+	mediaType, _, _ := strings.Cut(file.MediaType, ";")
+	if format, ok := imageFormats[mediaType]; ok {
+		return &types.ContentBlockMemberImage{
+			Value: types.ImageBlock{
+				Format: format,
+				Source: &types.ImageSourceMemberBytes{Value: file.Data},
+			},
+		}, false, true
+	}
+	if format, ok := documentFormats[mediaType]; ok {
+		return &types.ContentBlockMemberDocument{
+			Value: types.DocumentBlock{
+				Format: format,
+				Name:   aws.String(documentName(file.Filename, index)),
+				Source: &types.DocumentSourceMemberBytes{Value: file.Data},
+			},
+		}, true, true
+	}
+	return nil, false, false
+}
+
+// documentName returns a document name that Bedrock accepts, which only allows
+// alphanumeric characters, whitespace, hyphens, parentheses and square
+// brackets.
+func documentName(filename string, index int) string {
+	if i := strings.LastIndex(filename, "."); i > 0 {
+		filename = filename[:i]
+	}
+	var name strings.Builder
+	for _, r := range filename {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '-', r == '(', r == ')', r == '[', r == ']':
+			name.WriteRune(r)
+		case r == ' ' || r == '_' || r == '.':
+			name.WriteRune(' ')
+		}
+	}
+	return cmp.Or(strings.Join(strings.Fields(name.String()), " "), fmt.Sprintf("document-%d", index+1))
+}
+
+func mapFinishReason(stopReason types.StopReason) fantasy.FinishReason {
+	switch stopReason {
+	case types.StopReasonEndTurn, types.StopReasonStopSequence:
+		return fantasy.FinishReasonStop
+	case types.StopReasonMaxTokens:
+		return fantasy.FinishReasonLength
+	case types.StopReasonToolUse:
+		return fantasy.FinishReasonToolCalls
+	case types.StopReasonGuardrailIntervened, types.StopReasonContentFiltered:
+		return fantasy.FinishReasonContentFilter
+	default:
+		return fantasy.FinishReasonUnknown
+	}
+}
+
+func mapUsage(usage *types.TokenUsage) fantasy.Usage {
+	if usage == nil {
+		return fantasy.Usage{}
+	}
+	return fantasy.Usage{
+		InputTokens:         int64(aws.ToInt32(usage.InputTokens)),
+		OutputTokens:        int64(aws.ToInt32(usage.OutputTokens)),
+		TotalTokens:         int64(aws.ToInt32(usage.TotalTokens)),
+		CacheCreationTokens: int64(aws.ToInt32(usage.CacheWriteInputTokens)),
+		CacheReadTokens:     int64(aws.ToInt32(usage.CacheReadInputTokens)),
+	}
+}
+
+func reasoningMetadata(signature string, redactedData []byte) fantasy.ProviderMetadata {
+	if signature == "" && len(redactedData) == 0 {
+		return nil
+	}
+	return fantasy.ProviderMetadata{
+		Name: &ReasoningMetadata{
+			Signature:    signature,
+			RedactedData: redactedData,
+		},
+	}
+}
+
+func toolInput(input document.Interface) (string, error) {
+	if input == nil {
+		return "{}", nil
+	}
+	data, err := input.MarshalSmithyDocument()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Generate implements fantasy.LanguageModel.
+func (m languageModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	input, warnings, err := m.prepareParams(call)
+	if err != nil {
+		return nil, err
+	}
+	response, err := m.client.Converse(ctx, input)
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+
+	var content []fantasy.Content
+	if message, ok := response.Output.(*types.ConverseOutputMemberMessage); ok {
+		for _, block := range message.Value.Content {
+			switch block := block.(type) {
+			case *types.ContentBlockMemberText:
+				content = append(content, fantasy.TextContent{
+					Text: block.Value,
+				})
+			case *types.ContentBlockMemberReasoningContent:
+				switch reasoning := block.Value.(type) {
+				case *types.ReasoningContentBlockMemberReasoningText:
+					content = append(content, fantasy.ReasoningContent{
+						Text:             aws.ToString(reasoning.Value.Text),
+						ProviderMetadata: reasoningMetadata(aws.ToString(reasoning.Value.Signature), nil),
+					})
+				case *types.ReasoningContentBlockMemberRedactedContent:
+					content = append(content, fantasy.ReasoningContent{
+						ProviderMetadata: reasoningMetadata("", reasoning.Value),
+					})
+				}
+			case *types.ContentBlockMemberToolUse:
+				toolInput, err := toolInput(block.Value.Input)
+				if err != nil {
+					return nil, &fantasy.Error{Title: "invalid response", Message: "failed to decode tool input", Cause: err}
+				}
+				content = append(content, fantasy.ToolCallContent{
+					ToolCallID: aws.ToString(block.Value.ToolUseId),
+					ToolName:   aws.ToString(block.Value.Name),
+					Input:      toolInput,
+				})
+			}
+		}
+	}
+
+	return &fantasy.Response{
+		Content:      content,
+		Usage:        mapUsage(response.Usage),
+		FinishReason: mapFinishReason(response.StopReason),
+		Warnings:     warnings,
+	}, nil
+}
+
+type streamBlockType int
+
+const (
+	streamBlockText streamBlockType = iota
+	streamBlockReasoning
+	streamBlockToolUse
+)
+
+// streamBlock is a content block that is being streamed.
+type streamBlock struct {
+	typ          streamBlockType
+	id           string
+	name         string
+	input        strings.Builder
+	signature    string
+	redactedData []byte
+}
+
+// Stream implements fantasy.LanguageModel.
+func (m languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	input, warnings, err := m.prepareParams(call)
+	if err != nil {
+		return nil, err
+	}
+	output, err := m.client.ConverseStream(ctx, &bedrockruntime.ConverseStreamInput{
+		ModelId:                      input.ModelId,
+		System:                       input.System,
+		Messages:                     input.Messages,
+		InferenceConfig:              input.InferenceConfig,
+		ToolConfig:                   input.ToolConfig,
+		AdditionalModelRequestFields: input.AdditionalModelRequestFields,
+	})
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+	stream := output.GetStream()
+
+	return func(yield func(fantasy.StreamPart) bool) {
+		defer stream.Close() //nolint: errcheck
+
+		if len(warnings) > 0 {
+			if !yield(fantasy.StreamPart{
+				Type:     fantasy.StreamPartTypeWarnings,
+				Warnings: warnings,
+			}) {
+				return
+			}
+		}
+
+		// Text and reasoning blocks have no start event, they start with
+		// their first delta.
+		blocks := map[int32]*streamBlock{}
+		startBlock := func(index int32, typ streamBlockType) (*streamBlock, bool) {
+			if block, ok := blocks[index]; ok {
+				return block, true
+			}
+			block := &streamBlock{typ: typ, id: fmt.Sprintf("%d", index)}
+			blocks[index] = block
+			partType := fantasy.StreamPartTypeTextStart
+			if typ == streamBlockReasoning {
+				partType = fantasy.StreamPartTypeReasoningStart
+			}
+			return block, yield(fantasy.StreamPart{
+				Type: partType,
+				ID:   block.id,
+			})
+		}
+
+		finishReason := fantasy.FinishReasonUnknown
+		var usage fantasy.Usage
+		for event := range stream.Events() {
+			switch event := event.(type) {
+			case *types.ConverseStreamOutputMemberContentBlockStart:
+				start, ok := event.Value.Start.(*types.ContentBlockStartMemberToolUse)
+				if !ok {
+					continue
+				}
+				block := &streamBlock{
+					typ:  streamBlockToolUse,
+					id:   aws.ToString(start.Value.ToolUseId),
+					name: aws.ToString(start.Value.Name),
+				}
+				blocks[aws.ToInt32(event.Value.ContentBlockIndex)] = block
+				if !yield(fantasy.StreamPart{
+					Type:         fantasy.StreamPartTypeToolInputStart,
+					ID:           block.id,
+					ToolCallName: block.name,
+				}) {
+					return
+				}
+			case *types.ConverseStreamOutputMemberContentBlockDelta:
+				index := aws.ToInt32(event.Value.ContentBlockIndex)
+				switch delta := event.Value.Delta.(type) {
+				case *types.ContentBlockDeltaMemberText:
+					block, ok := startBlock(index, streamBlockText)
+					if !ok {
+						return
+					}
+					if !yield(fantasy.StreamPart{
+						Type:  fantasy.StreamPartTypeTextDelta,
+						ID:    block.id,
+						Delta: delta.Value,
+					}) {
+						return
+					}
+				case *types.ContentBlockDeltaMemberReasoningContent:
+					block, ok := startBlock(index, streamBlockReasoning)
+					if !ok {
+						return
+					}
+					// The signature and redacted content are sent with the end
+					// of the block.
+					switch reasoning := delta.Value.(type) {
+					case *types.ReasoningContentBlockDeltaMemberText:
+						if !yield(fantasy.StreamPart{
+							Type:  fantasy.StreamPartTypeReasoningDelta,
+							ID:    block.id,
+							Delta: reasoning.Value,
+						}) {
+							return
+						}
+					case *types.ReasoningContentBlockDeltaMemberSignature:
+						block.signature += reasoning.Value
+					case *types.ReasoningContentBlockDeltaMemberRedactedContent:
+						block.redactedData = append(block.redactedData, reasoning.Value...)
+					}
+				case *types.ContentBlockDeltaMemberToolUse:
+					block, ok := blocks[index]
+					if !ok || block.typ != streamBlockToolUse {
+						continue
+					}
+					inputDelta := aws.ToString(delta.Value.Input)
+					block.input.WriteString(inputDelta)
+					if !yield(fantasy.StreamPart{
+						Type:          fantasy.StreamPartTypeToolInputDelta,
+						ID:            block.id,
+						ToolCallInput: inputDelta,
+					}) {
+						return
+					}
+				}
+			case *types.ConverseStreamOutputMemberContentBlockStop:
+				index := aws.ToInt32(event.Value.ContentBlockIndex)
+				block, ok := blocks[index]
+				if !ok {
+					continue
+				}
+				delete(blocks, index)
+				switch block.typ {
+				case streamBlockText:
+					if !yield(fantasy.StreamPart{
+						Type: fantasy.StreamPartTypeTextEnd,
+						ID:   block.id,
+					}) {
+						return
+					}
+				case streamBlockReasoning:
+					if !yield(fantasy.StreamPart{
+						Type:             fantasy.StreamPartTypeReasoningEnd,
+						ID:               block.id,
+						ProviderMetadata: reasoningMetadata(block.signature, block.redactedData),
+					}) {
+						return
+					}
+				case streamBlockToolUse:
+					if !yield(fantasy.StreamPart{
+						Type: fantasy.StreamPartTypeToolInputEnd,
+						ID:   block.id,
+					}) {
+						return
+					}
+					if !yield(fantasy.StreamPart{
+						Type:          fantasy.StreamPartTypeToolCall,
+						ID:            block.id,
+						ToolCallName:  block.name,
+						ToolCallInput: cmp.Or(block.input.String(), "{}"),
+					}) {
+						return
+					}
+				}
+			case *types.ConverseStreamOutputMemberMessageStop:
+				finishReason = mapFinishReason(event.Value.StopReason)
+			case *types.ConverseStreamOutputMemberMetadata:
+				usage = mapUsage(event.Value.Usage)
+			}
+		}
+
+		if err := stream.Err(); err != nil {
+			yield(fantasy.StreamPart{
+				Type:  fantasy.StreamPartTypeError,
+				Error: toProviderErr(err),
+			})
+			return
+		}
+		yield(fantasy.StreamPart{
+			Type:         fantasy.StreamPartTypeFinish,
+			FinishReason: finishReason,
+			Usage:        usage,
+		})
+	}, nil
+}
+
+// GenerateObject implements fantasy.LanguageModel.
+func (m languageModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	switch m.options.objectMode {
+	case fantasy.ObjectModeText:
+		return object.GenerateWithText(ctx, m, call)
+	default:
+		return object.GenerateWithTool(ctx, m, call)
+	}
+}
+
+// StreamObject implements fantasy.LanguageModel.
+func (m languageModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	switch m.options.objectMode {
+	case fantasy.ObjectModeText:
+		return object.StreamWithText(ctx, m, call)
+	default:
+		return object.StreamWithTool(ctx, m, call)
+	}
+}
@@ -0,0 +1,175 @@
+package bedrock
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToPrompt(t *testing.T) {
+	t.Parallel()
+
+	t.Run("merges messages of the same role", func(t *testing.T) {
+		t.Parallel()
+
+		system, messages, warnings, err := toPrompt(fantasy.Prompt{
+			fantasy.NewSystemMessage("Be brief."),
+			fantasy.NewUserMessage("What is the weather?"),
+			{
+				Role: fantasy.MessageRoleAssistant,
+				Content: []fantasy.MessagePart{
+					fantasy.TextPart{Text: " "},
+					fantasy.ToolCallPart{ToolCallID: "call-1", ToolName: "weather", Input: `{"city":"Paris"}`},
+				},
+			},
+			{
+				Role: fantasy.MessageRoleTool,
+				Content: []fantasy.MessagePart{
+					fantasy.ToolResultPart{ToolCallID: "call-1", Output: fantasy.ToolResultOutputContentText{Text: "Sunny"}},
+				},
+			},
+			fantasy.NewUserMessage("And tomorrow?"),
+		}, false)
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+		require.Equal(t, []types.SystemContentBlock{&types.SystemContentBlockMemberText{Value: "Be brief."}}, system)
+
+		require.Len(t, messages, 3)
+		require.Equal(t, types.ConversationRoleUser, messages[0].Role)
+		require.Equal(t, types.ConversationRoleAssistant, messages[1].Role)
+		// The blank text is dropped.
+		require.Len(t, messages[1].Content, 1)
+		toolUse := messages[1].Content[0].(*types.ContentBlockMemberToolUse).Value
+		require.Equal(t, "call-1", *toolUse.ToolUseId)
+		require.Equal(t, "weather", *toolUse.Name)
+		// The tool result and the next user message form a single user message.
+		require.Equal(t, types.ConversationRoleUser, messages[2].Role)
+		require.Len(t, messages[2].Content, 2)
+		require.IsType(t, &types.ContentBlockMemberToolResult{}, messages[2].Content[0])
+		require.Equal(t, &types.ContentBlockMemberText{Value: "And tomorrow?"}, messages[2].Content[1])
+	})
+
+	t.Run("rejects invalid tool inputs", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, _, err := toPrompt(fantasy.Prompt{
+			fantasy.NewUserMessage("What is the weather?"),
+			{
+				Role: fantasy.MessageRoleAssistant,
+				Content: []fantasy.MessagePart{
+					fantasy.ToolCallPart{ToolCallID: "call-1", ToolName: "weather", Input: `{"city":`},
+				},
+			},
+		}, false)
+		var fantasyErr *fantasy.Error
+		require.ErrorAs(t, err, &fantasyErr)
+		require.Equal(t, "invalid argument", fantasyErr.Title)
+		require.Contains(t, fantasyErr.Message, "call-1")
+	})
+
+	t.Run("warns about unsupported content", func(t *testing.T) {
+		t.Parallel()
+
+		_, messages, warnings, err := toPrompt(fantasy.Prompt{
+			{
+				Role: fantasy.MessageRoleUser,
+				Content: []fantasy.MessagePart{
+					fantasy.TextPart{Text: "Listen to this"},
+					fantasy.FilePart{MediaType: "audio/wav", Data: []byte("wav")},
+				},
+			},
+			{
+				Role:    fantasy.MessageRoleAssistant,
+				Content: []fantasy.MessagePart{fantasy.ReasoningPart{Text: "Thinking"}},
+			},
+		}, false)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.Len(t, warnings, 3)
+		require.Equal(t, "file of type audio/wav is not supported", warnings[0].Message)
+		require.Equal(t, "sending reasoning content is disabled for this model", warnings[1].Message)
+		require.Contains(t, warnings[2].Message, "dropping empty assistant message")
+	})
+}
+
+func TestToTools(t *testing.T) {
+	t.Parallel()
+
+	weather := fantasy.FunctionTool{
+		Name:        "weather",
+		Description: "Get the weather",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+			"required":   []string{},
+		},
+	}
+
+	t.Run("converts function tools", func(t *testing.T) {
+		t.Parallel()
+
+		toolConfig, warnings := toTools([]fantasy.Tool{weather}, fantasy.Opt(fantasy.ToolChoiceRequired))
+		require.Empty(t, warnings)
+		require.Len(t, toolConfig.Tools, 1)
+		spec := toolConfig.Tools[0].(*types.ToolMemberToolSpec).Value
+		require.Equal(t, "weather", *spec.Name)
+		require.Equal(t, "Get the weather", *spec.Description)
+		require.Equal(t, &types.ToolChoiceMemberAny{}, toolConfig.ToolChoice)
+	})
+
+	t.Run("selects a specific tool", func(t *testing.T) {
+		t.Parallel()
+
+		choice := fantasy.ToolChoice("weather")
+		toolConfig, warnings := toTools([]fantasy.Tool{weather}, &choice)
+		require.Empty(t, warnings)
+		require.Equal(t, "weather", *toolConfig.ToolChoice.(*types.ToolChoiceMemberTool).Value.Name)
+	})
+
+	t.Run("warns that tools cannot be disabled", func(t *testing.T) {
+		t.Parallel()
+
+		toolConfig, warnings := toTools([]fantasy.Tool{weather}, fantasy.Opt(fantasy.ToolChoiceNone))
+		require.Len(t, toolConfig.Tools, 1)
+		require.Nil(t, toolConfig.ToolChoice)
+		require.Len(t, warnings, 1)
+		require.Equal(t, fantasy.CallWarningTypeUnsupportedSetting, warnings[0].Type)
+		require.Equal(t, "ToolChoice", warnings[0].Setting)
+	})
+
+	t.Run("warns about provider defined tools", func(t *testing.T) {
+		t.Parallel()
+
+		toolConfig, warnings := toTools([]fantasy.Tool{fantasy.ProviderDefinedTool{ID: "web_search", Name: "web_search"}}, nil)
+		require.Nil(t, toolConfig)
+		require.Len(t, warnings, 1)
+		require.Equal(t, fantasy.CallWarningTypeUnsupportedTool, warnings[0].Type)
+	})
+}
+
+func TestToProviderErr(t *testing.T) {
+	t.Parallel()
+
+	t.Run("maps stream exceptions to status codes", func(t *testing.T) {
+		t.Parallel()
+
+		err := toProviderErr(&smithy.GenericAPIError{Code: "ThrottlingException", Message: "Too many requests"})
+		var providerErr *fantasy.ProviderError
+		require.ErrorAs(t, err, &providerErr)
+		require.Equal(t, http.StatusTooManyRequests, providerErr.StatusCode)
+		require.Equal(t, "too many requests", providerErr.Title)
+		require.Equal(t, "Too many requests", providerErr.Message)
+	})
+
+	t.Run("keeps other errors", func(t *testing.T) {
+		t.Parallel()
+
+		err := errors.New("connection refused")
+		require.Equal(t, err, toProviderErr(err))
+	})
+}
@@ -0,0 +1,56 @@
+package bedrock
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"strings"
+
+	"charm.land/fantasy"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+)
+
+// exceptionStatusCodes maps the exceptions that Bedrock sends in the middle of
+// a stream, which have no HTTP response, to their status codes.
+var exceptionStatusCodes = map[string]int{
+	"ValidationException":         http.StatusBadRequest,
+	"ModelTimeoutException":       http.StatusRequestTimeout,
+	"ThrottlingException":         http.StatusTooManyRequests,
+	"InternalServerException":     http.StatusInternalServerError,
+	"ModelStreamErrorException":   http.StatusFailedDependency,
+	"ServiceUnavailableException": http.StatusServiceUnavailable,
+}
+
+func toProviderErr(err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	providerErr := &fantasy.ProviderError{
+		Message:    cmp.Or(apiErr.ErrorMessage(), apiErr.Error()),
+		Cause:      err,
+		StatusCode: exceptionStatusCodes[apiErr.ErrorCode()],
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		providerErr.StatusCode = respErr.HTTPStatusCode()
+		providerErr.ResponseHeaders = toHeaderMap(respErr.Response.Header)
+		if respErr.Response.Request != nil {
+			providerErr.URL = respErr.Response.Request.URL.String()
+		}
+	}
+	providerErr.Title = cmp.Or(fantasy.ErrorTitleForStatusCode(providerErr.StatusCode), "provider request failed")
+	return providerErr
+}
+
+func toHeaderMap(in http.Header) (out map[string]string) {
+	out = make(map[string]string, len(in))
+	for k, v := range in {
+		if l := len(v); l > 0 {
+			out[strings.ToLower(k)] = v[l-1]
+		}
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+package bedrock
+
+import (
+	"encoding/json"
+
+	"charm.land/fantasy"
+)
+
+// Global type identifiers for Bedrock-specific provider data.
+const (
+	TypeProviderOptions   = Name + ".options"
+	TypeReasoningMetadata = Name + ".reasoning_metadata"
+)
+
+// Register Bedrock provider-specific types with the global registry.
+func init() {
+	fantasy.RegisterProviderType(TypeProviderOptions, func(data []byte) (fantasy.ProviderOptionsData, error) {
+		var v ProviderOptions
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
+	fantasy.RegisterProviderType(TypeReasoningMetadata, func(data []byte) (fantasy.ProviderOptionsData, error) {
+		var v ReasoningMetadata
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
+}
+
+// ProviderOptions represents additional options for models called through the
+// Bedrock Converse API.
+type ProviderOptions struct {
+	// AdditionalModelRequestFields are model specific parameters that the
+	// Converse API does not support, e.g. {"top_k": 50} or the thinking
+	// configuration of Anthropic models.
+	AdditionalModelRequestFields map[string]any `json:"additional_model_request_fields"`
+	// SendReasoning controls whether reasoning content of previous assistant
+	// messages is sent back to the model, defaults to true.
+	SendReasoning *bool `json:"send_reasoning"`
+}
+
+// Options implements the ProviderOptions interface.
+func (o *ProviderOptions) Options() {}
+
+// MarshalJSON implements custom JSON marshaling with type info for ProviderOptions.
+func (o ProviderOptions) MarshalJSON() ([]byte, error) {
+	type plain ProviderOptions
+	return fantasy.MarshalProviderType(TypeProviderOptions, plain(o))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling with type info for ProviderOptions.
+func (o *ProviderOptions) UnmarshalJSON(data []byte) error {
+	type plain ProviderOptions
+	var p plain
+	if err := fantasy.UnmarshalProviderType(data, &p); err != nil {
+		return err
+	}
+	*o = ProviderOptions(p)
+	return nil
+}
+
+// ReasoningMetadata represents reasoning metadata returned by the Converse
+// API, it is needed to send the reasoning back to the model.
+type ReasoningMetadata struct {
+	Signature    string `json:"signature"`
+	RedactedData []byte `json:"redacted_data"`
+}
+
+// Options implements the ProviderOptions interface.
+func (*ReasoningMetadata) Options() {}
+
+// MarshalJSON implements custom JSON marshaling with type info for ReasoningMetadata.
+func (m ReasoningMetadata) MarshalJSON() ([]byte, error) {
+	type plain ReasoningMetadata
+	return fantasy.MarshalProviderType(TypeReasoningMetadata, plain(m))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling with type info for ReasoningMetadata.
+func (m *ReasoningMetadata) UnmarshalJSON(data []byte) error {
+	type plain ReasoningMetadata
+	var p plain
+	if err := fantasy.UnmarshalProviderType(data, &p); err != nil {
+		return err
+	}
+	*m = ReasoningMetadata(p)
+	return nil
+}
+
+// NewProviderOptions creates new provider options for Bedrock.
+func NewProviderOptions(opts *ProviderOptions) fantasy.ProviderOptions {
+	return fantasy.ProviderOptions{
+		Name: opts,
+	}
+}
+
+// ParseOptions parses provider options from a map for the Bedrock provider.
+func ParseOptions(data map[string]any) (*ProviderOptions, error) {
+	var options ProviderOptions
+	if err := fantasy.ParseOptions(data, &options); err != nil {
+		return nil, err
+	}
+	return &options, nil
+}
+
+// GetReasoningMetadata returns the Bedrock reasoning metadata of a part, if any.
+func GetReasoningMetadata(providerOptions fantasy.ProviderOptions) *ReasoningMetadata {
+	if v, ok := providerOptions[Name]; ok {
+		if metadata, ok := v.(*ReasoningMetadata); ok {
+			return metadata
+		}
+	}
+	return nil
+}
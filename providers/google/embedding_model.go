@@ -0,0 +1,135 @@
+package google
+
+import (
+	"context"
+	"strings"
+
+	"charm.land/fantasy"
+	"google.golang.org/genai"
+)
+
+type embeddingModel struct {
+	provider string
+	modelID  string
+	backend  genai.Backend
+	client   *genai.Client
+}
+
+// EmbeddingModel implements fantasy.EmbeddingProvider.
+func (a *provider) EmbeddingModel(ctx context.Context, modelID string) (fantasy.EmbeddingModel, error) {
+	client, err := a.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &embeddingModel{
+		provider: a.options.name,
+		modelID:  modelID,
+		backend:  a.options.backend,
+		client:   client,
+	}, nil
+}
+
+// Provider implements fantasy.EmbeddingModel.
+func (e *embeddingModel) Provider() string {
+	return e.provider
+}
+
+// Model implements fantasy.EmbeddingModel.
+func (e *embeddingModel) Model() string {
+	return e.modelID
+}
+
+// Dimensions implements fantasy.EmbeddingModel.
+func (e *embeddingModel) Dimensions() int64 {
+	switch {
+	case strings.HasPrefix(e.modelID, "gemini-embedding"):
+		return 3072
+	case strings.HasPrefix(e.modelID, "text-embedding"),
+		strings.HasPrefix(e.modelID, "text-multilingual-embedding"):
+		return 768
+	default:
+		return 0
+	}
+}
+
+// MaxEmbeddingsPerCall implements fantasy.EmbeddingModel.
+func (e *embeddingModel) MaxEmbeddingsPerCall() int {
+	if e.backend != genai.BackendVertexAI {
+		return 100
+	}
+	// Vertex AI only accepts a single input per request for Gemini embedding
+	// models.
+	if strings.HasPrefix(e.modelID, "gemini-embedding") {
+		return 1
+	}
+	return 250
+}
+
+// Embed implements fantasy.EmbeddingModel.
+func (e *embeddingModel) Embed(ctx context.Context, call fantasy.EmbedCall) (*fantasy.EmbedResponse, error) {
+	return fantasy.EmbedSingle(ctx, call, e.embedBatch)
+}
+
+// EmbedMany implements fantasy.EmbeddingModel.
+func (e *embeddingModel) EmbedMany(ctx context.Context, call fantasy.EmbedManyCall) (*fantasy.EmbedManyResponse, error) {
+	return fantasy.EmbedInBatches(ctx, call, e.MaxEmbeddingsPerCall(), e.embedBatch)
+}
+
+func (e *embeddingModel) prepareParams(call fantasy.EmbedManyCall) (*genai.EmbedContentConfig, []*genai.Content, error) {
+	config := &genai.EmbedContentConfig{}
+	if call.Dimensions != nil {
+		dimensions := int32(*call.Dimensions)
+		config.OutputDimensionality = &dimensions
+	}
+
+	if v, ok := call.ProviderOptions[Name]; ok {
+		providerOptions, ok := v.(*EmbeddingProviderOptions)
+		if !ok {
+			return nil, nil, &fantasy.Error{Title: "invalid argument", Message: "google embedding provider options should be *google.EmbeddingProviderOptions"}
+		}
+		if providerOptions.TaskType != nil {
+			config.TaskType = *providerOptions.TaskType
+		}
+		if providerOptions.Title != nil {
+			config.Title = *providerOptions.Title
+		}
+	}
+
+	contents := make([]*genai.Content, 0, len(call.Values))
+	for _, value := range call.Values {
+		contents = append(contents, genai.NewContentFromText(value, genai.RoleUser))
+	}
+	return config, contents, nil
+}
+
+func (e *embeddingModel) embedBatch(ctx context.Context, call fantasy.EmbedManyCall) (*fantasy.EmbedManyResponse, error) {
+	config, contents, err := e.prepareParams(call)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := e.client.Models.EmbedContent(ctx, e.modelID, contents, config)
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+
+	var usage fantasy.Usage
+	embeddings := make([]fantasy.Embedding, 0, len(response.Embeddings))
+	for _, embedding := range response.Embeddings {
+		values := make(fantasy.Embedding, len(embedding.Values))
+		for i, v := range embedding.Values {
+			values[i] = float64(v)
+		}
+		embeddings = append(embeddings, values)
+		// Token counts are only reported by Vertex AI.
+		if embedding.Statistics != nil {
+			usage.InputTokens += int64(embedding.Statistics.TokenCount)
+		}
+	}
+	usage.TotalTokens = usage.InputTokens
+
+	return &fantasy.EmbedManyResponse{
+		Embeddings: embeddings,
+		Usage:      usage,
+	}, nil
+}
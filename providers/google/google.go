@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"math"
 	"net/http"
 	"reflect"
 	"strings"
@@ -45,6 +46,26 @@ type options struct {
 	objectMode     fantasy.ObjectMode
 }
 
+// Register the Google provider with the factory registry.
+func init() {
+	fantasy.RegisterProviderFactory(Name, fantasy.ProviderFactory{
+		New: func(config fantasy.ProviderConfig) (fantasy.Provider, error) {
+			opts := []Option{WithHeaders(config.Headers)}
+			if config.APIKey != "" {
+				opts = append(opts, WithGeminiAPIKey(config.APIKey))
+			}
+			if config.BaseURL != "" {
+				opts = append(opts, WithBaseURL(config.BaseURL))
+			}
+			return New(opts...)
+		},
+		ParseOptions: func(data map[string]any) (fantasy.ProviderOptionsData, error) {
+			return ParseOptions(data)
+		},
+		APIKeyEnv: "GEMINI_API_KEY",
+	})
+}
+
 // Option defines a function that configures Google provider options.
 type Option = func(*options)
 
@@ -165,6 +186,26 @@ func (a *provider) LanguageModel(ctx context.Context, modelID string) (fantasy.L
 		return p.LanguageModel(ctx, modelID)
 	}
 
+	client, err := a.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	objectMode := a.options.objectMode
+	if objectMode == "" {
+		objectMode = fantasy.ObjectModeAuto
+	}
+
+	return fantasy.WithCost(&languageModel{
+		modelID:         modelID,
+		provider:        a.options.name,
+		providerOptions: a.options,
+		client:          client,
+		objectMode:      objectMode,
+	}), nil
+}
+
+func (a *provider) newClient(ctx context.Context) (*genai.Client, error) {
 	cc := &genai.ClientConfig{
 		HTTPClient: a.options.client,
 		Backend:    a.options.backend,
@@ -190,23 +231,7 @@ func (a *provider) LanguageModel(ctx context.Context, modelID string) (fantasy.L
 			Headers: headers,
 		}
 	}
-	client, err := genai.NewClient(ctx, cc)
-	if err != nil {
-		return nil, err
-	}
-
-	objectMode := a.options.objectMode
-	if objectMode == "" {
-		objectMode = fantasy.ObjectModeAuto
-	}
-
-	return &languageModel{
-		modelID:         modelID,
-		provider:        a.options.name,
-		providerOptions: a.options,
-		client:          client,
-		objectMode:      objectMode,
-	}, nil
+	return genai.NewClient(ctx, cc)
 }
 
 func (g languageModel) prepareParams(call fantasy.Call) (*genai.GenerateContentConfig, []*genai.Content, []fantasy.CallWarning, error) {
@@ -220,7 +245,12 @@ func (g languageModel) prepareParams(call fantasy.Call) (*genai.GenerateContentC
 		}
 	}
 
-	systemInstructions, content, warnings := toGooglePrompt(call.Prompt)
+	var warnings []fantasy.CallWarning
+	if info, ok := g.modelInfo(); ok {
+		call, warnings = fantasy.ApplyModelInfo(info, call)
+	}
+	systemInstructions, content, promptWarnings := toGooglePrompt(call.Prompt)
+	warnings = append(warnings, promptWarnings...)
 
 	if providerOptions.ThinkingConfig != nil {
 		if providerOptions.ThinkingConfig.IncludeThoughts != nil &&
@@ -288,6 +318,12 @@ func (g languageModel) prepareParams(call fantasy.Call) (*genai.GenerateContentC
 		tmp := float32(*call.PresencePenalty)
 		config.PresencePenalty = &tmp
 	}
+	if len(call.StopSequences) > 0 {
+		config.StopSequences = call.StopSequences
+	}
+	seed, seedWarnings := toSeed(call.Seed)
+	config.Seed = seed
+	warnings = append(warnings, seedWarnings...)
 
 	if providerOptions.ThinkingConfig != nil {
 		config.ThinkingConfig = &genai.ThinkingConfig{}
@@ -310,17 +346,38 @@ func (g languageModel) prepareParams(call fantasy.Call) (*genai.GenerateContentC
 	}
 
 	if len(call.Tools) > 0 {
-		tools, toolChoice, toolWarnings := toGoogleTools(call.Tools, call.ToolChoice)
-		config.ToolConfig = toolChoice
-		config.Tools = append(config.Tools, &genai.Tool{
-			FunctionDeclarations: tools,
-		})
+		tools, providerTools, toolChoice, toolWarnings := toGoogleTools(call.Tools, call.ToolChoice)
+		// Function calling config only applies when there are functions
+		if len(tools) > 0 {
+			config.ToolConfig = toolChoice
+			config.Tools = append(config.Tools, &genai.Tool{
+				FunctionDeclarations: tools,
+			})
+		}
+		config.Tools = append(config.Tools, providerTools...)
 		warnings = append(warnings, toolWarnings...)
 	}
 
 	return config, content, warnings, nil
 }
 
+// toSeed converts a seed to the 32-bit seed of the API, seeds out of range
+// are ignored with a warning.
+func toSeed(seed *int64) (*int32, []fantasy.CallWarning) {
+	if seed == nil {
+		return nil, nil
+	}
+	if *seed < math.MinInt32 || *seed > math.MaxInt32 {
+		return nil, []fantasy.CallWarning{{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "Seed",
+			Details: fmt.Sprintf("seed %d is out of the 32-bit range supported by Google and was ignored", *seed),
+		}}
+	}
+	s := int32(*seed)
+	return &s, nil
+}
+
 func toGooglePrompt(prompt fantasy.Prompt) (*genai.Content, []*genai.Content, []fantasy.CallWarning) { //nolint: unparam
 	var systemInstructions *genai.Content
 	var content []*genai.Content
@@ -420,7 +477,7 @@ func toGooglePrompt(prompt fantasy.Prompt) (*genai.Content, []*genai.Content, []
 					parts = append(parts, geminiPart)
 				case fantasy.ContentTypeToolCall:
 					toolCall, ok := fantasy.AsMessagePart[fantasy.ToolCallPart](part)
-					if !ok {
+					if !ok || toolCall.ProviderExecuted {
 						continue
 					}
 
@@ -588,6 +645,8 @@ func (g *languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.
 		var currentReasoningBlockID string
 		var usage *fantasy.Usage
 		var lastFinishReason fantasy.FinishReason
+		var codeExecutionID string
+		seenSources := map[string]struct{}{}
 
 		for resp, err := range chat.SendMessageStream(ctx, depointerSlice(lastMessage.Parts)...) {
 			if err != nil {
@@ -795,6 +854,43 @@ func (g *languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.
 							Input:            string(args),
 							ProviderExecuted: false,
 						})
+					case part.ExecutableCode != nil:
+						codeExecutionID = g.providerOptions.toolCallIDFunc()
+						toolCall := executableCodeToolCall(codeExecutionID, part.ExecutableCode)
+						if !yield(fantasy.StreamPart{
+							Type:             fantasy.StreamPartTypeToolCall,
+							ID:               toolCall.ToolCallID,
+							ToolCallName:     toolCall.ToolName,
+							ToolCallInput:    toolCall.Input,
+							ProviderExecuted: true,
+						}) {
+							return
+						}
+					case part.CodeExecutionResult != nil:
+						toolResult := codeExecutionToolResult(codeExecutionID, part.CodeExecutionResult)
+						if !yield(fantasy.StreamPart{
+							Type:             fantasy.StreamPartTypeToolResult,
+							ID:               toolResult.ToolCallID,
+							ToolCallName:     toolResult.ToolName,
+							ToolResult:       toolResult.Result,
+							ProviderExecuted: true,
+						}) {
+							return
+						}
+					}
+				}
+			}
+
+			if len(resp.Candidates) > 0 {
+				for _, source := range groundingSources(resp.Candidates[0].GroundingMetadata, seenSources) {
+					if !yield(fantasy.StreamPart{
+						Type:       fantasy.StreamPartTypeSource,
+						ID:         source.ID,
+						SourceType: source.SourceType,
+						URL:        source.URL,
+						Title:      source.Title,
+					}) {
+						return
 					}
 				}
 			}
@@ -850,9 +946,27 @@ func (g *languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.
 	}, nil
 }
 
+// resolvedObjectMode resolves ObjectModeAuto with the model catalog, unknown
+// models use JSON mode.
+func (g languageModel) resolvedObjectMode() fantasy.ObjectMode {
+	if info, ok := g.modelInfo(); ok {
+		return info.ResolveObjectMode(g.objectMode)
+	}
+	return g.objectMode
+}
+
+// modelInfo returns the catalog entry of the model, models of providers
+// registered under another name are looked up in the Google catalog.
+func (g languageModel) modelInfo() (fantasy.ModelInfo, bool) {
+	if info, ok := fantasy.LookupModelInfo(g.provider, g.modelID); ok {
+		return info, true
+	}
+	return fantasy.LookupModelInfo(Name, strings.TrimPrefix(g.modelID, "models/"))
+}
+
 // GenerateObject implements fantasy.LanguageModel.
 func (g *languageModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
-	switch g.objectMode {
+	switch g.resolvedObjectMode() {
 	case fantasy.ObjectModeText:
 		return object.GenerateWithText(ctx, g, call)
 	case fantasy.ObjectModeTool:
@@ -864,7 +978,7 @@ func (g *languageModel) GenerateObject(ctx context.Context, call fantasy.ObjectC
 
 // StreamObject implements fantasy.LanguageModel.
 func (g *languageModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
-	switch g.objectMode {
+	switch g.resolvedObjectMode() {
 	case fantasy.ObjectModeTool:
 		return object.StreamWithTool(ctx, g, call)
 	case fantasy.ObjectModeText:
@@ -876,7 +990,7 @@ func (g *languageModel) StreamObject(ctx context.Context, call fantasy.ObjectCal
 
 func (g *languageModel) generateObjectWithJSONMode(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
 	// Convert our Schema to Google's JSON Schema format
-	jsonSchemaMap := schema.ToMap(call.Schema)
+	jsonSchemaMap, schemaWarnings := fantasy.NormalizeSchema(call.Schema, responseSchemaDialect)
 
 	// Build request using prepareParams
 	fantasyCall := fantasy.Call{
@@ -887,6 +1001,8 @@ func (g *languageModel) generateObjectWithJSONMode(ctx context.Context, call fan
 		TopK:             call.TopK,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	}
 
@@ -894,6 +1010,7 @@ func (g *languageModel) generateObjectWithJSONMode(ctx context.Context, call fan
 	if err != nil {
 		return nil, err
 	}
+	warnings = append(warnings, schemaWarnings...)
 
 	// Set ResponseMIMEType and ResponseJsonSchema for structured output
 	config.ResponseMIMEType = "application/json"
@@ -958,7 +1075,7 @@ func (g *languageModel) generateObjectWithJSONMode(ctx context.Context, call fan
 
 func (g *languageModel) streamObjectWithJSONMode(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
 	// Convert our Schema to Google's JSON Schema format
-	jsonSchemaMap := schema.ToMap(call.Schema)
+	jsonSchemaMap, schemaWarnings := fantasy.NormalizeSchema(call.Schema, responseSchemaDialect)
 
 	// Build request using prepareParams
 	fantasyCall := fantasy.Call{
@@ -969,6 +1086,8 @@ func (g *languageModel) streamObjectWithJSONMode(ctx context.Context, call fanta
 		TopK:             call.TopK,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	}
 
@@ -976,6 +1095,7 @@ func (g *languageModel) streamObjectWithJSONMode(ctx context.Context, call fanta
 	if err != nil {
 		return nil, err
 	}
+	warnings = append(warnings, schemaWarnings...)
 
 	// Set ResponseMIMEType and ResponseJsonSchema for structured output
 	config.ResponseMIMEType = "application/json"
@@ -991,6 +1111,12 @@ func (g *languageModel) streamObjectWithJSONMode(ctx context.Context, call fanta
 		return nil, err
 	}
 
+	// The schema is compiled once, every partial object is validated.
+	validator, err := schema.Compile(call.Schema)
+	if err != nil {
+		return nil, err
+	}
+
 	return func(yield func(fantasy.ObjectStreamPart) bool) {
 		if len(warnings) > 0 {
 			if !yield(fantasy.ObjectStreamPart{
@@ -1027,7 +1153,7 @@ func (g *languageModel) streamObjectWithJSONMode(ctx context.Context, call fanta
 
 						// If we successfully parsed, validate and emit
 						if state == schema.ParseStateSuccessful || state == schema.ParseStateRepaired {
-							if err := schema.ValidateAgainstSchema(obj, call.Schema); err == nil {
+							if err := validator.Validate(obj); err == nil {
 								// Only emit if object is different from last
 								if !reflect.DeepEqual(obj, lastParsedObject) {
 									if !yield(fantasy.ObjectStreamPart{
@@ -1047,7 +1173,7 @@ func (g *languageModel) streamObjectWithJSONMode(ctx context.Context, call fanta
 							if repairErr == nil {
 								obj2, state2, _ := schema.ParsePartialJSON(repairedText)
 								if (state2 == schema.ParseStateSuccessful || state2 == schema.ParseStateRepaired) &&
-									schema.ValidateAgainstSchema(obj2, call.Schema) == nil {
+									validator.Validate(obj2) == nil {
 									if !reflect.DeepEqual(obj2, lastParsedObject) {
 										if !yield(fantasy.ObjectStreamPart{
 											Type:   fantasy.ObjectStreamPartTypeObject,
@@ -1112,7 +1238,7 @@ func (g *languageModel) streamObjectWithJSONMode(ctx context.Context, call fanta
 	}, nil
 }
 
-func toGoogleTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice) (googleTools []*genai.FunctionDeclaration, googleToolChoice *genai.ToolConfig, warnings []fantasy.CallWarning) {
+func toGoogleTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice) (googleTools []*genai.FunctionDeclaration, providerTools []*genai.Tool, googleToolChoice *genai.ToolConfig, warnings []fantasy.CallWarning) {
 	for _, tool := range tools {
 		if tool.GetType() == fantasy.ToolTypeFunction {
 			ft, ok := tool.(fantasy.FunctionTool)
@@ -1120,6 +1246,8 @@ func toGoogleTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice) (google
 				continue
 			}
 
+			ft, schemaWarnings := fantasy.NormalizeTool(ft, functionSchemaDialect)
+			warnings = append(warnings, schemaWarnings...)
 			required := []string{}
 			var properties map[string]any
 			if props, ok := ft.InputSchema["properties"]; ok {
@@ -1142,7 +1270,12 @@ func toGoogleTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice) (google
 			googleTools = append(googleTools, declaration)
 			continue
 		}
-		// TODO: handle provider tool calls
+		if pt, ok := tool.(fantasy.ProviderDefinedTool); ok {
+			if providerTool, ok := toGoogleProviderTool(pt); ok {
+				providerTools = append(providerTools, providerTool)
+				continue
+			}
+		}
 		warnings = append(warnings, fantasy.CallWarning{
 			Type:    fantasy.CallWarningTypeUnsupportedTool,
 			Tool:    tool,
@@ -1150,7 +1283,7 @@ func toGoogleTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice) (google
 		})
 	}
 	if toolChoice == nil {
-		return googleTools, googleToolChoice, warnings
+		return googleTools, providerTools, googleToolChoice, warnings
 	}
 	switch *toolChoice {
 	case fantasy.ToolChoiceAuto:
@@ -1181,7 +1314,33 @@ func toGoogleTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice) (google
 			},
 		}
 	}
-	return googleTools, googleToolChoice, warnings
+	return googleTools, providerTools, googleToolChoice, warnings
+}
+
+// functionSchemaDialect is the schema dialect of function declarations,
+// which are converted to [genai.Schema].
+var functionSchemaDialect = schema.Dialect{
+	Name:            "google",
+	InlineRefs:      true,
+	NullableKeyword: true,
+	FlattenAnyOf:    true,
+	OneOfAsAnyOf:    true,
+	StringEnums:     true,
+	Keywords: []string{
+		"type", "nullable", "title", "description", "enum", "format", "pattern",
+		"minimum", "maximum", "minLength", "maxLength", "minItems", "maxItems",
+		"default", "examples", "properties", "required", "items", "anyOf",
+	},
+}
+
+// responseSchemaDialect is the schema dialect of the response JSON schema.
+var responseSchemaDialect = schema.Dialect{
+	Name: "google",
+	Keywords: []string{
+		"$id", "$defs", "$ref", "$anchor", "type", "format", "title", "description",
+		"enum", "items", "prefixItems", "minItems", "maxItems", "minimum", "maximum",
+		"anyOf", "oneOf", "properties", "additionalProperties", "required", "propertyOrdering",
+	},
 }
 
 func convertSchemaProperties(parameters map[string]any) map[string]*genai.Schema {
@@ -1194,6 +1353,7 @@ func convertSchemaProperties(parameters map[string]any) map[string]*genai.Schema
 	return properties
 }
 
+// convertToSchema converts a schema normalized to the function dialect.
 func convertToSchema(param any) *genai.Schema {
 	schema := &genai.Schema{Type: genai.TypeString}
 
@@ -1206,6 +1366,18 @@ func convertToSchema(param any) *genai.Schema {
 		schema.Description = desc
 	}
 
+	if nullable, ok := paramMap["nullable"].(bool); ok && nullable {
+		schema.Nullable = genai.Ptr(true)
+	}
+
+	if variants, ok := paramMap["anyOf"].([]any); ok {
+		schema.Type = genai.TypeUnspecified
+		for _, v := range variants {
+			schema.AnyOf = append(schema.AnyOf, convertToSchema(v))
+		}
+		return schema
+	}
+
 	typeVal, hasType := paramMap["type"]
 	if !hasType {
 		return schema
@@ -1217,6 +1389,7 @@ func convertToSchema(param any) *genai.Schema {
 	}
 
 	schema.Type = mapJSONTypeToGoogle(typeStr)
+	applySchemaConstraints(schema, paramMap)
 
 	switch typeStr {
 	case "array":
@@ -1225,11 +1398,57 @@ func convertToSchema(param any) *genai.Schema {
 		if props, ok := paramMap["properties"].(map[string]any); ok {
 			schema.Properties = convertSchemaProperties(props)
 		}
+		if required, ok := paramMap["required"].([]string); ok {
+			schema.Required = required
+		}
 	}
 
 	return schema
 }
 
+func applySchemaConstraints(schema *genai.Schema, paramMap map[string]any) {
+	if title, ok := paramMap["title"].(string); ok {
+		schema.Title = title
+	}
+	if format, ok := paramMap["format"].(string); ok {
+		schema.Format = format
+	}
+	if pattern, ok := paramMap["pattern"].(string); ok {
+		schema.Pattern = pattern
+	}
+	if enum, ok := paramMap["enum"].([]any); ok {
+		for _, v := range enum {
+			if s, ok := v.(string); ok {
+				schema.Enum = append(schema.Enum, s)
+			}
+		}
+	}
+	if v, ok := toFloat(paramMap["minimum"]); ok {
+		schema.Minimum = &v
+	}
+	if v, ok := toFloat(paramMap["maximum"]); ok {
+		schema.Maximum = &v
+	}
+	if v, ok := toFloat(paramMap["minLength"]); ok {
+		schema.MinLength = genai.Ptr(int64(v))
+	}
+	if v, ok := toFloat(paramMap["maxLength"]); ok {
+		schema.MaxLength = genai.Ptr(int64(v))
+	}
+	if v, ok := toFloat(paramMap["minItems"]); ok {
+		schema.MinItems = genai.Ptr(int64(v))
+	}
+	if v, ok := toFloat(paramMap["maxItems"]); ok {
+		schema.MaxItems = genai.Ptr(int64(v))
+	}
+	if v, ok := paramMap["default"]; ok {
+		schema.Default = v
+	}
+	if examples, ok := paramMap["examples"].([]any); ok && len(examples) > 0 {
+		schema.Example = examples[0]
+	}
+}
+
 func processArrayItems(paramMap map[string]any) *genai.Schema {
 	items, ok := paramMap["items"].(map[string]any)
 	if !ok {
@@ -1239,6 +1458,20 @@ func processArrayItems(paramMap map[string]any) *genai.Schema {
 	return convertToSchema(items)
 }
 
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
 func mapJSONTypeToGoogle(jsonType string) genai.Type {
 	switch jsonType {
 	case "string":
@@ -1268,6 +1501,8 @@ func (g languageModel) mapResponse(response *genai.GenerateContentResponse, warn
 		finishReason fantasy.FinishReason
 		hasToolCalls bool
 		candidate    = response.Candidates[0]
+		// code execution results refer to the last executed code
+		codeExecutionID string
 	)
 
 	for _, part := range candidate.Content.Parts {
@@ -1359,12 +1594,21 @@ func (g languageModel) mapResponse(response *genai.GenerateContentResponse, warn
 				ProviderExecuted: false,
 			})
 			hasToolCalls = true
+		case part.ExecutableCode != nil:
+			codeExecutionID = g.providerOptions.toolCallIDFunc()
+			content = append(content, executableCodeToolCall(codeExecutionID, part.ExecutableCode))
+		case part.CodeExecutionResult != nil:
+			content = append(content, codeExecutionToolResult(codeExecutionID, part.CodeExecutionResult))
 		default:
 			// Silently skip unknown part types instead of erroring
 			// This allows for forward compatibility with new part types
 		}
 	}
 
+	for _, source := range groundingSources(candidate.GroundingMetadata, map[string]struct{}{}) {
+		content = append(content, source)
+	}
+
 	if hasToolCalls {
 		finishReason = fantasy.FinishReasonToolCalls
 	} else {
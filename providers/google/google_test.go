@@ -0,0 +1,245 @@
+package google
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+// mockServer is a Gemini API server that records the requests it receives.
+type mockServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	paths    []string
+	requests []map[string]any
+}
+
+// newMockServer starts a server that answers every request with the given
+// JSON response, or with the given events when it starts with "data:".
+func newMockServer(t *testing.T, response string) *mockServer {
+	t.Helper()
+
+	server := &mockServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var body map[string]any
+		if len(data) > 0 {
+			require.NoError(t, json.Unmarshal(data, &body))
+		}
+		server.mu.Lock()
+		server.paths = append(server.paths, r.URL.Path)
+		server.requests = append(server.requests, body)
+		server.mu.Unlock()
+
+		if strings.HasPrefix(response, "data:") {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *mockServer) lastRequest() (string, map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paths[len(s.paths)-1], s.requests[len(s.requests)-1]
+}
+
+func newTestProvider(t *testing.T, server *mockServer) *provider {
+	t.Helper()
+
+	p, err := New(WithGeminiAPIKey("test-key"), WithBaseURL(server.URL))
+	require.NoError(t, err)
+	return p.(*provider)
+}
+
+func TestGenerateSeed(t *testing.T) {
+	t.Parallel()
+
+	server := newMockServer(t, `{
+		"candidates": [{"content": {"role": "model", "parts": [{"text": "Hello"}]}, "finishReason": "STOP"}],
+		"usageMetadata": {"promptTokenCount": 1, "candidatesTokenCount": 1, "totalTokenCount": 2}
+	}`)
+	model, err := newTestProvider(t, server).LanguageModel(t.Context(), "gemini-2.5-flash")
+	require.NoError(t, err)
+
+	t.Run("sends seeds in range", func(t *testing.T) {
+		resp, err := model.Generate(t.Context(), fantasy.Call{
+			Prompt: fantasy.Prompt{fantasy.NewUserMessage("Hi")},
+			Seed:   fantasy.Opt(int64(42)),
+		})
+		require.NoError(t, err)
+		require.Empty(t, resp.Warnings)
+		require.Positive(t, resp.Usage.Cost.Total)
+		_, body := server.lastRequest()
+		require.Equal(t, float64(42), body["generationConfig"].(map[string]any)["seed"])
+	})
+
+	t.Run("ignores seeds out of range", func(t *testing.T) {
+		resp, err := model.Generate(t.Context(), fantasy.Call{
+			Prompt: fantasy.Prompt{fantasy.NewUserMessage("Hi")},
+			Seed:   fantasy.Opt(int64(1) << 40),
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Warnings, 1)
+		require.Equal(t, "Seed", resp.Warnings[0].Setting)
+		_, body := server.lastRequest()
+		require.NotContains(t, body["generationConfig"], "seed")
+	})
+}
+
+func TestEmbeddingModel(t *testing.T) {
+	t.Parallel()
+
+	server := newMockServer(t, `{"embeddings": [{"values": [0.1, 0.2]}, {"values": [0.3, 0.4]}]}`)
+	model, err := newTestProvider(t, server).EmbeddingModel(t.Context(), "gemini-embedding-001")
+	require.NoError(t, err)
+	require.Equal(t, int64(3072), model.Dimensions())
+	require.Equal(t, 100, model.MaxEmbeddingsPerCall())
+
+	resp, err := model.EmbedMany(t.Context(), fantasy.EmbedManyCall{
+		Values:     []string{"first", "second"},
+		Dimensions: fantasy.Opt(int64(2)),
+		ProviderOptions: fantasy.ProviderOptions{
+			Name: &EmbeddingProviderOptions{TaskType: fantasy.Opt("RETRIEVAL_DOCUMENT")},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Embeddings, 2)
+	require.InDeltaSlice(t, []float64{0.3, 0.4}, resp.Embeddings[1], 1e-6)
+
+	path, body := server.lastRequest()
+	require.Equal(t, "/v1beta/models/gemini-embedding-001:batchEmbedContents", path)
+	requests := body["requests"].([]any)
+	require.Len(t, requests, 2)
+	first := requests[0].(map[string]any)
+	require.Equal(t, "RETRIEVAL_DOCUMENT", first["taskType"])
+	require.Equal(t, float64(2), first["outputDimensionality"])
+	require.Equal(t, "first", first["content"].(map[string]any)["parts"].([]any)[0].(map[string]any)["text"])
+}
+
+func TestImageModel(t *testing.T) {
+	t.Parallel()
+
+	server := newMockServer(t, `{"predictions": [{"bytesBase64Encoded": "cG5n", "mimeType": "image/png"}]}`)
+	model, err := newTestProvider(t, server).ImageModel(t.Context(), "imagen-4.0-generate-001")
+	require.NoError(t, err)
+
+	resp, err := model.GenerateImage(t.Context(), fantasy.ImageCall{
+		Prompt:      "A cat",
+		AspectRatio: "16:9",
+		Seed:        fantasy.Opt(int64(1) << 40),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []fantasy.FileContent{{MediaType: "image/png", Data: []byte("png")}}, resp.Images)
+	require.Len(t, resp.Warnings, 1)
+	require.Equal(t, "Seed", resp.Warnings[0].Setting)
+
+	path, body := server.lastRequest()
+	require.Equal(t, "/v1beta/models/imagen-4.0-generate-001:predict", path)
+	parameters := body["parameters"].(map[string]any)
+	require.Equal(t, float64(1), parameters["sampleCount"])
+	require.Equal(t, "16:9", parameters["aspectRatio"])
+	require.NotContains(t, parameters, "seed")
+}
+
+func TestTranscriptionModel(t *testing.T) {
+	t.Parallel()
+
+	server := newMockServer(t, `{
+		"candidates": [{"content": {"role": "model", "parts": [{"text": "{\"language\": \"en\", \"text\": \" Hello world. \"}"}]}, "finishReason": "STOP"}],
+		"usageMetadata": {"promptTokenCount": 30, "candidatesTokenCount": 10, "totalTokenCount": 40}
+	}`)
+	model, err := newTestProvider(t, server).TranscriptionModel(t.Context(), "gemini-2.5-flash")
+	require.NoError(t, err)
+
+	resp, err := model.Transcribe(t.Context(), fantasy.TranscriptionCall{
+		Audio:    fantasy.FilePart{MediaType: "audio/wav", Data: []byte("wav")},
+		Language: "en",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Hello world.", resp.Text)
+	require.Equal(t, "en", resp.Language)
+	// Gemini does not report timestamps.
+	require.Empty(t, resp.Segments)
+	require.Equal(t, int64(40), resp.Usage.TotalTokens)
+
+	path, body := server.lastRequest()
+	require.Equal(t, "/v1beta/models/gemini-2.5-flash:generateContent", path)
+	config := body["generationConfig"].(map[string]any)
+	require.Equal(t, "application/json", config["responseMimeType"])
+	parts := body["contents"].([]any)[0].(map[string]any)["parts"].([]any)
+	require.Contains(t, parts[0].(map[string]any)["text"], "The speech is in the language en.")
+	require.Equal(t, "audio/wav", parts[1].(map[string]any)["inlineData"].(map[string]any)["mimeType"])
+}
+
+func TestSpeechModel(t *testing.T) {
+	t.Parallel()
+
+	audio := `{"candidates": [{"content": {"role": "model", "parts": [{"inlineData": {"mimeType": "audio/L16;codec=pcm;rate=24000", "data": "cGNt"}}]}}],
+		"usageMetadata": {"promptTokenCount": 5, "candidatesTokenCount": 20, "totalTokenCount": 25}}`
+
+	t.Run("generates speech", func(t *testing.T) {
+		t.Parallel()
+
+		server := newMockServer(t, audio)
+		model, err := newTestProvider(t, server).SpeechModel(t.Context(), "gemini-2.5-flash-preview-tts")
+		require.NoError(t, err)
+
+		resp, err := model.GenerateSpeech(t.Context(), fantasy.SpeechCall{
+			Text:         "Have a wonderful day!",
+			Voice:        "Kore",
+			Instructions: "Say cheerfully",
+			Speed:        fantasy.Opt(1.5),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []byte("pcm"), resp.Audio.Data)
+		require.Equal(t, "audio/L16;codec=pcm;rate=24000", resp.Audio.MediaType)
+		require.Len(t, resp.Warnings, 1)
+		require.Equal(t, "Speed", resp.Warnings[0].Setting)
+
+		_, body := server.lastRequest()
+		parts := body["contents"].([]any)[0].(map[string]any)["parts"].([]any)
+		require.Equal(t, "Say cheerfully: Have a wonderful day!", parts[0].(map[string]any)["text"])
+		config := body["generationConfig"].(map[string]any)
+		require.Equal(t, []any{"AUDIO"}, config["responseModalities"])
+		voice := config["speechConfig"].(map[string]any)["voiceConfig"].(map[string]any)["prebuiltVoiceConfig"].(map[string]any)
+		require.Equal(t, "Kore", voice["voiceName"])
+	})
+
+	t.Run("streams speech", func(t *testing.T) {
+		t.Parallel()
+
+		server := newMockServer(t, "data: "+strings.ReplaceAll(audio, "\n", "")+"\n\n")
+		model, err := newTestProvider(t, server).SpeechModel(t.Context(), "gemini-2.5-flash-preview-tts")
+		require.NoError(t, err)
+
+		stream, err := model.StreamSpeech(t.Context(), fantasy.SpeechCall{Text: "Hello"})
+		require.NoError(t, err)
+		var data []byte
+		var last fantasy.SpeechStreamPart
+		for part := range stream {
+			require.NoError(t, part.Error)
+			data = append(data, part.Data...)
+			last = part
+		}
+		require.Equal(t, []byte("pcm"), data)
+		require.Equal(t, fantasy.SpeechStreamPartTypeFinish, last.Type)
+		require.Equal(t, int64(25), last.Usage.TotalTokens)
+
+		path, _ := server.lastRequest()
+		require.Equal(t, "/v1beta/models/gemini-2.5-flash-preview-tts:streamGenerateContent", path)
+	})
+}
@@ -0,0 +1,168 @@
+package google
+
+import (
+	"cmp"
+	"context"
+
+	"charm.land/fantasy"
+	"google.golang.org/genai"
+)
+
+type imageModel struct {
+	provider string
+	modelID  string
+	client   *genai.Client
+}
+
+// ImageModel implements fantasy.ImageProvider. Only Imagen models are
+// supported, images are edited through Vertex AI.
+func (a *provider) ImageModel(ctx context.Context, modelID string) (fantasy.ImageModel, error) {
+	client, err := a.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &imageModel{
+		provider: a.options.name,
+		modelID:  modelID,
+		client:   client,
+	}, nil
+}
+
+// Provider implements fantasy.ImageModel.
+func (m *imageModel) Provider() string {
+	return m.provider
+}
+
+// Model implements fantasy.ImageModel.
+func (m *imageModel) Model() string {
+	return m.modelID
+}
+
+// GenerateImage implements fantasy.ImageModel.
+func (m *imageModel) GenerateImage(ctx context.Context, call fantasy.ImageCall) (*fantasy.ImageResponse, error) {
+	var warnings []fantasy.CallWarning
+	if call.Size != "" {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "Size",
+			Details: "size is not supported, use aspect ratio instead",
+		})
+	}
+
+	var providerOptions ImageProviderOptions
+	if v, ok := call.ProviderOptions[Name]; ok {
+		options, ok := v.(*ImageProviderOptions)
+		if !ok {
+			return nil, &fantasy.Error{Title: "invalid argument", Message: "google image provider options should be *google.ImageProviderOptions"}
+		}
+		providerOptions = *options
+	}
+
+	seed, seedWarnings := toSeed(call.Seed)
+	warnings = append(warnings, seedWarnings...)
+	// One image is generated when N is not set, see [fantasy.ImageCall].
+	numberOfImages := int32(max(call.N, 1)) //nolint: gosec
+
+	var generated []*genai.GeneratedImage
+	if len(call.Images) > 0 {
+		config := &genai.EditImageConfig{
+			NumberOfImages: numberOfImages,
+			AspectRatio:    call.AspectRatio,
+			Seed:           seed,
+			AddWatermark:   providerOptions.AddWatermark,
+		}
+		if providerOptions.NegativePrompt != nil {
+			config.NegativePrompt = *providerOptions.NegativePrompt
+		}
+		if providerOptions.PersonGeneration != nil {
+			config.PersonGeneration = genai.PersonGeneration(*providerOptions.PersonGeneration)
+		}
+		if providerOptions.OutputMIMEType != nil {
+			config.OutputMIMEType = *providerOptions.OutputMIMEType
+		}
+
+		references := make([]genai.ReferenceImage, 0, len(call.Images)+1)
+		for i, image := range call.Images {
+			references = append(references, genai.NewRawReferenceImage(toImage(image), int32(i+1)))
+		}
+		if call.Mask != nil {
+			references = append(references, genai.NewMaskReferenceImage(toImage(*call.Mask), int32(len(call.Images)+1), &genai.MaskReferenceConfig{
+				MaskMode: genai.MaskReferenceModeMaskModeUserProvided,
+			}))
+			config.EditMode = genai.EditModeInpaintInsertion
+		}
+
+		response, err := m.client.Models.EditImage(ctx, m.modelID, call.Prompt, references, config)
+		if err != nil {
+			return nil, toProviderErr(err)
+		}
+		generated = response.GeneratedImages
+	} else {
+		if call.Mask != nil {
+			warnings = append(warnings, fantasy.CallWarning{
+				Type:    fantasy.CallWarningTypeUnsupportedSetting,
+				Setting: "Mask",
+				Details: "a mask requires an input image",
+			})
+		}
+		config := &genai.GenerateImagesConfig{
+			NumberOfImages: numberOfImages,
+			AspectRatio:    call.AspectRatio,
+			Seed:           seed,
+		}
+		if providerOptions.NegativePrompt != nil {
+			config.NegativePrompt = *providerOptions.NegativePrompt
+		}
+		if providerOptions.PersonGeneration != nil {
+			config.PersonGeneration = genai.PersonGeneration(*providerOptions.PersonGeneration)
+		}
+		if providerOptions.OutputMIMEType != nil {
+			config.OutputMIMEType = *providerOptions.OutputMIMEType
+		}
+		if providerOptions.ImageSize != nil {
+			config.ImageSize = *providerOptions.ImageSize
+		}
+		if providerOptions.AddWatermark != nil {
+			config.AddWatermark = *providerOptions.AddWatermark
+		}
+		if providerOptions.EnhancePrompt != nil {
+			config.EnhancePrompt = *providerOptions.EnhancePrompt
+		}
+
+		response, err := m.client.Models.GenerateImages(ctx, m.modelID, call.Prompt, config)
+		if err != nil {
+			return nil, toProviderErr(err)
+		}
+		generated = response.GeneratedImages
+	}
+
+	images := make([]fantasy.FileContent, 0, len(generated))
+	for _, image := range generated {
+		if image.Image == nil {
+			// The image was filtered out by the safety filters.
+			if image.RAIFilteredReason != "" {
+				warnings = append(warnings, fantasy.CallWarning{
+					Type:    fantasy.CallWarningTypeOther,
+					Message: image.RAIFilteredReason,
+				})
+			}
+			continue
+		}
+		images = append(images, fantasy.FileContent{
+			MediaType: cmp.Or(image.Image.MIMEType, "image/png"),
+			Data:      image.Image.ImageBytes,
+		})
+	}
+
+	return &fantasy.ImageResponse{
+		Images:   images,
+		Warnings: warnings,
+	}, nil
+}
+
+func toImage(file fantasy.FilePart) *genai.Image {
+	return &genai.Image{
+		ImageBytes: file.Data,
+		MIMEType:   file.MediaType,
+	}
+}
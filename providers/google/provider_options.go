@@ -11,6 +11,9 @@ import (
 const (
 	TypeProviderOptions   = Name + ".options"
 	TypeReasoningMetadata = Name + ".reasoning_metadata"
+
+	TypeEmbeddingProviderOptions = Name + ".embedding_options"
+	TypeImageProviderOptions     = Name + ".image_options"
 )
 
 // Register Google provider-specific types with the global registry.
@@ -29,6 +32,20 @@ func init() {
 		}
 		return &v, nil
 	})
+	fantasy.RegisterProviderType(TypeEmbeddingProviderOptions, func(data []byte) (fantasy.ProviderOptionsData, error) {
+		var v EmbeddingProviderOptions
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
+	fantasy.RegisterProviderType(TypeImageProviderOptions, func(data []byte) (fantasy.ProviderOptionsData, error) {
+		var v ImageProviderOptions
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
 }
 
 // ThinkingConfig represents thinking configuration for the Google provider.
@@ -130,3 +147,98 @@ func ParseOptions(data map[string]any) (*ProviderOptions, error) {
 	}
 	return &options, nil
 }
+
+// EmbeddingProviderOptions represents additional options for Google embedding models.
+type EmbeddingProviderOptions struct {
+	// Optional. The task the embeddings will be used for, e.g.
+	// 'RETRIEVAL_QUERY', 'RETRIEVAL_DOCUMENT', 'SEMANTIC_SIMILARITY',
+	// 'CLASSIFICATION' or 'CLUSTERING'.
+	TaskType *string `json:"task_type"`
+
+	// Optional. The title of the embedded text. Only applies when TaskType is
+	// 'RETRIEVAL_DOCUMENT'.
+	Title *string `json:"title"`
+}
+
+// Options implements the ProviderOptionsData interface for EmbeddingProviderOptions.
+func (o *EmbeddingProviderOptions) Options() {}
+
+// MarshalJSON implements custom JSON marshaling with type info for EmbeddingProviderOptions.
+func (o EmbeddingProviderOptions) MarshalJSON() ([]byte, error) {
+	type plain EmbeddingProviderOptions
+	return fantasy.MarshalProviderType(TypeEmbeddingProviderOptions, plain(o))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling with type info for EmbeddingProviderOptions.
+func (o *EmbeddingProviderOptions) UnmarshalJSON(data []byte) error {
+	type plain EmbeddingProviderOptions
+	var p plain
+	if err := fantasy.UnmarshalProviderType(data, &p); err != nil {
+		return err
+	}
+	*o = EmbeddingProviderOptions(p)
+	return nil
+}
+
+// ParseEmbeddingOptions parses embedding provider options from a map for the Google provider.
+func ParseEmbeddingOptions(data map[string]any) (*EmbeddingProviderOptions, error) {
+	var options EmbeddingProviderOptions
+	if err := fantasy.ParseOptions(data, &options); err != nil {
+		return nil, err
+	}
+	return &options, nil
+}
+
+// ImageProviderOptions represents additional options for Google image models.
+type ImageProviderOptions struct {
+	// Optional. A description of what to discourage in the images.
+	NegativePrompt *string `json:"negative_prompt"`
+
+	// Optional. Whether people can be generated, 'DONT_ALLOW',
+	// 'ALLOW_ADULT' or 'ALLOW_ALL'.
+	PersonGeneration *string `json:"person_generation"`
+
+	// Optional. The media type of the images, e.g. 'image/png' or
+	// 'image/jpeg'.
+	OutputMIMEType *string `json:"output_mime_type"`
+
+	// Optional. The size of the largest dimension of the images, '1K' or
+	// '2K'. Only applies when generating images.
+	ImageSize *string `json:"image_size"`
+
+	// Optional. Whether to add a watermark to the images.
+	AddWatermark *bool `json:"add_watermark"`
+
+	// Optional. Whether to rewrite the prompt for better results. Only applies
+	// when generating images.
+	EnhancePrompt *bool `json:"enhance_prompt"`
+}
+
+// Options implements the ProviderOptionsData interface for ImageProviderOptions.
+func (o *ImageProviderOptions) Options() {}
+
+// MarshalJSON implements custom JSON marshaling with type info for ImageProviderOptions.
+func (o ImageProviderOptions) MarshalJSON() ([]byte, error) {
+	type plain ImageProviderOptions
+	return fantasy.MarshalProviderType(TypeImageProviderOptions, plain(o))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling with type info for ImageProviderOptions.
+func (o *ImageProviderOptions) UnmarshalJSON(data []byte) error {
+	type plain ImageProviderOptions
+	var p plain
+	if err := fantasy.UnmarshalProviderType(data, &p); err != nil {
+		return err
+	}
+	*o = ImageProviderOptions(p)
+	return nil
+}
+
+// ParseImageOptions parses image provider options from a map for the Google provider.
+func ParseImageOptions(data map[string]any) (*ImageProviderOptions, error) {
+	var options ImageProviderOptions
+	if err := fantasy.ParseOptions(data, &options); err != nil {
+		return nil, err
+	}
+	return &options, nil
+}
@@ -0,0 +1,107 @@
+package google
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/google/uuid"
+	"google.golang.org/genai"
+)
+
+const (
+	// GoogleSearchToolID is the ID of the Google Search grounding provider-defined tool.
+	GoogleSearchToolID = Name + ".google_search"
+	// CodeExecutionToolID is the ID of the code execution provider-defined tool.
+	CodeExecutionToolID = Name + ".code_execution"
+)
+
+// GoogleSearchTool creates the Google Search grounding tool. The sources used
+// to ground the response are returned as source content.
+func GoogleSearchTool() fantasy.ProviderDefinedTool {
+	return fantasy.ProviderDefinedTool{
+		ID:   GoogleSearchToolID,
+		Name: "google_search",
+	}
+}
+
+// CodeExecutionTool creates the code execution tool. The code run by the
+// model and its output are returned as provider executed tool calls and
+// results.
+func CodeExecutionTool() fantasy.ProviderDefinedTool {
+	return fantasy.ProviderDefinedTool{
+		ID:   CodeExecutionToolID,
+		Name: "code_execution",
+	}
+}
+
+func toGoogleProviderTool(tool fantasy.ProviderDefinedTool) (*genai.Tool, bool) {
+	switch tool.ID {
+	case GoogleSearchToolID:
+		return &genai.Tool{GoogleSearch: &genai.GoogleSearch{}}, true
+	case CodeExecutionToolID:
+		return &genai.Tool{CodeExecution: &genai.ToolCodeExecution{}}, true
+	}
+	return nil, false
+}
+
+type executableCodeInput struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+func executableCodeToolCall(id string, code *genai.ExecutableCode) fantasy.ToolCallContent {
+	input, _ := json.Marshal(executableCodeInput{
+		Language: string(code.Language),
+		Code:     code.Code,
+	})
+	return fantasy.ToolCallContent{
+		ToolCallID:       id,
+		ToolName:         "code_execution",
+		Input:            string(input),
+		ProviderExecuted: true,
+	}
+}
+
+func codeExecutionToolResult(id string, result *genai.CodeExecutionResult) fantasy.ToolResultContent {
+	toolResult := fantasy.ToolResultContent{
+		ToolCallID:       id,
+		ToolName:         "code_execution",
+		ProviderExecuted: true,
+	}
+	if result.Outcome != genai.OutcomeOK {
+		toolResult.Result = fantasy.ToolResultOutputContentError{
+			Error: errors.New(cmp.Or(result.Output, fmt.Sprintf("code execution failed: %s", result.Outcome))),
+		}
+		return toolResult
+	}
+	toolResult.Result = fantasy.ToolResultOutputContentText{Text: result.Output}
+	return toolResult
+}
+
+// groundingSources returns the web sources of the grounding metadata,
+// skipping the ones that were already seen.
+func groundingSources(metadata *genai.GroundingMetadata, seen map[string]struct{}) []fantasy.SourceContent {
+	if metadata == nil {
+		return nil
+	}
+	var sources []fantasy.SourceContent
+	for _, chunk := range metadata.GroundingChunks {
+		if chunk == nil || chunk.Web == nil || chunk.Web.URI == "" {
+			continue
+		}
+		if _, ok := seen[chunk.Web.URI]; ok {
+			continue
+		}
+		seen[chunk.Web.URI] = struct{}{}
+		sources = append(sources, fantasy.SourceContent{
+			SourceType: fantasy.SourceTypeURL,
+			ID:         uuid.NewString(),
+			URL:        chunk.Web.URI,
+			Title:      chunk.Web.Title,
+		})
+	}
+	return sources
+}
@@ -0,0 +1,155 @@
+package google
+
+import (
+	"context"
+
+	"charm.land/fantasy"
+	"google.golang.org/genai"
+)
+
+type speechModel struct {
+	provider string
+	modelID  string
+	client   *genai.Client
+}
+
+// SpeechModel implements fantasy.SpeechProvider. The speech is generated by
+// Gemini TTS models as raw 16-bit PCM audio.
+func (a *provider) SpeechModel(ctx context.Context, modelID string) (fantasy.SpeechModel, error) {
+	client, err := a.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &speechModel{
+		provider: a.options.name,
+		modelID:  modelID,
+		client:   client,
+	}, nil
+}
+
+// Provider implements fantasy.SpeechModel.
+func (m *speechModel) Provider() string {
+	return m.provider
+}
+
+// Model implements fantasy.SpeechModel.
+func (m *speechModel) Model() string {
+	return m.modelID
+}
+
+// GenerateSpeech implements fantasy.SpeechModel.
+func (m *speechModel) GenerateSpeech(ctx context.Context, call fantasy.SpeechCall) (*fantasy.SpeechResponse, error) {
+	contents, config, warnings := m.prepareParams(call)
+	response, err := m.client.Models.GenerateContent(ctx, m.modelID, contents, config)
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+
+	result := &fantasy.SpeechResponse{Warnings: warnings}
+	for _, blob := range audioBlobs(response) {
+		result.Audio.MediaType = blob.MIMEType
+		result.Audio.Data = append(result.Audio.Data, blob.Data...)
+	}
+	if len(result.Audio.Data) == 0 {
+		return nil, &fantasy.Error{Title: "invalid response", Message: "no audio was generated"}
+	}
+	if response.UsageMetadata != nil {
+		result.Usage = mapUsage(response.UsageMetadata)
+	}
+	return result, nil
+}
+
+// StreamSpeech implements fantasy.SpeechModel.
+func (m *speechModel) StreamSpeech(ctx context.Context, call fantasy.SpeechCall) (fantasy.SpeechStreamResponse, error) {
+	contents, config, warnings := m.prepareParams(call)
+
+	return func(yield func(fantasy.SpeechStreamPart) bool) {
+		if len(warnings) > 0 {
+			if !yield(fantasy.SpeechStreamPart{
+				Type:     fantasy.SpeechStreamPartTypeWarnings,
+				Warnings: warnings,
+			}) {
+				return
+			}
+		}
+
+		var usage fantasy.Usage
+		for response, err := range m.client.Models.GenerateContentStream(ctx, m.modelID, contents, config) {
+			if err != nil {
+				yield(fantasy.SpeechStreamPart{
+					Type:  fantasy.SpeechStreamPartTypeError,
+					Error: toProviderErr(err),
+				})
+				return
+			}
+			for _, blob := range audioBlobs(response) {
+				if !yield(fantasy.SpeechStreamPart{
+					Type:      fantasy.SpeechStreamPartTypeAudioDelta,
+					MediaType: blob.MIMEType,
+					Data:      blob.Data,
+				}) {
+					return
+				}
+			}
+			// The usage is reported cumulatively.
+			if response.UsageMetadata != nil && response.UsageMetadata.TotalTokenCount != 0 {
+				usage = mapUsage(response.UsageMetadata)
+			}
+		}
+
+		yield(fantasy.SpeechStreamPart{
+			Type:  fantasy.SpeechStreamPartTypeFinish,
+			Usage: usage,
+		})
+	}, nil
+}
+
+func (m *speechModel) prepareParams(call fantasy.SpeechCall) ([]*genai.Content, *genai.GenerateContentConfig, []fantasy.CallWarning) {
+	var warnings []fantasy.CallWarning
+	if call.Speed != nil {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "Speed",
+			Details: "use instructions to control the pace of the speech",
+		})
+	}
+	if call.OutputFormat != "" && call.OutputFormat != "pcm" {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "OutputFormat",
+			Details: "unsupported output format " + call.OutputFormat + ", using pcm",
+		})
+	}
+
+	// Gemini TTS models are steered with natural language, e.g.
+	// "Say cheerfully: Have a wonderful day!".
+	text := call.Text
+	if call.Instructions != "" {
+		text = call.Instructions + ": " + call.Text
+	}
+
+	config := &genai.GenerateContentConfig{
+		ResponseModalities: []string{string(genai.ModalityAudio)},
+	}
+	if call.Voice != "" {
+		config.SpeechConfig = &genai.SpeechConfig{
+			VoiceConfig: &genai.VoiceConfig{
+				PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{VoiceName: call.Voice},
+			},
+		}
+	}
+	return genai.Text(text), config, warnings
+}
+
+func audioBlobs(response *genai.GenerateContentResponse) []*genai.Blob {
+	if len(response.Candidates) == 0 || response.Candidates[0].Content == nil {
+		return nil
+	}
+	var blobs []*genai.Blob
+	for _, part := range response.Candidates[0].Content.Parts {
+		if part.InlineData != nil && len(part.InlineData.Data) > 0 {
+			blobs = append(blobs, part.InlineData)
+		}
+	}
+	return blobs
+}
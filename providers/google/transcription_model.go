@@ -0,0 +1,109 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"charm.land/fantasy"
+	"google.golang.org/genai"
+)
+
+// transcriptionPrompt asks the model for a transcription that matches
+// transcriptionSchema.
+const transcriptionPrompt = "Transcribe the speech in the audio verbatim and detect the ISO-639-1 language of the speech."
+
+// transcriptionSchema is the JSON schema of the transcription returned by the
+// model. Timestamps are not requested: the model would make them up rather
+// than measure them.
+var transcriptionSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"language": map[string]any{"type": "string"},
+		"text":     map[string]any{"type": "string"},
+	},
+	"required": []string{"language", "text"},
+}
+
+type transcription struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+type transcriptionModel struct {
+	provider string
+	modelID  string
+	client   *genai.Client
+}
+
+// TranscriptionModel implements fantasy.TranscriptionProvider. The audio is
+// transcribed by a Gemini model with structured output, no segments are
+// returned since Gemini does not report timestamps.
+func (a *provider) TranscriptionModel(ctx context.Context, modelID string) (fantasy.TranscriptionModel, error) {
+	client, err := a.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &transcriptionModel{
+		provider: a.options.name,
+		modelID:  modelID,
+		client:   client,
+	}, nil
+}
+
+// Provider implements fantasy.TranscriptionModel.
+func (m *transcriptionModel) Provider() string {
+	return m.provider
+}
+
+// Model implements fantasy.TranscriptionModel.
+func (m *transcriptionModel) Model() string {
+	return m.modelID
+}
+
+// Transcribe implements fantasy.TranscriptionModel.
+func (m *transcriptionModel) Transcribe(ctx context.Context, call fantasy.TranscriptionCall) (*fantasy.TranscriptionResponse, error) {
+	prompt := transcriptionPrompt
+	if call.Language != "" {
+		prompt += " The speech is in the language " + call.Language + "."
+	}
+	if call.Prompt != "" {
+		prompt += "\n\n" + call.Prompt
+	}
+
+	config := &genai.GenerateContentConfig{
+		ResponseMIMEType:   "application/json",
+		ResponseJsonSchema: transcriptionSchema,
+	}
+	if call.Temperature != nil {
+		temperature := float32(*call.Temperature)
+		config.Temperature = &temperature
+	}
+
+	contents := []*genai.Content{
+		genai.NewContentFromParts([]*genai.Part{
+			genai.NewPartFromText(prompt),
+			genai.NewPartFromBytes(call.Audio.Data, call.Audio.MediaType),
+		}, genai.RoleUser),
+	}
+	response, err := m.client.Models.GenerateContent(ctx, m.modelID, contents, config)
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+
+	var result transcription
+	if err := json.Unmarshal([]byte(response.Text()), &result); err != nil {
+		return nil, &fantasy.Error{Title: "invalid response", Message: "failed to decode transcription", Cause: err}
+	}
+
+	var usage fantasy.Usage
+	if response.UsageMetadata != nil {
+		usage = mapUsage(response.UsageMetadata)
+	}
+
+	return &fantasy.TranscriptionResponse{
+		Text:     strings.TrimSpace(result.Text),
+		Language: result.Language,
+		Usage:    usage,
+	}, nil
+}
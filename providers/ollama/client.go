@@ -0,0 +1,199 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"charm.land/fantasy"
+)
+
+// Client manages the models of an Ollama daemon.
+type Client struct {
+	options options
+}
+
+// Model is a model available on the daemon.
+type Model struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+// ModelDetails describes the format and size of a model.
+type ModelDetails struct {
+	ParentModel       string   `json:"parent_model"`
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+// ModelInfo holds the information returned when showing a model.
+type ModelInfo struct {
+	Modelfile    string         `json:"modelfile"`
+	Parameters   string         `json:"parameters"`
+	Template     string         `json:"template"`
+	System       string         `json:"system"`
+	License      string         `json:"license"`
+	Details      ModelDetails   `json:"details"`
+	ModelInfo    map[string]any `json:"model_info"`
+	Capabilities []string       `json:"capabilities"`
+	ModifiedAt   time.Time      `json:"modified_at"`
+}
+
+// PullProgress reports the progress of a model pull.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// NewClient creates a new client for the Ollama daemon, it accepts the same
+// options as the provider.
+func NewClient(opts ...Option) *Client {
+	return &Client{options: newOptions(opts)}
+}
+
+// List lists the models available on the daemon.
+func (c *Client) List(ctx context.Context) ([]Model, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var listResp struct {
+		Models []Model `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
+		return nil, &fantasy.Error{Title: "parse error", Message: "failed to parse response", Cause: err}
+	}
+	return listResp.Models, nil
+}
+
+// Show returns the information of a model.
+func (c *Client) Show(ctx context.Context, model string) (*ModelInfo, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/show", map[string]any{
+		"model": model,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var info ModelInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, &fantasy.Error{Title: "parse error", Message: "failed to parse response", Cause: err}
+	}
+	return &info, nil
+}
+
+// Pull downloads a model to the daemon, the progress function is called for
+// every progress update and may be nil. Pull returns once the download has
+// finished.
+func (c *Client) Pull(ctx context.Context, model string, progress func(PullProgress)) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/pull", map[string]any{
+		"model":  model,
+		"stream": true,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var update struct {
+			PullProgress
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(line, &update); err != nil {
+			return &fantasy.Error{Title: "parse error", Message: "failed to parse pull progress", Cause: err}
+		}
+		if update.Error != "" {
+			return &fantasy.Error{Title: "pull error", Message: update.Error}
+		}
+		if progress != nil {
+			progress(update.PullProgress)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return &fantasy.Error{Title: "stream error", Message: "failed to read pull progress", Cause: err}
+	}
+	return nil
+}
+
+// doRequest makes an HTTP request to the given path of the Ollama API.
+func (c *Client) doRequest(ctx context.Context, method, path string, reqBody any) (*http.Response, error) {
+	var body io.Reader
+	var jsonBody []byte
+	if reqBody != nil {
+		var err error
+		jsonBody, err = json.Marshal(reqBody)
+		if err != nil {
+			return nil, &fantasy.Error{
+				Title:   "invalid argument",
+				Message: "failed to marshal request body",
+				Cause:   err,
+			}
+		}
+		body = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.options.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.options.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.options.apiKey)
+	}
+	for k, v := range c.options.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.options.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		message := string(respBody)
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			message = errResp.Error
+		}
+		return nil, &fantasy.ProviderError{
+			Title:        fantasy.ErrorTitleForStatusCode(resp.StatusCode),
+			Message:      fmt.Sprintf("API error: %s", message),
+			StatusCode:   resp.StatusCode,
+			URL:          c.options.baseURL + path,
+			RequestBody:  jsonBody,
+			ResponseBody: respBody,
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,127 @@
+// Package ollama provides an implementation of the fantasy AI SDK for models
+// served by a local Ollama daemon.
+//
+// The daemon speaks the same API as Ollama Cloud, language and embedding
+// models are built on the ollamacloud provider. The package additionally
+// provides a Client to list, pull and show the models of the daemon.
+package ollama
+
+import (
+	"cmp"
+	"maps"
+	"net/http"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/ollamacloud"
+)
+
+const (
+	// Name is the name of the Ollama provider.
+	Name = "ollama"
+	// DefaultURL is the default URL of the local Ollama daemon.
+	DefaultURL = "http://localhost:11434"
+)
+
+type options struct {
+	baseURL    string
+	apiKey     string
+	name       string
+	headers    map[string]string
+	httpClient *http.Client
+	objectMode fantasy.ObjectMode
+}
+
+// Register the Ollama provider with the factory registry.
+func init() {
+	fantasy.RegisterProviderFactory(Name, fantasy.ProviderFactory{
+		New: func(config fantasy.ProviderConfig) (fantasy.Provider, error) {
+			opts := []Option{WithHeaders(config.Headers)}
+			if config.APIKey != "" {
+				opts = append(opts, WithAPIKey(config.APIKey))
+			}
+			if config.BaseURL != "" {
+				opts = append(opts, WithBaseURL(config.BaseURL))
+			}
+			return New(opts...)
+		},
+		ParseOptions: func(data map[string]any) (fantasy.ProviderOptionsData, error) {
+			return ParseOptions(data)
+		},
+		OptionsKey: ollamacloud.Name,
+	})
+}
+
+// Option defines a function that configures Ollama provider options.
+type Option = func(*options)
+
+func newOptions(opts []Option) options {
+	providerOptions := options{
+		headers:    map[string]string{},
+		httpClient: &http.Client{},
+		objectMode: fantasy.ObjectModeAuto,
+	}
+	for _, o := range opts {
+		o(&providerOptions)
+	}
+	providerOptions.baseURL = cmp.Or(providerOptions.baseURL, DefaultURL)
+	providerOptions.name = cmp.Or(providerOptions.name, Name)
+	return providerOptions
+}
+
+// New creates a new Ollama provider with the given options.
+func New(opts ...Option) (fantasy.Provider, error) {
+	o := newOptions(opts)
+	return ollamacloud.New(
+		ollamacloud.WithBaseURL(o.baseURL),
+		ollamacloud.WithAPIKey(o.apiKey),
+		ollamacloud.WithName(o.name),
+		ollamacloud.WithHeaders(o.headers),
+		ollamacloud.WithHTTPClient(o.httpClient),
+		ollamacloud.WithObjectMode(o.objectMode),
+	)
+}
+
+// WithBaseURL sets the base URL of the Ollama daemon.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithAPIKey sets an API key, only needed when the daemon is behind an
+// authenticating proxy.
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithName sets the name for the Ollama provider.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithHeaders sets the headers for the Ollama provider.
+func WithHeaders(headers map[string]string) Option {
+	return func(o *options) {
+		maps.Copy(o.headers, headers)
+	}
+}
+
+// WithHTTPClient sets the HTTP client for the Ollama provider.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithObjectMode sets the object generation mode for the Ollama provider.
+// ObjectModeAuto and ObjectModeJSON send the schema through the format field
+// of the chat API.
+func WithObjectMode(om fantasy.ObjectMode) Option {
+	return func(o *options) {
+		o.objectMode = om
+	}
+}
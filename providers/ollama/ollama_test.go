@@ -0,0 +1,321 @@
+package ollama
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/schema"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDaemon is a minimal Ollama daemon that records the chat requests.
+type fakeDaemon struct {
+	t        *testing.T
+	requests []map[string]any
+	chat     func(req map[string]any) []string
+}
+
+func newFakeDaemon(t *testing.T, chat func(req map[string]any) []string) (*fakeDaemon, *httptest.Server) {
+	d := &fakeDaemon{t: t, chat: chat}
+	server := httptest.NewServer(d)
+	t.Cleanup(server.Close)
+	return d, server
+}
+
+func (d *fakeDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/chat":
+		var req map[string]any
+		require.NoError(d.t, json.NewDecoder(r.Body).Decode(&req))
+		d.requests = append(d.requests, req)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, line := range d.chat(req) {
+			fmt.Fprintln(w, line)
+		}
+	case "/api/tags":
+		require.Equal(d.t, http.MethodGet, r.Method)
+		fmt.Fprint(w, `{"models":[{"name":"llama3.2:latest","model":"llama3.2:latest","modified_at":"2025-01-02T15:04:05Z","size":2019393189,"digest":"a80c4f17acd5","details":{"format":"gguf","family":"llama","families":["llama"],"parameter_size":"3.2B","quantization_level":"Q4_K_M"}}]}`)
+	case "/api/show":
+		var req map[string]any
+		require.NoError(d.t, json.NewDecoder(r.Body).Decode(&req))
+		if req["model"] != "llama3.2" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"error":"model '%s' not found"}`, req["model"])
+			return
+		}
+		fmt.Fprint(w, `{"modelfile":"FROM llama3.2","parameters":"num_ctx 8192","template":"{{ .Prompt }}","details":{"format":"gguf","family":"llama","parameter_size":"3.2B","quantization_level":"Q4_K_M"},"model_info":{"llama.context_length":131072},"capabilities":["completion","tools"]}`)
+	case "/api/pull":
+		var req map[string]any
+		require.NoError(d.t, json.NewDecoder(r.Body).Decode(&req))
+		if req["model"] == "missing" {
+			fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+			fmt.Fprintln(w, `{"error":"pull model manifest: file does not exist"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+		fmt.Fprintln(w, `{"status":"pulling a80c4f17acd5","digest":"sha256:a80c4f17acd5","total":100,"completed":50}`)
+		fmt.Fprintln(w, `{"status":"pulling a80c4f17acd5","digest":"sha256:a80c4f17acd5","total":100,"completed":100}`)
+		fmt.Fprintln(w, `{"status":"success"}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestLanguageModel(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should send images, think, keep alive and num_ctx", func(t *testing.T) {
+		t.Parallel()
+
+		daemon, server := newFakeDaemon(t, func(map[string]any) []string {
+			return []string{`{"model":"llava","message":{"role":"assistant","content":"A cat.","thinking":"It has whiskers."},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":3}`}
+		})
+		provider, err := New(WithBaseURL(server.URL))
+		require.NoError(t, err)
+		require.Equal(t, Name, provider.Name())
+		model, err := provider.LanguageModel(t.Context(), "llava")
+		require.NoError(t, err)
+
+		think := true
+		keepAlive := "10m"
+		numCtx := int64(8192)
+		result, err := model.Generate(t.Context(), fantasy.Call{
+			Prompt: fantasy.Prompt{
+				fantasy.NewUserMessage("What is this?",
+					fantasy.FilePart{MediaType: "image/png", Data: []byte("png")},
+					fantasy.FilePart{MediaType: "application/pdf", Data: []byte("pdf")},
+				),
+			},
+			ProviderOptions: NewProviderOptions(&ProviderOptions{
+				Think:     &think,
+				KeepAlive: &keepAlive,
+				NumCtx:    &numCtx,
+			}),
+		})
+		require.NoError(t, err)
+		require.Equal(t, "A cat.", result.Content.Text())
+		require.Equal(t, "It has whiskers.", result.Content.ReasoningText())
+		require.Equal(t, fantasy.FinishReasonStop, result.FinishReason)
+		require.Equal(t, int64(12), result.Usage.InputTokens)
+		require.Equal(t, int64(3), result.Usage.OutputTokens)
+		require.Len(t, result.Warnings, 1)
+		require.Equal(t, "file of type application/pdf is not supported", result.Warnings[0].Message)
+
+		require.Len(t, daemon.requests, 1)
+		req := daemon.requests[0]
+		require.Equal(t, "llava", req["model"])
+		require.Equal(t, true, req["think"])
+		require.Equal(t, "10m", req["keep_alive"])
+		require.Equal(t, map[string]any{"num_ctx": float64(8192)}, req["options"])
+		messages := req["messages"].([]any)
+		require.Len(t, messages, 1)
+		message := messages[0].(map[string]any)
+		require.Equal(t, "What is this?", message["content"])
+		require.Equal(t, []any{base64.StdEncoding.EncodeToString([]byte("png"))}, message["images"])
+	})
+
+	t.Run("should stream tool calls", func(t *testing.T) {
+		t.Parallel()
+
+		daemon, server := newFakeDaemon(t, func(map[string]any) []string {
+			return []string{
+				`{"model":"llama3.2","message":{"role":"assistant","content":"","tool_calls":[{"function":{"index":0,"name":"weather","arguments":{"location":"Florence"}}}]},"done":false}`,
+				`{"model":"llama3.2","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":20,"eval_count":8}`,
+			}
+		})
+		provider, err := New(WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "llama3.2")
+		require.NoError(t, err)
+
+		stream, err := model.Stream(t.Context(), fantasy.Call{
+			Prompt: fantasy.Prompt{fantasy.NewUserMessage("What's the weather in Florence?")},
+			Tools: []fantasy.Tool{fantasy.FunctionTool{
+				Name:        "weather",
+				InputSchema: map[string]any{"type": "object"},
+			}},
+		})
+		require.NoError(t, err)
+
+		var parts []fantasy.StreamPart
+		for part := range stream {
+			parts = append(parts, part)
+		}
+		require.Len(t, parts, 2)
+		require.Equal(t, fantasy.StreamPartTypeToolCall, parts[0].Type)
+		require.Equal(t, "weather", parts[0].ToolCallName)
+		require.JSONEq(t, `{"location":"Florence"}`, parts[0].ToolCallInput)
+		require.Equal(t, fantasy.StreamPartTypeFinish, parts[1].Type)
+		require.Equal(t, fantasy.FinishReasonToolCalls, parts[1].FinishReason)
+
+		require.Equal(t, true, daemon.requests[0]["stream"])
+		require.Len(t, daemon.requests[0]["tools"], 1)
+	})
+}
+
+func TestObjectGeneration(t *testing.T) {
+	t.Parallel()
+
+	objectSchema := schema.Schema{
+		Type: "object",
+		Properties: map[string]*schema.Schema{
+			"name": {Type: "string"},
+			"age":  {Type: "integer"},
+		},
+		Required: []string{"name", "age"},
+	}
+
+	t.Run("should generate objects with the format field", func(t *testing.T) {
+		t.Parallel()
+
+		daemon, server := newFakeDaemon(t, func(map[string]any) []string {
+			return []string{`{"model":"llama3.2","message":{"role":"assistant","content":"{\"name\":\"Ada\",\"age\":36}"},"done":true,"done_reason":"stop"}`}
+		})
+		provider, err := New(WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "llama3.2")
+		require.NoError(t, err)
+
+		result, err := model.GenerateObject(t.Context(), fantasy.ObjectCall{
+			Prompt: fantasy.Prompt{fantasy.NewUserMessage("Describe Ada Lovelace")},
+			Schema: objectSchema,
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"name": "Ada", "age": float64(36)}, result.Object)
+
+		format := daemon.requests[0]["format"].(map[string]any)
+		require.Equal(t, "object", format["type"])
+		require.Contains(t, format["properties"], "name")
+		require.Nil(t, daemon.requests[0]["tools"])
+	})
+
+	t.Run("should stream objects with the format field", func(t *testing.T) {
+		t.Parallel()
+
+		daemon, server := newFakeDaemon(t, func(map[string]any) []string {
+			return []string{
+				`{"model":"llama3.2","message":{"role":"assistant","content":"{\"name\":\"Ada\","},"done":false}`,
+				`{"model":"llama3.2","message":{"role":"assistant","content":"\"age\":36}"},"done":false}`,
+				`{"model":"llama3.2","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":10,"eval_count":9}`,
+			}
+		})
+		provider, err := New(WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "llama3.2")
+		require.NoError(t, err)
+
+		stream, err := model.StreamObject(t.Context(), fantasy.ObjectCall{
+			Prompt: fantasy.Prompt{fantasy.NewUserMessage("Describe Ada Lovelace")},
+			Schema: objectSchema,
+		})
+		require.NoError(t, err)
+
+		var last fantasy.ObjectStreamPart
+		var object any
+		for part := range stream {
+			if part.Type == fantasy.ObjectStreamPartTypeObject {
+				object = part.Object
+			}
+			last = part
+		}
+		require.Equal(t, map[string]any{"name": "Ada", "age": float64(36)}, object)
+		require.Equal(t, fantasy.ObjectStreamPartTypeFinish, last.Type)
+		require.Equal(t, int64(9), last.Usage.OutputTokens)
+		require.NotNil(t, daemon.requests[0]["format"])
+	})
+
+	t.Run("should use tools in tool mode", func(t *testing.T) {
+		t.Parallel()
+
+		daemon, server := newFakeDaemon(t, func(map[string]any) []string {
+			return []string{`{"model":"llama3.2","message":{"role":"assistant","content":"","tool_calls":[{"function":{"index":0,"name":"response","arguments":{"name":"Ada","age":36}}}]},"done":true,"done_reason":"stop"}`}
+		})
+		provider, err := New(WithBaseURL(server.URL), WithObjectMode(fantasy.ObjectModeTool))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "llama3.2")
+		require.NoError(t, err)
+
+		result, err := model.GenerateObject(t.Context(), fantasy.ObjectCall{
+			Prompt: fantasy.Prompt{fantasy.NewUserMessage("Describe Ada Lovelace")},
+			Schema: objectSchema,
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"name": "Ada", "age": float64(36)}, result.Object)
+		require.Nil(t, daemon.requests[0]["format"])
+		require.Len(t, daemon.requests[0]["tools"], 1)
+	})
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	_, server := newFakeDaemon(t, nil)
+	client := NewClient(WithBaseURL(server.URL))
+
+	t.Run("should list models", func(t *testing.T) {
+		t.Parallel()
+
+		models, err := client.List(t.Context())
+		require.NoError(t, err)
+		require.Len(t, models, 1)
+		require.Equal(t, "llama3.2:latest", models[0].Name)
+		require.Equal(t, int64(2019393189), models[0].Size)
+		require.Equal(t, "3.2B", models[0].Details.ParameterSize)
+		require.Equal(t, time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC), models[0].ModifiedAt)
+	})
+
+	t.Run("should show models", func(t *testing.T) {
+		t.Parallel()
+
+		info, err := client.Show(t.Context(), "llama3.2")
+		require.NoError(t, err)
+		require.Equal(t, "llama", info.Details.Family)
+		require.Equal(t, []string{"completion", "tools"}, info.Capabilities)
+		require.Equal(t, float64(131072), info.ModelInfo["llama.context_length"])
+	})
+
+	t.Run("should return provider errors", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := client.Show(t.Context(), "unknown")
+		var providerErr *fantasy.ProviderError
+		require.ErrorAs(t, err, &providerErr)
+		require.Equal(t, http.StatusNotFound, providerErr.StatusCode)
+		require.Contains(t, providerErr.Message, "model 'unknown' not found")
+	})
+
+	t.Run("should pull models", func(t *testing.T) {
+		t.Parallel()
+
+		var progress []PullProgress
+		err := client.Pull(t.Context(), "llama3.2", func(p PullProgress) {
+			progress = append(progress, p)
+		})
+		require.NoError(t, err)
+		require.Len(t, progress, 4)
+		require.Equal(t, int64(50), progress[1].Completed)
+		require.Equal(t, "success", progress[3].Status)
+	})
+
+	t.Run("should fail pulls that report an error", func(t *testing.T) {
+		t.Parallel()
+
+		err := client.Pull(t.Context(), "missing", nil)
+		require.ErrorContains(t, err, "file does not exist")
+	})
+}
+
+func TestParseOptions(t *testing.T) {
+	t.Parallel()
+
+	options, err := ParseOptions(map[string]any{"keep_alive": "5m", "num_ctx": 4096})
+	require.NoError(t, err)
+	require.Equal(t, "5m", *options.KeepAlive)
+	require.Equal(t, int64(4096), *options.NumCtx)
+}
@@ -0,0 +1,20 @@
+package ollama
+
+import (
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/ollamacloud"
+)
+
+// ProviderOptions represents additional options for the Ollama provider.
+// They are shared with the Ollama Cloud provider, which builds the requests.
+type ProviderOptions = ollamacloud.ProviderOptions
+
+// NewProviderOptions creates new provider options for the Ollama provider.
+func NewProviderOptions(opts *ProviderOptions) fantasy.ProviderOptions {
+	return ollamacloud.NewProviderOptions(opts)
+}
+
+// ParseOptions parses provider options from a map for the Ollama provider.
+func ParseOptions(data map[string]any) (*ProviderOptions, error) {
+	return ollamacloud.ParseOptions(data)
+}
@@ -0,0 +1,93 @@
+package ollamacloud
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"charm.land/fantasy"
+)
+
+type embeddingModel struct {
+	provider *provider
+	modelID  string
+}
+
+// Provider implements fantasy.EmbeddingModel.
+func (em *embeddingModel) Provider() string {
+	return em.provider.options.name
+}
+
+// Model implements fantasy.EmbeddingModel.
+func (em *embeddingModel) Model() string {
+	return em.modelID
+}
+
+// Dimensions implements fantasy.EmbeddingModel. The dimensionality depends on
+// the model being served and is not known up front.
+func (em *embeddingModel) Dimensions() int64 {
+	return 0
+}
+
+// MaxEmbeddingsPerCall implements fantasy.EmbeddingModel. The /api/embed
+// endpoint does not limit the number of inputs per request.
+func (em *embeddingModel) MaxEmbeddingsPerCall() int {
+	return 0
+}
+
+// Embed implements fantasy.EmbeddingModel.
+func (em *embeddingModel) Embed(ctx context.Context, call fantasy.EmbedCall) (*fantasy.EmbedResponse, error) {
+	return fantasy.EmbedSingle(ctx, call, em.embedBatch)
+}
+
+// EmbedMany implements fantasy.EmbeddingModel.
+func (em *embeddingModel) EmbedMany(ctx context.Context, call fantasy.EmbedManyCall) (*fantasy.EmbedManyResponse, error) {
+	return fantasy.EmbedInBatches(ctx, call, em.MaxEmbeddingsPerCall(), em.embedBatch)
+}
+
+func (em *embeddingModel) embedBatch(ctx context.Context, call fantasy.EmbedManyCall) (*fantasy.EmbedManyResponse, error) {
+	reqBody := map[string]any{
+		"model": em.modelID,
+		"input": call.Values,
+	}
+	if call.Dimensions != nil {
+		reqBody["dimensions"] = *call.Dimensions
+	}
+
+	resp, err := em.provider.doRequest(ctx, "/api/embed", reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var ollamaResp struct {
+		Model           string      `json:"model"`
+		Embeddings      [][]float64 `json:"embeddings"`
+		PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+	}
+	if err := json.Unmarshal(body, &ollamaResp); err != nil {
+		return nil, &fantasy.Error{
+			Title:   "parse error",
+			Message: "failed to parse response",
+			Cause:   err,
+		}
+	}
+
+	embeddings := make([]fantasy.Embedding, 0, len(ollamaResp.Embeddings))
+	for _, embedding := range ollamaResp.Embeddings {
+		embeddings = append(embeddings, embedding)
+	}
+
+	return &fantasy.EmbedManyResponse{
+		Embeddings: embeddings,
+		Usage: fantasy.Usage{
+			InputTokens: int64(ollamaResp.PromptEvalCount),
+			TotalTokens: int64(ollamaResp.PromptEvalCount),
+		},
+	}, nil
+}
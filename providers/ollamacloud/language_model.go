@@ -3,6 +3,7 @@ package ollamacloud
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 
 	"charm.land/fantasy"
 	"charm.land/fantasy/object"
+	"charm.land/fantasy/schema"
 )
 
 type languageModel struct {
@@ -18,12 +20,15 @@ type languageModel struct {
 }
 
 func (lm *languageModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
-	reqBody, err := lm.prepareRequest(call, false)
+	reqBody, warnings, err := lm.prepareRequest(call, false)
 	if err != nil {
 		return nil, err
 	}
+	return lm.generate(ctx, reqBody, warnings)
+}
 
-	resp, err := lm.provider.doRequest(ctx, reqBody)
+func (lm *languageModel) generate(ctx context.Context, reqBody map[string]any, warnings []fantasy.CallWarning) (*fantasy.Response, error) {
+	resp, err := lm.provider.doRequest(ctx, "/api/chat", reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -100,16 +105,20 @@ func (lm *languageModel) Generate(ctx context.Context, call fantasy.Call) (*fant
 			OutputTokens: int64(ollamaResp.EvalCount),
 		},
 		FinishReason: finishReason,
+		Warnings:     warnings,
 	}, nil
 }
 
 func (lm *languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
-	reqBody, err := lm.prepareRequest(call, true)
+	reqBody, warnings, err := lm.prepareRequest(call, true)
 	if err != nil {
 		return nil, err
 	}
+	return lm.stream(ctx, reqBody, warnings)
+}
 
-	resp, err := lm.provider.doRequest(ctx, reqBody)
+func (lm *languageModel) stream(ctx context.Context, reqBody map[string]any, warnings []fantasy.CallWarning) (fantasy.StreamResponse, error) {
+	resp, err := lm.provider.doRequest(ctx, "/api/chat", reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -117,6 +126,15 @@ func (lm *languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy
 	return func(yield func(fantasy.StreamPart) bool) {
 		defer resp.Body.Close()
 
+		if len(warnings) > 0 {
+			if !yield(fantasy.StreamPart{
+				Type:     fantasy.StreamPartTypeWarnings,
+				Warnings: warnings,
+			}) {
+				return
+			}
+		}
+
 		scanner := bufio.NewScanner(resp.Body)
 		scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
 		var thinkingStarted bool
@@ -271,7 +289,11 @@ func (lm *languageModel) mapFinishReason(reason string) fantasy.FinishReason {
 	}
 }
 
-func (lm *languageModel) prepareRequest(call fantasy.Call, stream bool) (map[string]any, error) {
+func (lm *languageModel) prepareRequest(call fantasy.Call, stream bool) (map[string]any, []fantasy.CallWarning, error) {
+	var warnings []fantasy.CallWarning
+	if info, ok := fantasy.LookupModelInfo(lm.Provider(), lm.modelID); ok {
+		call, warnings = fantasy.ApplyModelInfo(info, call)
+	}
 	messages := make([]map[string]any, 0, len(call.Prompt))
 	toolCallNames := make(map[string]string)
 
@@ -329,9 +351,23 @@ func (lm *languageModel) prepareRequest(call fantasy.Call, stream bool) (map[str
 			toolCalls    []map[string]any
 			textBuilder  strings.Builder
 			hasText      bool
+			images       []string
 		)
 
 		for _, part := range msg.Content {
+			if filePart, ok := fantasy.AsMessagePart[fantasy.FilePart](part); ok {
+				// Only images are supported by the chat API.
+				if !strings.HasPrefix(filePart.MediaType, "image/") {
+					warnings = append(warnings, fantasy.CallWarning{
+						Type:    fantasy.CallWarningTypeOther,
+						Message: fmt.Sprintf("file of type %s is not supported", filePart.MediaType),
+					})
+					continue
+				}
+				images = append(images, base64.StdEncoding.EncodeToString(filePart.Data))
+				continue
+			}
+
 			if textPart, ok := fantasy.AsMessagePart[fantasy.TextPart](part); ok {
 				hasText = true
 				textBuilder.WriteString(textPart.Text)
@@ -345,7 +381,7 @@ func (lm *languageModel) prepareRequest(call fantasy.Call, stream bool) (map[str
 				var args map[string]any
 				if toolCallPart.Input != "" {
 					if err := json.Unmarshal([]byte(toolCallPart.Input), &args); err != nil {
-						return nil, &fantasy.Error{
+						return nil, nil, &fantasy.Error{
 							Title:   "invalid argument",
 							Message: "invalid JSON in tool call input",
 							Cause:   err,
@@ -427,6 +463,10 @@ func (lm *languageModel) prepareRequest(call fantasy.Call, stream bool) (map[str
 			}
 		}
 
+		if len(images) > 0 {
+			ollamaMsg["images"] = images
+		}
+
 		messages = append(messages, ollamaMsg)
 	}
 
@@ -443,6 +483,8 @@ func (lm *languageModel) prepareRequest(call fantasy.Call, stream bool) (map[str
 			if !ok {
 				continue
 			}
+			funcTool, schemaWarnings := fantasy.NormalizeTool(funcTool, schemaDialect)
+			warnings = append(warnings, schemaWarnings...)
 			tools = append(tools, map[string]any{
 				"type": "function",
 				"function": map[string]any{
@@ -455,11 +497,18 @@ func (lm *languageModel) prepareRequest(call fantasy.Call, stream bool) (map[str
 		reqBody["tools"] = tools
 	}
 
+	options := map[string]any{}
 	if opts, ok := call.ProviderOptions[Name]; ok {
 		if providerOpts, ok := opts.(*ProviderOptions); ok {
 			if providerOpts.Think != nil && *providerOpts.Think {
 				reqBody["think"] = true
 			}
+			if providerOpts.KeepAlive != nil {
+				reqBody["keep_alive"] = *providerOpts.KeepAlive
+			}
+			if providerOpts.NumCtx != nil {
+				options["num_ctx"] = *providerOpts.NumCtx
+			}
 		}
 	}
 
@@ -476,15 +525,90 @@ func (lm *languageModel) prepareRequest(call fantasy.Call, stream bool) (map[str
 		reqBody["max_tokens"] = *call.MaxOutputTokens
 	}
 
-	return reqBody, nil
+	if len(call.StopSequences) > 0 {
+		options["stop"] = call.StopSequences
+	}
+	if call.Seed != nil {
+		options["seed"] = *call.Seed
+	}
+	if len(options) > 0 {
+		reqBody["options"] = options
+	}
+
+	return reqBody, warnings, nil
 }
 
-// GenerateObject implements fantasy.LanguageModel object generation using tool mode.
+// GenerateObject implements fantasy.LanguageModel.
 func (lm *languageModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
-	return object.GenerateWithTool(ctx, lm, call)
+	switch lm.provider.options.objectMode {
+	case fantasy.ObjectModeText:
+		return object.GenerateWithText(ctx, lm, call)
+	case fantasy.ObjectModeTool:
+		return object.GenerateWithTool(ctx, lm, call)
+	default:
+		return lm.generateObjectWithJSONMode(ctx, call)
+	}
 }
 
-// StreamObject implements fantasy.LanguageModel object streaming using tool mode.
+// StreamObject implements fantasy.LanguageModel.
 func (lm *languageModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
-	return object.StreamWithTool(ctx, lm, call)
+	switch lm.provider.options.objectMode {
+	case fantasy.ObjectModeText:
+		return object.StreamWithText(ctx, lm, call)
+	case fantasy.ObjectModeTool:
+		return object.StreamWithTool(ctx, lm, call)
+	default:
+		return lm.streamObjectWithJSONMode(ctx, call)
+	}
+}
+
+// prepareObjectRequest prepares a request that constrains the response to
+// the object schema through the format field.
+func (lm *languageModel) prepareObjectRequest(call fantasy.ObjectCall, stream bool) (map[string]any, []fantasy.CallWarning, error) {
+	reqBody, warnings, err := lm.prepareRequest(fantasy.Call{
+		Prompt:           call.Prompt,
+		MaxOutputTokens:  call.MaxOutputTokens,
+		Temperature:      call.Temperature,
+		TopP:             call.TopP,
+		TopK:             call.TopK,
+		PresencePenalty:  call.PresencePenalty,
+		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
+		ProviderOptions:  call.ProviderOptions,
+	}, stream)
+	if err != nil {
+		return nil, nil, err
+	}
+	format, schemaWarnings := fantasy.NormalizeSchema(call.Schema, schemaDialect)
+	reqBody["format"] = format
+	return reqBody, append(warnings, schemaWarnings...), nil
+}
+
+// schemaDialect is the schema dialect of tools and formats, Ollama accepts
+// the full specification.
+var schemaDialect = schema.Dialect{Name: "ollama"}
+
+func (lm *languageModel) generateObjectWithJSONMode(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	reqBody, warnings, err := lm.prepareObjectRequest(call, false)
+	if err != nil {
+		return nil, err
+	}
+	response, err := lm.generate(ctx, reqBody, warnings)
+	if err != nil {
+		return nil, err
+	}
+	return object.ParseResponse(ctx, call, response)
+}
+
+func (lm *languageModel) streamObjectWithJSONMode(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	reqBody, warnings, err := lm.prepareObjectRequest(call, true)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := lm.stream(ctx, reqBody, warnings)
+	if err != nil {
+		return nil, err
+	}
+	return object.ParseStream(ctx, call, stream), nil
 }
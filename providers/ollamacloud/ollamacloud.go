@@ -27,12 +27,33 @@ type options struct {
 	name       string
 	headers    map[string]string
 	httpClient *http.Client
+	objectMode fantasy.ObjectMode
 }
 
 type provider struct {
 	options options
 }
 
+// Register the Ollama Cloud provider with the factory registry.
+func init() {
+	fantasy.RegisterProviderFactory(Name, fantasy.ProviderFactory{
+		New: func(config fantasy.ProviderConfig) (fantasy.Provider, error) {
+			opts := []Option{WithHeaders(config.Headers)}
+			if config.APIKey != "" {
+				opts = append(opts, WithAPIKey(config.APIKey))
+			}
+			if config.BaseURL != "" {
+				opts = append(opts, WithBaseURL(config.BaseURL))
+			}
+			return New(opts...)
+		},
+		ParseOptions: func(data map[string]any) (fantasy.ProviderOptionsData, error) {
+			return ParseOptions(data)
+		},
+		APIKeyEnv: "OLLAMA_API_KEY",
+	})
+}
+
 // Option defines a function that configures Ollama Cloud provider options.
 type Option = func(*options)
 
@@ -41,6 +62,7 @@ func New(opts ...Option) (fantasy.Provider, error) {
 	providerOptions := options{
 		headers:    map[string]string{},
 		httpClient: &http.Client{},
+		objectMode: fantasy.ObjectModeAuto,
 	}
 	for _, o := range opts {
 		o(&providerOptions)
@@ -87,8 +109,25 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithObjectMode sets the object generation mode for the Ollama Cloud provider.
+// ObjectModeAuto and ObjectModeJSON send the schema through the format field
+// of the chat API.
+func WithObjectMode(om fantasy.ObjectMode) Option {
+	return func(o *options) {
+		o.objectMode = om
+	}
+}
+
 func (p *provider) LanguageModel(ctx context.Context, modelID string) (fantasy.LanguageModel, error) {
-	return &languageModel{
+	return fantasy.WithCost(&languageModel{
+		provider: p,
+		modelID:  modelID,
+	}), nil
+}
+
+// EmbeddingModel implements fantasy.EmbeddingProvider.
+func (p *provider) EmbeddingModel(ctx context.Context, modelID string) (fantasy.EmbeddingModel, error) {
+	return &embeddingModel{
 		provider: p,
 		modelID:  modelID,
 	}, nil
@@ -98,8 +137,8 @@ func (p *provider) Name() string {
 	return p.options.name
 }
 
-// doRequest makes an HTTP request to the Ollama Cloud API.
-func (p *provider) doRequest(ctx context.Context, reqBody any) (*http.Response, error) {
+// doRequest makes an HTTP request to the given path of the Ollama Cloud API.
+func (p *provider) doRequest(ctx context.Context, path string, reqBody any) (*http.Response, error) {
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, &fantasy.Error{
@@ -109,7 +148,7 @@ func (p *provider) doRequest(ctx context.Context, reqBody any) (*http.Response,
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", p.options.baseURL+"/api/chat", bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.options.baseURL+path, bytes.NewReader(jsonBody))
 	if err != nil {
 		return nil, err
 	}
@@ -131,11 +170,11 @@ func (p *provider) doRequest(ctx context.Context, reqBody any) (*http.Response,
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		return nil, &fantasy.ProviderError{
-			Title:       fmt.Sprintf("HTTP %d", resp.StatusCode),
-			Message:     fmt.Sprintf("API error: %s", string(body)),
-			StatusCode:  resp.StatusCode,
-			URL:         p.options.baseURL + "/api/chat",
-			RequestBody: jsonBody,
+			Title:        fmt.Sprintf("HTTP %d", resp.StatusCode),
+			Message:      fmt.Sprintf("API error: %s", string(body)),
+			StatusCode:   resp.StatusCode,
+			URL:          p.options.baseURL + path,
+			RequestBody:  jsonBody,
 			ResponseBody: body,
 		}
 	}
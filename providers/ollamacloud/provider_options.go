@@ -24,6 +24,12 @@ func init() {
 // ProviderOptions represents additional options for the Ollama Cloud provider.
 type ProviderOptions struct {
 	Think *bool `json:"think,omitempty"`
+	// KeepAlive controls how long the model stays loaded after the request,
+	// as a duration such as "5m" or "1h". A negative duration keeps it loaded
+	// indefinitely and "0" unloads it right away.
+	KeepAlive *string `json:"keep_alive,omitempty"`
+	// NumCtx sets the size of the context window.
+	NumCtx *int64 `json:"num_ctx,omitempty"`
 }
 
 // Options implements the ProviderOptions interface.
@@ -0,0 +1,129 @@
+package openai
+
+import (
+	"context"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/packages/param"
+)
+
+// maxEmbeddingsPerCall is the largest number of inputs the embeddings endpoint
+// accepts in a single request.
+const maxEmbeddingsPerCall = 2048
+
+type embeddingModel struct {
+	provider string
+	modelID  string
+	client   openai.Client
+}
+
+func newEmbeddingModel(modelID string, provider string, client openai.Client) embeddingModel {
+	return embeddingModel{
+		provider: provider,
+		modelID:  modelID,
+		client:   client,
+	}
+}
+
+// Provider implements fantasy.EmbeddingModel.
+func (e embeddingModel) Provider() string {
+	return e.provider
+}
+
+// Model implements fantasy.EmbeddingModel.
+func (e embeddingModel) Model() string {
+	return e.modelID
+}
+
+// Dimensions implements fantasy.EmbeddingModel.
+func (e embeddingModel) Dimensions() int64 {
+	switch e.modelID {
+	case "text-embedding-3-small", "text-embedding-ada-002":
+		return 1536
+	case "text-embedding-3-large":
+		return 3072
+	default:
+		return 0
+	}
+}
+
+// MaxEmbeddingsPerCall implements fantasy.EmbeddingModel.
+func (e embeddingModel) MaxEmbeddingsPerCall() int {
+	return maxEmbeddingsPerCall
+}
+
+// Embed implements fantasy.EmbeddingModel.
+func (e embeddingModel) Embed(ctx context.Context, call fantasy.EmbedCall) (*fantasy.EmbedResponse, error) {
+	return fantasy.EmbedSingle(ctx, call, e.embedBatch)
+}
+
+// EmbedMany implements fantasy.EmbeddingModel.
+func (e embeddingModel) EmbedMany(ctx context.Context, call fantasy.EmbedManyCall) (*fantasy.EmbedManyResponse, error) {
+	return fantasy.EmbedInBatches(ctx, call, e.MaxEmbeddingsPerCall(), e.embedBatch)
+}
+
+func (e embeddingModel) prepareParams(call fantasy.EmbedManyCall) (*openai.EmbeddingNewParams, []fantasy.CallWarning, error) {
+	params := &openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{
+			OfArrayOfStrings: call.Values,
+		},
+		Model:          e.modelID,
+		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
+	}
+	var warnings []fantasy.CallWarning
+
+	if call.Dimensions != nil {
+		// Only text-embedding-3 and later models can shorten their embeddings.
+		if strings.HasPrefix(e.modelID, "text-embedding-ada") {
+			warnings = append(warnings, fantasy.CallWarning{
+				Type:    fantasy.CallWarningTypeUnsupportedSetting,
+				Setting: "Dimensions",
+				Details: "dimensions is not supported for " + e.modelID,
+			})
+		} else {
+			params.Dimensions = param.NewOpt(*call.Dimensions)
+		}
+	}
+
+	if v, ok := call.ProviderOptions[Name]; ok {
+		providerOptions, ok := v.(*EmbeddingProviderOptions)
+		if !ok {
+			return nil, nil, &fantasy.Error{Title: "invalid argument", Message: "openai embedding provider options should be *openai.EmbeddingProviderOptions"}
+		}
+		if providerOptions.User != nil {
+			params.User = param.NewOpt(*providerOptions.User)
+		}
+	}
+	return params, warnings, nil
+}
+
+func (e embeddingModel) embedBatch(ctx context.Context, call fantasy.EmbedManyCall) (*fantasy.EmbedManyResponse, error) {
+	params, warnings, err := e.prepareParams(call)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := e.client.Embeddings.New(ctx, *params)
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+
+	embeddings := make([]fantasy.Embedding, len(response.Data))
+	for _, data := range response.Data {
+		if data.Index < 0 || int(data.Index) >= len(embeddings) {
+			return nil, &fantasy.Error{Title: "invalid response", Message: "embedding index out of range"}
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+
+	return &fantasy.EmbedManyResponse{
+		Embeddings: embeddings,
+		Usage: fantasy.Usage{
+			InputTokens: response.Usage.PromptTokens,
+			TotalTokens: response.Usage.TotalTokens,
+		},
+		Warnings: warnings,
+	}, nil
+}
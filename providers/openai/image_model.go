@@ -0,0 +1,198 @@
+package openai
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/packages/param"
+)
+
+type imageModel struct {
+	provider string
+	modelID  string
+	client   openai.Client
+}
+
+func newImageModel(modelID string, provider string, client openai.Client) imageModel {
+	return imageModel{
+		provider: provider,
+		modelID:  modelID,
+		client:   client,
+	}
+}
+
+// Provider implements fantasy.ImageModel.
+func (m imageModel) Provider() string {
+	return m.provider
+}
+
+// Model implements fantasy.ImageModel.
+func (m imageModel) Model() string {
+	return m.modelID
+}
+
+// GenerateImage implements fantasy.ImageModel. Calls with input images use
+// the edits endpoint.
+func (m imageModel) GenerateImage(ctx context.Context, call fantasy.ImageCall) (*fantasy.ImageResponse, error) {
+	var warnings []fantasy.CallWarning
+	if call.AspectRatio != "" {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "AspectRatio",
+			Details: "aspect ratio is not supported, use size instead",
+		})
+	}
+	if call.Seed != nil {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "Seed",
+		})
+	}
+
+	var providerOptions ImageProviderOptions
+	if v, ok := call.ProviderOptions[Name]; ok {
+		options, ok := v.(*ImageProviderOptions)
+		if !ok {
+			return nil, &fantasy.Error{Title: "invalid argument", Message: "openai image provider options should be *openai.ImageProviderOptions"}
+		}
+		providerOptions = *options
+	}
+
+	var response *openai.ImagesResponse
+	var err error
+	if len(call.Images) > 0 {
+		response, err = m.client.Images.Edit(ctx, m.prepareEditParams(call, providerOptions))
+	} else {
+		if call.Mask != nil {
+			warnings = append(warnings, fantasy.CallWarning{
+				Type:    fantasy.CallWarningTypeUnsupportedSetting,
+				Setting: "Mask",
+				Details: "a mask requires an input image",
+			})
+		}
+		response, err = m.client.Images.Generate(ctx, m.prepareGenerateParams(call, providerOptions))
+	}
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+
+	mediaType := "image/" + cmp.Or(string(response.OutputFormat), "png")
+	images := make([]fantasy.FileContent, 0, len(response.Data))
+	for _, image := range response.Data {
+		data, err := base64.StdEncoding.DecodeString(image.B64JSON)
+		if err != nil {
+			return nil, &fantasy.Error{Title: "invalid response", Message: "failed to decode image", Cause: err}
+		}
+		images = append(images, fantasy.FileContent{
+			MediaType: mediaType,
+			Data:      data,
+		})
+	}
+
+	return &fantasy.ImageResponse{
+		Images: images,
+		Usage: fantasy.Usage{
+			InputTokens:  response.Usage.InputTokens,
+			OutputTokens: response.Usage.OutputTokens,
+			TotalTokens:  response.Usage.TotalTokens,
+		},
+		Warnings: warnings,
+	}, nil
+}
+
+// returnsURLs reports whether the model returns URLs by default instead of
+// base64 encoded images.
+func (m imageModel) returnsURLs() bool {
+	return strings.HasPrefix(m.modelID, "dall-e")
+}
+
+func (m imageModel) prepareGenerateParams(call fantasy.ImageCall, options ImageProviderOptions) openai.ImageGenerateParams {
+	params := openai.ImageGenerateParams{
+		Prompt: call.Prompt,
+		Model:  m.modelID,
+	}
+	if call.N > 0 {
+		params.N = param.NewOpt(int64(call.N))
+	}
+	if call.Size != "" {
+		params.Size = openai.ImageGenerateParamsSize(call.Size)
+	}
+	if m.returnsURLs() {
+		params.ResponseFormat = openai.ImageGenerateParamsResponseFormatB64JSON
+	}
+	if options.Quality != nil {
+		params.Quality = openai.ImageGenerateParamsQuality(*options.Quality)
+	}
+	if options.Background != nil {
+		params.Background = openai.ImageGenerateParamsBackground(*options.Background)
+	}
+	if options.OutputFormat != nil {
+		params.OutputFormat = openai.ImageGenerateParamsOutputFormat(*options.OutputFormat)
+	}
+	if options.Style != nil {
+		params.Style = openai.ImageGenerateParamsStyle(*options.Style)
+	}
+	if options.Moderation != nil {
+		params.Moderation = openai.ImageGenerateParamsModeration(*options.Moderation)
+	}
+	if options.User != nil {
+		params.User = param.NewOpt(*options.User)
+	}
+	return params
+}
+
+func (m imageModel) prepareEditParams(call fantasy.ImageCall, options ImageProviderOptions) openai.ImageEditParams {
+	images := make([]io.Reader, 0, len(call.Images))
+	for i, image := range call.Images {
+		images = append(images, imageFile(image, fmt.Sprintf("image-%d", i)))
+	}
+	params := openai.ImageEditParams{
+		Image:  openai.ImageEditParamsImageUnion{OfFileArray: images},
+		Prompt: call.Prompt,
+		Model:  m.modelID,
+	}
+	if call.Mask != nil {
+		params.Mask = imageFile(*call.Mask, "mask")
+	}
+	if call.N > 0 {
+		params.N = param.NewOpt(int64(call.N))
+	}
+	if call.Size != "" {
+		params.Size = openai.ImageEditParamsSize(call.Size)
+	}
+	if m.returnsURLs() {
+		params.ResponseFormat = openai.ImageEditParamsResponseFormatB64JSON
+	}
+	if options.Quality != nil {
+		params.Quality = openai.ImageEditParamsQuality(*options.Quality)
+	}
+	if options.Background != nil {
+		params.Background = openai.ImageEditParamsBackground(*options.Background)
+	}
+	if options.OutputFormat != nil {
+		params.OutputFormat = openai.ImageEditParamsOutputFormat(*options.OutputFormat)
+	}
+	if options.User != nil {
+		params.User = param.NewOpt(*options.User)
+	}
+	return params
+}
+
+// imageFile creates a multipart file from an image, the filename is derived
+// from the media type when it is not set.
+func imageFile(image fantasy.FilePart, name string) io.Reader {
+	mediaType := cmp.Or(image.MediaType, "image/png")
+	filename := image.Filename
+	if filename == "" {
+		_, ext, _ := strings.Cut(mediaType, "/")
+		filename = name + "." + ext
+	}
+	return openai.File(bytes.NewReader(image.Data), filename, mediaType)
+}
@@ -136,7 +136,13 @@ func (o languageModel) Provider() string {
 
 func (o languageModel) prepareParams(call fantasy.Call) (*openai.ChatCompletionNewParams, []fantasy.CallWarning, error) {
 	params := &openai.ChatCompletionNewParams{}
-	messages, warnings := o.toPromptFunc(call.Prompt, o.provider, o.modelID)
+	info, known := modelInfo(o.provider, o.modelID)
+	var warnings []fantasy.CallWarning
+	if known {
+		call, warnings = fantasy.ApplyModelInfo(info, call)
+	}
+	messages, promptWarnings := o.toPromptFunc(call.Prompt, o.provider, o.modelID)
+	warnings = append(warnings, promptWarnings...)
 	if call.TopK != nil {
 		warnings = append(warnings, fantasy.CallWarning{
 			Type:    fantasy.CallWarningTypeUnsupportedSetting,
@@ -159,8 +165,16 @@ func (o languageModel) prepareParams(call fantasy.Call) (*openai.ChatCompletionN
 	if call.PresencePenalty != nil {
 		params.PresencePenalty = param.NewOpt(*call.PresencePenalty)
 	}
+	if len(call.StopSequences) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{
+			OfStringArray: call.StopSequences,
+		}
+	}
+	if call.Seed != nil {
+		params.Seed = param.NewOpt(*call.Seed)
+	}
 
-	if isReasoningModel(o.modelID) {
+	if info.Reasoning {
 		// remove unsupported settings for reasoning models
 		// see https://platform.openai.com/docs/guides/reasoning#limitations
 		if call.Temperature != nil {
@@ -206,7 +220,7 @@ func (o languageModel) prepareParams(call fantasy.Call) (*openai.ChatCompletionN
 	}
 
 	// Handle search preview models
-	if isSearchPreviewModel(o.modelID) {
+	if info.WebSearch {
 		if call.Temperature != nil {
 			params.Temperature = param.Opt[float64]{}
 			warnings = append(warnings, fantasy.CallWarning{
@@ -229,8 +243,27 @@ func (o languageModel) prepareParams(call fantasy.Call) (*openai.ChatCompletionN
 	params.Messages = messages
 	params.Model = o.modelID
 
-	if len(call.Tools) > 0 {
-		tools, toolChoice, toolWarnings := toOpenAiTools(call.Tools, call.ToolChoice)
+	callTools := call.Tools
+	if info.WebSearch {
+		// Search preview models configure web search through the request
+		// options instead of a tool.
+		callTools = make([]fantasy.Tool, 0, len(call.Tools))
+		for _, tool := range call.Tools {
+			pt, ok := tool.(fantasy.ProviderDefinedTool)
+			if !ok || pt.ID != WebSearchToolID {
+				callTools = append(callTools, tool)
+				continue
+			}
+			webSearchOptions, err := parseWebSearchToolArgs(pt)
+			if err != nil {
+				return nil, nil, err
+			}
+			params.WebSearchOptions = toChatWebSearchOptions(webSearchOptions)
+		}
+	}
+
+	if len(callTools) > 0 {
+		tools, toolChoice, toolWarnings := toOpenAiTools(callTools, call.ToolChoice)
 		params.Tools = tools
 		if toolChoice != nil {
 			params.ToolChoice = *toolChoice
@@ -556,27 +589,32 @@ func (o languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.S
 	}, nil
 }
 
+// modelInfo returns the catalog entry of a model. Models served through
+// other OpenAI-compatible APIs are also looked up in the OpenAI catalog, with
+// the "openai/" vendor prefix and the fine-tuning prefix removed. Models
+// missing from the catalog are reported as unknown, they are treated as
+// reasoning models when their ID names a reasoning model family.
+func modelInfo(provider, modelID string) (fantasy.ModelInfo, bool) {
+	if info, ok := fantasy.LookupModelInfo(provider, modelID); ok {
+		return info, true
+	}
+	modelID = strings.TrimPrefix(modelID, "openai/")
+	modelID = strings.TrimPrefix(modelID, "ft:")
+	if info, ok := fantasy.LookupModelInfo(Name, modelID); ok {
+		return info, true
+	}
+	if isReasoningModel(modelID) {
+		return fantasy.ModelInfo{Reasoning: true, SystemMessageMode: "developer"}, false
+	}
+	return fantasy.ModelInfo{}, false
+}
+
 func isReasoningModel(modelID string) bool {
 	return strings.HasPrefix(modelID, "o1") || strings.Contains(modelID, "-o1") ||
 		strings.HasPrefix(modelID, "o3") || strings.Contains(modelID, "-o3") ||
 		strings.HasPrefix(modelID, "o4") || strings.Contains(modelID, "-o4") ||
 		strings.HasPrefix(modelID, "oss") || strings.Contains(modelID, "-oss") ||
-		strings.Contains(modelID, "gpt-5") || strings.Contains(modelID, "gpt-5-chat")
-}
-
-func isSearchPreviewModel(modelID string) bool {
-	return strings.Contains(modelID, "search-preview")
-}
-
-func supportsFlexProcessing(modelID string) bool {
-	return strings.HasPrefix(modelID, "o3") || strings.Contains(modelID, "-o3") ||
-		strings.Contains(modelID, "o4-mini") || strings.Contains(modelID, "gpt-5")
-}
-
-func supportsPriorityProcessing(modelID string) bool {
-	return strings.Contains(modelID, "gpt-4") || strings.Contains(modelID, "gpt-5") ||
-		strings.Contains(modelID, "gpt-5-mini") || strings.HasPrefix(modelID, "o3") ||
-		strings.Contains(modelID, "-o3") || strings.Contains(modelID, "o4-mini")
+		(strings.Contains(modelID, "gpt-5") && !strings.Contains(modelID, "gpt-5-chat"))
 }
 
 func toOpenAiTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice) (openAiTools []openai.ChatCompletionToolUnionParam, openAiToolChoice *openai.ChatCompletionToolChoiceOptionUnionParam, warnings []fantasy.CallWarning) {
@@ -586,6 +624,8 @@ func toOpenAiTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice) (openAi
 			if !ok {
 				continue
 			}
+			ft, schemaWarnings := fantasy.NormalizeTool(ft, jsonSchemaDialect)
+			warnings = append(warnings, schemaWarnings...)
 			openAiTools = append(openAiTools, openai.ChatCompletionToolUnionParam{
 				OfFunction: &openai.ChatCompletionFunctionToolParam{
 					Function: shared.FunctionDefinitionParam{
@@ -667,7 +707,7 @@ func parseAnnotationsFromDelta(delta openai.ChatCompletionChunkChoiceDelta) []op
 
 // GenerateObject implements fantasy.LanguageModel.
 func (o languageModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
-	switch o.objectMode {
+	switch o.resolvedObjectMode() {
 	case fantasy.ObjectModeText:
 		return object.GenerateWithText(ctx, o, call)
 	case fantasy.ObjectModeTool:
@@ -679,7 +719,7 @@ func (o languageModel) GenerateObject(ctx context.Context, call fantasy.ObjectCa
 
 // StreamObject implements fantasy.LanguageModel.
 func (o languageModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
-	switch o.objectMode {
+	switch o.resolvedObjectMode() {
 	case fantasy.ObjectModeTool:
 		return object.StreamWithTool(ctx, o, call)
 	case fantasy.ObjectModeText:
@@ -689,10 +729,17 @@ func (o languageModel) StreamObject(ctx context.Context, call fantasy.ObjectCall
 	}
 }
 
-func (o languageModel) generateObjectWithJSONMode(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
-	jsonSchemaMap := schema.ToMap(call.Schema)
+// resolvedObjectMode resolves ObjectModeAuto with the model catalog, unknown
+// models use JSON mode.
+func (o languageModel) resolvedObjectMode() fantasy.ObjectMode {
+	if info, ok := modelInfo(o.provider, o.modelID); ok {
+		return info.ResolveObjectMode(o.objectMode)
+	}
+	return o.objectMode
+}
 
-	addAdditionalPropertiesFalse(jsonSchemaMap)
+func (o languageModel) generateObjectWithJSONMode(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	jsonSchemaMap, schemaWarnings := fantasy.NormalizeSchema(call.Schema, strictSchemaDialect)
 
 	schemaName := call.SchemaName
 	if schemaName == "" {
@@ -706,6 +753,8 @@ func (o languageModel) generateObjectWithJSONMode(ctx context.Context, call fant
 		TopP:             call.TopP,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	}
 
@@ -713,6 +762,7 @@ func (o languageModel) generateObjectWithJSONMode(ctx context.Context, call fant
 	if err != nil {
 		return nil, err
 	}
+	warnings = append(warnings, schemaWarnings...)
 
 	params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
 		OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
@@ -743,11 +793,13 @@ func (o languageModel) generateObjectWithJSONMode(ctx context.Context, call fant
 	choice := response.Choices[0]
 	jsonText := choice.Message.Content
 
+	// Strict mode makes the model send null for optional properties.
+	objectText := removeOptionalNulls(jsonText, schema.ToMap(call.Schema))
 	var obj any
 	if call.RepairText != nil {
-		obj, err = schema.ParseAndValidateWithRepair(ctx, jsonText, call.Schema, call.RepairText)
+		obj, err = schema.ParseAndValidateWithRepair(ctx, objectText, call.Schema, call.RepairText)
 	} else {
-		obj, err = schema.ParseAndValidate(jsonText, call.Schema)
+		obj, err = schema.ParseAndValidate(objectText, call.Schema)
 	}
 
 	usage, _ := o.usageFunc(*response)
@@ -771,9 +823,7 @@ func (o languageModel) generateObjectWithJSONMode(ctx context.Context, call fant
 }
 
 func (o languageModel) streamObjectWithJSONMode(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
-	jsonSchemaMap := schema.ToMap(call.Schema)
-
-	addAdditionalPropertiesFalse(jsonSchemaMap)
+	jsonSchemaMap, schemaWarnings := fantasy.NormalizeSchema(call.Schema, strictSchemaDialect)
 
 	schemaName := call.SchemaName
 	if schemaName == "" {
@@ -787,6 +837,8 @@ func (o languageModel) streamObjectWithJSONMode(ctx context.Context, call fantas
 		TopP:             call.TopP,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	}
 
@@ -794,6 +846,7 @@ func (o languageModel) streamObjectWithJSONMode(ctx context.Context, call fantas
 	if err != nil {
 		return nil, err
 	}
+	warnings = append(warnings, schemaWarnings...)
 
 	params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
 		OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
@@ -810,6 +863,13 @@ func (o languageModel) streamObjectWithJSONMode(ctx context.Context, call fantas
 		IncludeUsage: openai.Bool(true),
 	}
 
+	// The schema is compiled once, every partial object is validated.
+	validator, err := schema.Compile(call.Schema)
+	if err != nil {
+		return nil, err
+	}
+	schemaMap := schema.ToMap(call.Schema)
+
 	stream := o.client.Chat.Completions.NewStreaming(ctx, *params)
 
 	return func(yield func(fantasy.ObjectStreamPart) bool) {
@@ -848,9 +908,10 @@ func (o languageModel) streamObjectWithJSONMode(ctx context.Context, call fantas
 				accumulated += choice.Delta.Content
 
 				obj, state, parseErr := schema.ParsePartialJSON(accumulated)
+				obj = schema.RemoveOptionalNulls(obj, schemaMap)
 
 				if state == schema.ParseStateSuccessful || state == schema.ParseStateRepaired {
-					if err := schema.ValidateAgainstSchema(obj, call.Schema); err == nil {
+					if err := validator.Validate(obj); err == nil {
 						if !reflect.DeepEqual(obj, lastParsedObject) {
 							if !yield(fantasy.ObjectStreamPart{
 								Type:   fantasy.ObjectStreamPartTypeObject,
@@ -867,8 +928,9 @@ func (o languageModel) streamObjectWithJSONMode(ctx context.Context, call fantas
 					repairedText, repairErr := call.RepairText(ctx, accumulated, parseErr)
 					if repairErr == nil {
 						obj2, state2, _ := schema.ParsePartialJSON(repairedText)
+						obj2 = schema.RemoveOptionalNulls(obj2, schemaMap)
 						if (state2 == schema.ParseStateSuccessful || state2 == schema.ParseStateRepaired) &&
-							schema.ValidateAgainstSchema(obj2, call.Schema) == nil {
+							validator.Validate(obj2) == nil {
 							if !reflect.DeepEqual(obj2, lastParsedObject) {
 								if !yield(fantasy.ObjectStreamPart{
 									Type:   fantasy.ObjectStreamPartTypeObject,
@@ -915,26 +977,37 @@ func (o languageModel) streamObjectWithJSONMode(ctx context.Context, call fantas
 	}, nil
 }
 
-// addAdditionalPropertiesFalse recursively adds "additionalProperties": false to all object schemas.
-// This is required by OpenAI's strict mode for structured outputs.
-func addAdditionalPropertiesFalse(schema map[string]any) {
-	if schema["type"] == "object" {
-		if _, hasAdditional := schema["additionalProperties"]; !hasAdditional {
-			schema["additionalProperties"] = false
-		}
-
-		// Recursively process nested properties
-		if properties, ok := schema["properties"].(map[string]any); ok {
-			for _, propValue := range properties {
-				if propSchema, ok := propValue.(map[string]any); ok {
-					addAdditionalPropertiesFalse(propSchema)
-				}
-			}
-		}
-	}
+// jsonSchemaDialect is the schema dialect of function tools.
+var jsonSchemaDialect = schema.Dialect{Name: "openai"}
+
+// strictSchemaDialect is the schema dialect of strict structured outputs and
+// strict function tools.
+var strictSchemaDialect = schema.Dialect{
+	Name:         "openai strict",
+	CloseObjects: true,
+	RequireAll:   true,
+	OneOfAsAnyOf: true,
+	UnsupportedKeywords: []string{
+		"allOf", "not", "if", "then", "else", "dependentRequired", "dependentSchemas",
+		"patternProperties", "unevaluatedProperties", "propertyNames", "minProperties", "maxProperties",
+		"minLength", "maxLength",
+		"unevaluatedItems", "contains", "minContains", "maxContains", "uniqueItems",
+	},
+}
 
-	// Handle array items
-	if items, ok := schema["items"].(map[string]any); ok {
-		addAdditionalPropertiesFalse(items)
+// removeOptionalNulls removes the nulls a model sends in strict mode for the
+// optional properties of the schema s from a JSON text. The text is returned
+// unchanged when it is not valid JSON.
+func removeOptionalNulls(text string, s map[string]any) string {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return text
+	}
+	data, err := json.Marshal(schema.RemoveOptionalNulls(value, s))
+	if err != nil {
+		return text
 	}
+	return string(data)
 }
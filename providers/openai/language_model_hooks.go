@@ -124,7 +124,8 @@ func DefaultPrepareCallFunc(model fantasy.LanguageModel, params *openai.ChatComp
 		}
 	}
 
-	if isReasoningModel(model.Model()) {
+	info, _ := modelInfo(model.Provider(), model.Model())
+	if info.Reasoning {
 		if providerOptions.LogitBias != nil {
 			params.LogitBias = nil
 			warnings = append(warnings, fantasy.CallWarning{
@@ -154,14 +155,14 @@ func DefaultPrepareCallFunc(model fantasy.LanguageModel, params *openai.ChatComp
 	// Handle service tier validation
 	if providerOptions.ServiceTier != nil {
 		serviceTier := *providerOptions.ServiceTier
-		if serviceTier == "flex" && !supportsFlexProcessing(model.Model()) {
+		if serviceTier == "flex" && !info.SupportsServiceTier("flex") {
 			params.ServiceTier = ""
 			warnings = append(warnings, fantasy.CallWarning{
 				Type:    fantasy.CallWarningTypeUnsupportedSetting,
 				Setting: "ServiceTier",
 				Details: "flex processing is only available for o3, o4-mini, and gpt-5 models",
 			})
-		} else if serviceTier == "priority" && !supportsPriorityProcessing(model.Model()) {
+		} else if serviceTier == "priority" && !info.SupportsServiceTier("priority") {
 			params.ServiceTier = ""
 			warnings = append(warnings, fantasy.CallWarning{
 				Type:    fantasy.CallWarningTypeUnsupportedSetting,
@@ -485,6 +486,9 @@ func DefaultToPrompt(prompt fantasy.Prompt, _, _ string) ([]openai.ChatCompletio
 						})
 						continue
 					}
+					if toolCallPart.ProviderExecuted {
+						continue
+					}
 					assistantMsg.ToolCalls = append(assistantMsg.ToolCalls,
 						openai.ChatCompletionMessageToolCallUnionParam{
 							OfFunction: &openai.ChatCompletionMessageFunctionToolCallParam{
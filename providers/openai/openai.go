@@ -36,6 +36,26 @@ type options struct {
 	languageModelOptions []LanguageModelOption
 }
 
+// Register the OpenAI provider with the factory registry.
+func init() {
+	fantasy.RegisterProviderFactory(Name, fantasy.ProviderFactory{
+		New: func(config fantasy.ProviderConfig) (fantasy.Provider, error) {
+			opts := []Option{WithHeaders(config.Headers)}
+			if config.APIKey != "" {
+				opts = append(opts, WithAPIKey(config.APIKey))
+			}
+			if config.BaseURL != "" {
+				opts = append(opts, WithBaseURL(config.BaseURL))
+			}
+			return New(opts...)
+		},
+		ParseOptions: func(data map[string]any) (fantasy.ProviderOptionsData, error) {
+			return ParseOptions(data)
+		},
+		APIKeyEnv: "OPENAI_API_KEY",
+	})
+}
+
 // Option defines a function that configures OpenAI provider options.
 type Option = func(*options)
 
@@ -145,6 +165,48 @@ func WithObjectMode(om fantasy.ObjectMode) Option {
 
 // LanguageModel implements fantasy.Provider.
 func (o *provider) LanguageModel(_ context.Context, modelID string) (fantasy.LanguageModel, error) {
+	client := o.newClient()
+
+	if o.options.useResponsesAPI && IsResponsesModel(modelID) {
+		// Not supported for responses API
+		objectMode := o.options.objectMode
+		if objectMode == fantasy.ObjectModeJSON {
+			objectMode = fantasy.ObjectModeAuto
+		}
+		return fantasy.WithCost(newResponsesLanguageModel(modelID, o.options.name, client, objectMode)), nil
+	}
+
+	o.options.languageModelOptions = append(o.options.languageModelOptions, WithLanguageModelObjectMode(o.options.objectMode))
+
+	return fantasy.WithCost(newLanguageModel(
+		modelID,
+		o.options.name,
+		client,
+		o.options.languageModelOptions...,
+	)), nil
+}
+
+// EmbeddingModel implements fantasy.EmbeddingProvider.
+func (o *provider) EmbeddingModel(_ context.Context, modelID string) (fantasy.EmbeddingModel, error) {
+	return newEmbeddingModel(modelID, o.options.name, o.newClient()), nil
+}
+
+// ImageModel implements fantasy.ImageProvider.
+func (o *provider) ImageModel(_ context.Context, modelID string) (fantasy.ImageModel, error) {
+	return newImageModel(modelID, o.options.name, o.newClient()), nil
+}
+
+// TranscriptionModel implements fantasy.TranscriptionProvider.
+func (o *provider) TranscriptionModel(_ context.Context, modelID string) (fantasy.TranscriptionModel, error) {
+	return newTranscriptionModel(modelID, o.options.name, o.newClient()), nil
+}
+
+// SpeechModel implements fantasy.SpeechProvider.
+func (o *provider) SpeechModel(_ context.Context, modelID string) (fantasy.SpeechModel, error) {
+	return newSpeechModel(modelID, o.options.name, o.newClient()), nil
+}
+
+func (o *provider) newClient() openai.Client {
 	openaiClientOptions := make([]option.RequestOption, 0, 5+len(o.options.headers)+len(o.options.sdkOptions))
 	openaiClientOptions = append(openaiClientOptions, option.WithMaxRetries(0))
 
@@ -165,25 +227,7 @@ func (o *provider) LanguageModel(_ context.Context, modelID string) (fantasy.Lan
 
 	openaiClientOptions = append(openaiClientOptions, o.options.sdkOptions...)
 
-	client := openai.NewClient(openaiClientOptions...)
-
-	if o.options.useResponsesAPI && IsResponsesModel(modelID) {
-		// Not supported for responses API
-		objectMode := o.options.objectMode
-		if objectMode == fantasy.ObjectModeJSON {
-			objectMode = fantasy.ObjectModeAuto
-		}
-		return newResponsesLanguageModel(modelID, o.options.name, client, objectMode), nil
-	}
-
-	o.options.languageModelOptions = append(o.options.languageModelOptions, WithLanguageModelObjectMode(o.options.objectMode))
-
-	return newLanguageModel(
-		modelID,
-		o.options.name,
-		client,
-		o.options.languageModelOptions...,
-	), nil
+	return openai.NewClient(openaiClientOptions...)
 }
 
 func (o *provider) Name() string {
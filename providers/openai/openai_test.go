@@ -1,16 +1,21 @@
 package openai
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"charm.land/fantasy"
+	"charm.land/fantasy/schema"
 	"github.com/openai/openai-go/v2/packages/param"
 	"github.com/stretchr/testify/require"
 )
@@ -1087,6 +1092,36 @@ func TestDoGenerate(t *testing.T) {
 		require.Equal(t, "test-user-id", call.body["user"])
 	})
 
+	t.Run("should pass stop sequences and seed", func(t *testing.T) {
+		t.Parallel()
+
+		server := newMockServer()
+		defer server.close()
+
+		server.prepareJSONResponse(map[string]any{})
+
+		provider, err := New(
+			WithAPIKey("test-api-key"),
+			WithBaseURL(server.server.URL),
+		)
+		require.NoError(t, err)
+		model, _ := provider.LanguageModel(t.Context(), "gpt-3.5-turbo")
+
+		result, err := model.Generate(context.Background(), fantasy.Call{
+			Prompt:        testPrompt,
+			StopSequences: []string{"END"},
+			Seed:          fantasy.Opt[int64](42),
+		})
+
+		require.NoError(t, err)
+		require.Empty(t, result.Warnings)
+		require.Len(t, server.calls, 1)
+
+		call := server.calls[0]
+		require.Equal(t, []any{"END"}, call.body["stop"])
+		require.Equal(t, float64(42), call.body["seed"])
+	})
+
 	t.Run("should pass reasoningEffort setting", func(t *testing.T) {
 		t.Parallel()
 
@@ -1494,6 +1529,42 @@ func TestDoGenerate(t *testing.T) {
 		require.Equal(t, "Hello", message["content"])
 	})
 
+	t.Run("should use registered model info", func(t *testing.T) {
+		t.Parallel()
+
+		fantasy.RegisterModelInfo("openai-test-gateway", "house-reasoner", fantasy.ModelInfo{Reasoning: true})
+
+		server := newMockServer()
+		defer server.close()
+
+		server.prepareJSONResponse(map[string]any{})
+
+		provider, err := New(
+			WithName("openai-test-gateway"),
+			WithAPIKey("test-api-key"),
+			WithBaseURL(server.server.URL),
+		)
+		require.NoError(t, err)
+		model, _ := provider.LanguageModel(t.Context(), "house-reasoner")
+
+		result, err := model.Generate(context.Background(), fantasy.Call{
+			Prompt:          testPrompt,
+			Temperature:     &[]float64{0.5}[0],
+			MaxOutputTokens: &[]int64{1000}[0],
+			Tools:           []fantasy.Tool{fantasy.FunctionTool{Name: "search"}},
+		})
+
+		require.NoError(t, err)
+		call := server.calls[0]
+		require.Nil(t, call.body["temperature"])
+		require.Nil(t, call.body["tools"])
+		require.Equal(t, float64(1000), call.body["max_completion_tokens"])
+
+		require.Len(t, result.Warnings, 2)
+		require.Equal(t, fantasy.CallWarningTypeUnsupportedTool, result.Warnings[0].Type)
+		require.Equal(t, "temperature", result.Warnings[1].Setting)
+	})
+
 	t.Run("should return reasoning tokens", func(t *testing.T) {
 		t.Parallel()
 
@@ -3188,3 +3259,925 @@ func TestResponsesToPrompt_DropsEmptyMessages(t *testing.T) {
 		require.Empty(t, warnings)
 	})
 }
+
+func TestEmbeddingModel(t *testing.T) {
+	t.Parallel()
+
+	newEmbeddingServer := func(t *testing.T, requests *[]map[string]any) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/embeddings", r.URL.Path)
+
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			*requests = append(*requests, body)
+
+			inputs, _ := body["input"].([]any)
+			data := make([]map[string]any, 0, len(inputs))
+			// Return the embeddings in reverse order to check they are
+			// reordered by index.
+			for i := len(inputs) - 1; i >= 0; i-- {
+				data = append(data, map[string]any{
+					"object":    "embedding",
+					"index":     i,
+					"embedding": []float64{float64(len(inputs[i].(string))), 0.5},
+				})
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"object": "list",
+				"model":  body["model"],
+				"data":   data,
+				"usage": map[string]any{
+					"prompt_tokens": len(inputs) * 2,
+					"total_tokens":  len(inputs) * 2,
+				},
+			})
+		}))
+	}
+
+	t.Run("should embed many values", func(t *testing.T) {
+		t.Parallel()
+
+		var requests []map[string]any
+		server := newEmbeddingServer(t, &requests)
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.EmbeddingProvider).EmbeddingModel(t.Context(), "text-embedding-3-small")
+		require.NoError(t, err)
+		require.Equal(t, int64(1536), model.Dimensions())
+		require.Equal(t, 2048, model.MaxEmbeddingsPerCall())
+
+		dimensions := int64(256)
+		result, err := model.EmbedMany(context.Background(), fantasy.EmbedManyCall{
+			Values:          []string{"a", "bb", "ccc"},
+			Dimensions:      &dimensions,
+			ProviderOptions: NewEmbeddingProviderOptions(&EmbeddingProviderOptions{User: fantasy.Opt("user-1")}),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []fantasy.Embedding{{1, 0.5}, {2, 0.5}, {3, 0.5}}, result.Embeddings)
+		require.Equal(t, int64(6), result.Usage.InputTokens)
+		require.Empty(t, result.Warnings)
+
+		require.Len(t, requests, 1)
+		require.Equal(t, "text-embedding-3-small", requests[0]["model"])
+		require.Equal(t, "float", requests[0]["encoding_format"])
+		require.Equal(t, float64(256), requests[0]["dimensions"])
+		require.Equal(t, "user-1", requests[0]["user"])
+	})
+
+	t.Run("should embed a single value", func(t *testing.T) {
+		t.Parallel()
+
+		var requests []map[string]any
+		server := newEmbeddingServer(t, &requests)
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.EmbeddingProvider).EmbeddingModel(t.Context(), "text-embedding-3-large")
+		require.NoError(t, err)
+
+		result, err := model.Embed(context.Background(), fantasy.EmbedCall{Value: "hello"})
+		require.NoError(t, err)
+		require.Equal(t, fantasy.Embedding{5, 0.5}, result.Embedding)
+		require.Equal(t, int64(2), result.Usage.InputTokens)
+		require.NotContains(t, requests[0], "dimensions")
+	})
+
+	t.Run("should warn about unsupported dimensions", func(t *testing.T) {
+		t.Parallel()
+
+		var requests []map[string]any
+		server := newEmbeddingServer(t, &requests)
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.EmbeddingProvider).EmbeddingModel(t.Context(), "text-embedding-ada-002")
+		require.NoError(t, err)
+
+		dimensions := int64(256)
+		result, err := model.Embed(context.Background(), fantasy.EmbedCall{Value: "hello", Dimensions: &dimensions})
+		require.NoError(t, err)
+		require.Len(t, result.Warnings, 1)
+		require.Equal(t, fantasy.CallWarningTypeUnsupportedSetting, result.Warnings[0].Type)
+		require.NotContains(t, requests[0], "dimensions")
+	})
+
+	t.Run("should return provider errors", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"slow down","type":"rate_limit"}}`))
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.EmbeddingProvider).EmbeddingModel(t.Context(), "text-embedding-3-small")
+		require.NoError(t, err)
+
+		_, err = model.EmbedMany(context.Background(), fantasy.EmbedManyCall{Values: []string{"a"}})
+		var providerErr *fantasy.ProviderError
+		require.ErrorAs(t, err, &providerErr)
+		require.Equal(t, http.StatusTooManyRequests, providerErr.StatusCode)
+		require.Equal(t, "slow down", providerErr.Message)
+	})
+}
+
+func TestWebSearchTool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should map web search call output items in the responses api", func(t *testing.T) {
+		t.Parallel()
+
+		var request map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/responses", r.URL.Path)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":     "resp_1",
+				"object": "response",
+				"model":  "gpt-4o",
+				"status": "completed",
+				"output": []any{
+					map[string]any{
+						"type":   "web_search_call",
+						"id":     "ws_1",
+						"status": "completed",
+						"action": map[string]any{
+							"type":  "search",
+							"query": "fantasy go",
+						},
+					},
+					map[string]any{
+						"type":   "message",
+						"id":     "msg_1",
+						"role":   "assistant",
+						"status": "completed",
+						"content": []any{
+							map[string]any{
+								"type": "output_text",
+								"text": "Found it.",
+								"annotations": []any{
+									map[string]any{
+										"type":        "url_citation",
+										"url":         "https://charm.land",
+										"title":       "Charm",
+										"start_index": 0,
+										"end_index":   9,
+									},
+								},
+							},
+						},
+					},
+				},
+				"usage": map[string]any{
+					"input_tokens":  10,
+					"output_tokens": 5,
+					"total_tokens":  15,
+				},
+			})
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithUseResponsesAPI())
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "gpt-4o")
+		require.NoError(t, err)
+
+		result, err := model.Generate(t.Context(), fantasy.Call{
+			Prompt: testPrompt,
+			Tools: []fantasy.Tool{
+				WebSearchTool(&WebSearchToolOptions{
+					SearchContextSize: "low",
+					UserLocation:      &WebSearchUserLocation{Country: "GB"},
+				}),
+			},
+		})
+		require.NoError(t, err)
+		require.Empty(t, result.Warnings)
+
+		tools, ok := request["tools"].([]any)
+		require.True(t, ok)
+		require.Equal(t, []any{
+			map[string]any{
+				"type":                "web_search",
+				"search_context_size": "low",
+				"user_location": map[string]any{
+					"type":    "approximate",
+					"country": "GB",
+				},
+			},
+		}, tools)
+
+		toolCalls := result.Content.ToolCalls()
+		require.Len(t, toolCalls, 1)
+		require.True(t, toolCalls[0].ProviderExecuted)
+		require.Equal(t, "ws_1", toolCalls[0].ToolCallID)
+		require.JSONEq(t, `{"type":"search","query":"fantasy go"}`, toolCalls[0].Input)
+
+		toolResults := result.Content.ToolResults()
+		require.Len(t, toolResults, 1)
+		require.True(t, toolResults[0].ProviderExecuted)
+		require.Equal(t, "web_search", toolResults[0].ToolName)
+
+		sources := result.Content.Sources()
+		require.Len(t, sources, 1)
+		require.Equal(t, "https://charm.land", sources[0].URL)
+		require.Equal(t, fantasy.FinishReasonStop, result.FinishReason)
+	})
+
+	t.Run("should send web search options for search preview models", func(t *testing.T) {
+		t.Parallel()
+
+		server := newMockServer()
+		defer server.close()
+		server.prepareJSONResponse(map[string]any{})
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.server.URL))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "gpt-4o-search-preview")
+		require.NoError(t, err)
+
+		result, err := model.Generate(t.Context(), fantasy.Call{
+			Prompt: testPrompt,
+			Tools: []fantasy.Tool{
+				WebSearchTool(&WebSearchToolOptions{SearchContextSize: "high"}),
+			},
+		})
+		require.NoError(t, err)
+		require.Empty(t, result.Warnings)
+
+		require.Len(t, server.calls, 1)
+		require.Equal(t, map[string]any{"search_context_size": "high"}, server.calls[0].body["web_search_options"])
+		require.Nil(t, server.calls[0].body["tools"])
+	})
+
+	t.Run("should warn for other chat models", func(t *testing.T) {
+		t.Parallel()
+
+		server := newMockServer()
+		defer server.close()
+		server.prepareJSONResponse(map[string]any{})
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.server.URL))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "gpt-4o")
+		require.NoError(t, err)
+
+		result, err := model.Generate(t.Context(), fantasy.Call{
+			Prompt: testPrompt,
+			Tools:  []fantasy.Tool{WebSearchTool(nil)},
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Warnings, 1)
+		require.Equal(t, fantasy.CallWarningTypeUnsupportedTool, result.Warnings[0].Type)
+	})
+}
+
+func TestImageModel(t *testing.T) {
+	t.Parallel()
+
+	pixel := []byte{0x89, 0x50, 0x4e, 0x47}
+
+	writeImages := func(w http.ResponseWriter, n int, outputFormat string) {
+		data := make([]map[string]any, 0, n)
+		for range n {
+			data = append(data, map[string]any{"b64_json": base64.StdEncoding.EncodeToString(pixel)})
+		}
+		response := map[string]any{
+			"created": 1,
+			"data":    data,
+			"usage": map[string]any{
+				"input_tokens":  10,
+				"output_tokens": 20,
+				"total_tokens":  30,
+			},
+		}
+		if outputFormat != "" {
+			response["output_format"] = outputFormat
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+
+	t.Run("should generate images", func(t *testing.T) {
+		t.Parallel()
+
+		var body map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/images/generations", r.URL.Path)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			writeImages(w, 2, "webp")
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.ImageProvider).ImageModel(t.Context(), "gpt-image-1")
+		require.NoError(t, err)
+
+		result, err := model.GenerateImage(t.Context(), fantasy.ImageCall{
+			Prompt: "a cat",
+			N:      2,
+			Size:   "1024x1024",
+			ProviderOptions: NewImageProviderOptions(&ImageProviderOptions{
+				Quality:      fantasy.Opt("high"),
+				OutputFormat: fantasy.Opt("webp"),
+			}),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []fantasy.FileContent{
+			{MediaType: "image/webp", Data: pixel},
+			{MediaType: "image/webp", Data: pixel},
+		}, result.Images)
+		require.Equal(t, fantasy.Usage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30}, result.Usage)
+		require.Empty(t, result.Warnings)
+
+		require.Equal(t, "gpt-image-1", body["model"])
+		require.Equal(t, "a cat", body["prompt"])
+		require.Equal(t, float64(2), body["n"])
+		require.Equal(t, "1024x1024", body["size"])
+		require.Equal(t, "high", body["quality"])
+		require.Equal(t, "webp", body["output_format"])
+		require.NotContains(t, body, "response_format")
+	})
+
+	t.Run("should request base64 images from dall-e", func(t *testing.T) {
+		t.Parallel()
+
+		var body map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			writeImages(w, 1, "")
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.ImageProvider).ImageModel(t.Context(), "dall-e-3")
+		require.NoError(t, err)
+
+		result, err := model.GenerateImage(t.Context(), fantasy.ImageCall{Prompt: "a dog"})
+		require.NoError(t, err)
+		require.Equal(t, []fantasy.FileContent{{MediaType: "image/png", Data: pixel}}, result.Images)
+		require.Equal(t, "b64_json", body["response_format"])
+	})
+
+	t.Run("should edit images", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/images/edits", r.URL.Path)
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			require.Equal(t, "add a hat", r.FormValue("prompt"))
+			require.Equal(t, "gpt-image-1", r.FormValue("model"))
+			require.Len(t, r.MultipartForm.File["image[]"], 2)
+			require.Equal(t, "image-0.png", r.MultipartForm.File["image[]"][0].Filename)
+			require.Equal(t, "cat.jpeg", r.MultipartForm.File["image[]"][1].Filename)
+			require.Len(t, r.MultipartForm.File["mask"], 1)
+			writeImages(w, 1, "png")
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.ImageProvider).ImageModel(t.Context(), "gpt-image-1")
+		require.NoError(t, err)
+
+		result, err := model.GenerateImage(t.Context(), fantasy.ImageCall{
+			Prompt: "add a hat",
+			Images: []fantasy.FilePart{
+				{Data: pixel, MediaType: "image/png"},
+				{Data: pixel, MediaType: "image/jpeg", Filename: "cat.jpeg"},
+			},
+			Mask: &fantasy.FilePart{Data: pixel, MediaType: "image/png"},
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Images, 1)
+	})
+
+	t.Run("should warn about unsupported settings", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeImages(w, 1, "")
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.ImageProvider).ImageModel(t.Context(), "gpt-image-1")
+		require.NoError(t, err)
+
+		seed := int64(42)
+		result, err := model.GenerateImage(t.Context(), fantasy.ImageCall{
+			Prompt:      "a cat",
+			AspectRatio: "16:9",
+			Seed:        &seed,
+			Mask:        &fantasy.FilePart{Data: pixel, MediaType: "image/png"},
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Warnings, 3)
+		require.Equal(t, "AspectRatio", result.Warnings[0].Setting)
+		require.Equal(t, "Seed", result.Warnings[1].Setting)
+		require.Equal(t, "Mask", result.Warnings[2].Setting)
+	})
+}
+
+func TestTranscriptionModel(t *testing.T) {
+	t.Parallel()
+
+	audio := []byte("fake audio")
+
+	t.Run("should transcribe with segments", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/audio/transcriptions", r.URL.Path)
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			require.Equal(t, "whisper-1", r.FormValue("model"))
+			require.Equal(t, "verbose_json", r.FormValue("response_format"))
+			require.Equal(t, "en", r.FormValue("language"))
+			require.Equal(t, "fantasy", r.FormValue("prompt"))
+			file, header, err := r.FormFile("file")
+			require.NoError(t, err)
+			require.Equal(t, "audio.mp3", header.Filename)
+			data, err := io.ReadAll(file)
+			require.NoError(t, err)
+			require.Equal(t, audio, data)
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"text":     "Hello there. General Kenobi.",
+				"language": "english",
+				"duration": 3.5,
+				"segments": []map[string]any{
+					{"id": 0, "text": " Hello there.", "start": 0, "end": 1.5},
+					{"id": 1, "text": " General Kenobi.", "start": 1.5, "end": 3.5},
+				},
+				"usage": map[string]any{"type": "duration", "seconds": 4},
+			})
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.TranscriptionProvider).TranscriptionModel(t.Context(), "whisper-1")
+		require.NoError(t, err)
+
+		result, err := model.Transcribe(t.Context(), fantasy.TranscriptionCall{
+			Audio:    fantasy.FilePart{Data: audio, MediaType: "audio/mpeg"},
+			Language: "en",
+			Prompt:   "fantasy",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Hello there. General Kenobi.", result.Text)
+		require.Equal(t, "english", result.Language)
+		require.Equal(t, 3500*time.Millisecond, result.Duration)
+		require.Equal(t, []fantasy.TranscriptionSegment{
+			{Text: "Hello there.", Start: 0, End: 1500 * time.Millisecond},
+			{Text: "General Kenobi.", Start: 1500 * time.Millisecond, End: 3500 * time.Millisecond},
+		}, result.Segments)
+	})
+
+	t.Run("should transcribe without segments", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			require.Empty(t, r.FormValue("response_format"))
+			_, header, err := r.FormFile("file")
+			require.NoError(t, err)
+			require.Equal(t, "voice.wav", header.Filename)
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"text": "Hello",
+				"usage": map[string]any{
+					"type":          "tokens",
+					"input_tokens":  10,
+					"output_tokens": 2,
+					"total_tokens":  12,
+				},
+			})
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.TranscriptionProvider).TranscriptionModel(t.Context(), "gpt-4o-transcribe")
+		require.NoError(t, err)
+
+		result, err := model.Transcribe(t.Context(), fantasy.TranscriptionCall{
+			Audio: fantasy.FilePart{Data: audio, MediaType: "audio/wav", Filename: "voice.wav"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Hello", result.Text)
+		require.Empty(t, result.Segments)
+		require.Equal(t, fantasy.Usage{InputTokens: 10, OutputTokens: 2, TotalTokens: 12}, result.Usage)
+	})
+
+	t.Run("should return provider errors", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"invalid file format","type":"invalid_request_error"}}`))
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.TranscriptionProvider).TranscriptionModel(t.Context(), "whisper-1")
+		require.NoError(t, err)
+
+		_, err = model.Transcribe(t.Context(), fantasy.TranscriptionCall{
+			Audio: fantasy.FilePart{Data: audio, MediaType: "audio/mpeg"},
+		})
+		var providerErr *fantasy.ProviderError
+		require.ErrorAs(t, err, &providerErr)
+		require.Equal(t, http.StatusBadRequest, providerErr.StatusCode)
+	})
+}
+
+func TestSpeechModel(t *testing.T) {
+	t.Parallel()
+
+	audio := bytes.Repeat([]byte("audio"), speechChunkSize)
+
+	newSpeechServer := func(t *testing.T, body *map[string]any) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/audio/speech", r.URL.Path)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(body))
+			w.Header().Set("Content-Type", "audio/mpeg")
+			_, _ = w.Write(audio)
+		}))
+	}
+
+	t.Run("should generate speech", func(t *testing.T) {
+		t.Parallel()
+
+		var body map[string]any
+		server := newSpeechServer(t, &body)
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.SpeechProvider).SpeechModel(t.Context(), "gpt-4o-mini-tts")
+		require.NoError(t, err)
+
+		speed := 1.5
+		result, err := model.GenerateSpeech(t.Context(), fantasy.SpeechCall{
+			Text:         "Hello",
+			Voice:        "coral",
+			Instructions: "Speak cheerfully",
+			Speed:        &speed,
+			OutputFormat: "wav",
+		})
+		require.NoError(t, err)
+		require.Equal(t, fantasy.FileContent{MediaType: "audio/wav", Data: audio}, result.Audio)
+		require.Empty(t, result.Warnings)
+
+		require.Equal(t, "gpt-4o-mini-tts", body["model"])
+		require.Equal(t, "Hello", body["input"])
+		require.Equal(t, "coral", body["voice"])
+		require.Equal(t, "Speak cheerfully", body["instructions"])
+		require.Equal(t, 1.5, body["speed"])
+		require.Equal(t, "wav", body["response_format"])
+	})
+
+	t.Run("should stream speech", func(t *testing.T) {
+		t.Parallel()
+
+		var body map[string]any
+		server := newSpeechServer(t, &body)
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+		require.NoError(t, err)
+		model, err := provider.(fantasy.SpeechProvider).SpeechModel(t.Context(), "tts-1")
+		require.NoError(t, err)
+
+		stream, err := model.StreamSpeech(t.Context(), fantasy.SpeechCall{Text: "Hello", OutputFormat: "ogg"})
+		require.NoError(t, err)
+
+		var data []byte
+		var parts []fantasy.SpeechStreamPart
+		for part := range stream {
+			parts = append(parts, part)
+			if part.Type == fantasy.SpeechStreamPartTypeAudioDelta {
+				require.Equal(t, "audio/mpeg", part.MediaType)
+				require.LessOrEqual(t, len(part.Data), speechChunkSize)
+				data = append(data, part.Data...)
+			}
+		}
+		require.Equal(t, audio, data)
+		require.Greater(t, len(parts), 3)
+		require.Equal(t, fantasy.SpeechStreamPartTypeWarnings, parts[0].Type)
+		require.Equal(t, "OutputFormat", parts[0].Warnings[0].Setting)
+		require.Equal(t, fantasy.SpeechStreamPartTypeFinish, parts[len(parts)-1].Type)
+
+		require.Equal(t, "alloy", body["voice"])
+		require.Equal(t, "mp3", body["response_format"])
+	})
+}
+
+func TestStrictSchemaDialect(t *testing.T) {
+	t.Parallel()
+
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"labels": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+			},
+			"next": map[string]any{
+				"anyOf": []any{
+					map[string]any{"$ref": "#/$defs/Node"},
+					map[string]any{"type": "null"},
+				},
+			},
+			"shape": map[string]any{
+				"oneOf": []any{
+					map[string]any{"type": "object", "properties": map[string]any{}},
+				},
+			},
+			"name": map[string]any{"type": "string", "minLength": 1},
+		},
+		"required": []string{"labels", "next", "shape"},
+		"$defs": map[string]any{
+			"Node": map[string]any{
+				"type":       []any{"object", "null"},
+				"properties": map[string]any{},
+			},
+		},
+	}
+
+	result, losses := schema.Normalize(s, strictSchemaDialect)
+
+	props := result["properties"].(map[string]any)
+	require.Equal(t, false, result["additionalProperties"])
+	require.Equal(t, []string{"labels", "next", "shape", "name"}, result["required"])
+	require.Equal(t, false, props["labels"].(map[string]any)["additionalProperties"])
+	require.Equal(t, false, result["$defs"].(map[string]any)["Node"].(map[string]any)["additionalProperties"])
+
+	name := props["name"].(map[string]any)
+	require.Equal(t, []any{"string", "null"}, name["type"])
+	require.NotContains(t, name, "minLength")
+
+	shape := props["shape"].(map[string]any)
+	require.NotContains(t, shape, "oneOf")
+	variant := shape["anyOf"].([]any)[0].(map[string]any)
+	require.Equal(t, false, variant["additionalProperties"])
+
+	require.Len(t, losses, 3)
+
+	// The input schema is left untouched.
+	require.NotContains(t, s, "additionalProperties")
+	require.Contains(t, s["properties"].(map[string]any)["shape"], "oneOf")
+}
+
+func TestStrictOptionalProperties(t *testing.T) {
+	t.Parallel()
+
+	type Person struct {
+		Name     string `json:"name"`
+		Nickname string `json:"nickname,omitempty"`
+	}
+
+	t.Run("should accept null for optional properties of objects", func(t *testing.T) {
+		t.Parallel()
+
+		server := newMockServer()
+		defer server.close()
+		server.prepareJSONResponse(map[string]any{
+			"content": `{"name":"Ada","nickname":null}`,
+		})
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.server.URL))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "gpt-4o")
+		require.NoError(t, err)
+
+		result, err := model.GenerateObject(t.Context(), fantasy.ObjectCall{
+			Prompt: testPrompt,
+			Schema: schema.Generate(reflect.TypeFor[Person]()),
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"name": "Ada"}, result.Object)
+		require.Equal(t, `{"name":"Ada","nickname":null}`, result.RawText)
+
+		responseFormat := server.calls[0].body["response_format"].(map[string]any)
+		sentSchema := responseFormat["json_schema"].(map[string]any)["schema"].(map[string]any)
+		require.Equal(t, []any{"name", "nickname"}, sentSchema["required"])
+	})
+
+	t.Run("should accept null for optional properties of strict tools", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":     "resp_1",
+				"object": "response",
+				"model":  "gpt-4o",
+				"status": "completed",
+				"output": []any{
+					map[string]any{
+						"type":      "function_call",
+						"id":        "fc_1",
+						"call_id":   "call_1",
+						"name":      "greet",
+						"arguments": `{"name":"Ada","nickname":null}`,
+						"status":    "completed",
+					},
+				},
+				"usage": map[string]any{
+					"input_tokens":  10,
+					"output_tokens": 5,
+					"total_tokens":  15,
+				},
+			})
+		}))
+		defer server.Close()
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithUseResponsesAPI())
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "gpt-4o")
+		require.NoError(t, err)
+
+		result, err := model.Generate(t.Context(), fantasy.Call{
+			Prompt: testPrompt,
+			Tools: []fantasy.Tool{
+				fantasy.FunctionTool{
+					Name:        "greet",
+					InputSchema: schema.ToMap(schema.Generate(reflect.TypeFor[Person]())),
+				},
+			},
+			ProviderOptions: NewResponsesProviderOptions(&ResponsesProviderOptions{
+				StrictJSONSchema: fantasy.Opt(true),
+			}),
+		})
+		require.NoError(t, err)
+		toolCalls := result.Content.ToolCalls()
+		require.Len(t, toolCalls, 1)
+		require.JSONEq(t, `{"name":"Ada"}`, toolCalls[0].Input)
+	})
+}
+
+func TestStreamObjectInvalidSchema(t *testing.T) {
+	t.Parallel()
+
+	for name, opts := range map[string][]Option{
+		"chat":      nil,
+		"responses": {WithUseResponsesAPI()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newStreamingMockServer()
+			defer server.close()
+
+			provider, err := New(append(opts, WithAPIKey("test-api-key"), WithBaseURL(server.server.URL))...)
+			require.NoError(t, err)
+			model, err := provider.LanguageModel(t.Context(), "gpt-4o")
+			require.NoError(t, err)
+
+			_, err = model.StreamObject(t.Context(), fantasy.ObjectCall{
+				Prompt: testPrompt,
+				Schema: schema.Schema{Type: "string", Pattern: "("},
+			})
+			require.Error(t, err)
+			// The schema is compiled before the request is sent.
+			require.Empty(t, server.calls)
+		})
+	}
+}
+
+func TestReasoningModels(t *testing.T) {
+	t.Parallel()
+
+	generate := func(t *testing.T, modelID string, opts ...Option) (*fantasy.Response, map[string]any) {
+		t.Helper()
+
+		var request map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":     "resp_1",
+				"object": "response",
+				"model":  modelID,
+				"status": "completed",
+				"output": []any{},
+				"usage":  map[string]any{"input_tokens": 1, "output_tokens": 1, "total_tokens": 2},
+			})
+		}))
+		t.Cleanup(server.Close)
+
+		provider, err := New(append(opts, WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithUseResponsesAPI())...)
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), modelID)
+		require.NoError(t, err)
+
+		result, err := model.Generate(t.Context(), fantasy.Call{
+			Prompt:      testPrompt,
+			Temperature: fantasy.Opt(0.5),
+			ProviderOptions: NewResponsesProviderOptions(&ResponsesProviderOptions{
+				ReasoningEffort: fantasy.Opt(ReasoningEffortHigh),
+			}),
+		})
+		require.NoError(t, err)
+		return result, request
+	}
+
+	for _, modelID := range []string{"gpt-5.1", "gpt-5.1-codex-max", "gpt-5.2-codex"} {
+		t.Run("should send reasoning options to "+modelID, func(t *testing.T) {
+			t.Parallel()
+
+			result, request := generate(t, modelID)
+			require.Equal(t, map[string]any{"effort": "high"}, request["reasoning"])
+			require.NotContains(t, request, "temperature")
+			require.Len(t, result.Warnings, 1)
+			require.Equal(t, "temperature", result.Warnings[0].Setting)
+		})
+	}
+
+	t.Run("should warn when dropping reasoning options", func(t *testing.T) {
+		t.Parallel()
+
+		result, request := generate(t, "gpt-4o")
+		require.NotContains(t, request, "reasoning")
+		require.Equal(t, 0.5, request["temperature"])
+		require.Len(t, result.Warnings, 1)
+		require.Equal(t, "reasoningEffort", result.Warnings[0].Setting)
+	})
+
+	t.Run("should treat unknown gpt-5 models as reasoning models", func(t *testing.T) {
+		t.Parallel()
+
+		server := newMockServer()
+		defer server.close()
+		server.prepareJSONResponse(map[string]any{})
+
+		provider, err := New(WithAPIKey("test-api-key"), WithBaseURL(server.server.URL))
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), "gpt-5.3")
+		require.NoError(t, err)
+
+		result, err := model.Generate(t.Context(), fantasy.Call{
+			Prompt:      testPrompt,
+			Temperature: fantasy.Opt(0.5),
+			ProviderOptions: NewProviderOptions(&ProviderOptions{
+				ReasoningEffort: fantasy.Opt(ReasoningEffortHigh),
+			}),
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Warnings, 1)
+		require.Equal(t, "temperature", result.Warnings[0].Setting)
+		require.Equal(t, "high", server.calls[0].body["reasoning_effort"])
+		require.NotContains(t, server.calls[0].body, "temperature")
+	})
+}
+
+func TestRegistryOpenModel(t *testing.T) {
+	t.Parallel()
+
+	server := newMockServer()
+	defer server.close()
+	server.prepareJSONResponse(map[string]any{})
+
+	registry := fantasy.NewRegistry(fantasy.Config{
+		Providers: map[string]fantasy.ProviderConfig{
+			"gateway": {
+				Type:    Name,
+				APIKey:  "test-api-key",
+				BaseURL: server.server.URL,
+				Headers: map[string]string{"X-Gateway": "fantasy"},
+				Options: map[string]any{"user": "config-user"},
+			},
+		},
+	})
+	model, err := registry.OpenModel(t.Context(), "gateway/gpt-4o-mini")
+	require.NoError(t, err)
+
+	_, err = model.Generate(t.Context(), fantasy.Call{Prompt: testPrompt})
+	require.NoError(t, err)
+	require.Len(t, server.calls, 1)
+	call := server.calls[0]
+	require.Equal(t, "gpt-4o-mini", call.body["model"])
+	require.Equal(t, "config-user", call.body["user"])
+	require.Equal(t, "fantasy", call.headers["X-Gateway"])
+	require.Equal(t, "Bearer test-api-key", call.headers["Authorization"])
+}
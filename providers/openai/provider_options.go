@@ -27,6 +27,9 @@ const (
 	TypeProviderOptions     = Name + ".options"
 	TypeProviderFileOptions = Name + ".file_options"
 	TypeProviderMetadata    = Name + ".metadata"
+
+	TypeEmbeddingProviderOptions = Name + ".embedding_options"
+	TypeImageProviderOptions     = Name + ".image_options"
 )
 
 // Register OpenAI provider-specific types with the global registry.
@@ -52,6 +55,20 @@ func init() {
 		}
 		return &v, nil
 	})
+	fantasy.RegisterProviderType(TypeEmbeddingProviderOptions, func(data []byte) (fantasy.ProviderOptionsData, error) {
+		var v EmbeddingProviderOptions
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
+	fantasy.RegisterProviderType(TypeImageProviderOptions, func(data []byte) (fantasy.ProviderOptionsData, error) {
+		var v ImageProviderOptions
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
 }
 
 // ProviderMetadata represents additional metadata from OpenAI provider.
@@ -145,6 +162,71 @@ func (o *ProviderFileOptions) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// EmbeddingProviderOptions represents additional options for OpenAI embedding models.
+type EmbeddingProviderOptions struct {
+	User *string `json:"user"`
+}
+
+// Options implements the ProviderOptions interface.
+func (*EmbeddingProviderOptions) Options() {}
+
+// MarshalJSON implements custom JSON marshaling with type info for EmbeddingProviderOptions.
+func (o EmbeddingProviderOptions) MarshalJSON() ([]byte, error) {
+	type plain EmbeddingProviderOptions
+	return fantasy.MarshalProviderType(TypeEmbeddingProviderOptions, plain(o))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling with type info for EmbeddingProviderOptions.
+func (o *EmbeddingProviderOptions) UnmarshalJSON(data []byte) error {
+	type plain EmbeddingProviderOptions
+	var p plain
+	if err := fantasy.UnmarshalProviderType(data, &p); err != nil {
+		return err
+	}
+	*o = EmbeddingProviderOptions(p)
+	return nil
+}
+
+// ImageProviderOptions represents additional options for OpenAI image models.
+type ImageProviderOptions struct {
+	// Quality is the quality of the images, e.g. "low", "medium", "high",
+	// "hd" or "standard" depending on the model.
+	Quality *string `json:"quality"`
+	// Background is the background of the images, "transparent", "opaque"
+	// or "auto". Only supported by gpt-image models.
+	Background *string `json:"background"`
+	// OutputFormat is the format of the images, "png", "jpeg" or "webp".
+	// Only supported by gpt-image models.
+	OutputFormat *string `json:"output_format"`
+	// Style is the style of the images, "vivid" or "natural". Only supported
+	// by dall-e-3.
+	Style *string `json:"style"`
+	// Moderation is the content moderation level, "low" or "auto". Only
+	// supported by gpt-image models.
+	Moderation *string `json:"moderation"`
+	User       *string `json:"user"`
+}
+
+// Options implements the ProviderOptions interface.
+func (*ImageProviderOptions) Options() {}
+
+// MarshalJSON implements custom JSON marshaling with type info for ImageProviderOptions.
+func (o ImageProviderOptions) MarshalJSON() ([]byte, error) {
+	type plain ImageProviderOptions
+	return fantasy.MarshalProviderType(TypeImageProviderOptions, plain(o))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling with type info for ImageProviderOptions.
+func (o *ImageProviderOptions) UnmarshalJSON(data []byte) error {
+	type plain ImageProviderOptions
+	var p plain
+	if err := fantasy.UnmarshalProviderType(data, &p); err != nil {
+		return err
+	}
+	*o = ImageProviderOptions(p)
+	return nil
+}
+
 // ReasoningEffortOption creates a pointer to a ReasoningEffort value.
 func ReasoningEffortOption(e ReasoningEffort) *ReasoningEffort {
 	return &e
@@ -164,6 +246,20 @@ func NewProviderFileOptions(opts *ProviderFileOptions) fantasy.ProviderOptions {
 	}
 }
 
+// NewEmbeddingProviderOptions creates new embedding provider options for OpenAI.
+func NewEmbeddingProviderOptions(opts *EmbeddingProviderOptions) fantasy.ProviderOptions {
+	return fantasy.ProviderOptions{
+		Name: opts,
+	}
+}
+
+// NewImageProviderOptions creates new image provider options for OpenAI.
+func NewImageProviderOptions(opts *ImageProviderOptions) fantasy.ProviderOptions {
+	return fantasy.ProviderOptions{
+		Name: opts,
+	}
+}
+
 // ParseOptions parses provider options from a map.
 func ParseOptions(data map[string]any) (*ProviderOptions, error) {
 	var options ProviderOptions
@@ -172,3 +268,21 @@ func ParseOptions(data map[string]any) (*ProviderOptions, error) {
 	}
 	return &options, nil
 }
+
+// ParseEmbeddingOptions parses embedding provider options from a map.
+func ParseEmbeddingOptions(data map[string]any) (*EmbeddingProviderOptions, error) {
+	var options EmbeddingProviderOptions
+	if err := fantasy.ParseOptions(data, &options); err != nil {
+		return nil, err
+	}
+	return &options, nil
+}
+
+// ParseImageOptions parses image provider options from a map.
+func ParseImageOptions(data map[string]any) (*ImageProviderOptions, error) {
+	var options ImageProviderOptions
+	if err := fantasy.ParseOptions(data, &options); err != nil {
+		return nil, err
+	}
+	return &options, nil
+}
@@ -0,0 +1,148 @@
+package openai
+
+import (
+	"encoding/json"
+
+	"charm.land/fantasy"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/packages/param"
+	"github.com/openai/openai-go/v2/responses"
+)
+
+// WebSearchToolID is the ID of the OpenAI web search provider-defined tool.
+const WebSearchToolID = Name + ".web_search"
+
+// WebSearchToolOptions configures the OpenAI web search tool.
+type WebSearchToolOptions struct {
+	// SearchContextSize is one of "low", "medium" or "high".
+	SearchContextSize string `json:"search_context_size,omitempty"`
+	// AllowedDomains restricts the search to the given domains, only
+	// supported by the Responses API.
+	AllowedDomains []string               `json:"allowed_domains,omitempty"`
+	UserLocation   *WebSearchUserLocation `json:"user_location,omitempty"`
+}
+
+// WebSearchUserLocation is the approximate location of the user.
+type WebSearchUserLocation struct {
+	City     string `json:"city,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// WebSearchTool creates the OpenAI web search tool.
+//
+// With the Responses API it is available to all models that support web
+// search, with the Chat Completions API only the search preview models
+// support it.
+func WebSearchTool(opts *WebSearchToolOptions) fantasy.ProviderDefinedTool {
+	return fantasy.ProviderDefinedTool{
+		ID:   WebSearchToolID,
+		Name: "web_search",
+		Args: toToolArgs(opts),
+	}
+}
+
+func toToolArgs(opts any) map[string]any {
+	args := map[string]any{}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return args
+	}
+	_ = json.Unmarshal(data, &args)
+	return args
+}
+
+func parseWebSearchToolArgs(tool fantasy.ProviderDefinedTool) (*WebSearchToolOptions, error) {
+	var opts WebSearchToolOptions
+	if err := fantasy.ParseOptions(tool.Args, &opts); err != nil {
+		return nil, err
+	}
+	return &opts, nil
+}
+
+func toResponsesWebSearchTool(opts *WebSearchToolOptions) *responses.WebSearchToolParam {
+	tool := &responses.WebSearchToolParam{
+		Type:              responses.WebSearchToolTypeWebSearch,
+		SearchContextSize: responses.WebSearchToolSearchContextSize(opts.SearchContextSize),
+	}
+	if len(opts.AllowedDomains) > 0 {
+		tool.Filters.AllowedDomains = opts.AllowedDomains
+	}
+	if loc := opts.UserLocation; loc != nil {
+		tool.UserLocation = responses.WebSearchToolUserLocationParam{
+			Type:     "approximate",
+			City:     optString(loc.City),
+			Region:   optString(loc.Region),
+			Country:  optString(loc.Country),
+			Timezone: optString(loc.Timezone),
+		}
+	}
+	return tool
+}
+
+func toChatWebSearchOptions(opts *WebSearchToolOptions) openai.ChatCompletionNewParamsWebSearchOptions {
+	webSearch := openai.ChatCompletionNewParamsWebSearchOptions{
+		SearchContextSize: opts.SearchContextSize,
+	}
+	if loc := opts.UserLocation; loc != nil {
+		webSearch.UserLocation = openai.ChatCompletionNewParamsWebSearchOptionsUserLocation{
+			Approximate: openai.ChatCompletionNewParamsWebSearchOptionsUserLocationApproximate{
+				City:     optString(loc.City),
+				Region:   optString(loc.Region),
+				Country:  optString(loc.Country),
+				Timezone: optString(loc.Timezone),
+			},
+		}
+	}
+	return webSearch
+}
+
+func optString(s string) param.Opt[string] {
+	if s == "" {
+		return param.Opt[string]{}
+	}
+	return param.NewOpt(s)
+}
+
+type webSearchCallInput struct {
+	Type    string `json:"type,omitempty"`
+	Query   string `json:"query,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+type webSearchCallResult struct {
+	Status  string   `json:"status"`
+	Sources []string `json:"sources,omitempty"`
+}
+
+// webSearchCallContent converts a web search call output item into a
+// provider executed tool call and its result.
+func webSearchCallContent(item responses.ResponseOutputItemUnion) (fantasy.ToolCallContent, fantasy.ToolResultContent) {
+	input, _ := json.Marshal(webSearchCallInput{
+		Type:    item.Action.Type,
+		Query:   item.Action.Query,
+		URL:     item.Action.URL,
+		Pattern: item.Action.Pattern,
+	})
+	result := webSearchCallResult{Status: item.Status}
+	for _, source := range item.Action.Sources {
+		result.Sources = append(result.Sources, source.URL)
+	}
+	output, _ := json.Marshal(result)
+
+	toolCall := fantasy.ToolCallContent{
+		ToolCallID:       item.ID,
+		ToolName:         "web_search",
+		Input:            string(input),
+		ProviderExecuted: true,
+	}
+	toolResult := fantasy.ToolResultContent{
+		ToolCallID:       item.ID,
+		ToolName:         "web_search",
+		Result:           fantasy.ToolResultOutputContentText{Text: string(output)},
+		ProviderExecuted: true,
+	}
+	return toolCall, toolResult
+}
@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -46,86 +47,16 @@ func (o responsesLanguageModel) Provider() string {
 	return o.provider
 }
 
-type responsesModelConfig struct {
-	isReasoningModel           bool
-	systemMessageMode          string
-	requiredAutoTruncation     bool
-	supportsFlexProcessing     bool
-	supportsPriorityProcessing bool
-}
-
-func getResponsesModelConfig(modelID string) responsesModelConfig {
-	supportsFlexProcessing := strings.HasPrefix(modelID, "o3") ||
-		strings.Contains(modelID, "-o3") || strings.Contains(modelID, "o4-mini") ||
-		(strings.Contains(modelID, "gpt-5") && !strings.Contains(modelID, "gpt-5-chat"))
-
-	supportsPriorityProcessing := strings.Contains(modelID, "gpt-4") ||
-		strings.Contains(modelID, "gpt-5-mini") ||
-		(strings.Contains(modelID, "gpt-5") &&
-			!strings.Contains(modelID, "gpt-5-nano") &&
-			!strings.Contains(modelID, "gpt-5-chat")) ||
-		strings.HasPrefix(modelID, "o3") ||
-		strings.Contains(modelID, "-o3") ||
-		strings.Contains(modelID, "o4-mini")
-
-	defaults := responsesModelConfig{
-		requiredAutoTruncation:     false,
-		systemMessageMode:          "system",
-		supportsFlexProcessing:     supportsFlexProcessing,
-		supportsPriorityProcessing: supportsPriorityProcessing,
-	}
-
-	if strings.Contains(modelID, "gpt-5-chat") {
-		return responsesModelConfig{
-			isReasoningModel:           false,
-			systemMessageMode:          defaults.systemMessageMode,
-			requiredAutoTruncation:     defaults.requiredAutoTruncation,
-			supportsFlexProcessing:     defaults.supportsFlexProcessing,
-			supportsPriorityProcessing: defaults.supportsPriorityProcessing,
-		}
-	}
-
-	if strings.HasPrefix(modelID, "o1") || strings.Contains(modelID, "-o1") ||
-		strings.HasPrefix(modelID, "o3") || strings.Contains(modelID, "-o3") ||
-		strings.HasPrefix(modelID, "o4") || strings.Contains(modelID, "-o4") ||
-		strings.HasPrefix(modelID, "oss") || strings.Contains(modelID, "-oss") ||
-		strings.Contains(modelID, "gpt-5") || strings.Contains(modelID, "codex-") ||
-		strings.Contains(modelID, "computer-use") {
-		if strings.Contains(modelID, "o1-mini") || strings.Contains(modelID, "o1-preview") {
-			return responsesModelConfig{
-				isReasoningModel:           true,
-				systemMessageMode:          "remove",
-				requiredAutoTruncation:     defaults.requiredAutoTruncation,
-				supportsFlexProcessing:     defaults.supportsFlexProcessing,
-				supportsPriorityProcessing: defaults.supportsPriorityProcessing,
-			}
-		}
-
-		return responsesModelConfig{
-			isReasoningModel:           true,
-			systemMessageMode:          "developer",
-			requiredAutoTruncation:     defaults.requiredAutoTruncation,
-			supportsFlexProcessing:     defaults.supportsFlexProcessing,
-			supportsPriorityProcessing: defaults.supportsPriorityProcessing,
-		}
-	}
-
-	return responsesModelConfig{
-		isReasoningModel:           false,
-		systemMessageMode:          defaults.systemMessageMode,
-		requiredAutoTruncation:     defaults.requiredAutoTruncation,
-		supportsFlexProcessing:     defaults.supportsFlexProcessing,
-		supportsPriorityProcessing: defaults.supportsPriorityProcessing,
-	}
-}
-
 func (o responsesLanguageModel) prepareParams(call fantasy.Call) (*responses.ResponseNewParams, []fantasy.CallWarning) {
 	var warnings []fantasy.CallWarning
 	params := &responses.ResponseNewParams{
 		Store: param.NewOpt(false),
 	}
 
-	modelConfig := getResponsesModelConfig(o.modelID)
+	info, known := modelInfo(o.provider, o.modelID)
+	if known {
+		call, warnings = fantasy.ApplyModelInfo(info, call)
+	}
 
 	if call.TopK != nil {
 		warnings = append(warnings, fantasy.CallWarning{
@@ -148,6 +79,20 @@ func (o responsesLanguageModel) prepareParams(call fantasy.Call) (*responses.Res
 		})
 	}
 
+	if len(call.StopSequences) > 0 {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "stopSequences",
+		})
+	}
+
+	if call.Seed != nil {
+		warnings = append(warnings, fantasy.CallWarning{
+			Type:    fantasy.CallWarningTypeUnsupportedSetting,
+			Setting: "seed",
+		})
+	}
+
 	var openaiOptions *ResponsesProviderOptions
 	if opts, ok := call.ProviderOptions[Name]; ok {
 		if typedOpts, ok := opts.(*ResponsesProviderOptions); ok {
@@ -155,7 +100,7 @@ func (o responsesLanguageModel) prepareParams(call fantasy.Call) (*responses.Res
 		}
 	}
 
-	input, inputWarnings := toResponsesPrompt(call.Prompt, modelConfig.systemMessageMode)
+	input, inputWarnings := toResponsesPrompt(call.Prompt, cmp.Or(info.SystemMessageMode, "system"))
 	warnings = append(warnings, inputWarnings...)
 
 	var include []IncludeType
@@ -236,7 +181,7 @@ func (o responsesLanguageModel) prepareParams(call fantasy.Call) (*responses.Res
 			include = append(include, openaiOptions.Include...)
 		}
 
-		if modelConfig.isReasoningModel && (openaiOptions.ReasoningEffort != nil || openaiOptions.ReasoningSummary != nil) {
+		if info.Reasoning && (openaiOptions.ReasoningEffort != nil || openaiOptions.ReasoningSummary != nil) {
 			reasoning := shared.ReasoningParam{}
 			if openaiOptions.ReasoningEffort != nil {
 				reasoning.Effort = shared.ReasoningEffort(*openaiOptions.ReasoningEffort)
@@ -248,10 +193,6 @@ func (o responsesLanguageModel) prepareParams(call fantasy.Call) (*responses.Res
 		}
 	}
 
-	if modelConfig.requiredAutoTruncation {
-		params.Truncation = responses.ResponseNewParamsTruncationAuto
-	}
-
 	if len(include) > 0 {
 		includeParams := make([]responses.ResponseIncludable, len(include))
 		for i, inc := range include {
@@ -260,7 +201,7 @@ func (o responsesLanguageModel) prepareParams(call fantasy.Call) (*responses.Res
 		params.Include = includeParams
 	}
 
-	if modelConfig.isReasoningModel {
+	if info.Reasoning {
 		if call.Temperature != nil {
 			params.Temperature = param.Opt[float64]{}
 			warnings = append(warnings, fantasy.CallWarning{
@@ -299,7 +240,7 @@ func (o responsesLanguageModel) prepareParams(call fantasy.Call) (*responses.Res
 	}
 
 	if openaiOptions != nil && openaiOptions.ServiceTier != nil {
-		if *openaiOptions.ServiceTier == ServiceTierFlex && !modelConfig.supportsFlexProcessing {
+		if *openaiOptions.ServiceTier == ServiceTierFlex && !info.SupportsServiceTier(string(ServiceTierFlex)) {
 			warnings = append(warnings, fantasy.CallWarning{
 				Type:    fantasy.CallWarningTypeUnsupportedSetting,
 				Setting: "serviceTier",
@@ -308,7 +249,7 @@ func (o responsesLanguageModel) prepareParams(call fantasy.Call) (*responses.Res
 			params.ServiceTier = ""
 		}
 
-		if *openaiOptions.ServiceTier == ServiceTierPriority && !modelConfig.supportsPriorityProcessing {
+		if *openaiOptions.ServiceTier == ServiceTierPriority && !info.SupportsServiceTier(string(ServiceTierPriority)) {
 			warnings = append(warnings, fantasy.CallWarning{
 				Type:    fantasy.CallWarningTypeUnsupportedSetting,
 				Setting: "serviceTier",
@@ -597,6 +538,31 @@ func hasVisibleResponsesAssistantContent(items []responses.ResponseInputItemUnio
 	return false
 }
 
+// strictToolSchemas returns the input schemas of the function tools of a call
+// that are sent in strict mode, by tool name.
+func strictToolSchemas(call fantasy.Call) map[string]map[string]any {
+	options, _ := call.ProviderOptions[Name].(*ResponsesProviderOptions)
+	if options == nil || options.StrictJSONSchema == nil || !*options.StrictJSONSchema {
+		return nil
+	}
+	schemas := make(map[string]map[string]any)
+	for _, tool := range call.Tools {
+		if ft, ok := tool.(fantasy.FunctionTool); ok {
+			schemas[ft.Name] = ft.InputSchema
+		}
+	}
+	return schemas
+}
+
+// toolInput removes the nulls that strict mode makes the model send for
+// optional properties from the input of a tool call.
+func toolInput(strictTools map[string]map[string]any, name, input string) string {
+	if s, ok := strictTools[name]; ok {
+		return removeOptionalNulls(input, s)
+	}
+	return input
+}
+
 func toResponsesTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice, options *ResponsesProviderOptions) ([]responses.ToolUnionParam, responses.ResponseNewParamsToolChoiceUnion, []fantasy.CallWarning) {
 	warnings := make([]fantasy.CallWarning, 0)
 	var openaiTools []responses.ToolUnionParam
@@ -609,6 +575,10 @@ func toResponsesTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice, opti
 	if options != nil && options.StrictJSONSchema != nil {
 		strictJSONSchema = *options.StrictJSONSchema
 	}
+	dialect := jsonSchemaDialect
+	if strictJSONSchema {
+		dialect = strictSchemaDialect
+	}
 
 	for _, tool := range tools {
 		if tool.GetType() == fantasy.ToolTypeFunction {
@@ -616,6 +586,8 @@ func toResponsesTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice, opti
 			if !ok {
 				continue
 			}
+			ft, schemaWarnings := fantasy.NormalizeTool(ft, dialect)
+			warnings = append(warnings, schemaWarnings...)
 			openaiTools = append(openaiTools, responses.ToolUnionParam{
 				OfFunction: &responses.FunctionToolParam{
 					Name:        ft.Name,
@@ -628,6 +600,22 @@ func toResponsesTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice, opti
 			continue
 		}
 
+		if pt, ok := tool.(fantasy.ProviderDefinedTool); ok && pt.ID == WebSearchToolID {
+			webSearchOptions, err := parseWebSearchToolArgs(pt)
+			if err != nil {
+				warnings = append(warnings, fantasy.CallWarning{
+					Type:    fantasy.CallWarningTypeUnsupportedTool,
+					Tool:    tool,
+					Message: fmt.Sprintf("invalid web search tool arguments: %v", err),
+				})
+				continue
+			}
+			openaiTools = append(openaiTools, responses.ToolUnionParam{
+				OfWebSearch: toResponsesWebSearchTool(webSearchOptions),
+			})
+			continue
+		}
+
 		warnings = append(warnings, fantasy.CallWarning{
 			Type:    fantasy.CallWarningTypeUnsupportedTool,
 			Tool:    tool,
@@ -668,6 +656,7 @@ func toResponsesTools(tools []fantasy.Tool, toolChoice *fantasy.ToolChoice, opti
 
 func (o responsesLanguageModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
 	params, warnings := o.prepareParams(call)
+	strictTools := strictToolSchemas(call)
 	response, err := o.client.Responses.New(ctx, *params)
 	if err != nil {
 		return nil, toProviderErr(err)
@@ -728,9 +717,13 @@ func (o responsesLanguageModel) Generate(ctx context.Context, call fantasy.Call)
 				ProviderExecuted: false,
 				ToolCallID:       outputItem.CallID,
 				ToolName:         outputItem.Name,
-				Input:            outputItem.Arguments,
+				Input:            toolInput(strictTools, outputItem.Name, outputItem.Arguments),
 			})
 
+		case "web_search_call":
+			toolCall, toolResult := webSearchCallContent(outputItem)
+			content = append(content, toolCall, toolResult)
+
 		case "reasoning":
 			metadata := &ResponsesReasoningMetadata{
 				ItemID: outputItem.ID,
@@ -805,6 +798,7 @@ func mapResponsesFinishReason(reason string, hasFunctionCall bool) fantasy.Finis
 
 func (o responsesLanguageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
 	params, warnings := o.prepareParams(call)
+	strictTools := strictToolSchemas(call)
 
 	stream := o.client.Responses.NewStreaming(ctx, *params)
 
@@ -847,6 +841,16 @@ func (o responsesLanguageModel) Stream(ctx context.Context, call fantasy.Call) (
 						return
 					}
 
+				case "web_search_call":
+					if !yield(fantasy.StreamPart{
+						Type:             fantasy.StreamPartTypeToolInputStart,
+						ID:               added.Item.ID,
+						ToolCallName:     "web_search",
+						ProviderExecuted: true,
+					}) {
+						return
+					}
+
 				case "message":
 					if !yield(fantasy.StreamPart{
 						Type: fantasy.StreamPartTypeTextStart,
@@ -897,12 +901,40 @@ func (o responsesLanguageModel) Stream(ctx context.Context, call fantasy.Call) (
 							Type:          fantasy.StreamPartTypeToolCall,
 							ID:            done.Item.CallID,
 							ToolCallName:  done.Item.Name,
-							ToolCallInput: done.Item.Arguments,
+							ToolCallInput: toolInput(strictTools, done.Item.Name, done.Item.Arguments),
 						}) {
 							return
 						}
 					}
 
+				case "web_search_call":
+					toolCall, toolResult := webSearchCallContent(done.Item)
+					if !yield(fantasy.StreamPart{
+						Type:             fantasy.StreamPartTypeToolInputEnd,
+						ID:               toolCall.ToolCallID,
+						ProviderExecuted: true,
+					}) {
+						return
+					}
+					if !yield(fantasy.StreamPart{
+						Type:             fantasy.StreamPartTypeToolCall,
+						ID:               toolCall.ToolCallID,
+						ToolCallName:     toolCall.ToolName,
+						ToolCallInput:    toolCall.Input,
+						ProviderExecuted: true,
+					}) {
+						return
+					}
+					if !yield(fantasy.StreamPart{
+						Type:             fantasy.StreamPartTypeToolResult,
+						ID:               toolResult.ToolCallID,
+						ToolCallName:     toolResult.ToolName,
+						ToolResult:       toolResult.Result,
+						ProviderExecuted: true,
+					}) {
+						return
+					}
+
 				case "message":
 					if !yield(fantasy.StreamPart{
 						Type: fantasy.StreamPartTypeTextEnd,
@@ -950,6 +982,26 @@ func (o responsesLanguageModel) Stream(ctx context.Context, call fantasy.Call) (
 					return
 				}
 
+			case "response.output_text.annotation.added":
+				added := event.AsResponseOutputTextAnnotationAdded()
+				annotation, ok := added.Annotation.(map[string]any)
+				if !ok {
+					continue
+				}
+				if annotationType, _ := annotation["type"].(string); annotationType == "url_citation" {
+					url, _ := annotation["url"].(string)
+					title, _ := annotation["title"].(string)
+					if !yield(fantasy.StreamPart{
+						Type:       fantasy.StreamPartTypeSource,
+						ID:         uuid.NewString(),
+						SourceType: fantasy.SourceTypeURL,
+						URL:        url,
+						Title:      title,
+					}) {
+						return
+					}
+				}
+
 			case "response.reasoning_summary_part.added":
 				added := event.AsResponseReasoningSummaryPartAdded()
 				state := activeReasoning[added.ItemID]
@@ -1051,9 +1103,18 @@ type reasoningState struct {
 	metadata *ResponsesReasoningMetadata
 }
 
+// resolvedObjectMode resolves ObjectModeAuto with the model catalog, unknown
+// models use JSON mode.
+func (o responsesLanguageModel) resolvedObjectMode() fantasy.ObjectMode {
+	if info, ok := modelInfo(o.provider, o.modelID); ok {
+		return info.ResolveObjectMode(o.objectMode)
+	}
+	return o.objectMode
+}
+
 // GenerateObject implements fantasy.LanguageModel.
 func (o responsesLanguageModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
-	switch o.objectMode {
+	switch o.resolvedObjectMode() {
 	case fantasy.ObjectModeText:
 		return object.GenerateWithText(ctx, o, call)
 	case fantasy.ObjectModeTool:
@@ -1065,7 +1126,7 @@ func (o responsesLanguageModel) GenerateObject(ctx context.Context, call fantasy
 
 // StreamObject implements fantasy.LanguageModel.
 func (o responsesLanguageModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
-	switch o.objectMode {
+	switch o.resolvedObjectMode() {
 	case fantasy.ObjectModeTool:
 		return object.StreamWithTool(ctx, o, call)
 	case fantasy.ObjectModeText:
@@ -1077,10 +1138,8 @@ func (o responsesLanguageModel) StreamObject(ctx context.Context, call fantasy.O
 
 func (o responsesLanguageModel) generateObjectWithJSONMode(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
 	// Convert our Schema to OpenAI's JSON Schema format
-	jsonSchemaMap := schema.ToMap(call.Schema)
-
-	// Add additionalProperties: false recursively for strict mode (OpenAI requirement)
-	addAdditionalPropertiesFalse(jsonSchemaMap)
+	// Strict mode requires closed objects with all properties required
+	jsonSchemaMap, schemaWarnings := fantasy.NormalizeSchema(call.Schema, strictSchemaDialect)
 
 	schemaName := call.SchemaName
 	if schemaName == "" {
@@ -1095,10 +1154,13 @@ func (o responsesLanguageModel) generateObjectWithJSONMode(ctx context.Context,
 		TopP:             call.TopP,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	}
 
 	params, warnings := o.prepareParams(fantasyCall)
+	warnings = append(warnings, schemaWarnings...)
 
 	// Add structured output via Text.Format field
 	params.Text = responses.ResponseTextConfigParam{
@@ -1147,11 +1209,13 @@ func (o responsesLanguageModel) generateObjectWithJSONMode(ctx context.Context,
 	}
 
 	// Parse and validate
+	// Strict mode makes the model send null for optional properties.
+	objectText := removeOptionalNulls(jsonText, schema.ToMap(call.Schema))
 	var obj any
 	if call.RepairText != nil {
-		obj, err = schema.ParseAndValidateWithRepair(ctx, jsonText, call.Schema, call.RepairText)
+		obj, err = schema.ParseAndValidateWithRepair(ctx, objectText, call.Schema, call.RepairText)
 	} else {
-		obj, err = schema.ParseAndValidate(jsonText, call.Schema)
+		obj, err = schema.ParseAndValidate(objectText, call.Schema)
 	}
 
 	usage := fantasy.Usage{
@@ -1188,10 +1252,8 @@ func (o responsesLanguageModel) generateObjectWithJSONMode(ctx context.Context,
 
 func (o responsesLanguageModel) streamObjectWithJSONMode(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
 	// Convert our Schema to OpenAI's JSON Schema format
-	jsonSchemaMap := schema.ToMap(call.Schema)
-
-	// Add additionalProperties: false recursively for strict mode (OpenAI requirement)
-	addAdditionalPropertiesFalse(jsonSchemaMap)
+	// Strict mode requires closed objects with all properties required
+	jsonSchemaMap, schemaWarnings := fantasy.NormalizeSchema(call.Schema, strictSchemaDialect)
 
 	schemaName := call.SchemaName
 	if schemaName == "" {
@@ -1206,16 +1268,26 @@ func (o responsesLanguageModel) streamObjectWithJSONMode(ctx context.Context, ca
 		TopP:             call.TopP,
 		PresencePenalty:  call.PresencePenalty,
 		FrequencyPenalty: call.FrequencyPenalty,
+		StopSequences:    call.StopSequences,
+		Seed:             call.Seed,
 		ProviderOptions:  call.ProviderOptions,
 	}
 
 	params, warnings := o.prepareParams(fantasyCall)
+	warnings = append(warnings, schemaWarnings...)
 
 	// Add structured output via Text.Format field
 	params.Text = responses.ResponseTextConfigParam{
 		Format: responses.ResponseFormatTextConfigParamOfJSONSchema(schemaName, jsonSchemaMap),
 	}
 
+	// The schema is compiled once, every partial object is validated.
+	validator, err := schema.Compile(call.Schema)
+	if err != nil {
+		return nil, err
+	}
+	schemaMap := schema.ToMap(call.Schema)
+
 	stream := o.client.Responses.NewStreaming(ctx, *params)
 
 	return func(yield func(fantasy.ObjectStreamPart) bool) {
@@ -1245,10 +1317,11 @@ func (o responsesLanguageModel) streamObjectWithJSONMode(ctx context.Context, ca
 
 				// Try to parse the accumulated text
 				obj, state, parseErr := schema.ParsePartialJSON(accumulated)
+				obj = schema.RemoveOptionalNulls(obj, schemaMap)
 
 				// If we successfully parsed, validate and emit
 				if state == schema.ParseStateSuccessful || state == schema.ParseStateRepaired {
-					if err := schema.ValidateAgainstSchema(obj, call.Schema); err == nil {
+					if err := validator.Validate(obj); err == nil {
 						// Only emit if object is different from last
 						if !reflect.DeepEqual(obj, lastParsedObject) {
 							if !yield(fantasy.ObjectStreamPart{
@@ -1267,8 +1340,9 @@ func (o responsesLanguageModel) streamObjectWithJSONMode(ctx context.Context, ca
 					repairedText, repairErr := call.RepairText(ctx, accumulated, parseErr)
 					if repairErr == nil {
 						obj2, state2, _ := schema.ParsePartialJSON(repairedText)
+						obj2 = schema.RemoveOptionalNulls(obj2, schemaMap)
 						if (state2 == schema.ParseStateSuccessful || state2 == schema.ParseStateRepaired) &&
-							schema.ValidateAgainstSchema(obj2, call.Schema) == nil {
+							validator.Validate(obj2) == nil {
 							if !reflect.DeepEqual(obj2, lastParsedObject) {
 								if !yield(fantasy.ObjectStreamPart{
 									Type:   fantasy.ObjectStreamPartTypeObject,
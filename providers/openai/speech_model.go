@@ -0,0 +1,154 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"charm.land/fantasy"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/packages/param"
+)
+
+// speechChunkSize is the maximum size of the audio chunks of a speech stream.
+const speechChunkSize = 16 * 1024
+
+type speechModel struct {
+	provider string
+	modelID  string
+	client   openai.Client
+}
+
+func newSpeechModel(modelID string, provider string, client openai.Client) speechModel {
+	return speechModel{
+		provider: provider,
+		modelID:  modelID,
+		client:   client,
+	}
+}
+
+// Provider implements fantasy.SpeechModel.
+func (m speechModel) Provider() string {
+	return m.provider
+}
+
+// Model implements fantasy.SpeechModel.
+func (m speechModel) Model() string {
+	return m.modelID
+}
+
+// GenerateSpeech implements fantasy.SpeechModel.
+func (m speechModel) GenerateSpeech(ctx context.Context, call fantasy.SpeechCall) (*fantasy.SpeechResponse, error) {
+	params, mediaType, warnings := m.prepareParams(call)
+	response, err := m.client.Audio.Speech.New(ctx, params)
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+	defer response.Body.Close() //nolint: errcheck
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, &fantasy.Error{Title: "invalid response", Message: "failed to read audio", Cause: err}
+	}
+	return &fantasy.SpeechResponse{
+		Audio: fantasy.FileContent{
+			MediaType: mediaType,
+			Data:      data,
+		},
+		Warnings: warnings,
+	}, nil
+}
+
+// StreamSpeech implements fantasy.SpeechModel. The audio is streamed as it is
+// received.
+func (m speechModel) StreamSpeech(ctx context.Context, call fantasy.SpeechCall) (fantasy.SpeechStreamResponse, error) {
+	params, mediaType, warnings := m.prepareParams(call)
+	response, err := m.client.Audio.Speech.New(ctx, params)
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+
+	return func(yield func(fantasy.SpeechStreamPart) bool) {
+		defer response.Body.Close() //nolint: errcheck
+
+		if len(warnings) > 0 {
+			if !yield(fantasy.SpeechStreamPart{
+				Type:     fantasy.SpeechStreamPartTypeWarnings,
+				Warnings: warnings,
+			}) {
+				return
+			}
+		}
+
+		buf := make([]byte, speechChunkSize)
+		for {
+			n, err := response.Body.Read(buf)
+			if n > 0 {
+				if !yield(fantasy.SpeechStreamPart{
+					Type:      fantasy.SpeechStreamPartTypeAudioDelta,
+					MediaType: mediaType,
+					Data:      append([]byte(nil), buf[:n]...),
+				}) {
+					return
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				yield(fantasy.SpeechStreamPart{
+					Type:  fantasy.SpeechStreamPartTypeError,
+					Error: toProviderErr(err),
+				})
+				return
+			}
+		}
+
+		yield(fantasy.SpeechStreamPart{
+			Type: fantasy.SpeechStreamPartTypeFinish,
+		})
+	}, nil
+}
+
+func (m speechModel) prepareParams(call fantasy.SpeechCall) (openai.AudioSpeechNewParams, string, []fantasy.CallWarning) {
+	var warnings []fantasy.CallWarning
+	params := openai.AudioSpeechNewParams{
+		Input: call.Text,
+		Model: m.modelID,
+		Voice: openai.AudioSpeechNewParamsVoice(call.Voice),
+	}
+	if params.Voice == "" {
+		params.Voice = openai.AudioSpeechNewParamsVoiceAlloy
+	}
+	if call.Instructions != "" {
+		params.Instructions = param.NewOpt(call.Instructions)
+	}
+	if call.Speed != nil {
+		params.Speed = param.NewOpt(*call.Speed)
+	}
+
+	format := openai.AudioSpeechNewParamsResponseFormatMP3
+	if call.OutputFormat != "" {
+		if _, ok := speechMediaTypes[call.OutputFormat]; ok {
+			format = openai.AudioSpeechNewParamsResponseFormat(call.OutputFormat)
+		} else {
+			warnings = append(warnings, fantasy.CallWarning{
+				Type:    fantasy.CallWarningTypeUnsupportedSetting,
+				Setting: "OutputFormat",
+				Details: "unsupported output format " + call.OutputFormat + ", using mp3",
+			})
+		}
+	}
+	params.ResponseFormat = format
+	return params, speechMediaTypes[string(format)], warnings
+}
+
+// speechMediaTypes maps the supported output formats to their media types.
+var speechMediaTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"opus": "audio/opus",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"wav":  "audio/wav",
+	"pcm":  "audio/pcm",
+}
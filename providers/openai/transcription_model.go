@@ -0,0 +1,137 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/packages/param"
+)
+
+type transcriptionModel struct {
+	provider string
+	modelID  string
+	client   openai.Client
+}
+
+func newTranscriptionModel(modelID string, provider string, client openai.Client) transcriptionModel {
+	return transcriptionModel{
+		provider: provider,
+		modelID:  modelID,
+		client:   client,
+	}
+}
+
+// verboseTranscription holds the fields of a verbose_json transcription that
+// the SDK does not decode.
+type verboseTranscription struct {
+	Language string  `json:"language"`
+	Duration float64 `json:"duration"`
+	Segments []struct {
+		Text  string  `json:"text"`
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	} `json:"segments"`
+}
+
+// Provider implements fantasy.TranscriptionModel.
+func (m transcriptionModel) Provider() string {
+	return m.provider
+}
+
+// Model implements fantasy.TranscriptionModel.
+func (m transcriptionModel) Model() string {
+	return m.modelID
+}
+
+// Transcribe implements fantasy.TranscriptionModel. Segments, the language
+// and the duration are only returned by the whisper models.
+func (m transcriptionModel) Transcribe(ctx context.Context, call fantasy.TranscriptionCall) (*fantasy.TranscriptionResponse, error) {
+	params := openai.AudioTranscriptionNewParams{
+		File:  audioFile(call.Audio),
+		Model: m.modelID,
+	}
+	if call.Language != "" {
+		params.Language = param.NewOpt(call.Language)
+	}
+	if call.Prompt != "" {
+		params.Prompt = param.NewOpt(call.Prompt)
+	}
+	if call.Temperature != nil {
+		params.Temperature = param.NewOpt(*call.Temperature)
+	}
+	if m.returnsSegments() {
+		params.ResponseFormat = openai.AudioResponseFormatVerboseJSON
+		params.TimestampGranularities = []string{"segment"}
+	}
+
+	response, err := m.client.Audio.Transcriptions.New(ctx, params)
+	if err != nil {
+		return nil, toProviderErr(err)
+	}
+
+	result := &fantasy.TranscriptionResponse{
+		Text: response.Text,
+		Usage: fantasy.Usage{
+			InputTokens:  response.Usage.InputTokens,
+			OutputTokens: response.Usage.OutputTokens,
+			TotalTokens:  response.Usage.TotalTokens,
+		},
+	}
+	if response.Usage.Type == "duration" {
+		result.Duration = seconds(response.Usage.Seconds)
+	}
+
+	if m.returnsSegments() {
+		var verbose verboseTranscription
+		if err := json.Unmarshal([]byte(response.RawJSON()), &verbose); err != nil {
+			return nil, &fantasy.Error{Title: "invalid response", Message: "failed to decode transcription", Cause: err}
+		}
+		result.Language = verbose.Language
+		if verbose.Duration > 0 {
+			result.Duration = seconds(verbose.Duration)
+		}
+		for _, segment := range verbose.Segments {
+			result.Segments = append(result.Segments, fantasy.TranscriptionSegment{
+				Text:  strings.TrimSpace(segment.Text),
+				Start: seconds(segment.Start),
+				End:   seconds(segment.End),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// returnsSegments reports whether the model supports the verbose_json
+// response format, which includes the segments.
+func (m transcriptionModel) returnsSegments() bool {
+	return strings.HasPrefix(m.modelID, "whisper")
+}
+
+// audioFile creates a multipart file from an audio part, the filename is
+// derived from the media type when it is not set since it is used to detect
+// the format of the audio.
+func audioFile(audio fantasy.FilePart) io.Reader {
+	filename := audio.Filename
+	if filename == "" {
+		_, ext, _ := strings.Cut(audio.MediaType, "/")
+		switch ext {
+		case "mpeg", "":
+			ext = "mp3"
+		case "x-wav", "wave":
+			ext = "wav"
+		}
+		filename = "audio." + ext
+	}
+	return openai.File(bytes.NewReader(audio.Data), filename, audio.MediaType)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -22,6 +22,26 @@ const (
 	Name = "openrouter"
 )
 
+// Register the OpenRouter provider with the factory registry.
+func init() {
+	fantasy.RegisterProviderFactory(Name, fantasy.ProviderFactory{
+		New: func(config fantasy.ProviderConfig) (fantasy.Provider, error) {
+			opts := []Option{WithHeaders(config.Headers)}
+			if config.APIKey != "" {
+				opts = append(opts, WithAPIKey(config.APIKey))
+			}
+			if config.BaseURL != "" {
+				opts = append(opts, WithBaseURL(config.BaseURL))
+			}
+			return New(opts...)
+		},
+		ParseOptions: func(data map[string]any) (fantasy.ProviderOptionsData, error) {
+			return ParseOptions(data)
+		},
+		APIKeyEnv: "OPENROUTER_API_KEY",
+	})
+}
+
 // Option defines a function that configures OpenRouter provider options.
 type Option = func(*options)
 
@@ -61,6 +81,13 @@ func New(opts ...Option) (fantasy.Provider, error) {
 	return openai.New(providerOptions.openaiOptions...)
 }
 
+// WithBaseURL sets the base URL for the OpenRouter provider.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.openaiOptions = append(o.openaiOptions, openai.WithBaseURL(baseURL))
+	}
+}
+
 // WithAPIKey sets the API key for the OpenRouter provider.
 func WithAPIKey(apiKey string) Option {
 	return func(o *options) {
@@ -80,6 +80,12 @@ type ProviderMetadata struct {
 // Options implements the ProviderOptionsData interface for ProviderMetadata.
 func (*ProviderMetadata) Options() {}
 
+// ReportedCost implements fantasy.CostReporter, it returns the cost of the
+// call reported by OpenRouter usage accounting.
+func (m *ProviderMetadata) ReportedCost() (float64, bool) {
+	return m.Usage.Cost, m.Usage.Cost > 0
+}
+
 // MarshalJSON implements custom JSON marshaling with type info for ProviderMetadata.
 func (m ProviderMetadata) MarshalJSON() ([]byte, error) {
 	type plain ProviderMetadata
@@ -8,6 +8,7 @@ import (
 	"charm.land/fantasy"
 	"charm.land/fantasy/providers/bedrock"
 	"charm.land/x/vcr"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBedrockCommon(t *testing.T) {
@@ -18,6 +19,47 @@ func TestBedrockCommon(t *testing.T) {
 	})
 }
 
+func TestBedrockConverseCommon(t *testing.T) {
+	testCommon(t, []builderPair{
+		{"bedrock-amazon-nova-lite", bedrockConverseBuilder("us.amazon.nova-lite-v1:0"), nil, nil},
+		{"bedrock-meta-llama-3-3-70b", bedrockConverseBuilder("us.meta.llama3-3-70b-instruct-v1:0"), nil, nil},
+	})
+}
+
+func TestBedrockConverseThinking(t *testing.T) {
+	opts := fantasy.ProviderOptions{
+		bedrock.Name: &bedrock.ProviderOptions{
+			AdditionalModelRequestFields: map[string]any{
+				"thinking": map[string]any{
+					"type":          "enabled",
+					"budget_tokens": 2000,
+				},
+			},
+		},
+	}
+	testThinking(t, []builderPair{
+		{"bedrock-anthropic-claude-3-7-sonnet", bedrockConverseBuilder("us.anthropic.claude-3-7-sonnet-20250219-v1:0", bedrock.WithConverse()), opts, nil},
+	}, testBedrockConverseThinking)
+}
+
+func testBedrockConverseThinking(t *testing.T, result *fantasy.AgentResult) {
+	signaturesCount := 0
+	for _, step := range result.Steps {
+		for _, msg := range step.Messages {
+			for _, content := range msg.Content {
+				if content.GetType() != fantasy.ContentTypeReasoning {
+					continue
+				}
+				metadata := bedrock.GetReasoningMetadata(content.Options())
+				if metadata != nil && metadata.Signature != "" {
+					signaturesCount++
+				}
+			}
+		}
+	}
+	require.Greater(t, signaturesCount, 0)
+}
+
 func TestBedrockBasicAuth(t *testing.T) {
 	testSimple(t, builderPair{"bedrock-anthropic-claude-3-sonnet", buildersBedrockBasicAuth, nil, nil})
 }
@@ -66,3 +108,19 @@ func buildersBedrockBasicAuth(t *testing.T, r *vcr.Recorder) (fantasy.LanguageMo
 	}
 	return provider.LanguageModel(t.Context(), "anthropic.claude-3-sonnet-20240229-v1:0")
 }
+
+func bedrockConverseBuilder(model string, opts ...bedrock.Option) builderFunc {
+	return func(t *testing.T, r *vcr.Recorder) (fantasy.LanguageModel, error) {
+		provider, err := bedrock.New(
+			append(
+				opts,
+				bedrock.WithHTTPClient(&http.Client{Transport: r}),
+				bedrock.WithSkipAuth(!r.IsRecording()),
+			)...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return provider.LanguageModel(t.Context(), model)
+	}
+}
@@ -0,0 +1,485 @@
+package schema
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// defaultMaxRefDepth is the number of nested references inlined when a
+// dialect does not set MaxRefDepth.
+const defaultMaxRefDepth = 3
+
+// Dialect describes the subset of JSON Schema accepted by a provider. The
+// zero value accepts the full specification.
+type Dialect struct {
+	// Name identifies the dialect in the loss messages.
+	Name string
+	// InlineRefs replaces references with their definitions. Recursive
+	// references nested deeper than MaxRefDepth are replaced with an object
+	// without properties.
+	InlineRefs  bool
+	MaxRefDepth int
+	// CloseObjects sets additionalProperties to false on every object, maps
+	// described with an additionalProperties schema lose their values.
+	CloseObjects bool
+	// RequireAll makes every property required, optional properties become
+	// nullable instead.
+	RequireAll bool
+	// NullableKeyword describes nullable types with the OpenAPI nullable
+	// keyword instead of a type array or an anyOf with the null type.
+	NullableKeyword bool
+	// FlattenAnyOf merges nested anyOf and replaces an anyOf with a single
+	// variant by the variant itself.
+	FlattenAnyOf bool
+	// OneOfAsAnyOf relaxes oneOf to anyOf.
+	OneOfAsAnyOf bool
+	// StringEnums drops enums on non string types.
+	StringEnums bool
+	// Keywords lists the supported keywords, all others are dropped. When nil
+	// every keyword not in UnsupportedKeywords is kept.
+	Keywords []string
+	// UnsupportedKeywords lists the keywords that are dropped.
+	UnsupportedKeywords []string
+}
+
+// Normalize converts a JSON schema map, as produced by ToMap or found in tool
+// input schemas, to the given dialect. The input is not modified. Normalize
+// returns a message for every transformation that loses information.
+func Normalize(s map[string]any, d Dialect) (map[string]any, []string) {
+	n := &normalizer{
+		dialect: d,
+		root:    s,
+		refs:    make(map[string]int),
+	}
+	n.defs, _ = s["$defs"].(map[string]any)
+	if n.dialect.MaxRefDepth == 0 {
+		n.dialect.MaxRefDepth = defaultMaxRefDepth
+	}
+
+	result := n.normalize(s, "")
+	if d.InlineRefs {
+		delete(result, "$defs")
+	}
+	return result, n.losses
+}
+
+type normalizer struct {
+	dialect Dialect
+	root    map[string]any
+	defs    map[string]any
+	refs    map[string]int
+	losses  []string
+}
+
+func (n *normalizer) lose(path, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if n.dialect.Name != "" {
+		msg = fmt.Sprintf("%s: %s", n.dialect.Name, msg)
+	}
+	n.losses = append(n.losses, fmt.Sprintf("schema #%s: %s", path, msg))
+}
+
+func (n *normalizer) normalize(s map[string]any, path string) map[string]any {
+	if ref, ok := s["$ref"].(string); ok && n.dialect.InlineRefs {
+		return n.inline(s, ref, path)
+	}
+
+	result := maps.Clone(s)
+
+	if defs, ok := s["$defs"].(map[string]any); ok && !n.dialect.InlineRefs {
+		normalized := make(map[string]any, len(defs))
+		for name, def := range defs {
+			if defMap, ok := def.(map[string]any); ok {
+				normalized[name] = n.normalize(defMap, path+"/$defs/"+name)
+			} else {
+				normalized[name] = def
+			}
+		}
+		result["$defs"] = normalized
+	}
+
+	if props, ok := s["properties"].(map[string]any); ok {
+		normalized := make(map[string]any, len(props))
+		for name, prop := range props {
+			if propMap, ok := prop.(map[string]any); ok {
+				normalized[name] = n.normalize(propMap, path+"/properties/"+name)
+			} else {
+				normalized[name] = prop
+			}
+		}
+		result["properties"] = normalized
+	}
+
+	if required, ok := s["required"]; ok {
+		result["required"] = toStrings(required)
+	}
+
+	if items, ok := s["items"].(map[string]any); ok {
+		result["items"] = n.normalize(items, path+"/items")
+	}
+
+	if additional, ok := s["additionalProperties"].(map[string]any); ok {
+		result["additionalProperties"] = n.normalize(additional, path+"/additionalProperties")
+	}
+
+	if oneOf, ok := result["oneOf"]; ok && n.dialect.OneOfAsAnyOf {
+		delete(result, "oneOf")
+		if _, hasAnyOf := result["anyOf"]; hasAnyOf {
+			n.lose(path, "oneOf is not supported next to anyOf")
+		} else {
+			result["anyOf"] = oneOf
+			n.lose(path, "oneOf is relaxed to anyOf")
+		}
+	}
+
+	for _, key := range []string{"anyOf", "oneOf", "allOf"} {
+		if variants, ok := result[key].([]any); ok {
+			normalized := make([]any, len(variants))
+			for i, v := range variants {
+				if vMap, ok := v.(map[string]any); ok {
+					normalized[i] = n.normalize(vMap, fmt.Sprintf("%s/%s/%d", path, key, i))
+				} else {
+					normalized[i] = v
+				}
+			}
+			result[key] = normalized
+		}
+	}
+
+	if isObject(result) {
+		if n.dialect.CloseObjects {
+			if _, isSchema := result["additionalProperties"].(map[string]any); isSchema {
+				n.lose(path, "additionalProperties schemas are not supported, the object is closed")
+			}
+			result["additionalProperties"] = false
+		}
+		if n.dialect.RequireAll {
+			n.requireAll(result)
+		}
+	}
+
+	if n.dialect.StringEnums {
+		if _, ok := result["enum"]; ok && !hasType(result, "string") {
+			delete(result, "enum")
+			n.lose(path, "enum is only supported for strings")
+		}
+	}
+
+	if n.dialect.FlattenAnyOf {
+		result = flattenAnyOf(result)
+	}
+
+	if n.dialect.NullableKeyword {
+		result = toNullableKeyword(result)
+	}
+
+	n.dropKeywords(result, path)
+	return result
+}
+
+// inline replaces a reference with its normalized definition.
+func (n *normalizer) inline(s map[string]any, ref, path string) map[string]any {
+	var def map[string]any
+	switch {
+	case ref == "#":
+		def = n.root
+	case strings.HasPrefix(ref, "#/$defs/"):
+		def, _ = n.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+	}
+
+	var result map[string]any
+	switch {
+	case def == nil:
+		n.lose(path, "reference %s can not be resolved", ref)
+		result = map[string]any{"type": "object"}
+	case n.refs[ref] >= n.dialect.MaxRefDepth:
+		n.lose(path, "recursive reference %s is truncated", ref)
+		result = map[string]any{"type": "object"}
+	default:
+		n.refs[ref]++
+		def = maps.Clone(def)
+		delete(def, "$defs")
+		result = n.normalize(def, path)
+		n.refs[ref]--
+	}
+
+	// Annotations next to the reference take precedence over the definition.
+	for key, value := range s {
+		if key != "$ref" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// requireAll makes every property of an object required, the optional ones
+// are made nullable.
+func (n *normalizer) requireAll(s map[string]any) {
+	props, ok := s["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	required := toStrings(s["required"])
+	for _, name := range slices.Sorted(maps.Keys(props)) {
+		if slices.Contains(required, name) {
+			continue
+		}
+		required = append(required, name)
+		if prop, ok := props[name].(map[string]any); ok {
+			props[name] = makeNullable(prop, n.dialect.NullableKeyword)
+		}
+	}
+	s["required"] = required
+}
+
+// RemoveOptionalNulls removes the null values of optional properties that do
+// not accept null from a value decoded from JSON. Models send them for the
+// optional properties of a schema normalized with RequireAll, removing them
+// makes the value valid against the original schema s again. The value is
+// modified in place.
+func RemoveOptionalNulls(value any, s map[string]any) any {
+	defs, _ := s["$defs"].(map[string]any)
+	r := &nullRemover{root: s, defs: defs}
+	r.remove(value, s, nil)
+	return value
+}
+
+type nullRemover struct {
+	root map[string]any
+	defs map[string]any
+}
+
+// remove walks the value along the schema. The references followed for the
+// current value are tracked to stop on references to themselves.
+func (r *nullRemover) remove(value any, s map[string]any, refs []string) {
+	if ref, ok := s["$ref"].(string); ok && !slices.Contains(refs, ref) {
+		var def map[string]any
+		switch {
+		case ref == "#":
+			def = r.root
+		case strings.HasPrefix(ref, "#/$defs/"):
+			def, _ = r.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		}
+		if def != nil {
+			r.remove(value, def, append(refs, ref))
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf", "allOf"} {
+		variants, _ := s[key].([]any)
+		for _, v := range variants {
+			if vMap, ok := v.(map[string]any); ok {
+				r.remove(value, vMap, refs)
+			}
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		props, _ := s["properties"].(map[string]any)
+		additional, _ := s["additionalProperties"].(map[string]any)
+		required := toStrings(s["required"])
+		for name, propValue := range value {
+			prop, ok := props[name].(map[string]any)
+			if !ok {
+				if additional != nil {
+					r.remove(propValue, additional, nil)
+				}
+				continue
+			}
+			if propValue == nil && !slices.Contains(required, name) && !r.acceptsNull(prop) {
+				delete(value, name)
+				continue
+			}
+			r.remove(propValue, prop, nil)
+		}
+	case []any:
+		if items, ok := s["items"].(map[string]any); ok {
+			for _, item := range value {
+				r.remove(item, items, nil)
+			}
+		}
+	}
+}
+
+// acceptsNull reports whether a schema accepts null.
+func (r *nullRemover) acceptsNull(s map[string]any) bool {
+	if hasType(s, "null") || s["nullable"] == true {
+		return true
+	}
+	if types, ok := s["type"].([]string); ok && slices.Contains(types, "null") {
+		return true
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		variants, _ := s[key].([]any)
+		for _, v := range variants {
+			if vMap, ok := v.(map[string]any); ok && r.acceptsNull(vMap) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flattenAnyOf merges nested anyOf and replaces a single variant anyOf with the
+// variant, a null variant makes the schema nullable.
+func flattenAnyOf(s map[string]any) map[string]any {
+	variants, ok := s["anyOf"].([]any)
+	if !ok {
+		return s
+	}
+
+	var flat []any
+	nullable := false
+	for _, v := range variants {
+		vMap, ok := v.(map[string]any)
+		if !ok {
+			flat = append(flat, v)
+			continue
+		}
+		if vMap["type"] == "null" {
+			nullable = true
+			continue
+		}
+		if nested, ok := vMap["anyOf"].([]any); ok && len(vMap) == 1 {
+			flat = append(flat, nested...)
+			continue
+		}
+		flat = append(flat, vMap)
+	}
+
+	result := s
+	if len(flat) == 1 {
+		if single, ok := flat[0].(map[string]any); ok {
+			result = maps.Clone(single)
+			for key, value := range s {
+				if key != "anyOf" {
+					result[key] = value
+				}
+			}
+		}
+	} else {
+		result["anyOf"] = flat
+	}
+	if nullable {
+		result = makeNullable(result, true)
+	}
+	return result
+}
+
+// dropKeywords drops the keywords the dialect does not support.
+func (n *normalizer) dropKeywords(s map[string]any, path string) {
+	for _, key := range slices.Sorted(maps.Keys(s)) {
+		supported := !slices.Contains(n.dialect.UnsupportedKeywords, key)
+		if n.dialect.Keywords != nil && !slices.Contains(n.dialect.Keywords, key) {
+			supported = false
+		}
+		if supported {
+			continue
+		}
+		// Closing an object is not a loss for dialects that can not express it.
+		if key != "additionalProperties" || s[key] != false {
+			n.lose(path, "%s is not supported", key)
+		}
+		delete(s, key)
+	}
+}
+
+// makeNullable makes a schema accept null.
+func makeNullable(s map[string]any, keyword bool) map[string]any {
+	s = maps.Clone(s)
+	if keyword {
+		s["nullable"] = true
+		return s
+	}
+
+	switch t := s["type"].(type) {
+	case string:
+		s["type"] = []any{t, "null"}
+	case []any:
+		if !slices.Contains(t, any("null")) {
+			s["type"] = append(slices.Clone(t), "null")
+		}
+	default:
+		result := map[string]any{
+			"anyOf": []any{s, map[string]any{"type": "null"}},
+		}
+		if desc, ok := s["description"]; ok {
+			result["description"] = desc
+			delete(s, "description")
+		}
+		return result
+	}
+	if enum, ok := s["enum"].([]any); ok && !slices.Contains(enum, nil) {
+		s["enum"] = append(slices.Clone(enum), nil)
+	}
+	return s
+}
+
+// toNullableKeyword replaces a type array containing null with the nullable
+// keyword.
+func toNullableKeyword(s map[string]any) map[string]any {
+	types, ok := s["type"].([]any)
+	if !ok {
+		return s
+	}
+
+	var nonNull []any
+	for _, t := range types {
+		if t != "null" {
+			nonNull = append(nonNull, t)
+		}
+	}
+	if len(nonNull) != len(types) {
+		s["nullable"] = true
+		if enum, ok := s["enum"].([]any); ok {
+			s["enum"] = slices.DeleteFunc(slices.Clone(enum), func(v any) bool { return v == nil })
+		}
+	}
+	switch len(nonNull) {
+	case 0:
+		delete(s, "type")
+	case 1:
+		s["type"] = nonNull[0]
+	default:
+		variants := make([]any, len(nonNull))
+		for i, t := range nonNull {
+			variants[i] = map[string]any{"type": t}
+		}
+		delete(s, "type")
+		s["anyOf"] = variants
+	}
+	return s
+}
+
+func isObject(s map[string]any) bool {
+	return hasType(s, "object")
+}
+
+func hasType(s map[string]any, typ string) bool {
+	switch t := s["type"].(type) {
+	case string:
+		return t == typ
+	case []any:
+		return slices.Contains(t, any(typ))
+	}
+	return false
+}
+
+func toStrings(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return slices.Clone(v)
+	case []any:
+		result := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return []string{}
+}
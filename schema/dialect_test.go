@@ -0,0 +1,172 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeInlineRefs(t *testing.T) {
+	t.Parallel()
+
+	type Node struct {
+		Value    string  `json:"value"`
+		Children []*Node `json:"children"`
+	}
+	type Tree struct {
+		Root  Node `json:"root"`
+		Other Node `json:"other"`
+	}
+
+	s := ToMap(Generate(reflect.TypeOf(Tree{})))
+	result, losses := Normalize(s, Dialect{InlineRefs: true, MaxRefDepth: 2})
+
+	require.NotContains(t, result, "$defs")
+	root := result["properties"].(map[string]any)["root"].(map[string]any)
+	require.Equal(t, "object", root["type"])
+	require.NotContains(t, root, "$ref")
+
+	// Node is inlined twice, the third level is truncated.
+	child := root["properties"].(map[string]any)["children"].(map[string]any)["items"].(map[string]any)
+	variants := child["anyOf"].([]any)
+	grandChild := variants[0].(map[string]any)["properties"].(map[string]any)["children"].(map[string]any)["items"].(map[string]any)
+	truncated := grandChild["anyOf"].([]any)[0].(map[string]any)
+	require.Equal(t, map[string]any{"type": "object"}, truncated)
+	require.Len(t, losses, 2)
+	require.Contains(t, losses[0], "recursive reference #/$defs/Node is truncated")
+
+	// The input keeps its references.
+	require.Contains(t, s, "$defs")
+}
+
+func TestNormalizeNullableKeyword(t *testing.T) {
+	t.Parallel()
+
+	d := Dialect{
+		Name:            "test",
+		InlineRefs:      true,
+		NullableKeyword: true,
+		FlattenAnyOf:    true,
+		OneOfAsAnyOf:    true,
+		StringEnums:     true,
+		Keywords:        []string{"type", "nullable", "description", "enum", "properties", "required", "items", "anyOf"},
+	}
+
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type": []any{"string", "null"},
+				"enum": []any{"a", "b", nil},
+			},
+			"level": map[string]any{
+				"type": "integer",
+				"enum": []any{1, 2},
+			},
+			"shape": map[string]any{
+				"description": "A shape",
+				"oneOf": []any{
+					map[string]any{"anyOf": []any{
+						map[string]any{"type": "string"},
+						map[string]any{"type": "number"},
+					}},
+					map[string]any{"type": "null"},
+				},
+			},
+			"single": map[string]any{
+				"anyOf": []any{
+					map[string]any{"type": "boolean"},
+					map[string]any{"type": "null"},
+				},
+			},
+			"labels": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+				"pattern":              "^x$",
+			},
+		},
+	}
+
+	result, losses := Normalize(s, d)
+	props := result["properties"].(map[string]any)
+
+	require.Equal(t, map[string]any{
+		"type":     "string",
+		"nullable": true,
+		"enum":     []any{"a", "b"},
+	}, props["name"])
+
+	require.Equal(t, map[string]any{"type": "integer"}, props["level"])
+
+	require.Equal(t, map[string]any{
+		"description": "A shape",
+		"nullable":    true,
+		"anyOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "number"},
+		},
+	}, props["shape"])
+
+	require.Equal(t, map[string]any{"type": "boolean", "nullable": true}, props["single"])
+
+	require.Equal(t, map[string]any{"type": "object"}, props["labels"])
+
+	require.ElementsMatch(t, []string{
+		"schema #/properties/level: test: enum is only supported for strings",
+		"schema #/properties/shape: test: oneOf is relaxed to anyOf",
+		"schema #/properties/labels: test: additionalProperties is not supported",
+		"schema #/properties/labels: test: pattern is not supported",
+	}, losses)
+}
+
+func TestNormalizeZeroDialect(t *testing.T) {
+	t.Parallel()
+
+	type Input struct {
+		Name  string            `json:"name" pattern:"^[a-z]+$"`
+		Tags  map[string]string `json:"tags,omitempty"`
+		Maybe *int              `json:"maybe"`
+	}
+
+	s := ToMap(Generate(reflect.TypeOf(Input{})))
+	result, losses := Normalize(s, Dialect{})
+
+	require.Empty(t, losses)
+	require.Equal(t, s, result)
+}
+
+func TestRemoveOptionalNulls(t *testing.T) {
+	t.Parallel()
+
+	type Pet struct {
+		Name     string `json:"name"`
+		Nickname string `json:"nickname,omitempty"`
+	}
+	type Person struct {
+		Name     string  `json:"name"`
+		Nickname string  `json:"nickname,omitempty"`
+		Note     *string `json:"note"`
+		Pets     []Pet   `json:"pets"`
+	}
+
+	s := ToMap(Generate(reflect.TypeOf(Person{})))
+	strict, _ := Normalize(s, Dialect{RequireAll: true})
+	value := map[string]any{
+		"name":     "Ada",
+		"nickname": nil,
+		"note":     nil,
+		"pets":     []any{map[string]any{"name": "Rex", "nickname": nil}},
+	}
+	require.NoError(t, ValidateAgainstMap(value, strict))
+	require.Error(t, ValidateAgainstMap(value, s))
+
+	RemoveOptionalNulls(value, s)
+	require.Equal(t, map[string]any{
+		"name": "Ada",
+		// Required and nullable properties keep their nulls.
+		"note": nil,
+		"pets": []any{map[string]any{"name": "Rex"}},
+	}, value)
+	require.NoError(t, ValidateAgainstMap(value, s))
+}
@@ -3,15 +3,19 @@
 package schema
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"reflect"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	jsonrepair "github.com/RealAlexandreAI/json-repair"
-	"github.com/kaptinlin/jsonschema"
 )
 
 // ObjectRepairFunc is a function that attempts to repair invalid JSON output.
@@ -28,18 +32,107 @@ type ParseError struct {
 }
 
 // Schema represents a JSON schema for tool input validation.
+//
+// Schemas generated from recursive or shared struct types reference their
+// definitions with Ref, the definitions are collected in Defs of the root
+// schema. Nullable is rendered as a type array or, for schemas without a type,
+// as an anyOf with the null type.
 type Schema struct {
-	Type        string             `json:"type,omitempty"`
-	Properties  map[string]*Schema `json:"properties,omitempty"`
-	Required    []string           `json:"required,omitempty"`
-	Items       *Schema            `json:"items,omitempty"`
-	Description string             `json:"description,omitempty"`
-	Enum        []any              `json:"enum,omitempty"`
-	Format      string             `json:"format,omitempty"`
-	Minimum     *float64           `json:"minimum,omitempty"`
-	Maximum     *float64           `json:"maximum,omitempty"`
-	MinLength   *int               `json:"minLength,omitempty"`
-	MaxLength   *int               `json:"maxLength,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Nullable             bool               `json:"-"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Examples             []any              `json:"examples,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, it marshals the map representation
+// of the schema so that Nullable is preserved.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ToMap(s))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it reads the nullable forms
+// written by MarshalJSON back into Nullable.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type schema Schema
+	var raw struct {
+		schema
+		Type json.RawMessage `json:"type,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = Schema(raw.schema)
+
+	if len(raw.Type) > 0 && raw.Type[0] == '[' {
+		var types []string
+		if err := json.Unmarshal(raw.Type, &types); err != nil {
+			return err
+		}
+		types = slices.DeleteFunc(types, func(typ string) bool {
+			if typ == "null" {
+				s.Nullable = true
+				return true
+			}
+			return false
+		})
+		if len(types) > 1 {
+			return fmt.Errorf("unsupported schema type %s", raw.Type)
+		}
+		if len(types) == 1 {
+			s.Type = types[0]
+		}
+		if s.Nullable {
+			s.Enum = slices.DeleteFunc(s.Enum, func(value any) bool { return value == nil })
+		}
+	} else if len(raw.Type) > 0 {
+		if err := json.Unmarshal(raw.Type, &s.Type); err != nil {
+			return err
+		}
+	}
+
+	if nullableSchema, ok := nullableAnyOf(*s); ok {
+		*s = nullableSchema
+	}
+	return nil
+}
+
+// nullableAnyOf returns the schema of an anyOf with the null type written by
+// MarshalJSON for nullable schemas without a type.
+func nullableAnyOf(s Schema) (Schema, bool) {
+	if len(s.AnyOf) != 2 || s.AnyOf[0] == nil || s.AnyOf[0].Type != "" ||
+		!reflect.DeepEqual(s.AnyOf[1], &Schema{Type: "null"}) {
+		return Schema{}, false
+	}
+	rest := s
+	rest.AnyOf, rest.Description, rest.Defs = nil, "", nil
+	if !reflect.DeepEqual(rest, Schema{}) {
+		return Schema{}, false
+	}
+	result := *s.AnyOf[0]
+	result.Nullable = true
+	result.Description = cmp.Or(result.Description, s.Description)
+	if result.Defs == nil {
+		result.Defs = s.Defs
+	}
+	return result, true
 }
 
 // ParseState represents the state of JSON parsing.
@@ -72,23 +165,149 @@ func ToParameters(s Schema) map[string]any {
 	return result
 }
 
+// ToDefs converts the definitions of a Schema to the definitions map format
+// expected by ToolInfo. It returns nil when the schema has no definitions.
+func ToDefs(s Schema) map[string]any {
+	if len(s.Defs) == 0 {
+		return nil
+	}
+
+	result := make(map[string]any, len(s.Defs))
+	for name, defSchema := range s.Defs {
+		result[name] = ToMap(*defSchema)
+	}
+	return result
+}
+
+var (
+	variantsMu sync.RWMutex
+	variants   = map[reflect.Type][]reflect.Type{}
+)
+
+// RegisterVariants registers the concrete types implementing the interface I.
+// Generate describes fields of type I as an anyOf of the variant schemas,
+// fields of an interface type without registered variants are described as
+// objects.
+//
+// Example:
+//
+//	schema.RegisterVariants[Shape](Circle{}, Square{})
+func RegisterVariants[I any](values ...I) {
+	iface := reflect.TypeFor[I]()
+	types := make([]reflect.Type, 0, len(values))
+	for _, v := range values {
+		types = append(types, reflect.TypeOf(v))
+	}
+
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+	variants[iface] = types
+}
+
+func variantsOf(t reflect.Type) []reflect.Type {
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+	return variants[t]
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
 // Generate generates a JSON schema from a reflect.Type.
 // It recursively processes struct fields, arrays, maps, and primitive types.
+//
+// Named struct types that are recursive or used more than once are added to
+// the definitions of the root schema and referenced with $ref, references to
+// the root type itself use "#". Pointers are nullable, maps are described
+// with additionalProperties and interfaces with registered variants, see
+// [RegisterVariants], with anyOf.
+//
+// Besides json, the description and enum struct tags, fields accept the
+// format, pattern, minimum, maximum, minLength, maxLength, minItems, maxItems,
+// default and examples tags. Enum and examples values are comma separated.
 func Generate(t reflect.Type) Schema {
-	return generateSchemaRecursive(t, make(map[reflect.Type]bool))
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	g := &generator{
+		root:      t,
+		uses:      make(map[reflect.Type]int),
+		recursive: make(map[reflect.Type]bool),
+		names:     make(map[reflect.Type]string),
+		defs:      make(map[string]*Schema),
+	}
+	g.scan(t, make(map[reflect.Type]bool))
+
+	var schema Schema
+	if t.Kind() == reflect.Struct && t != timeType {
+		schema = g.generateStruct(t)
+	} else {
+		schema = g.generateType(t)
+	}
+	if len(g.defs) > 0 {
+		schema.Defs = g.defs
+	}
+	return schema
 }
 
-func generateSchemaRecursive(t reflect.Type, visited map[reflect.Type]bool) Schema {
-	if t.Kind() == reflect.Pointer {
+// generator holds the state of a single Generate call.
+type generator struct {
+	root      reflect.Type
+	uses      map[reflect.Type]int
+	recursive map[reflect.Type]bool
+	names     map[reflect.Type]string
+	defs      map[string]*Schema
+}
+
+// scan counts the uses of struct types and finds the recursive ones.
+func (g *generator) scan(t reflect.Type, stack map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer {
 		t = t.Elem()
 	}
 
-	if visited[t] {
-		return Schema{Type: "object"}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		g.scan(t.Elem(), stack)
+	case reflect.Interface:
+		for _, v := range variantsOf(t) {
+			g.scan(v, stack)
+		}
+	case reflect.Struct:
+		if t == timeType {
+			return
+		}
+		if stack[t] {
+			g.recursive[t] = true
+			return
+		}
+		g.uses[t]++
+		if g.uses[t] > 1 {
+			return
+		}
+		stack[t] = true
+		defer delete(stack, t)
+		for i := range t.NumField() {
+			if field := t.Field(i); field.IsExported() {
+				g.scan(field.Type, stack)
+			}
+		}
+	}
+}
+
+// generate generates the schema of a nested type, pointers are nullable.
+func (g *generator) generate(t reflect.Type) Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
 	}
-	visited[t] = true
-	defer delete(visited, t)
 
+	schema := g.generateType(t)
+	schema.Nullable = nullable
+	return schema
+}
+
+func (g *generator) generateType(t reflect.Type) Schema {
 	switch t.Kind() {
 	case reflect.String:
 		return Schema{Type: "string"}
@@ -100,89 +319,209 @@ func generateSchemaRecursive(t reflect.Type, visited map[reflect.Type]bool) Sche
 	case reflect.Bool:
 		return Schema{Type: "boolean"}
 	case reflect.Slice, reflect.Array:
-		itemSchema := generateSchemaRecursive(t.Elem(), visited)
+		itemSchema := g.generate(t.Elem())
 		return Schema{
 			Type:  "array",
 			Items: &itemSchema,
 		}
 	case reflect.Map:
-		if t.Key().Kind() == reflect.String {
-			valueSchema := generateSchemaRecursive(t.Elem(), visited)
-			schema := Schema{
-				Type: "object",
-				Properties: map[string]*Schema{
-					"*": &valueSchema,
-				},
-			}
-			return schema
+		valueSchema := g.generate(t.Elem())
+		return Schema{
+			Type:                 "object",
+			AdditionalProperties: &valueSchema,
 		}
-		return Schema{Type: "object"}
 	case reflect.Struct:
-		schema := Schema{
-			Type:       "object",
-			Properties: make(map[string]*Schema),
+		if t == timeType {
+			return Schema{Type: "string", Format: "date-time"}
 		}
-		for i := range t.NumField() {
-			field := t.Field(i)
+		if t == g.root {
+			return Schema{Ref: "#"}
+		}
+		if t.Name() == "" || (!g.recursive[t] && g.uses[t] < 2) {
+			return g.generateStruct(t)
+		}
+		name := g.defName(t)
+		if _, ok := g.defs[name]; !ok {
+			// Register the definition before generating it so that recursive
+			// fields resolve to a reference.
+			def := &Schema{}
+			g.defs[name] = def
+			*def = g.generateStruct(t)
+		}
+		return Schema{Ref: "#/$defs/" + name}
+	case reflect.Interface:
+		types := variantsOf(t)
+		if len(types) == 0 {
+			return Schema{Type: "object"}
+		}
+		schema := Schema{AnyOf: make([]*Schema, 0, len(types))}
+		for _, v := range types {
+			variant := g.generate(v)
+			schema.AnyOf = append(schema.AnyOf, &variant)
+		}
+		return schema
+	default:
+		return Schema{Type: "object"}
+	}
+}
+
+func (g *generator) generateStruct(t reflect.Type) Schema {
+	schema := Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
 
-			if !field.IsExported() {
-				continue
+		if !field.IsExported() {
+			continue
+		}
+
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+
+		fieldName := field.Name
+		required := true
+
+		if jsonTag != "" {
+			parts := strings.Split(jsonTag, ",")
+			if parts[0] != "" {
+				fieldName = parts[0]
 			}
 
-			jsonTag := field.Tag.Get("json")
-			if jsonTag == "-" {
-				continue
+			if slices.Contains(parts[1:], "omitempty") {
+				required = false
 			}
+		} else {
+			fieldName = toSnakeCase(fieldName)
+		}
 
-			fieldName := field.Name
-			required := true
+		fieldSchema := g.generate(field.Type)
+		applyTags(&fieldSchema, field.Tag)
 
-			if jsonTag != "" {
-				parts := strings.Split(jsonTag, ",")
-				if parts[0] != "" {
-					fieldName = parts[0]
-				}
+		schema.Properties[fieldName] = &fieldSchema
 
-				if slices.Contains(parts[1:], "omitempty") {
-					required = false
-				}
-			} else {
-				fieldName = toSnakeCase(fieldName)
-			}
+		if required {
+			schema.Required = append(schema.Required, fieldName)
+		}
+	}
 
-			fieldSchema := generateSchemaRecursive(field.Type, visited)
+	return schema
+}
 
-			if desc := field.Tag.Get("description"); desc != "" {
-				fieldSchema.Description = desc
-			}
+// defName returns the unique definition name of a named struct type.
+func (g *generator) defName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
 
-			if enumTag := field.Tag.Get("enum"); enumTag != "" {
-				enumValues := strings.Split(enumTag, ",")
-				fieldSchema.Enum = make([]any, len(enumValues))
-				for i, v := range enumValues {
-					fieldSchema.Enum[i] = strings.TrimSpace(v)
-				}
-			}
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, t.Name())
+	name := base
+	for i := 2; slices.Contains(slices.Collect(maps.Values(g.names)), name); i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.names[t] = name
+	return name
+}
 
-			schema.Properties[fieldName] = &fieldSchema
+// applyTags applies the schema struct tags of a field to its schema.
+func applyTags(schema *Schema, tag reflect.StructTag) {
+	if desc := tag.Get("description"); desc != "" {
+		schema.Description = desc
+	}
 
-			if required {
-				schema.Required = append(schema.Required, fieldName)
-			}
+	if enumTag := tag.Get("enum"); enumTag != "" {
+		enumValues := strings.Split(enumTag, ",")
+		schema.Enum = make([]any, len(enumValues))
+		for i, v := range enumValues {
+			schema.Enum[i] = parseTagValue(schema.Type, strings.TrimSpace(v))
 		}
+	}
 
-		return schema
-	case reflect.Interface:
-		return Schema{Type: "object"}
-	default:
-		return Schema{Type: "object"}
+	if format := tag.Get("format"); format != "" {
+		schema.Format = format
+	}
+
+	if pattern := tag.Get("pattern"); pattern != "" {
+		schema.Pattern = pattern
+	}
+
+	schema.Minimum = cmp.Or(parseFloatTag(tag, "minimum"), schema.Minimum)
+	schema.Maximum = cmp.Or(parseFloatTag(tag, "maximum"), schema.Maximum)
+	schema.MinLength = cmp.Or(parseIntTag(tag, "minLength"), schema.MinLength)
+	schema.MaxLength = cmp.Or(parseIntTag(tag, "maxLength"), schema.MaxLength)
+	schema.MinItems = cmp.Or(parseIntTag(tag, "minItems"), schema.MinItems)
+	schema.MaxItems = cmp.Or(parseIntTag(tag, "maxItems"), schema.MaxItems)
+
+	if def, ok := tag.Lookup("default"); ok {
+		schema.Default = parseTagValue(schema.Type, def)
 	}
+
+	if examplesTag := tag.Get("examples"); examplesTag != "" {
+		examples := strings.Split(examplesTag, ",")
+		schema.Examples = make([]any, len(examples))
+		for i, v := range examples {
+			schema.Examples[i] = parseTagValue(schema.Type, strings.TrimSpace(v))
+		}
+	}
+}
+
+// parseTagValue parses a struct tag value as a value of the schema type, it
+// falls back to the raw string when the value does not parse.
+func parseTagValue(typ, value string) any {
+	switch typ {
+	case "integer":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	case "array", "object":
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+func parseFloatTag(tag reflect.StructTag, key string) *float64 {
+	v, err := strconv.ParseFloat(tag.Get(key), 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+func parseIntTag(tag reflect.StructTag, key string) *int {
+	v, err := strconv.Atoi(tag.Get(key))
+	if err != nil {
+		return nil
+	}
+	return &v
 }
 
 // ToMap converts a Schema to a map representation suitable for JSON Schema.
 func ToMap(schema Schema) map[string]any {
 	result := make(map[string]any)
 
+	if schema.Ref != "" {
+		result["$ref"] = schema.Ref
+	}
+
 	if schema.Type != "" {
 		result["type"] = schema.Type
 	}
@@ -199,6 +538,10 @@ func ToMap(schema Schema) map[string]any {
 		result["format"] = schema.Format
 	}
 
+	if schema.Pattern != "" {
+		result["pattern"] = schema.Pattern
+	}
+
 	if schema.Minimum != nil {
 		result["minimum"] = *schema.Minimum
 	}
@@ -215,6 +558,22 @@ func ToMap(schema Schema) map[string]any {
 		result["maxLength"] = *schema.MaxLength
 	}
 
+	if schema.MinItems != nil {
+		result["minItems"] = *schema.MinItems
+	}
+
+	if schema.MaxItems != nil {
+		result["maxItems"] = *schema.MaxItems
+	}
+
+	if schema.Default != nil {
+		result["default"] = schema.Default
+	}
+
+	if len(schema.Examples) > 0 {
+		result["examples"] = schema.Examples
+	}
+
 	if schema.Properties != nil {
 		props := make(map[string]any)
 		for name, propSchema := range schema.Properties {
@@ -223,6 +582,10 @@ func ToMap(schema Schema) map[string]any {
 		result["properties"] = props
 	}
 
+	if schema.AdditionalProperties != nil {
+		result["additionalProperties"] = ToMap(*schema.AdditionalProperties)
+	}
+
 	if len(schema.Required) > 0 {
 		result["required"] = schema.Required
 	}
@@ -238,6 +601,57 @@ func ToMap(schema Schema) map[string]any {
 		result["items"] = itemsMap
 	}
 
+	if len(schema.AnyOf) > 0 {
+		result["anyOf"] = toMaps(schema.AnyOf)
+	}
+
+	if len(schema.OneOf) > 0 {
+		result["oneOf"] = toMaps(schema.OneOf)
+	}
+
+	if len(schema.Defs) > 0 {
+		result["$defs"] = ToDefs(schema)
+	}
+
+	if schema.Nullable {
+		result = nullable(result)
+	}
+
+	return result
+}
+
+func toMaps(schemas []*Schema) []any {
+	result := make([]any, len(schemas))
+	for i, s := range schemas {
+		result[i] = ToMap(*s)
+	}
+	return result
+}
+
+// nullable makes a schema map accept null, with a type array when the schema
+// has a type and an anyOf otherwise.
+func nullable(m map[string]any) map[string]any {
+	if typ, ok := m["type"].(string); ok {
+		m["type"] = []any{typ, "null"}
+		if enum, ok := m["enum"].([]any); ok && !slices.Contains(enum, nil) {
+			m["enum"] = append(slices.Clone(enum), nil)
+		}
+		return m
+	}
+
+	result := map[string]any{
+		"anyOf": []any{m, map[string]any{"type": "null"}},
+	}
+	// Keep the annotations next to the anyOf so that they are not hidden in
+	// the first branch.
+	if desc, ok := m["description"]; ok {
+		result["description"] = desc
+		delete(m, "description")
+	}
+	if defs, ok := m["$defs"]; ok {
+		result["$defs"] = defs
+		delete(m, "$defs")
+	}
 	return result
 }
 
@@ -297,7 +711,11 @@ func ParseAndValidate(text string, schema Schema) (any, error) {
 		}
 	}
 
-	if err := validateAgainstSchema(obj, schema); err != nil {
+	validator, err := Compile(schema)
+	if err == nil {
+		err = validator.Validate(obj)
+	}
+	if err != nil {
 		return nil, &ParseError{
 			RawText:         text,
 			ValidationError: err,
@@ -309,31 +727,21 @@ func ParseAndValidate(text string, schema Schema) (any, error) {
 
 // ValidateAgainstSchema validates a parsed object against a Schema.
 func ValidateAgainstSchema(obj any, schema Schema) error {
-	return validateAgainstSchema(obj, schema)
-}
-
-func validateAgainstSchema(obj any, schema Schema) error {
-	jsonSchemaBytes, err := json.Marshal(schema)
+	validator, err := Compile(schema)
 	if err != nil {
-		return fmt.Errorf("failed to marshal schema: %w", err)
+		return err
 	}
+	return validator.Validate(obj)
+}
 
-	compiler := jsonschema.NewCompiler()
-	validator, err := compiler.Compile(jsonSchemaBytes)
+// ValidateAgainstMap validates a parsed object against a JSON schema map, such
+// as the input schema of a tool.
+func ValidateAgainstMap(obj any, schema map[string]any) error {
+	validator, err := CompileMap(schema)
 	if err != nil {
-		return fmt.Errorf("invalid schema: %w", err)
+		return err
 	}
-
-	result := validator.Validate(obj)
-	if !result.IsValid() {
-		var errMsgs []string
-		for field, validationErr := range result.Errors {
-			errMsgs = append(errMsgs, fmt.Sprintf("%s: %s", field, validationErr.Message))
-		}
-		return fmt.Errorf("validation failed: %s", strings.Join(errMsgs, "; "))
-	}
-
-	return nil
+	return validator.Validate(obj)
 }
 
 // ParseAndValidateWithRepair attempts parsing, validation, and custom repair.
@@ -343,10 +751,18 @@ func ParseAndValidateWithRepair(
 	schema Schema,
 	repair ObjectRepairFunc,
 ) (any, error) {
+	validator, err := Compile(schema)
+	if err != nil {
+		return nil, &ParseError{
+			RawText:         text,
+			ValidationError: err,
+		}
+	}
+
 	obj, state, parseErr := ParsePartialJSON(text)
 
 	if state == ParseStateSuccessful || state == ParseStateRepaired {
-		validationErr := validateAgainstSchema(obj, schema)
+		validationErr := validator.Validate(obj)
 		if validationErr == nil {
 			return obj, nil
 		}
@@ -356,7 +772,7 @@ func ParseAndValidateWithRepair(
 			if repairErr == nil {
 				obj2, state2, _ := ParsePartialJSON(repairedText)
 				if state2 == ParseStateSuccessful || state2 == ParseStateRepaired {
-					if err := validateAgainstSchema(obj2, schema); err == nil {
+					if err := validator.Validate(obj2); err == nil {
 						return obj2, nil
 					}
 				}
@@ -374,7 +790,7 @@ func ParseAndValidateWithRepair(
 		if repairErr == nil {
 			obj2, state2, parseErr2 := ParsePartialJSON(repairedText)
 			if state2 == ParseStateSuccessful || state2 == ParseStateRepaired {
-				if err := validateAgainstSchema(obj2, schema); err == nil {
+				if err := validator.Validate(obj2); err == nil {
 					return obj2, nil
 				}
 			}
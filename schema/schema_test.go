@@ -1,8 +1,10 @@
 package schema
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -213,6 +215,8 @@ func TestGenerateSchemaMapTypes(t *testing.T) {
 			t.Parallel()
 			schema := Generate(reflect.TypeOf(tt.input))
 			require.Equal(t, tt.expected, schema.Type)
+			require.NotNil(t, schema.AdditionalProperties)
+			require.Empty(t, schema.Properties)
 		})
 	}
 }
@@ -309,6 +313,10 @@ func TestGenerateSchemaPointerTypes(t *testing.T) {
 
 	require.NotNil(t, schema.Properties["age"], "Expected age property to exist")
 	require.Equal(t, "integer", schema.Properties["age"].Type)
+	require.True(t, schema.Properties["age"].Nullable)
+
+	ageMap := ToMap(*schema.Properties["age"])
+	require.Equal(t, []any{"integer", "null"}, ageMap["type"])
 }
 
 func TestGenerateSchemaNestedStructs(t *testing.T) {
@@ -353,9 +361,11 @@ func TestGenerateSchemaRecursiveStructs(t *testing.T) {
 
 	require.NotNil(t, schema.Properties["next"], "Expected next property to exist")
 
-	// The recursive reference should be handled gracefully
+	// The recursive reference points to the root schema
 	nextSchema := schema.Properties["next"]
-	require.Equal(t, "object", nextSchema.Type)
+	require.Equal(t, "#", nextSchema.Ref)
+	require.True(t, nextSchema.Nullable)
+	require.Empty(t, schema.Defs)
 }
 
 func TestGenerateSchemaWithEnumTags(t *testing.T) {
@@ -532,3 +542,260 @@ func TestSchemaToParametersEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateSchemaDefs(t *testing.T) {
+	t.Parallel()
+
+	type Address struct {
+		Street string `json:"street"`
+	}
+
+	type Category struct {
+		Name     string      `json:"name"`
+		Children []*Category `json:"children"`
+	}
+
+	type Order struct {
+		Billing  Address  `json:"billing"`
+		Shipping Address  `json:"shipping"`
+		Category Category `json:"category"`
+	}
+
+	schema := Generate(reflect.TypeOf(Order{}))
+
+	require.Equal(t, "#/$defs/Address", schema.Properties["billing"].Ref)
+	require.Equal(t, "#/$defs/Address", schema.Properties["shipping"].Ref)
+	require.Equal(t, "#/$defs/Category", schema.Properties["category"].Ref)
+	require.Len(t, schema.Defs, 2)
+	require.Equal(t, "object", schema.Defs["Address"].Type)
+	require.Equal(t, "#/$defs/Category", schema.Defs["Category"].Properties["children"].Items.Ref)
+
+	m := ToMap(schema)
+	require.Contains(t, m, "$defs")
+
+	valid := map[string]any{
+		"billing":  map[string]any{"street": "Main St"},
+		"shipping": map[string]any{"street": "Main St"},
+		"category": map[string]any{
+			"name": "a",
+			"children": []any{
+				map[string]any{"name": "b", "children": []any{}},
+				nil,
+			},
+		},
+	}
+	require.NoError(t, ValidateAgainstSchema(valid, schema))
+
+	invalid := map[string]any{
+		"billing":  map[string]any{"street": "Main St"},
+		"shipping": map[string]any{"street": "Main St"},
+		"category": map[string]any{
+			"name":     "a",
+			"children": []any{map[string]any{"name": 1, "children": []any{}}},
+		},
+	}
+	require.Error(t, ValidateAgainstSchema(invalid, schema))
+}
+
+type testShape interface {
+	area() float64
+}
+
+type testCircle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c testCircle) area() float64 { return 3.14 * c.Radius * c.Radius }
+
+type testSquare struct {
+	Side float64 `json:"side"`
+}
+
+func (s testSquare) area() float64 { return s.Side * s.Side }
+
+func TestGenerateSchemaVariants(t *testing.T) {
+	t.Parallel()
+
+	RegisterVariants[testShape](testCircle{}, testSquare{})
+
+	type Drawing struct {
+		Shape testShape `json:"shape"`
+	}
+
+	schema := Generate(reflect.TypeOf(Drawing{}))
+
+	shapeSchema := schema.Properties["shape"]
+	require.Len(t, shapeSchema.AnyOf, 2)
+	require.Contains(t, shapeSchema.AnyOf[0].Properties, "radius")
+	require.Contains(t, shapeSchema.AnyOf[1].Properties, "side")
+
+	require.NoError(t, ValidateAgainstSchema(map[string]any{"shape": map[string]any{"side": 2.0}}, schema))
+	require.Error(t, ValidateAgainstSchema(map[string]any{"shape": "square"}, schema))
+}
+
+func TestGenerateSchemaTags(t *testing.T) {
+	t.Parallel()
+
+	type Input struct {
+		Code     string    `json:"code" pattern:"^[A-Z]{3}$" examples:"ABC,XYZ"`
+		Count    int       `json:"count" minimum:"1" maximum:"10" default:"5"`
+		Tags     []string  `json:"tags" minItems:"1" maxItems:"3"`
+		Name     string    `json:"name" minLength:"2" maxLength:"20" format:"hostname"`
+		Level    int       `json:"level" enum:"1,2,3"`
+		Created  time.Time `json:"created"`
+		Optional *string   `json:"optional" enum:"a,b"`
+	}
+
+	schema := Generate(reflect.TypeOf(Input{}))
+
+	code := schema.Properties["code"]
+	require.Equal(t, "^[A-Z]{3}$", code.Pattern)
+	require.Equal(t, []any{"ABC", "XYZ"}, code.Examples)
+
+	count := schema.Properties["count"]
+	require.Equal(t, 1.0, *count.Minimum)
+	require.Equal(t, 10.0, *count.Maximum)
+	require.Equal(t, int64(5), count.Default)
+
+	tags := schema.Properties["tags"]
+	require.Equal(t, 1, *tags.MinItems)
+	require.Equal(t, 3, *tags.MaxItems)
+
+	name := schema.Properties["name"]
+	require.Equal(t, 2, *name.MinLength)
+	require.Equal(t, 20, *name.MaxLength)
+	require.Equal(t, "hostname", name.Format)
+
+	require.Equal(t, []any{int64(1), int64(2), int64(3)}, schema.Properties["level"].Enum)
+
+	created := schema.Properties["created"]
+	require.Equal(t, "string", created.Type)
+	require.Equal(t, "date-time", created.Format)
+
+	optional := ToMap(*schema.Properties["optional"])
+	require.Equal(t, []any{"string", "null"}, optional["type"])
+	require.Equal(t, []any{"a", "b", nil}, optional["enum"])
+
+	m := ToMap(schema)
+	props := m["properties"].(map[string]any)
+	require.Equal(t, "^[A-Z]{3}$", props["code"].(map[string]any)["pattern"])
+	require.Equal(t, 1, props["tags"].(map[string]any)["minItems"])
+
+	valid := map[string]any{
+		"code":     "ABC",
+		"count":    5,
+		"tags":     []any{"a"},
+		"name":     "host",
+		"level":    2,
+		"created":  "2025-01-01T00:00:00Z",
+		"optional": nil,
+	}
+	require.NoError(t, ValidateAgainstSchema(valid, schema))
+
+	valid["tags"] = []any{}
+	require.Error(t, ValidateAgainstSchema(valid, schema))
+}
+
+func TestToMapNullableRef(t *testing.T) {
+	t.Parallel()
+
+	m := ToMap(Schema{Ref: "#/$defs/Node", Nullable: true, Description: "The next node"})
+	require.Equal(t, "The next node", m["description"])
+	require.Equal(t, []any{
+		map[string]any{"$ref": "#/$defs/Node"},
+		map[string]any{"type": "null"},
+	}, m["anyOf"])
+}
+
+func TestSchemaJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type Address struct {
+		City string `json:"city"`
+	}
+	type Node struct {
+		Name     string   `json:"name" description:"The name"`
+		Nickname *string  `json:"nickname"`
+		Kind     *string  `json:"kind" enum:"a,b"`
+		Address  *Address `json:"address" description:"The address"`
+		Next     *Node    `json:"next,omitempty"`
+		Tags     []string `json:"tags"`
+	}
+
+	schema := Generate(reflect.TypeOf(Node{}))
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	var decoded Schema
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, schema, decoded)
+
+	t.Run("reads the nullable forms", func(t *testing.T) {
+		t.Parallel()
+
+		var decoded Schema
+		require.NoError(t, json.Unmarshal([]byte(`{"type":["integer","null"],"enum":[1,2,null]}`), &decoded))
+		require.Equal(t, Schema{Type: "integer", Nullable: true, Enum: []any{1.0, 2.0}}, decoded)
+
+		decoded = Schema{}
+		require.NoError(t, json.Unmarshal([]byte(`{"description":"The next node","anyOf":[{"$ref":"#/$defs/Node"},{"type":"null"}]}`), &decoded))
+		require.Equal(t, Schema{Ref: "#/$defs/Node", Nullable: true, Description: "The next node"}, decoded)
+	})
+
+	t.Run("keeps other anyOf schemas", func(t *testing.T) {
+		t.Parallel()
+
+		var decoded Schema
+		require.NoError(t, json.Unmarshal([]byte(`{"anyOf":[{"type":"string"},{"type":"null"}]}`), &decoded))
+		require.False(t, decoded.Nullable)
+		require.Len(t, decoded.AnyOf, 2)
+	})
+
+	t.Run("rejects several types", func(t *testing.T) {
+		t.Parallel()
+
+		var decoded Schema
+		require.ErrorContains(t, json.Unmarshal([]byte(`{"type":["string","integer"]}`), &decoded), "unsupported schema type")
+	})
+}
+
+func TestValidateAgainstMapViolations(t *testing.T) {
+	t.Parallel()
+
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"units": map[string]any{"type": "string", "enum": []any{"celsius", "fahrenheit"}},
+			"location": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"city": map[string]any{"type": "string"},
+				},
+				"required": []any{"city"},
+			},
+		},
+		"required": []any{"units", "location"},
+	}
+
+	require.NoError(t, ValidateAgainstMap(map[string]any{
+		"units":    "celsius",
+		"location": map[string]any{"city": "Lisbon"},
+	}, s))
+
+	err := ValidateAgainstMap(map[string]any{
+		"units":    "kelvin",
+		"location": map[string]any{},
+	}, s)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Violations, 2)
+	require.Equal(t, "/location", validationErr.Violations[0].Path)
+	require.Equal(t, "required", validationErr.Violations[0].Keyword)
+	require.Equal(t, "/units", validationErr.Violations[1].Path)
+	require.Equal(t, "enum", validationErr.Violations[1].Keyword)
+
+	err = ValidateAgainstMap(map[string]any{"location": map[string]any{"city": "Lisbon"}}, s)
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Violations, 1)
+	require.Equal(t, "(root): Required property 'units' is missing", validationErr.Violations[0].String())
+}
@@ -0,0 +1,196 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kaptinlin/jsonschema"
+)
+
+// maxCachedValidators bounds the number of compiled validators kept in the
+// cache, schemas generated per request would otherwise grow it forever.
+const maxCachedValidators = 1024
+
+// Validator validates values against a compiled schema. It is safe for
+// concurrent use.
+type Validator struct {
+	compiled *jsonschema.Schema
+}
+
+// validatorCache caches the compiled validators by the hash of the JSON of
+// their schema.
+type validatorCache struct {
+	mu         sync.RWMutex
+	validators map[[sha256.Size]byte]*Validator
+}
+
+var validators = &validatorCache{
+	validators: make(map[[sha256.Size]byte]*Validator),
+}
+
+// Compile compiles a schema into a validator. Compiled validators are cached,
+// compiling an equal schema again returns the cached validator.
+func Compile(s Schema) (*Validator, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return validators.compile(data)
+}
+
+// CompileMap compiles a JSON schema map, such as the input schema of a tool,
+// into a validator. Like [Compile], it caches the compiled validators.
+func CompileMap(s map[string]any) (*Validator, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return validators.compile(data)
+}
+
+func (c *validatorCache) compile(data []byte) (*Validator, error) {
+	key := sha256.Sum256(data)
+
+	c.mu.RLock()
+	v, ok := c.validators[key]
+	c.mu.RUnlock()
+	if ok {
+		return v, nil
+	}
+
+	compiled, err := jsonschema.NewCompiler().Compile(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	v = &Validator{compiled: compiled}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.validators[key]; ok {
+		// Another goroutine compiled the same schema first.
+		return cached, nil
+	}
+	if len(c.validators) >= maxCachedValidators {
+		for k := range c.validators {
+			delete(c.validators, k)
+			break
+		}
+	}
+	c.validators[key] = v
+	return v, nil
+}
+
+// Validate validates a parsed object, it returns a [*ValidationError] listing
+// every violation if the object does not match the schema.
+func (v *Validator) Validate(obj any) error {
+	result := v.compiled.Validate(obj)
+	if result.IsValid() {
+		return nil
+	}
+
+	violations := collectViolations(result, obj, "", nil)
+	slices.SortStableFunc(violations, func(a, b Violation) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return &ValidationError{Violations: violations}
+}
+
+// ValidationError is returned when a value does not match its schema, it
+// lists every violation.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Violation is a single schema violation.
+type Violation struct {
+	// Path is the JSON pointer of the invalid value, empty for the root.
+	Path    string
+	Keyword string
+	Message string
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	return fmt.Sprintf("validation failed: %s", strings.Join(msgs, "; "))
+}
+
+// String returns the violation as "path: message".
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return fmt.Sprintf("%s: %s", path, v.Message)
+}
+
+// summaryKeywords only report that nested values are invalid, the nested
+// results hold the actual violations.
+var summaryKeywords = []string{"properties", "items", "prefixItems", "$ref", "additionalProperties"}
+
+// collectViolations flattens an evaluation result into violations. The
+// results of the anyOf and oneOf variants are skipped, the keyword error
+// already reports that no variant matched, as are the results of missing
+// properties which are reported by the required keyword.
+func collectViolations(result *jsonschema.EvaluationResult, obj any, path string, violations []Violation) []Violation {
+	path += result.InstanceLocation
+	if !hasPointer(obj, path) {
+		return violations
+	}
+	for _, keyword := range slices.Sorted(maps.Keys(result.Errors)) {
+		if slices.Contains(summaryKeywords, keyword) && len(result.Details) > 0 {
+			continue
+		}
+		violations = append(violations, Violation{
+			Path:    path,
+			Keyword: keyword,
+			Message: result.Errors[keyword].Error(),
+		})
+	}
+	for _, detail := range result.Details {
+		if detail.Valid ||
+			strings.HasPrefix(detail.EvaluationPath, "/anyOf/") ||
+			strings.HasPrefix(detail.EvaluationPath, "/oneOf/") {
+			continue
+		}
+		violations = collectViolations(detail, obj, path, violations)
+	}
+	return violations
+}
+
+// hasPointer reports whether the JSON pointer exists in the value.
+func hasPointer(obj any, pointer string) bool {
+	if pointer == "" {
+		return true
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := obj.(type) {
+		case map[string]any:
+			next, ok := v[token]
+			if !ok {
+				return false
+			}
+			obj = next
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return false
+			}
+			obj = v[i]
+		default:
+			// Values that are not decoded JSON can not be inspected.
+			return true
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kaptinlin/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type benchmarkAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+	Zip    string `json:"zip" pattern:"^[0-9]{5}$"`
+}
+
+type benchmarkPerson struct {
+	Name      string             `json:"name" minLength:"1"`
+	Age       int                `json:"age" minimum:"0" maximum:"150"`
+	Email     *string            `json:"email" format:"email"`
+	Role      string             `json:"role" enum:"admin,user,guest"`
+	Addresses []benchmarkAddress `json:"addresses"`
+	Tags      map[string]string  `json:"tags,omitempty"`
+}
+
+var benchmarkObject = map[string]any{
+	"name":  "Ada",
+	"age":   36,
+	"email": nil,
+	"role":  "admin",
+	"addresses": []any{
+		map[string]any{"street": "1 Main St", "city": "London", "zip": "12345"},
+		map[string]any{"street": "2 High St", "city": "Paris", "zip": "54321"},
+	},
+}
+
+func TestCompileCache(t *testing.T) {
+	t.Parallel()
+
+	s := Generate(reflect.TypeOf(benchmarkPerson{}))
+	v1, err := Compile(s)
+	require.NoError(t, err)
+
+	// An equal schema built separately hits the cache.
+	v2, err := Compile(Generate(reflect.TypeOf(benchmarkPerson{})))
+	require.NoError(t, err)
+	require.Same(t, v1, v2)
+
+	v3, err := CompileMap(ToMap(s))
+	require.NoError(t, err)
+	require.Same(t, v1, v3)
+
+	require.NoError(t, v1.Validate(benchmarkObject))
+
+	var validationErr *ValidationError
+	err = v1.Validate(map[string]any{"name": "Ada", "age": 200, "email": nil, "role": "root", "addresses": []any{}})
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Violations, 2)
+	require.Equal(t, "/age", validationErr.Violations[0].Path)
+	require.Equal(t, "/role", validationErr.Violations[1].Path)
+}
+
+func TestCompileConcurrent(t *testing.T) {
+	t.Parallel()
+
+	s := Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"concurrent": {Type: "string"},
+		},
+		Required: []string{"concurrent"},
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*Validator, 16)
+	for i := range results {
+		wg.Go(func() {
+			v, err := Compile(s)
+			if assert.NoError(t, err) {
+				assert.NoError(t, v.Validate(map[string]any{"concurrent": "yes"}))
+			}
+			results[i] = v
+		})
+	}
+	wg.Wait()
+
+	// Every goroutine ends up with the cached validator.
+	v, err := Compile(s)
+	require.NoError(t, err)
+	for _, got := range results {
+		require.Same(t, v, got)
+	}
+}
+
+func TestCompileInvalidSchema(t *testing.T) {
+	t.Parallel()
+
+	_, err := CompileMap(map[string]any{"type": 42})
+	require.ErrorContains(t, err, "invalid schema")
+}
+
+// BenchmarkCompileUncached measures the cost of compiling a schema on every
+// validation, as done before validators were cached.
+func BenchmarkCompileUncached(b *testing.B) {
+	s := Generate(reflect.TypeOf(benchmarkPerson{}))
+	for b.Loop() {
+		data, err := json.Marshal(s)
+		if err != nil {
+			b.Fatal(err)
+		}
+		compiled, err := jsonschema.NewCompiler().Compile(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		compiled.Validate(benchmarkObject)
+	}
+}
+
+func BenchmarkValidateAgainstSchema(b *testing.B) {
+	s := Generate(reflect.TypeOf(benchmarkPerson{}))
+	for b.Loop() {
+		if err := ValidateAgainstSchema(benchmarkObject, s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidatorValidate(b *testing.B) {
+	v, err := Compile(Generate(reflect.TypeOf(benchmarkPerson{})))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		if err := v.Validate(benchmarkObject); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidatorValidateParallel(b *testing.B) {
+	v, err := Compile(Generate(reflect.TypeOf(benchmarkPerson{})))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := v.Validate(benchmarkObject); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
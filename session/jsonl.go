@@ -0,0 +1,276 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+)
+
+// JSONLStore stores sessions as files in a directory: the messages of a
+// session in <id>.jsonl and its information in <id>.json.
+//
+// Every append is written as a single JSON line holding all its messages, so
+// an append interrupted by a crash leaves a partial last line. That line is
+// ignored when the session is loaded and removed by the next append. The
+// information files are written atomically.
+//
+// A JSONLStore is safe for concurrent use, but the files are not locked: a
+// directory must only be used by a single store, in a single process.
+// Another process appending to the same session could lose its line when the
+// partial line of an interrupted append is removed.
+type JSONLStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+var _ Store = (*JSONLStore)(nil)
+
+// record is a line of a messages file.
+type record struct {
+	Time     time.Time         `json:"time"`
+	Messages []fantasy.Message `json:"messages"`
+}
+
+// NewJSONLStore creates a store in the given directory, creating it if needed.
+func NewJSONLStore(dir string) (*JSONLStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	return &JSONLStore{dir: dir}, nil
+}
+
+// Load implements Store.
+func (s *JSONLStore) Load(_ context.Context, id string) ([]fantasy.Message, error) {
+	path, err := s.path(id, ".jsonl")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint: errcheck
+
+	var messages []fantasy.Message
+	// A line that cannot be decoded is only an error when another line
+	// follows it, a bad last line is an interrupted append.
+	var lineErr error
+	reader := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A last line without a newline is an interrupted append.
+			return messages, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		if lineErr != nil {
+			return nil, lineErr
+		}
+		var r record
+		if err := json.Unmarshal(data, &r); err != nil {
+			lineErr = fmt.Errorf("failed to read line %d of session %s: %w", line, id, err)
+			continue
+		}
+		messages = append(messages, r.Messages...)
+	}
+}
+
+// Append implements Store.
+func (s *JSONLStore) Append(_ context.Context, id string, messages []fantasy.Message) error {
+	path, err := s.path(id, ".jsonl")
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	data, err := json.Marshal(record{Time: now, Messages: messages})
+	if err != nil {
+		return fmt.Errorf("failed to encode messages of session %s: %w", id, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := truncatePartialLine(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to repair messages of session %s: %w", id, err)
+	}
+	// The line is written with a single write, so a crash leaves at most a
+	// partial last line.
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return s.updateInfo(id, func(info *Info) {
+		info.UpdatedAt = now
+	})
+}
+
+// Info implements Store.
+func (s *JSONLStore) Info(_ context.Context, id string) (Info, bool, error) {
+	path, err := s.path(id, ".json")
+	if err != nil {
+		return Info{}, false, err
+	}
+	return readInfo(path)
+}
+
+// SetMetadata implements Store.
+func (s *JSONLStore) SetMetadata(_ context.Context, id string, metadata map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateInfo(id, func(info *Info) {
+		info.Metadata = maps.Clone(metadata)
+		info.UpdatedAt = time.Now()
+	})
+}
+
+// List implements Store.
+func (s *JSONLStore) List(_ context.Context) ([]Info, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]Info, 0, len(paths))
+	for _, path := range paths {
+		info, ok, err := readInfo(path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			infos = append(infos, info)
+		}
+	}
+	sortInfos(infos)
+	return infos, nil
+}
+
+// Delete implements Store.
+func (s *JSONLStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ext := range []string{".json", ".jsonl"} {
+		path, err := s.path(id, ext)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncatePartialLine removes the partial last line left by an interrupted
+// append, so the next line does not continue it.
+func truncatePartialLine(f *os.File) error {
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := stat.Size()
+	buf := make([]byte, 4096)
+	for offset := size; offset > 0; {
+		n := min(offset, int64(len(buf)))
+		offset -= n
+		if _, err := f.ReadAt(buf[:n], offset); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			if end := offset + int64(i) + 1; end < size {
+				return f.Truncate(end)
+			}
+			return nil
+		}
+	}
+	if size == 0 {
+		return nil
+	}
+	// The file holds no complete line.
+	return f.Truncate(0)
+}
+
+// updateInfo updates the information of a session, creating it if needed.
+// The caller must hold the lock.
+func (s *JSONLStore) updateInfo(id string, update func(info *Info)) error {
+	path, err := s.path(id, ".json")
+	if err != nil {
+		return err
+	}
+	info, ok, err := readInfo(path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		info = Info{ID: id, CreatedAt: time.Now()}
+	}
+	update(&info)
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint: errcheck
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *JSONLStore) path(id, ext string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return "", fmt.Errorf("invalid session id %q", id)
+	}
+	return filepath.Join(s.dir, id+ext), nil
+}
+
+func readInfo(path string) (Info, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Info{}, false, nil
+	}
+	if err != nil {
+		return Info{}, false, err
+	}
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return Info{}, false, fmt.Errorf("failed to read session info %s: %w", filepath.Base(path), err)
+	}
+	return info, true, nil
+}
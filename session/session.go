@@ -0,0 +1,165 @@
+// Package session keeps the conversation history of agents in a store, so
+// callers don't have to pass it back on every turn:
+//
+//	store, err := session.NewJSONLStore("sessions")
+//	...
+//	s := session.New(agent, store, "user-42")
+//	result, err := s.Generate(ctx, fantasy.AgentCall{Prompt: "Hello"})
+//
+// Each turn loads the history of the session, runs the agent with it and
+// appends the prompt and the messages of the completed steps to the store in
+// a single atomic append. A step is only stored once all its tool results are
+// known, so the stored history never holds a tool call without its result.
+package session
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+)
+
+// Info describes a stored session.
+type Info struct {
+	ID        string            `json:"id"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Store stores the messages and the metadata of sessions. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Load returns the messages of a session in order, it returns no messages
+	// for unknown sessions.
+	Load(ctx context.Context, id string) ([]fantasy.Message, error)
+	// Append appends messages to a session, creating it if needed. The append
+	// is atomic: either all the messages are stored or none of them.
+	Append(ctx context.Context, id string, messages []fantasy.Message) error
+	// Info returns the information of a session and whether it exists.
+	Info(ctx context.Context, id string) (Info, bool, error)
+	// SetMetadata replaces the metadata of a session, creating it if needed.
+	SetMetadata(ctx context.Context, id string, metadata map[string]string) error
+	// List returns the stored sessions, most recently updated first.
+	List(ctx context.Context) ([]Info, error)
+	// Delete removes a session and its messages, deleting an unknown session
+	// is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// Session runs an agent with the history of a stored conversation. A session
+// runs one turn at a time, concurrent calls wait for the running turn.
+type Session struct {
+	agent fantasy.Agent
+	store Store
+	id    string
+
+	mu sync.Mutex
+}
+
+var _ fantasy.Agent = (*Session)(nil)
+
+// New creates a session with the given ID, sessions are created in the store
+// on their first turn.
+func New(agent fantasy.Agent, store Store, id string) *Session {
+	return &Session{
+		agent: agent,
+		store: store,
+		id:    id,
+	}
+}
+
+// ID returns the ID of the session.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Messages returns the stored history of the session.
+func (s *Session) Messages(ctx context.Context) ([]fantasy.Message, error) {
+	return s.store.Load(ctx, s.id)
+}
+
+// Info returns the information of the session and whether it is stored.
+func (s *Session) Info(ctx context.Context) (Info, bool, error) {
+	return s.store.Info(ctx, s.id)
+}
+
+// SetMetadata replaces the metadata of the session.
+func (s *Session) SetMetadata(ctx context.Context, metadata map[string]string) error {
+	return s.store.SetMetadata(ctx, s.id, metadata)
+}
+
+// Generate implements fantasy.Agent. The history of the session is prepended
+// to the messages of the call and the prompt, the messages of the call and
+// the messages of the steps are stored once the agent returns.
+//
+// When the run is suspended for approval only the completed steps are stored,
+// the run is then continued with [fantasy.AgentCall.Resume] on the session.
+func (s *Session) Generate(ctx context.Context, call fantasy.AgentCall) (*fantasy.AgentResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	turn, err := s.prepare(ctx, call.Resume, call.Prompt, call.Files, &call.Messages)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.agent.Generate(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	return result, s.save(ctx, call.Resume, turn, result)
+}
+
+// Stream implements fantasy.Agent, the history is handled like in
+// [Session.Generate].
+func (s *Session) Stream(ctx context.Context, call fantasy.AgentStreamCall) (*fantasy.AgentResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	turn, err := s.prepare(ctx, call.Resume, call.Prompt, call.Files, &call.Messages)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.agent.Stream(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	return result, s.save(ctx, call.Resume, turn, result)
+}
+
+// prepare prepends the history to the messages of a call and returns the
+// messages of the turn. Resumed runs already hold their messages.
+func (s *Session) prepare(ctx context.Context, resume *fantasy.SuspendedRun, prompt string, files []fantasy.FilePart, messages *[]fantasy.Message) ([]fantasy.Message, error) {
+	if resume != nil {
+		return nil, nil
+	}
+	history, err := s.store.Load(ctx, s.id)
+	if err != nil {
+		return nil, err
+	}
+	turn := slices.Clone(*messages)
+	if prompt != "" {
+		turn = append(turn, fantasy.NewUserMessage(prompt, files...))
+	}
+	*messages = slices.Concat(history, *messages)
+	return turn, nil
+}
+
+// save appends the messages of the turn and of the steps that were not
+// stored yet.
+func (s *Session) save(ctx context.Context, resume *fantasy.SuspendedRun, turn []fantasy.Message, result *fantasy.AgentResult) error {
+	steps := result.Steps
+	if resume != nil {
+		steps = steps[min(len(resume.Steps), len(steps)):]
+	}
+	messages := turn
+	for _, step := range steps {
+		messages = append(messages, step.Messages...)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return s.store.Append(ctx, s.id, messages)
+}
@@ -0,0 +1,280 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+// mockLanguageModel calls the weather tool when the last message is from the
+// user and answers with the number of messages of the prompt otherwise.
+type mockLanguageModel struct {
+	prompts []fantasy.Prompt
+}
+
+func (m *mockLanguageModel) Generate(_ context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	m.prompts = append(m.prompts, call.Prompt)
+	if call.Prompt[len(call.Prompt)-1].Role == fantasy.MessageRoleUser {
+		return &fantasy.Response{
+			Content: fantasy.ResponseContent{
+				fantasy.ToolCallContent{ToolCallID: "call-1", ToolName: "weather", Input: `{"city":"Paris"}`},
+			},
+			FinishReason: fantasy.FinishReasonToolCalls,
+		}, nil
+	}
+	return &fantasy.Response{
+		Content:      fantasy.ResponseContent{fantasy.TextContent{Text: "Sunny"}},
+		FinishReason: fantasy.FinishReasonStop,
+	}, nil
+}
+
+func (m *mockLanguageModel) Stream(context.Context, fantasy.Call) (fantasy.StreamResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLanguageModel) GenerateObject(context.Context, fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLanguageModel) StreamObject(context.Context, fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLanguageModel) Provider() string {
+	return "mock-provider"
+}
+
+func (m *mockLanguageModel) Model() string {
+	return "mock-model"
+}
+
+type weatherInput struct {
+	City string `json:"city"`
+}
+
+var weatherTool = fantasy.NewAgentTool("weather", "Get the weather", func(context.Context, weatherInput, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	return fantasy.NewTextResponse("sunny"), nil
+})
+
+func newJSONLStore(t *testing.T) Store {
+	store, err := NewJSONLStore(t.TempDir())
+	require.NoError(t, err)
+	return store
+}
+
+func TestStores(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range map[string]func(t *testing.T) Store{
+		"memory": func(*testing.T) Store { return NewMemoryStore() },
+		"jsonl":  newJSONLStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			store := newStore(t)
+
+			messages, err := store.Load(ctx, "missing")
+			require.NoError(t, err)
+			require.Empty(t, messages)
+			_, ok, err := store.Info(ctx, "missing")
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			first := []fantasy.Message{fantasy.NewUserMessage("Hello")}
+			second := []fantasy.Message{
+				{Role: fantasy.MessageRoleAssistant, Content: []fantasy.MessagePart{
+					fantasy.ToolCallPart{ToolCallID: "call-1", ToolName: "weather", Input: `{"city":"Paris"}`},
+				}},
+				{Role: fantasy.MessageRoleTool, Content: []fantasy.MessagePart{
+					fantasy.ToolResultPart{ToolCallID: "call-1", Output: fantasy.ToolResultOutputContentText{Text: "sunny"}},
+				}},
+			}
+			require.NoError(t, store.Append(ctx, "a", first))
+			require.NoError(t, store.Append(ctx, "a", second))
+			require.NoError(t, store.SetMetadata(ctx, "b", map[string]string{"title": "Weather"}))
+
+			messages, err = store.Load(ctx, "a")
+			require.NoError(t, err)
+			require.Equal(t, append(first, second...), messages)
+
+			info, ok, err := store.Info(ctx, "b")
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, map[string]string{"title": "Weather"}, info.Metadata)
+
+			infos, err := store.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, infos, 2)
+			require.Equal(t, "b", infos[0].ID)
+			require.Equal(t, "a", infos[1].ID)
+			require.False(t, infos[1].UpdatedAt.Before(infos[1].CreatedAt))
+
+			require.NoError(t, store.Delete(ctx, "a"))
+			require.NoError(t, store.Delete(ctx, "a"))
+			messages, err = store.Load(ctx, "a")
+			require.NoError(t, err)
+			require.Empty(t, messages)
+			infos, err = store.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, infos, 1)
+		})
+	}
+}
+
+func TestJSONLStoreInterruptedAppend(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewJSONLStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Append(t.Context(), "a", []fantasy.Message{fantasy.NewUserMessage("Hello")}))
+
+	f, err := os.OpenFile(filepath.Join(dir, "a.jsonl"), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"messages":[{"role":"user"`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	messages, err := store.Load(t.Context(), "a")
+	require.NoError(t, err)
+	require.Equal(t, []fantasy.Message{fantasy.NewUserMessage("Hello")}, messages)
+
+	_, err = store.Load(t.Context(), "../a")
+	require.ErrorContains(t, err, "invalid session id")
+
+	// The next append replaces the partial line.
+	require.NoError(t, store.Append(t.Context(), "a", []fantasy.Message{fantasy.NewUserMessage("Again")}))
+	messages, err = store.Load(t.Context(), "a")
+	require.NoError(t, err)
+	require.Equal(t, []fantasy.Message{fantasy.NewUserMessage("Hello"), fantasy.NewUserMessage("Again")}, messages)
+}
+
+func TestJSONLStoreBadLines(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewJSONLStore(dir)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "a.jsonl")
+
+	t.Run("skips a bad last line", func(t *testing.T) {
+		require.NoError(t, store.Append(t.Context(), "a", []fantasy.Message{fantasy.NewUserMessage("Hello")}))
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		require.NoError(t, err)
+		_, err = f.WriteString("{\"messages\":[{\"role\"\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		messages, err := store.Load(t.Context(), "a")
+		require.NoError(t, err)
+		require.Equal(t, []fantasy.Message{fantasy.NewUserMessage("Hello")}, messages)
+	})
+
+	t.Run("fails on a bad line followed by other lines", func(t *testing.T) {
+		require.NoError(t, store.Append(t.Context(), "a", []fantasy.Message{fantasy.NewUserMessage("Again")}))
+
+		_, err := store.Load(t.Context(), "a")
+		require.ErrorContains(t, err, "failed to read line 2 of session a")
+	})
+
+	t.Run("replaces a file without a complete line", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(`{"messages":[`), 0o644))
+		require.NoError(t, store.Append(t.Context(), "a", []fantasy.Message{fantasy.NewUserMessage("Hello")}))
+
+		messages, err := store.Load(t.Context(), "a")
+		require.NoError(t, err)
+		require.Equal(t, []fantasy.Message{fantasy.NewUserMessage("Hello")}, messages)
+	})
+}
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	t.Run("keeps the history between turns", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		model := &mockLanguageModel{}
+		store := newJSONLStore(t)
+		session := New(fantasy.NewAgent(model, fantasy.WithSystemPrompt("Be brief."), fantasy.WithTools(weatherTool)), store, "s1")
+
+		_, err := session.Generate(ctx, fantasy.AgentCall{Prompt: "Weather in Paris?"})
+		require.NoError(t, err)
+		messages, err := session.Messages(ctx)
+		require.NoError(t, err)
+		// The prompt, the tool call, its result and the answer.
+		require.Len(t, messages, 4)
+		require.Equal(t, fantasy.NewUserMessage("Weather in Paris?"), messages[0])
+		require.Equal(t, fantasy.MessageRoleAssistant, messages[1].Role)
+		require.Equal(t, fantasy.MessageRoleTool, messages[2].Role)
+		require.Equal(t, fantasy.MessageRoleAssistant, messages[3].Role)
+
+		result, err := session.Generate(ctx, fantasy.AgentCall{Prompt: "And tomorrow?"})
+		require.NoError(t, err)
+		require.Equal(t, "Sunny", result.Response.Content.Text())
+
+		// The system prompt, the history and the new prompt.
+		prompt := model.prompts[2]
+		require.Len(t, prompt, 6)
+		require.Equal(t, fantasy.MessageRoleSystem, prompt[0].Role)
+		require.Equal(t, messages, []fantasy.Message(prompt[1:5]))
+		require.Equal(t, fantasy.NewUserMessage("And tomorrow?"), prompt[5])
+
+		messages, err = session.Messages(ctx)
+		require.NoError(t, err)
+		require.Len(t, messages, 8)
+		info, ok, err := session.Info(ctx)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "s1", info.ID)
+	})
+
+	t.Run("stores completed steps of suspended runs", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		store := NewMemoryStore()
+		agent := fantasy.NewAgent(&mockLanguageModel{}, fantasy.WithTools(fantasy.RequireApproval(weatherTool)))
+		session := New(agent, store, "s1")
+
+		result, err := session.Generate(ctx, fantasy.AgentCall{Prompt: "Weather in Paris?"})
+		require.NoError(t, err)
+		require.NotNil(t, result.Suspended)
+		messages, err := session.Messages(ctx)
+		require.NoError(t, err)
+		// The tool call waiting for approval is not stored.
+		require.Equal(t, []fantasy.Message{fantasy.NewUserMessage("Weather in Paris?")}, messages)
+
+		result, err = session.Generate(ctx, fantasy.AgentCall{
+			Resume:    result.Suspended,
+			Approvals: []fantasy.ToolApproval{{ToolCallID: "call-1", Decision: fantasy.ApprovalDecisionApprove}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Sunny", result.Response.Content.Text())
+		messages, err = session.Messages(ctx)
+		require.NoError(t, err)
+		require.Len(t, messages, 4)
+		require.Equal(t, fantasy.MessageRoleTool, messages[2].Role)
+	})
+
+	t.Run("stores nothing when the run fails", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		store := NewMemoryStore()
+		session := New(fantasy.NewAgent(&mockLanguageModel{}), store, "s1")
+
+		_, err := session.Generate(ctx, fantasy.AgentCall{})
+		require.Error(t, err)
+		_, ok, err := store.Info(ctx, "s1")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+)
+
+type memorySession struct {
+	info     Info
+	messages []fantasy.Message
+}
+
+// MemoryStore is an in-memory store, sessions are lost when the process
+// exits.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]*memorySession
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]*memorySession),
+	}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(_ context.Context, id string) ([]fantasy.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	return slices.Clone(session.messages), nil
+}
+
+// Append implements Store.
+func (s *MemoryStore) Append(_ context.Context, id string, messages []fantasy.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session := s.session(id)
+	session.messages = append(session.messages, messages...)
+	session.info.UpdatedAt = time.Now()
+	return nil
+}
+
+// Info implements Store.
+func (s *MemoryStore) Info(_ context.Context, id string) (Info, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return Info{}, false, nil
+	}
+	return cloneInfo(session.info), true, nil
+}
+
+// SetMetadata implements Store.
+func (s *MemoryStore) SetMetadata(_ context.Context, id string, metadata map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session := s.session(id)
+	session.info.Metadata = maps.Clone(metadata)
+	session.info.UpdatedAt = time.Now()
+	return nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context) ([]Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]Info, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		infos = append(infos, cloneInfo(session.info))
+	}
+	sortInfos(infos)
+	return infos, nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+// session returns the session with the given ID, creating it if needed.
+func (s *MemoryStore) session(id string) *memorySession {
+	session, ok := s.sessions[id]
+	if !ok {
+		now := time.Now()
+		session = &memorySession{info: Info{ID: id, CreatedAt: now, UpdatedAt: now}}
+		s.sessions[id] = session
+	}
+	return session
+}
+
+func cloneInfo(info Info) Info {
+	info.Metadata = maps.Clone(info.Metadata)
+	return info
+}
+
+// sortInfos sorts sessions by most recent update, then by ID.
+func sortInfos(infos []Info) {
+	slices.SortFunc(infos, func(a, b Info) int {
+		return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), cmp.Compare(a.ID, b.ID))
+	})
+}
@@ -0,0 +1,97 @@
+package fantasy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"charm.land/fantasy/schema"
+)
+
+// SubAgentInput is the input of a tool created with [NewSubAgentTool].
+type SubAgentInput struct {
+	Prompt string `json:"prompt" description:"The task to hand over, with all the context needed to complete it"`
+}
+
+// SubAgentToolOption configures a tool created with [NewSubAgentTool].
+type SubAgentToolOption func(*subAgentToolSettings)
+
+type subAgentToolSettings struct {
+	steps    bool
+	parallel bool
+}
+
+// WithSubAgentSteps adds the steps of the sub-agent, serialized as JSON, to
+// the metadata of the tool response.
+func WithSubAgentSteps() SubAgentToolOption {
+	return func(s *subAgentToolSettings) {
+		s.steps = true
+	}
+}
+
+// WithSubAgentParallel marks the tool as safe to run in parallel with other
+// tools, so a coordinator can run several sub-agents at once.
+func WithSubAgentParallel() SubAgentToolOption {
+	return func(s *subAgentToolSettings) {
+		s.parallel = true
+	}
+}
+
+// NewSubAgentTool creates a tool that hands a task over to an agent, so a
+// coordinator agent can delegate focused subtasks to specialist agents with
+// their own model, system prompt and tools.
+//
+// The model calls the tool with a prompt and receives the final text of the
+// sub-agent. The usage of the sub-agent is added to the tool usage of the step
+// of the calling agent, see [ReportToolUsage]. When the calling agent streams
+// with an OnChunk callback, the sub-agent streams as well and its parts are
+// forwarded to that callback with [StreamPart.AgentPath] set.
+func NewSubAgentTool(name, description string, agent Agent, opts ...SubAgentToolOption) AgentTool {
+	var settings subAgentToolSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	return &funcToolWrapper[SubAgentInput]{
+		name:        name,
+		description: description,
+		fn: func(ctx context.Context, input SubAgentInput, call ToolCall) (ToolResponse, error) {
+			result, err := runSubAgent(ctx, agent, input, call)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ToolResponse{}, err
+				}
+				return NewTextErrorResponse(fmt.Sprintf("agent %s failed: %s", name, err)), nil
+			}
+			ReportToolUsage(ctx, result.TotalUsage)
+			if result.Suspended != nil {
+				return NewTextErrorResponse(fmt.Sprintf("agent %s stopped to wait for the approval of a tool call", name)), nil
+			}
+
+			response := NewTextResponse(result.Response.Content.Text())
+			if settings.steps {
+				response = WithResponseMetadata(response, result.Steps)
+			}
+			return response, nil
+		},
+		schema:   schema.Generate(reflect.TypeFor[SubAgentInput]()),
+		parallel: settings.parallel,
+	}
+}
+
+// runSubAgent runs the agent with the prompt of the tool call. The agent
+// streams when the calling agent forwards stream parts to an OnChunk callback.
+func runSubAgent(ctx context.Context, agent Agent, input SubAgentInput, call ToolCall) (*AgentResult, error) {
+	run, ok := ctx.Value(toolRunKey{}).(*toolRun)
+	if !ok || run.onChunk == nil {
+		return agent.Generate(ctx, AgentCall{Prompt: input.Prompt})
+	}
+	return agent.Stream(ctx, AgentStreamCall{
+		Prompt: input.Prompt,
+		OnChunk: func(part StreamPart) error {
+			part.AgentPath = slices.Concat([]string{call.ID}, part.AgentPath)
+			return run.onChunk(part)
+		},
+	})
+}
@@ -0,0 +1,148 @@
+package fantasy
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// coordinatorModel calls the researcher tool in its first step and stops in
+// the second one.
+func coordinatorModel() *mockLanguageModel {
+	usage := Usage{InputTokens: 5, OutputTokens: 5, TotalTokens: 10}
+	steps := 0
+	respond := func() *Response {
+		steps++
+		if steps == 1 {
+			return &Response{
+				Content: []Content{ToolCallContent{
+					ToolCallID: "call-1",
+					ToolName:   "researcher",
+					Input:      `{"prompt":"Find the answer"}`,
+				}},
+				Usage:        usage,
+				FinishReason: FinishReasonToolCalls,
+			}
+		}
+		return &Response{
+			Content:      []Content{TextContent{Text: "Done"}},
+			Usage:        usage,
+			FinishReason: FinishReasonStop,
+		}
+	}
+	return &mockLanguageModel{
+		generateFunc: func(context.Context, Call) (*Response, error) {
+			return respond(), nil
+		},
+		streamFunc: func(context.Context, Call) (StreamResponse, error) {
+			resp := respond()
+			return func(yield func(StreamPart) bool) {
+				for _, content := range resp.Content {
+					if toolCall, ok := content.(ToolCallContent); ok {
+						if !yield(StreamPart{Type: StreamPartTypeToolCall, ID: toolCall.ToolCallID, ToolCallName: toolCall.ToolName, ToolCallInput: toolCall.Input}) {
+							return
+						}
+					}
+				}
+				yield(StreamPart{Type: StreamPartTypeFinish, Usage: resp.Usage, FinishReason: resp.FinishReason})
+			}, nil
+		},
+	}
+}
+
+func TestSubAgentTool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns the final text of the sub-agent", func(t *testing.T) {
+		t.Parallel()
+
+		var prompt Prompt
+		researcher := NewAgent(&mockLanguageModel{
+			generateFunc: func(_ context.Context, call Call) (*Response, error) {
+				prompt = call.Prompt
+				return &Response{
+					Content:      []Content{TextContent{Text: "42"}},
+					Usage:        Usage{InputTokens: 3, OutputTokens: 10, TotalTokens: 13},
+					FinishReason: FinishReasonStop,
+				}, nil
+			},
+		}, WithSystemPrompt("You are a researcher."))
+		tool := NewSubAgentTool("researcher", "Researches a question", researcher, WithSubAgentSteps())
+		require.Equal(t, []string{"prompt"}, tool.Info().Required)
+
+		coordinator := NewAgent(coordinatorModel(), WithTools(tool))
+		result, err := coordinator.Generate(t.Context(), AgentCall{Prompt: "What is the answer?"})
+		require.NoError(t, err)
+
+		require.Equal(t, Prompt{NewSystemMessage("You are a researcher."), NewUserMessage("Find the answer")}, prompt)
+		toolResults := result.Steps[0].Content.ToolResults()
+		require.Len(t, toolResults, 1)
+		require.Equal(t, ToolResultOutputContentText{Text: "42"}, toolResults[0].Result)
+
+		var steps []StepResult
+		require.NoError(t, json.Unmarshal([]byte(toolResults[0].ClientMetadata), &steps))
+		require.Len(t, steps, 1)
+		require.Equal(t, "42", steps[0].Content.Text())
+
+		require.Equal(t, int64(10), result.Steps[0].Usage.TotalTokens)
+		require.Equal(t, int64(13), result.Steps[0].ToolUsage.TotalTokens)
+		require.Equal(t, int64(33), result.TotalUsage.TotalTokens)
+	})
+
+	t.Run("forwards the stream parts of the sub-agent", func(t *testing.T) {
+		t.Parallel()
+
+		researcher := NewAgent(&mockLanguageModel{
+			streamFunc: func(context.Context, Call) (StreamResponse, error) {
+				return func(yield func(StreamPart) bool) {
+					_ = yield(StreamPart{Type: StreamPartTypeTextStart, ID: "text-1"}) &&
+						yield(StreamPart{Type: StreamPartTypeTextDelta, ID: "text-1", Delta: "42"}) &&
+						yield(StreamPart{Type: StreamPartTypeTextEnd, ID: "text-1"}) &&
+						yield(StreamPart{Type: StreamPartTypeFinish, Usage: Usage{TotalTokens: 13}, FinishReason: FinishReasonStop})
+				}, nil
+			},
+		})
+		tool := NewSubAgentTool("researcher", "Researches a question", researcher)
+
+		var mu sync.Mutex
+		var nested []StreamPart
+		coordinator := NewAgent(coordinatorModel(), WithTools(tool))
+		result, err := coordinator.Stream(t.Context(), AgentStreamCall{
+			Prompt: "What is the answer?",
+			OnChunk: func(part StreamPart) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if len(part.AgentPath) > 0 {
+					nested = append(nested, part)
+				}
+				return nil
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, nested, 4)
+		require.Equal(t, []string{"call-1"}, nested[1].AgentPath)
+		require.Equal(t, "42", nested[1].Delta)
+		require.Equal(t, "42", result.Steps[0].Content.ToolResults()[0].Result.(ToolResultOutputContentText).Text)
+		require.Equal(t, int64(33), result.TotalUsage.TotalTokens)
+	})
+
+	t.Run("reports failures to the model", func(t *testing.T) {
+		t.Parallel()
+
+		researcher := NewAgent(&mockLanguageModel{
+			generateFunc: func(context.Context, Call) (*Response, error) {
+				return nil, &Error{Title: "overloaded", Message: "try again later"}
+			},
+		}, WithMaxRetries(0))
+		tool := NewSubAgentTool("researcher", "Researches a question", researcher)
+
+		response, err := tool.Run(t.Context(), ToolCall{ID: "call-1", Name: "researcher", Input: `{"prompt":"Find the answer"}`})
+		require.NoError(t, err)
+		require.True(t, response.IsError)
+		require.Contains(t, response.Content, "agent researcher failed")
+	})
+}
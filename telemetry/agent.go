@@ -0,0 +1,125 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"charm.land/fantasy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type stepStateKey struct{}
+
+type stepState struct {
+	retries int
+}
+
+// AgentHooks returns the agent hooks that create the spans of agent runs,
+// steps and tool calls. Pass them to the agent with [fantasy.WithHooks].
+//
+// Model calls made by the agent are children of the step span, they are only
+// traced when the model is wrapped with [Instrumentation.WrapLanguageModel].
+func (i *Instrumentation) AgentHooks() fantasy.AgentHooks {
+	return fantasy.AgentHooks{
+		OnRunStart:   i.runStart,
+		OnRunFinish:  i.runFinish,
+		OnStepStart:  i.stepStart,
+		OnStepRetry:  i.stepRetry,
+		OnStepFinish: i.stepFinish,
+		OnToolStart:  i.toolStart,
+		OnToolFinish: i.toolFinish,
+	}
+}
+
+func (i *Instrumentation) runStart(ctx context.Context, model fantasy.LanguageModel) context.Context {
+	name := operationInvokeAgent
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameKey.String(operationInvokeAgent),
+		semconv.GenAISystemKey.String(model.Provider()),
+		semconv.GenAIRequestModel(model.Model()),
+	}
+	if i.agentName != "" {
+		name += " " + i.agentName
+		attrs = append(attrs, semconv.GenAIAgentName(i.agentName))
+	}
+	ctx, _ = i.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (i *Instrumentation) runFinish(ctx context.Context, result *fantasy.AgentResult, err error) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+	if err != nil {
+		recordError(span, err)
+		return
+	}
+	span.SetAttributes(usageAttributes(result.TotalUsage)...)
+	span.SetAttributes(
+		StepCountKey.Int(len(result.Steps)),
+		semconv.GenAIResponseFinishReasons(string(result.Response.FinishReason)),
+	)
+}
+
+func (i *Instrumentation) stepStart(ctx context.Context, stepNumber int, model fantasy.LanguageModel) context.Context {
+	ctx, _ = i.tracer.Start(ctx, "step", trace.WithAttributes(
+		StepNumberKey.Int(stepNumber),
+		semconv.GenAISystemKey.String(model.Provider()),
+		semconv.GenAIRequestModel(model.Model()),
+	))
+	return context.WithValue(ctx, stepStateKey{}, &stepState{})
+}
+
+func (i *Instrumentation) stepRetry(ctx context.Context, err *fantasy.ProviderError, delay time.Duration) {
+	if state, ok := ctx.Value(stepStateKey{}).(*stepState); ok {
+		state.retries++
+	}
+	trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+		semconv.ErrorTypeKey.String(errorType(err)),
+		attribute.String("exception.message", err.Error()),
+		attribute.Float64("retry.delay", delay.Seconds()),
+	))
+}
+
+func (i *Instrumentation) stepFinish(ctx context.Context, step *fantasy.StepResult, err error) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+	if state, ok := ctx.Value(stepStateKey{}).(*stepState); ok {
+		span.SetAttributes(RetryCountKey.Int(state.retries))
+	}
+	if err != nil {
+		recordError(span, err)
+		return
+	}
+	if step == nil {
+		// The step was suspended for approval.
+		return
+	}
+	span.SetAttributes(usageAttributes(step.Usage)...)
+	span.SetAttributes(semconv.GenAIResponseFinishReasons(string(step.FinishReason)))
+}
+
+func (i *Instrumentation) toolStart(ctx context.Context, toolCall fantasy.ToolCall) context.Context {
+	ctx, _ = i.tracer.Start(ctx, operationExecuteTool+" "+toolCall.Name, trace.WithAttributes(
+		semconv.GenAIOperationNameKey.String(operationExecuteTool),
+		semconv.GenAIToolName(toolCall.Name),
+		semconv.GenAIToolCallID(toolCall.ID),
+		semconv.GenAIToolType("function"),
+	))
+	return ctx
+}
+
+func (i *Instrumentation) toolFinish(ctx context.Context, _ fantasy.ToolCall, response fantasy.ToolResponse, err error) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+	if err != nil {
+		recordError(span, err)
+		return
+	}
+	if response.IsError {
+		span.SetStatus(codes.Error, response.Content)
+		span.SetAttributes(semconv.ErrorTypeKey.String("tool_error"))
+	}
+}
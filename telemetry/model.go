@@ -0,0 +1,227 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"charm.land/fantasy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapLanguageModel instruments a language model.
+//
+// Every call gets a client span carrying the request settings, the token
+// usage and the finish reason, and records the duration and token usage
+// metrics. Streamed calls also record the time to first token, their span
+// ends once the stream has been consumed.
+func (i *Instrumentation) WrapLanguageModel(model fantasy.LanguageModel) fantasy.LanguageModel {
+	provider, modelID := model.Provider(), model.Model()
+	return fantasy.WrapLanguageModel(model, fantasy.LanguageModelMiddleware{
+		WrapGenerate: func(ctx context.Context, call fantasy.Call, next fantasy.GenerateFunc) (*fantasy.Response, error) {
+			ctx, mc := i.startModelCall(ctx, provider, modelID, false, callAttributes(call))
+			resp, err := next(ctx, call)
+			if err != nil {
+				mc.end(fantasy.Usage{}, "", err)
+				return nil, err
+			}
+			mc.end(resp.Usage, resp.FinishReason, nil)
+			return resp, nil
+		},
+		WrapStream: func(ctx context.Context, call fantasy.Call, next fantasy.StreamFunc) (fantasy.StreamResponse, error) {
+			ctx, mc := i.startModelCall(ctx, provider, modelID, true, callAttributes(call))
+			stream, err := next(ctx, call)
+			if err != nil {
+				mc.end(fantasy.Usage{}, "", err)
+				return nil, err
+			}
+			return func(yield func(fantasy.StreamPart) bool) {
+				var usage fantasy.Usage
+				var finishReason fantasy.FinishReason
+				var streamErr error
+				defer func() { mc.end(usage, finishReason, streamErr) }()
+				for part := range stream {
+					switch part.Type {
+					case fantasy.StreamPartTypeWarnings:
+					case fantasy.StreamPartTypeFinish:
+						usage, finishReason = part.Usage, part.FinishReason
+					case fantasy.StreamPartTypeError:
+						streamErr = part.Error
+					default:
+						mc.chunk()
+					}
+					if !yield(part) {
+						return
+					}
+				}
+			}, nil
+		},
+		WrapGenerateObject: func(ctx context.Context, call fantasy.ObjectCall, next fantasy.GenerateObjectFunc) (*fantasy.ObjectResponse, error) {
+			ctx, mc := i.startModelCall(ctx, provider, modelID, false, objectCallAttributes(call))
+			resp, err := next(ctx, call)
+			if err != nil {
+				mc.end(fantasy.Usage{}, "", err)
+				return nil, err
+			}
+			mc.end(resp.Usage, resp.FinishReason, nil)
+			return resp, nil
+		},
+		WrapStreamObject: func(ctx context.Context, call fantasy.ObjectCall, next fantasy.StreamObjectFunc) (fantasy.ObjectStreamResponse, error) {
+			ctx, mc := i.startModelCall(ctx, provider, modelID, true, objectCallAttributes(call))
+			stream, err := next(ctx, call)
+			if err != nil {
+				mc.end(fantasy.Usage{}, "", err)
+				return nil, err
+			}
+			return func(yield func(fantasy.ObjectStreamPart) bool) {
+				var usage fantasy.Usage
+				var finishReason fantasy.FinishReason
+				var streamErr error
+				defer func() { mc.end(usage, finishReason, streamErr) }()
+				for part := range stream {
+					switch part.Type {
+					case fantasy.ObjectStreamPartTypeFinish:
+						usage, finishReason = part.Usage, part.FinishReason
+					case fantasy.ObjectStreamPartTypeError:
+						streamErr = part.Error
+					default:
+						mc.chunk()
+					}
+					if !yield(part) {
+						return
+					}
+				}
+			}, nil
+		},
+	})
+}
+
+// modelCall tracks the span and metrics of a single model call.
+type modelCall struct {
+	inst        *Instrumentation
+	ctx         context.Context
+	span        trace.Span
+	start       time.Time
+	metricAttrs []attribute.KeyValue
+	firstChunk  bool
+}
+
+func (i *Instrumentation) startModelCall(ctx context.Context, provider, modelID string, stream bool, attrs []attribute.KeyValue) (context.Context, *modelCall) {
+	metricAttrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameKey.String(operationChat),
+		semconv.GenAISystemKey.String(provider),
+		semconv.GenAIRequestModel(modelID),
+	}
+	attrs = append(attrs, metricAttrs...)
+	attrs = append(attrs, StreamKey.Bool(stream))
+	ctx, span := i.tracer.Start(ctx, operationChat+" "+modelID,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, &modelCall{
+		inst:        i,
+		ctx:         ctx,
+		span:        span,
+		start:       time.Now(),
+		metricAttrs: metricAttrs,
+	}
+}
+
+// chunk records the time to first token on the first content chunk of a
+// stream.
+func (c *modelCall) chunk() {
+	if c.firstChunk {
+		return
+	}
+	c.firstChunk = true
+	c.inst.timeToFirstToken.Record(c.ctx, time.Since(c.start).Seconds(), metric.WithAttributes(c.metricAttrs...))
+}
+
+func (c *modelCall) end(usage fantasy.Usage, finishReason fantasy.FinishReason, err error) {
+	defer c.span.End()
+
+	metricAttrs := c.metricAttrs
+	if err != nil {
+		recordError(c.span, err)
+		metricAttrs = append(metricAttrs, semconv.ErrorTypeKey.String(errorType(err)))
+		c.inst.duration.Record(c.ctx, time.Since(c.start).Seconds(), metric.WithAttributes(metricAttrs...))
+		return
+	}
+
+	c.span.SetAttributes(usageAttributes(usage)...)
+	if finishReason != "" {
+		c.span.SetAttributes(semconv.GenAIResponseFinishReasons(string(finishReason)))
+	}
+	c.inst.duration.Record(c.ctx, time.Since(c.start).Seconds(), metric.WithAttributes(metricAttrs...))
+	c.inst.tokenUsage.Record(c.ctx, usage.InputTokens, metric.WithAttributes(
+		append(metricAttrs, semconv.GenAITokenTypeInput)...,
+	))
+	c.inst.tokenUsage.Record(c.ctx, usage.OutputTokens, metric.WithAttributes(
+		append(metricAttrs, semconv.GenAITokenTypeOutput)...,
+	))
+}
+
+func callAttributes(call fantasy.Call) []attribute.KeyValue {
+	return settingsAttributes(
+		call.MaxOutputTokens,
+		call.Temperature,
+		call.TopP,
+		call.TopK,
+		call.PresencePenalty,
+		call.FrequencyPenalty,
+		call.StopSequences,
+		call.Seed,
+	)
+}
+
+func objectCallAttributes(call fantasy.ObjectCall) []attribute.KeyValue {
+	attrs := settingsAttributes(
+		call.MaxOutputTokens,
+		call.Temperature,
+		call.TopP,
+		call.TopK,
+		call.PresencePenalty,
+		call.FrequencyPenalty,
+		call.StopSequences,
+		call.Seed,
+	)
+	return append(attrs, semconv.GenAIOutputTypeJSON)
+}
+
+func settingsAttributes(
+	maxOutputTokens *int64,
+	temperature, topP *float64,
+	topK *int64,
+	presencePenalty, frequencyPenalty *float64,
+	stopSequences []string,
+	seed *int64,
+) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if maxOutputTokens != nil {
+		attrs = append(attrs, semconv.GenAIRequestMaxTokens(int(*maxOutputTokens)))
+	}
+	if temperature != nil {
+		attrs = append(attrs, semconv.GenAIRequestTemperature(*temperature))
+	}
+	if topP != nil {
+		attrs = append(attrs, semconv.GenAIRequestTopP(*topP))
+	}
+	if topK != nil {
+		attrs = append(attrs, semconv.GenAIRequestTopK(float64(*topK)))
+	}
+	if presencePenalty != nil {
+		attrs = append(attrs, semconv.GenAIRequestPresencePenalty(*presencePenalty))
+	}
+	if frequencyPenalty != nil {
+		attrs = append(attrs, semconv.GenAIRequestFrequencyPenalty(*frequencyPenalty))
+	}
+	if len(stopSequences) > 0 {
+		attrs = append(attrs, semconv.GenAIRequestStopSequences(stopSequences...))
+	}
+	if seed != nil {
+		attrs = append(attrs, semconv.GenAIRequestSeed(int(*seed)))
+	}
+	return attrs
+}
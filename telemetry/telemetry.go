@@ -0,0 +1,199 @@
+// Package telemetry instruments fantasy agents, language models and tools
+// with OpenTelemetry traces and metrics.
+//
+// Language models are instrumented with [Instrumentation.WrapLanguageModel]
+// and agents with the hooks returned by [Instrumentation.AgentHooks]:
+//
+//	inst, err := telemetry.New()
+//	if err != nil {
+//		return err
+//	}
+//	agent := fantasy.NewAgent(
+//		inst.WrapLanguageModel(model),
+//		fantasy.WithHooks(inst.AgentHooks()),
+//	)
+//
+// Spans and metrics follow the OpenTelemetry semantic conventions for
+// generative AI where they exist.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"charm.land/fantasy"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the tracer and meter.
+const ScopeName = "charm.land/fantasy/telemetry"
+
+// Attribute keys that are not part of the semantic conventions.
+const (
+	// StepNumberKey is the number of the agent step, starting at 0.
+	StepNumberKey = attribute.Key("fantasy.step.number")
+	// StepCountKey is the number of steps of an agent run.
+	StepCountKey = attribute.Key("fantasy.agent.steps")
+	// RetryCountKey is the number of times the model call of a step was retried.
+	RetryCountKey = attribute.Key("fantasy.retry.count")
+	// StreamKey is set on model calls that stream the response.
+	StreamKey = attribute.Key("fantasy.stream")
+	// ReasoningTokensKey is the number of reasoning tokens used.
+	ReasoningTokensKey = attribute.Key("fantasy.usage.reasoning_tokens")
+	// CacheReadTokensKey is the number of input tokens read from the cache.
+	CacheReadTokensKey = attribute.Key("fantasy.usage.cache_read_tokens")
+	// CacheCreationTokensKey is the number of input tokens written to the cache.
+	CacheCreationTokensKey = attribute.Key("fantasy.usage.cache_creation_tokens")
+)
+
+// Metric names.
+const (
+	// OperationDurationMetric is the duration of model calls in seconds.
+	OperationDurationMetric = "gen_ai.client.operation.duration"
+	// TokenUsageMetric is the number of input and output tokens used by model calls.
+	TokenUsageMetric = "gen_ai.client.token.usage"
+	// TimeToFirstTokenMetric is the time until the first chunk of a streamed
+	// response was received in seconds.
+	TimeToFirstTokenMetric = "gen_ai.client.time_to_first_token"
+)
+
+const (
+	operationChat        = "chat"
+	operationInvokeAgent = "invoke_agent"
+	operationExecuteTool = "execute_tool"
+)
+
+// Instrumentation creates spans and records metrics for agents, language
+// models and tools.
+type Instrumentation struct {
+	tracer    trace.Tracer
+	agentName string
+
+	duration         metric.Float64Histogram
+	tokenUsage       metric.Int64Histogram
+	timeToFirstToken metric.Float64Histogram
+}
+
+type settings struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	agentName      string
+}
+
+// Option configures the instrumentation.
+type Option = func(*settings)
+
+// WithTracerProvider sets the tracer provider, the global one is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(s *settings) {
+		s.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider, the global one is used by default.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(s *settings) {
+		s.meterProvider = provider
+	}
+}
+
+// WithAgentName sets the agent name reported on agent spans.
+func WithAgentName(name string) Option {
+	return func(s *settings) {
+		s.agentName = name
+	}
+}
+
+// New creates a new instrumentation.
+func New(opts ...Option) (*Instrumentation, error) {
+	s := settings{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, o := range opts {
+		o(&s)
+	}
+
+	meter := s.meterProvider.Meter(ScopeName)
+	duration, err := meter.Float64Histogram(
+		OperationDurationMetric,
+		metric.WithDescription("GenAI operation duration."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92),
+	)
+	if err != nil {
+		return nil, err
+	}
+	tokenUsage, err := meter.Int64Histogram(
+		TokenUsageMetric,
+		metric.WithDescription("Measures number of input and output tokens used."),
+		metric.WithUnit("{token}"),
+		metric.WithExplicitBucketBoundaries(1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864),
+	)
+	if err != nil {
+		return nil, err
+	}
+	timeToFirstToken, err := meter.Float64Histogram(
+		TimeToFirstTokenMetric,
+		metric.WithDescription("Time to receive the first chunk of a streamed response."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.001, 0.005, 0.01, 0.02, 0.04, 0.06, 0.08, 0.1, 0.25, 0.5, 0.75, 1.0, 2.5, 5.0, 7.5, 10.0),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instrumentation{
+		tracer:           s.tracerProvider.Tracer(ScopeName),
+		agentName:        s.agentName,
+		duration:         duration,
+		tokenUsage:       tokenUsage,
+		timeToFirstToken: timeToFirstToken,
+	}, nil
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(semconv.ErrorTypeKey.String(errorType(err)))
+}
+
+// errorType returns the error.type attribute value of an error: the status
+// code for provider errors and the Go type otherwise.
+func errorType(err error) string {
+	var providerErr *fantasy.ProviderError
+	if errors.As(err, &providerErr) && providerErr.StatusCode != 0 {
+		return strconv.Itoa(providerErr.StatusCode)
+	}
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+func usageAttributes(usage fantasy.Usage) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.GenAIUsageInputTokens(int(usage.InputTokens)),
+		semconv.GenAIUsageOutputTokens(int(usage.OutputTokens)),
+	}
+	if usage.ReasoningTokens > 0 {
+		attrs = append(attrs, ReasoningTokensKey.Int64(usage.ReasoningTokens))
+	}
+	if usage.CacheReadTokens > 0 {
+		attrs = append(attrs, CacheReadTokensKey.Int64(usage.CacheReadTokens))
+	}
+	if usage.CacheCreationTokens > 0 {
+		attrs = append(attrs, CacheCreationTokensKey.Int64(usage.CacheCreationTokens))
+	}
+	return attrs
+}
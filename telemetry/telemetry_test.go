@@ -0,0 +1,337 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type mockLanguageModel struct {
+	generateFunc func(ctx context.Context, call fantasy.Call) (*fantasy.Response, error)
+	streamFunc   func(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error)
+}
+
+func (m *mockLanguageModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	return m.generateFunc(ctx, call)
+}
+
+func (m *mockLanguageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	return m.streamFunc(ctx, call)
+}
+
+func (m *mockLanguageModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLanguageModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLanguageModel) Provider() string {
+	return "mock-provider"
+}
+
+func (m *mockLanguageModel) Model() string {
+	return "mock-model"
+}
+
+type testInstrumentation struct {
+	*Instrumentation
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+}
+
+func newTestInstrumentation(t *testing.T, opts ...Option) testInstrumentation {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	opts = append([]Option{
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	}, opts...)
+	inst, err := New(opts...)
+	require.NoError(t, err)
+	return testInstrumentation{Instrumentation: inst, spans: spans, reader: reader}
+}
+
+func (ti testInstrumentation) span(t *testing.T, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range ti.spans.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	require.Failf(t, "span not found", "no ended span named %q", name)
+	return nil
+}
+
+func (ti testInstrumentation) metric(t *testing.T, name string) metricdata.Metrics {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, ti.reader.Collect(t.Context(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+	require.Failf(t, "metric not found", "no metric named %q", name)
+	return metricdata.Metrics{}
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestWrapLanguageModel_Generate(t *testing.T) {
+	t.Parallel()
+
+	ti := newTestInstrumentation(t)
+	model := ti.WrapLanguageModel(&mockLanguageModel{
+		generateFunc: func(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+			return &fantasy.Response{
+				Content:      fantasy.ResponseContent{fantasy.TextContent{Text: "hi"}},
+				FinishReason: fantasy.FinishReasonStop,
+				Usage:        fantasy.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+			}, nil
+		},
+	})
+	require.Equal(t, "mock-provider", model.Provider())
+	require.Equal(t, "mock-model", model.Model())
+
+	_, err := model.Generate(t.Context(), fantasy.Call{Temperature: fantasy.Opt(0.2)})
+	require.NoError(t, err)
+
+	span := ti.span(t, "chat mock-model")
+	attrs := attributes(span)
+	require.Equal(t, "chat", attrs["gen_ai.operation.name"].AsString())
+	require.Equal(t, "mock-provider", attrs["gen_ai.system"].AsString())
+	require.Equal(t, "mock-model", attrs["gen_ai.request.model"].AsString())
+	require.Equal(t, 0.2, attrs["gen_ai.request.temperature"].AsFloat64())
+	require.Equal(t, int64(10), attrs["gen_ai.usage.input_tokens"].AsInt64())
+	require.Equal(t, int64(5), attrs["gen_ai.usage.output_tokens"].AsInt64())
+	require.Equal(t, []string{"stop"}, attrs["gen_ai.response.finish_reasons"].AsStringSlice())
+	require.False(t, attrs[StreamKey].AsBool())
+
+	duration := ti.metric(t, OperationDurationMetric).Data.(metricdata.Histogram[float64])
+	require.Len(t, duration.DataPoints, 1)
+	require.Equal(t, uint64(1), duration.DataPoints[0].Count)
+
+	tokens := ti.metric(t, TokenUsageMetric).Data.(metricdata.Histogram[int64])
+	require.Len(t, tokens.DataPoints, 2)
+	var sum int64
+	for _, dp := range tokens.DataPoints {
+		sum += dp.Sum
+	}
+	require.Equal(t, int64(15), sum)
+}
+
+func TestWrapLanguageModel_GenerateError(t *testing.T) {
+	t.Parallel()
+
+	ti := newTestInstrumentation(t)
+	model := ti.WrapLanguageModel(&mockLanguageModel{
+		generateFunc: func(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+			return nil, &fantasy.ProviderError{Message: "overloaded", StatusCode: 529}
+		},
+	})
+
+	_, err := model.Generate(t.Context(), fantasy.Call{})
+	require.Error(t, err)
+
+	span := ti.span(t, "chat mock-model")
+	require.Equal(t, codes.Error, span.Status().Code)
+	require.Equal(t, "529", attributes(span)["error.type"].AsString())
+}
+
+func TestWrapLanguageModel_Stream(t *testing.T) {
+	t.Parallel()
+
+	ti := newTestInstrumentation(t)
+	model := ti.WrapLanguageModel(&mockLanguageModel{
+		streamFunc: func(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+			return func(yield func(fantasy.StreamPart) bool) {
+				parts := []fantasy.StreamPart{
+					{Type: fantasy.StreamPartTypeWarnings},
+					{Type: fantasy.StreamPartTypeTextStart, ID: "0"},
+					{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: "hello"},
+					{Type: fantasy.StreamPartTypeTextEnd, ID: "0"},
+					{
+						Type:         fantasy.StreamPartTypeFinish,
+						FinishReason: fantasy.FinishReasonStop,
+						Usage:        fantasy.Usage{InputTokens: 3, OutputTokens: 1},
+					},
+				}
+				for _, part := range parts {
+					if !yield(part) {
+						return
+					}
+				}
+			}, nil
+		},
+	})
+
+	stream, err := model.Stream(t.Context(), fantasy.Call{})
+	require.NoError(t, err)
+	require.Empty(t, ti.spans.Ended(), "span should end once the stream is consumed")
+
+	var text string
+	for part := range stream {
+		text += part.Delta
+	}
+	require.Equal(t, "hello", text)
+
+	attrs := attributes(ti.span(t, "chat mock-model"))
+	require.True(t, attrs[StreamKey].AsBool())
+	require.Equal(t, int64(3), attrs["gen_ai.usage.input_tokens"].AsInt64())
+
+	ttft := ti.metric(t, TimeToFirstTokenMetric).Data.(metricdata.Histogram[float64])
+	require.Len(t, ttft.DataPoints, 1)
+	require.Equal(t, uint64(1), ttft.DataPoints[0].Count)
+}
+
+func TestAgentHooks(t *testing.T) {
+	t.Parallel()
+
+	ti := newTestInstrumentation(t, WithAgentName("weather"))
+
+	calls := 0
+	model := ti.WrapLanguageModel(&mockLanguageModel{
+		generateFunc: func(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+			calls++
+			switch calls {
+			case 1:
+				return nil, &fantasy.ProviderError{
+					Message:         "rate limited",
+					StatusCode:      429,
+					ResponseHeaders: map[string]string{"retry-after-ms": "1"},
+				}
+			case 2:
+				return &fantasy.Response{
+					Content: fantasy.ResponseContent{fantasy.ToolCallContent{
+						ToolCallID: "call-1",
+						ToolName:   "weather",
+						Input:      `{"city":"Paris"}`,
+					}},
+					FinishReason: fantasy.FinishReasonToolCalls,
+					Usage:        fantasy.Usage{InputTokens: 10, OutputTokens: 5},
+				}, nil
+			default:
+				return &fantasy.Response{
+					Content:      fantasy.ResponseContent{fantasy.TextContent{Text: "sunny"}},
+					FinishReason: fantasy.FinishReasonStop,
+					Usage:        fantasy.Usage{InputTokens: 20, OutputTokens: 2},
+				}, nil
+			}
+		},
+	})
+
+	type weatherInput struct {
+		City string `json:"city"`
+	}
+	tool := fantasy.NewAgentTool("weather", "Get the weather",
+		func(ctx context.Context, input weatherInput, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return fantasy.NewTextResponse("sunny in " + input.City), nil
+		},
+	)
+
+	agent := fantasy.NewAgent(model,
+		fantasy.WithTools(tool),
+		fantasy.WithHooks(ti.AgentHooks()),
+	)
+	result, err := agent.Generate(t.Context(), fantasy.AgentCall{Prompt: "weather in Paris?"})
+	require.NoError(t, err)
+	require.Len(t, result.Steps, 2)
+
+	run := ti.span(t, "invoke_agent weather")
+	runAttrs := attributes(run)
+	require.Equal(t, "weather", runAttrs["gen_ai.agent.name"].AsString())
+	require.Equal(t, int64(30), runAttrs["gen_ai.usage.input_tokens"].AsInt64())
+	require.Equal(t, int64(2), runAttrs[StepCountKey].AsInt64())
+
+	var steps []sdktrace.ReadOnlySpan
+	var chats []sdktrace.ReadOnlySpan
+	for _, span := range ti.spans.Ended() {
+		switch span.Name() {
+		case "step":
+			steps = append(steps, span)
+		case "chat mock-model":
+			chats = append(chats, span)
+		}
+	}
+	require.Len(t, steps, 2)
+	require.Len(t, chats, 3, "the failed attempt gets its own span")
+	for _, step := range steps {
+		require.Equal(t, run.SpanContext().SpanID(), step.Parent().SpanID())
+	}
+
+	first := attributes(steps[0])
+	require.Equal(t, int64(0), first[StepNumberKey].AsInt64())
+	require.Equal(t, int64(1), first[RetryCountKey].AsInt64())
+	require.Len(t, steps[0].Events(), 1)
+	require.Equal(t, "retry", steps[0].Events()[0].Name)
+	require.Equal(t, int64(0), attributes(steps[1])[RetryCountKey].AsInt64())
+
+	for _, chat := range chats[:2] {
+		require.Equal(t, steps[0].SpanContext().SpanID(), chat.Parent().SpanID())
+	}
+	require.Equal(t, codes.Error, chats[0].Status().Code)
+	require.Equal(t, steps[1].SpanContext().SpanID(), chats[2].Parent().SpanID())
+
+	toolSpan := ti.span(t, "execute_tool weather")
+	toolAttrs := attributes(toolSpan)
+	require.Equal(t, "call-1", toolAttrs["gen_ai.tool.call.id"].AsString())
+	require.Equal(t, steps[0].SpanContext().SpanID(), toolSpan.Parent().SpanID())
+}
+
+func TestAgentHooks_ToolError(t *testing.T) {
+	t.Parallel()
+
+	ti := newTestInstrumentation(t)
+
+	calls := 0
+	model := &mockLanguageModel{
+		generateFunc: func(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+			calls++
+			if calls == 1 {
+				return &fantasy.Response{
+					Content: fantasy.ResponseContent{fantasy.ToolCallContent{
+						ToolCallID: "call-1",
+						ToolName:   "fail",
+						Input:      `{}`,
+					}},
+					FinishReason: fantasy.FinishReasonToolCalls,
+				}, nil
+			}
+			return &fantasy.Response{FinishReason: fantasy.FinishReasonStop}, nil
+		},
+	}
+	tool := fantasy.NewAgentTool("fail", "Always fails",
+		func(ctx context.Context, input struct{}, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return fantasy.NewTextErrorResponse("boom"), nil
+		},
+	)
+
+	agent := fantasy.NewAgent(model, fantasy.WithTools(tool), fantasy.WithHooks(ti.AgentHooks()))
+	_, err := agent.Generate(t.Context(), fantasy.AgentCall{Prompt: "go"})
+	require.NoError(t, err)
+
+	toolSpan := ti.span(t, "execute_tool fail")
+	require.Equal(t, codes.Error, toolSpan.Status().Code)
+	require.Equal(t, "boom", toolSpan.Status().Description)
+	require.Equal(t, "invoke_agent", ti.span(t, "invoke_agent").Name())
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sync"
 
 	"charm.land/fantasy/schema"
 )
@@ -18,7 +19,16 @@ type ToolInfo struct {
 	Description string         `json:"description"`
 	Parameters  map[string]any `json:"parameters"`
 	Required    []string       `json:"required"`
-	Parallel    bool           `json:"parallel"` // Whether this tool can run in parallel with other tools
+	// Defs holds the schema definitions referenced from Parameters with $ref.
+	Defs map[string]any `json:"defs,omitempty"`
+	// OutputSchema is the JSON schema of the tool output, if the tool
+	// advertises one. The agent checks text outputs against it and sends
+	// outputs that do not match to the model as errors.
+	OutputSchema map[string]any `json:"output_schema,omitempty"`
+	Parallel     bool           `json:"parallel"` // Whether this tool can run in parallel with other tools
+	// RequiresApproval makes the agent suspend before running the tool, see
+	// [RequireApproval].
+	RequiresApproval bool `json:"requires_approval,omitempty"`
 }
 
 // ToolCall represents a tool invocation, matching the existing pattern.
@@ -96,6 +106,39 @@ type AgentTool interface {
 	SetProviderOptions(opts ProviderOptions)
 }
 
+// toolRunKey is the context key of the [toolRun] of the tools of a step.
+type toolRunKey struct{}
+
+// toolRun is shared by the tools of a step through their context.
+type toolRun struct {
+	// onChunk forwards stream parts to the agent, it is nil unless the agent
+	// streams with an OnChunk callback.
+	onChunk OnChunkFunc
+
+	mu    sync.Mutex
+	usage Usage
+}
+
+func (r *toolRun) totalUsage() Usage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage
+}
+
+// ReportToolUsage adds the usage of a tool, such as the model calls of a
+// sub-agent, to the [StepResult.ToolUsage] of the agent step that runs the
+// tool. It is a no-op when the context is not the context of a tool run by an
+// agent.
+func ReportToolUsage(ctx context.Context, usage Usage) {
+	run, ok := ctx.Value(toolRunKey{}).(*toolRun)
+	if !ok {
+		return
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.usage = addUsage(run.usage, usage)
+}
+
 // NewAgentTool creates a typed tool from a function with automatic schema generation.
 // This is the recommended way to create tools.
 func NewAgentTool[TInput any](
@@ -130,12 +173,70 @@ func NewParallelAgentTool[TInput any](
 	return tool
 }
 
+// NewTypedAgentTool creates a tool from a function with a typed input and
+// output. The output is serialized to JSON and its schema, generated like the
+// input schema, is advertised in [ToolInfo.OutputSchema]. Errors returned by
+// the function are sent to the model as error responses.
+func NewTypedAgentTool[TInput, TOutput any](
+	name string,
+	description string,
+	fn func(ctx context.Context, input TInput, call ToolCall) (TOutput, error),
+) AgentTool {
+	var input TInput
+	outputSchema := schema.ToMap(schema.Generate(reflect.TypeFor[TOutput]()))
+
+	return &funcToolWrapper[TInput]{
+		name:        name,
+		description: description,
+		fn: func(ctx context.Context, input TInput, call ToolCall) (ToolResponse, error) {
+			output, err := fn(ctx, input, call)
+			if err != nil {
+				return NewTextErrorResponse(err.Error()), nil
+			}
+			data, err := json.Marshal(output)
+			if err != nil {
+				return ToolResponse{}, fmt.Errorf("failed to marshal output of tool %s: %w", name, err)
+			}
+			return NewTextResponse(string(data)), nil
+		},
+		schema:       schema.Generate(reflect.TypeOf(input)),
+		outputSchema: outputSchema,
+		parallel:     false, // Default to sequential execution
+	}
+}
+
+// RequireApproval marks a tool as requiring approval: instead of running it,
+// the agent suspends and returns an [AgentResult] with the pending calls in
+// [AgentResult.Suspended]. The run is continued with [AgentCall.Resume] and a
+// decision for each pending call.
+//
+// Provider-defined tools are executed by the provider and cannot require
+// approval, RequireApproval panics when given one.
+func RequireApproval(tool AgentTool) AgentTool {
+	if _, ok := tool.(ProviderDefinedTool); ok {
+		panic("provider-defined tool " + tool.Info().Name + " cannot require approval")
+	}
+	return approvalTool{tool}
+}
+
+// approvalTool wraps a tool that requires approval.
+type approvalTool struct {
+	AgentTool
+}
+
+func (t approvalTool) Info() ToolInfo {
+	info := t.AgentTool.Info()
+	info.RequiresApproval = true
+	return info
+}
+
 // funcToolWrapper wraps a function to implement the AgentTool interface.
 type funcToolWrapper[TInput any] struct {
 	name            string
 	description     string
 	fn              func(ctx context.Context, input TInput, call ToolCall) (ToolResponse, error)
 	schema          Schema
+	outputSchema    map[string]any
 	providerOptions ProviderOptions
 	parallel        bool
 }
@@ -157,11 +258,13 @@ func (w *funcToolWrapper[TInput]) Info() ToolInfo {
 		w.schema.Required = []string{}
 	}
 	return ToolInfo{
-		Name:        w.name,
-		Description: w.description,
-		Parameters:  schema.ToParameters(w.schema),
-		Required:    w.schema.Required,
-		Parallel:    w.parallel,
+		Name:         w.name,
+		Description:  w.description,
+		Parameters:   schema.ToParameters(w.schema),
+		Required:     w.schema.Required,
+		Defs:         schema.ToDefs(w.schema),
+		OutputSchema: w.outputSchema,
+		Parallel:     w.parallel,
 	}
 }
 
@@ -173,3 +276,32 @@ func (w *funcToolWrapper[TInput]) Run(ctx context.Context, params ToolCall) (Too
 
 	return w.fn(ctx, input, params)
 }
+
+// Provider-defined tools can be passed to an agent alongside regular tools.
+// They are sent to the model as-is and executed by the provider, so the agent
+// never runs them itself.
+var _ AgentTool = ProviderDefinedTool{}
+
+// Info implements AgentTool.
+func (p ProviderDefinedTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:     p.Name,
+		Parallel: true,
+	}
+}
+
+// Run implements AgentTool. Provider-defined tools are executed by the
+// provider, so running them locally always fails.
+func (p ProviderDefinedTool) Run(context.Context, ToolCall) (ToolResponse, error) {
+	return ToolResponse{}, fmt.Errorf("provider-defined tool %q is executed by the provider", p.Name)
+}
+
+// ProviderOptions implements AgentTool. Provider-defined tools are configured
+// through their Args instead.
+func (p ProviderDefinedTool) ProviderOptions() ProviderOptions {
+	return nil
+}
+
+// SetProviderOptions implements AgentTool. It is a no-op, provider-defined
+// tools are configured through their Args instead.
+func (p ProviderDefinedTool) SetProviderOptions(ProviderOptions) {}
@@ -109,3 +109,77 @@ func TestNewMediaResponse(t *testing.T) {
 	require.False(t, resp.IsError)
 	require.Empty(t, resp.Content)
 }
+
+func TestRecursiveToolDefs(t *testing.T) {
+	type Person struct {
+		Name    string    `json:"name"`
+		Friends []*Person `json:"friends,omitempty"`
+	}
+	type GreetInput struct {
+		From Person `json:"from"`
+		To   Person `json:"to"`
+	}
+
+	tool := NewAgentTool(
+		"greet",
+		"Greets a person",
+		func(ctx context.Context, input GreetInput, _ ToolCall) (ToolResponse, error) {
+			return NewTextResponse("hello " + input.To.Name), nil
+		},
+	)
+
+	info := tool.Info()
+	from, ok := info.Parameters["from"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "#/$defs/Person", from["$ref"])
+	require.Contains(t, info.Defs, "Person")
+
+	a := &agent{}
+	prepared := a.prepareTools([]AgentTool{tool}, nil, false)
+	require.Len(t, prepared, 1)
+	functionTool, ok := prepared[0].(FunctionTool)
+	require.True(t, ok)
+	require.Equal(t, info.Defs, functionTool.InputSchema["$defs"])
+}
+
+func TestTypedAgentTool(t *testing.T) {
+	t.Parallel()
+
+	type ForecastInput struct {
+		Location string `json:"location"`
+	}
+	type ForecastOutput struct {
+		Location    string  `json:"location"`
+		Temperature float64 `json:"temperature" description:"Temperature in celsius"`
+	}
+
+	tool := NewTypedAgentTool(
+		"forecast",
+		"Gets the forecast for a location",
+		func(ctx context.Context, input ForecastInput, _ ToolCall) (ForecastOutput, error) {
+			if input.Location == "" {
+				return ForecastOutput{}, fmt.Errorf("unknown location")
+			}
+			return ForecastOutput{Location: input.Location, Temperature: 21.5}, nil
+		},
+	)
+
+	info := tool.Info()
+	require.Equal(t, []string{"location"}, info.Required)
+	require.Equal(t, "object", info.OutputSchema["type"])
+	props := info.OutputSchema["properties"].(map[string]any)
+	require.Equal(t, map[string]any{
+		"type":        "number",
+		"description": "Temperature in celsius",
+	}, props["temperature"])
+
+	result, err := tool.Run(context.Background(), ToolCall{Input: `{"location": "Lisbon"}`})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.JSONEq(t, `{"location": "Lisbon", "temperature": 21.5}`, result.Content)
+
+	result, err = tool.Run(context.Background(), ToolCall{Input: `{"location": ""}`})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Equal(t, "unknown location", result.Content)
+}